@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/swaggo/swag"
+	"github.com/swaggo/swag/gen"
+)
+
+// собирает OpenAPI спеку из swag аннотаций хендлеров. Запускается при сборке (make docs),
+// результат не коммитится: сервис читает его на старте, так новые ручки сразу видны в /swagger
+func main() {
+	out := flag.String("out", "docs", "output directory for swagger.json")
+	flag.Parse()
+
+	err := gen.New().Build(&gen.Config{
+		SearchDir:          "./cmd/app,./internal",
+		MainAPIFile:        "main.go",
+		PropNamingStrategy: swag.CamelCase,
+		OutputDir:          *out,
+		OutputTypes:        []string{"json"},
+		ParseDepth:         100,
+		LeftTemplateDelim:  "{{",
+		RightTemplateDelim: "}}",
+		Debugger:           log.New(os.Stderr, "apidocs: ", 0),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cant build api docs: %s\n", err)
+		os.Exit(1)
+	}
+}
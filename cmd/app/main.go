@@ -2,20 +2,14 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
-	"github.com/mmoldabe-dev/EffectiveTask/internal/config"
-	"github.com/mmoldabe-dev/EffectiveTask/internal/handler"
-	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
-	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
-	"github.com/mmoldabe-dev/EffectiveTask/internal/storage/postgres"
-	"github.com/mmoldabe-dev/EffectiveTask/pkg/logger"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/app"
 )
 
 //@title Effective Task
@@ -25,60 +19,32 @@ import (
 // host@ localhost:8080
 // basePath /
 func main() {
-	// грузим конфиг
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		fmt.Printf("cant load config: %s", err)
-		os.Exit(1)
-	}
-
-	log := logger.SetupLogger(cfg.Logger.Level, "effective_task")
-
-	// запускаем миграции перед стартом
-	if err := postgres.RunMigrations(cfg, log); err != nil {
-		log.Error("migration faild", slog.String("err", err.Error()))
-		os.Exit(1)
-	}
+	selfTest := flag.Bool("selftest", false, "run start-up checks, print a json report and exit")
+	flag.Parse()
 
-	db, err := postgres.NewPostgres(cfg, log)
+	cfg, log, err := app.Load("effective_task")
 	if err != nil {
-		log.Error("db init error")
+		fmt.Println(err)
 		os.Exit(1)
 	}
-	defer db.Close()
-
-	// собираем слои
-	repo := repository.NewSubscriptionRepository(db, log)
-	svc := service.NewSubscriptionService(repo, log)
-	h := handler.NewHandlerSubscription(svc, log)
-
-	srv := &http.Server{
-		Addr:         ":" + cfg.Server.Port,
-		Handler:      h.SetupRouter(), // прокидываем роутер
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-	}
-
-	go func() {
-		log.Info("server starting...", slog.String("port", cfg.Server.Port))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Error("listen error", slog.String("err", err.Error()))
+	if *selfTest {
+		if !app.SelfTest(cfg, os.Stdout) {
+			os.Exit(1)
 		}
-	}()
+		return
+	}
 
 	// ждем сигнал на выход
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	log.Info("stopping server...")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Error("forced shutdown", slog.String("error", err.Error()))
+	a, err := app.New(ctx, cfg, log)
+	if err != nil {
+		log.Error("app init error", slog.String("err", err.Error()))
+		os.Exit(1)
+	}
+	if err := a.Run(ctx); err != nil {
+		log.Error("app stopped with error", slog.String("err", err.Error()))
+		os.Exit(1)
 	}
-
-	log.Info("server stopped")
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/app"
+)
+
+// выгружает обезличенную выборку из базы в файл фикстур + отчет
+func main() {
+	var opts app.FixtureExport
+	flag.IntVar(&opts.Limit, "limit", 1000, "rows in sample")
+	flag.StringVar(&opts.Seed, "seed", "", "anonymization seed, same seed gives same output (required)")
+	flag.StringVar(&opts.Out, "out", "fixtures.json", "fixture file")
+	flag.StringVar(&opts.ReportPath, "report", "fixtures.report.json", "anonymization report file")
+	flag.Parse()
+
+	if opts.Seed == "" {
+		fmt.Println("seed is required")
+		os.Exit(2)
+	}
+
+	cfg, log, err := app.Load("effective_task_fixtures")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := app.ExportFixtures(ctx, cfg, log, opts); err != nil {
+		log.Error("fixtures export failed", slog.String("err", err.Error()))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,260 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: subscription/v1/subscription.proto
+
+// контракт API подписок. Из него генерируются RPC хендлеры (gRPC, gRPC-Web, Connect),
+// HTTP ручки постепенно переводятся на них, чтобы две поверхности не расходились
+
+package subscriptionv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/mmoldabe-dev/EffectiveTask/gen/subscription/v1"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// SubscriptionServiceName is the fully-qualified name of the SubscriptionService service.
+	SubscriptionServiceName = "subscription.v1.SubscriptionService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// SubscriptionServiceCreateSubscriptionProcedure is the fully-qualified name of the
+	// SubscriptionService's CreateSubscription RPC.
+	SubscriptionServiceCreateSubscriptionProcedure = "/subscription.v1.SubscriptionService/CreateSubscription"
+	// SubscriptionServiceGetSubscriptionProcedure is the fully-qualified name of the
+	// SubscriptionService's GetSubscription RPC.
+	SubscriptionServiceGetSubscriptionProcedure = "/subscription.v1.SubscriptionService/GetSubscription"
+	// SubscriptionServiceListSubscriptionsProcedure is the fully-qualified name of the
+	// SubscriptionService's ListSubscriptions RPC.
+	SubscriptionServiceListSubscriptionsProcedure = "/subscription.v1.SubscriptionService/ListSubscriptions"
+	// SubscriptionServiceStreamSubscriptionsProcedure is the fully-qualified name of the
+	// SubscriptionService's StreamSubscriptions RPC.
+	SubscriptionServiceStreamSubscriptionsProcedure = "/subscription.v1.SubscriptionService/StreamSubscriptions"
+	// SubscriptionServiceDeleteSubscriptionProcedure is the fully-qualified name of the
+	// SubscriptionService's DeleteSubscription RPC.
+	SubscriptionServiceDeleteSubscriptionProcedure = "/subscription.v1.SubscriptionService/DeleteSubscription"
+	// SubscriptionServiceGetTotalCostProcedure is the fully-qualified name of the SubscriptionService's
+	// GetTotalCost RPC.
+	SubscriptionServiceGetTotalCostProcedure = "/subscription.v1.SubscriptionService/GetTotalCost"
+)
+
+// SubscriptionServiceClient is a client for the subscription.v1.SubscriptionService service.
+type SubscriptionServiceClient interface {
+	CreateSubscription(context.Context, *connect.Request[v1.CreateSubscriptionRequest]) (*connect.Response[v1.CreateSubscriptionResponse], error)
+	GetSubscription(context.Context, *connect.Request[v1.GetSubscriptionRequest]) (*connect.Response[v1.GetSubscriptionResponse], error)
+	ListSubscriptions(context.Context, *connect.Request[v1.ListSubscriptionsRequest]) (*connect.Response[v1.ListSubscriptionsResponse], error)
+	// все подписки по фильтру потоком, limit и offset игнорируются
+	StreamSubscriptions(context.Context, *connect.Request[v1.StreamSubscriptionsRequest]) (*connect.ServerStreamForClient[v1.StreamSubscriptionsResponse], error)
+	DeleteSubscription(context.Context, *connect.Request[v1.DeleteSubscriptionRequest]) (*connect.Response[v1.DeleteSubscriptionResponse], error)
+	GetTotalCost(context.Context, *connect.Request[v1.GetTotalCostRequest]) (*connect.Response[v1.GetTotalCostResponse], error)
+}
+
+// NewSubscriptionServiceClient constructs a client for the subscription.v1.SubscriptionService
+// service. By default, it uses the Connect protocol with the binary Protobuf Codec, asks for
+// gzipped responses, and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply
+// the connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewSubscriptionServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) SubscriptionServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	subscriptionServiceMethods := v1.File_subscription_v1_subscription_proto.Services().ByName("SubscriptionService").Methods()
+	return &subscriptionServiceClient{
+		createSubscription: connect.NewClient[v1.CreateSubscriptionRequest, v1.CreateSubscriptionResponse](
+			httpClient,
+			baseURL+SubscriptionServiceCreateSubscriptionProcedure,
+			connect.WithSchema(subscriptionServiceMethods.ByName("CreateSubscription")),
+			connect.WithClientOptions(opts...),
+		),
+		getSubscription: connect.NewClient[v1.GetSubscriptionRequest, v1.GetSubscriptionResponse](
+			httpClient,
+			baseURL+SubscriptionServiceGetSubscriptionProcedure,
+			connect.WithSchema(subscriptionServiceMethods.ByName("GetSubscription")),
+			connect.WithClientOptions(opts...),
+		),
+		listSubscriptions: connect.NewClient[v1.ListSubscriptionsRequest, v1.ListSubscriptionsResponse](
+			httpClient,
+			baseURL+SubscriptionServiceListSubscriptionsProcedure,
+			connect.WithSchema(subscriptionServiceMethods.ByName("ListSubscriptions")),
+			connect.WithClientOptions(opts...),
+		),
+		streamSubscriptions: connect.NewClient[v1.StreamSubscriptionsRequest, v1.StreamSubscriptionsResponse](
+			httpClient,
+			baseURL+SubscriptionServiceStreamSubscriptionsProcedure,
+			connect.WithSchema(subscriptionServiceMethods.ByName("StreamSubscriptions")),
+			connect.WithClientOptions(opts...),
+		),
+		deleteSubscription: connect.NewClient[v1.DeleteSubscriptionRequest, v1.DeleteSubscriptionResponse](
+			httpClient,
+			baseURL+SubscriptionServiceDeleteSubscriptionProcedure,
+			connect.WithSchema(subscriptionServiceMethods.ByName("DeleteSubscription")),
+			connect.WithClientOptions(opts...),
+		),
+		getTotalCost: connect.NewClient[v1.GetTotalCostRequest, v1.GetTotalCostResponse](
+			httpClient,
+			baseURL+SubscriptionServiceGetTotalCostProcedure,
+			connect.WithSchema(subscriptionServiceMethods.ByName("GetTotalCost")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// subscriptionServiceClient implements SubscriptionServiceClient.
+type subscriptionServiceClient struct {
+	createSubscription  *connect.Client[v1.CreateSubscriptionRequest, v1.CreateSubscriptionResponse]
+	getSubscription     *connect.Client[v1.GetSubscriptionRequest, v1.GetSubscriptionResponse]
+	listSubscriptions   *connect.Client[v1.ListSubscriptionsRequest, v1.ListSubscriptionsResponse]
+	streamSubscriptions *connect.Client[v1.StreamSubscriptionsRequest, v1.StreamSubscriptionsResponse]
+	deleteSubscription  *connect.Client[v1.DeleteSubscriptionRequest, v1.DeleteSubscriptionResponse]
+	getTotalCost        *connect.Client[v1.GetTotalCostRequest, v1.GetTotalCostResponse]
+}
+
+// CreateSubscription calls subscription.v1.SubscriptionService.CreateSubscription.
+func (c *subscriptionServiceClient) CreateSubscription(ctx context.Context, req *connect.Request[v1.CreateSubscriptionRequest]) (*connect.Response[v1.CreateSubscriptionResponse], error) {
+	return c.createSubscription.CallUnary(ctx, req)
+}
+
+// GetSubscription calls subscription.v1.SubscriptionService.GetSubscription.
+func (c *subscriptionServiceClient) GetSubscription(ctx context.Context, req *connect.Request[v1.GetSubscriptionRequest]) (*connect.Response[v1.GetSubscriptionResponse], error) {
+	return c.getSubscription.CallUnary(ctx, req)
+}
+
+// ListSubscriptions calls subscription.v1.SubscriptionService.ListSubscriptions.
+func (c *subscriptionServiceClient) ListSubscriptions(ctx context.Context, req *connect.Request[v1.ListSubscriptionsRequest]) (*connect.Response[v1.ListSubscriptionsResponse], error) {
+	return c.listSubscriptions.CallUnary(ctx, req)
+}
+
+// StreamSubscriptions calls subscription.v1.SubscriptionService.StreamSubscriptions.
+func (c *subscriptionServiceClient) StreamSubscriptions(ctx context.Context, req *connect.Request[v1.StreamSubscriptionsRequest]) (*connect.ServerStreamForClient[v1.StreamSubscriptionsResponse], error) {
+	return c.streamSubscriptions.CallServerStream(ctx, req)
+}
+
+// DeleteSubscription calls subscription.v1.SubscriptionService.DeleteSubscription.
+func (c *subscriptionServiceClient) DeleteSubscription(ctx context.Context, req *connect.Request[v1.DeleteSubscriptionRequest]) (*connect.Response[v1.DeleteSubscriptionResponse], error) {
+	return c.deleteSubscription.CallUnary(ctx, req)
+}
+
+// GetTotalCost calls subscription.v1.SubscriptionService.GetTotalCost.
+func (c *subscriptionServiceClient) GetTotalCost(ctx context.Context, req *connect.Request[v1.GetTotalCostRequest]) (*connect.Response[v1.GetTotalCostResponse], error) {
+	return c.getTotalCost.CallUnary(ctx, req)
+}
+
+// SubscriptionServiceHandler is an implementation of the subscription.v1.SubscriptionService
+// service.
+type SubscriptionServiceHandler interface {
+	CreateSubscription(context.Context, *connect.Request[v1.CreateSubscriptionRequest]) (*connect.Response[v1.CreateSubscriptionResponse], error)
+	GetSubscription(context.Context, *connect.Request[v1.GetSubscriptionRequest]) (*connect.Response[v1.GetSubscriptionResponse], error)
+	ListSubscriptions(context.Context, *connect.Request[v1.ListSubscriptionsRequest]) (*connect.Response[v1.ListSubscriptionsResponse], error)
+	// все подписки по фильтру потоком, limit и offset игнорируются
+	StreamSubscriptions(context.Context, *connect.Request[v1.StreamSubscriptionsRequest], *connect.ServerStream[v1.StreamSubscriptionsResponse]) error
+	DeleteSubscription(context.Context, *connect.Request[v1.DeleteSubscriptionRequest]) (*connect.Response[v1.DeleteSubscriptionResponse], error)
+	GetTotalCost(context.Context, *connect.Request[v1.GetTotalCostRequest]) (*connect.Response[v1.GetTotalCostResponse], error)
+}
+
+// NewSubscriptionServiceHandler builds an HTTP handler from the service implementation. It returns
+// the path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewSubscriptionServiceHandler(svc SubscriptionServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	subscriptionServiceMethods := v1.File_subscription_v1_subscription_proto.Services().ByName("SubscriptionService").Methods()
+	subscriptionServiceCreateSubscriptionHandler := connect.NewUnaryHandler(
+		SubscriptionServiceCreateSubscriptionProcedure,
+		svc.CreateSubscription,
+		connect.WithSchema(subscriptionServiceMethods.ByName("CreateSubscription")),
+		connect.WithHandlerOptions(opts...),
+	)
+	subscriptionServiceGetSubscriptionHandler := connect.NewUnaryHandler(
+		SubscriptionServiceGetSubscriptionProcedure,
+		svc.GetSubscription,
+		connect.WithSchema(subscriptionServiceMethods.ByName("GetSubscription")),
+		connect.WithHandlerOptions(opts...),
+	)
+	subscriptionServiceListSubscriptionsHandler := connect.NewUnaryHandler(
+		SubscriptionServiceListSubscriptionsProcedure,
+		svc.ListSubscriptions,
+		connect.WithSchema(subscriptionServiceMethods.ByName("ListSubscriptions")),
+		connect.WithHandlerOptions(opts...),
+	)
+	subscriptionServiceStreamSubscriptionsHandler := connect.NewServerStreamHandler(
+		SubscriptionServiceStreamSubscriptionsProcedure,
+		svc.StreamSubscriptions,
+		connect.WithSchema(subscriptionServiceMethods.ByName("StreamSubscriptions")),
+		connect.WithHandlerOptions(opts...),
+	)
+	subscriptionServiceDeleteSubscriptionHandler := connect.NewUnaryHandler(
+		SubscriptionServiceDeleteSubscriptionProcedure,
+		svc.DeleteSubscription,
+		connect.WithSchema(subscriptionServiceMethods.ByName("DeleteSubscription")),
+		connect.WithHandlerOptions(opts...),
+	)
+	subscriptionServiceGetTotalCostHandler := connect.NewUnaryHandler(
+		SubscriptionServiceGetTotalCostProcedure,
+		svc.GetTotalCost,
+		connect.WithSchema(subscriptionServiceMethods.ByName("GetTotalCost")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/subscription.v1.SubscriptionService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case SubscriptionServiceCreateSubscriptionProcedure:
+			subscriptionServiceCreateSubscriptionHandler.ServeHTTP(w, r)
+		case SubscriptionServiceGetSubscriptionProcedure:
+			subscriptionServiceGetSubscriptionHandler.ServeHTTP(w, r)
+		case SubscriptionServiceListSubscriptionsProcedure:
+			subscriptionServiceListSubscriptionsHandler.ServeHTTP(w, r)
+		case SubscriptionServiceStreamSubscriptionsProcedure:
+			subscriptionServiceStreamSubscriptionsHandler.ServeHTTP(w, r)
+		case SubscriptionServiceDeleteSubscriptionProcedure:
+			subscriptionServiceDeleteSubscriptionHandler.ServeHTTP(w, r)
+		case SubscriptionServiceGetTotalCostProcedure:
+			subscriptionServiceGetTotalCostHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedSubscriptionServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedSubscriptionServiceHandler struct{}
+
+func (UnimplementedSubscriptionServiceHandler) CreateSubscription(context.Context, *connect.Request[v1.CreateSubscriptionRequest]) (*connect.Response[v1.CreateSubscriptionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("subscription.v1.SubscriptionService.CreateSubscription is not implemented"))
+}
+
+func (UnimplementedSubscriptionServiceHandler) GetSubscription(context.Context, *connect.Request[v1.GetSubscriptionRequest]) (*connect.Response[v1.GetSubscriptionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("subscription.v1.SubscriptionService.GetSubscription is not implemented"))
+}
+
+func (UnimplementedSubscriptionServiceHandler) ListSubscriptions(context.Context, *connect.Request[v1.ListSubscriptionsRequest]) (*connect.Response[v1.ListSubscriptionsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("subscription.v1.SubscriptionService.ListSubscriptions is not implemented"))
+}
+
+func (UnimplementedSubscriptionServiceHandler) StreamSubscriptions(context.Context, *connect.Request[v1.StreamSubscriptionsRequest], *connect.ServerStream[v1.StreamSubscriptionsResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("subscription.v1.SubscriptionService.StreamSubscriptions is not implemented"))
+}
+
+func (UnimplementedSubscriptionServiceHandler) DeleteSubscription(context.Context, *connect.Request[v1.DeleteSubscriptionRequest]) (*connect.Response[v1.DeleteSubscriptionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("subscription.v1.SubscriptionService.DeleteSubscription is not implemented"))
+}
+
+func (UnimplementedSubscriptionServiceHandler) GetTotalCost(context.Context, *connect.Request[v1.GetTotalCostRequest]) (*connect.Response[v1.GetTotalCostResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("subscription.v1.SubscriptionService.GetTotalCost is not implemented"))
+}
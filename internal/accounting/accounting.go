@@ -0,0 +1,92 @@
+package accounting
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// Format - раскладка файла для бухгалтерии
+type Format interface {
+	Name() string
+	ContentType() string
+	Extension() string
+	Write(w io.Writer, lines []domain.AccountingLine) error
+}
+
+// New - формат по имени из ACCOUNTING_FORMAT
+func New(name string) (Format, error) {
+	switch name {
+	case "1c":
+		return OneC{}, nil
+	case "csv":
+		return CSV{}, nil
+	}
+	return nil, fmt.Errorf("unknown accounting format %q, expected 1c or csv", name)
+}
+
+// сумма всегда с двумя знаками, как ждут бухгалтерские программы: 799.00
+func amount(m domain.Money, sep byte) string {
+	v := int64(m)
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	return fmt.Sprintf("%s%d%c%02d", sign, v/domain.MinorUnits, sep, v%domain.MinorUnits)
+}
+
+func category(l domain.AccountingLine) string {
+	if l.CategoryName == "" {
+		return "-"
+	}
+	return l.CategoryName
+}
+
+// writeRows пишет строки с кавычками по правилам csv, encoding/csv не умеет CRLF вместе с другим разделителем
+func writeRows(w io.StringWriter, rows [][]string, sep rune, eol string) error {
+	for _, row := range rows {
+		var b strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteRune(sep)
+			}
+			if strings.ContainsAny(cell, `"`+string(sep)+"\r\n") {
+				cell = `"` + strings.ReplaceAll(cell, `"`, `""`) + `"`
+			}
+			b.WriteString(cell)
+		}
+		b.WriteString(eol)
+		if _, err := w.WriteString(b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CSV - обычный csv в utf-8 с точкой в суммах
+type CSV struct{}
+
+func (CSV) Name() string { return "csv" }
+
+func (CSV) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (CSV) Extension() string { return "csv" }
+
+func (CSV) Write(w io.Writer, lines []domain.AccountingLine) error {
+	bw := bufio.NewWriter(w)
+	rows := [][]string{{"period", "user_id", "subscription_id", "service_name", "category", "billing_period", "price", "months", "amount", "currency"}}
+	for _, l := range lines {
+		rows = append(rows, []string{
+			l.Period.Format("2006-01"), l.UserID.String(), strconv.FormatInt(l.SubscriptionID, 10), l.ServiceName, category(l),
+			l.BillingPeriod, amount(l.Price, '.'), strconv.Itoa(l.Months), amount(l.Amount, '.'), l.Currency,
+		})
+	}
+	if err := writeRows(bw, rows, ',', "\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,72 @@
+package accounting
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// OneC - раскладка для загрузки в 1С: windows-1251, разделитель ;,
+// даты дд.мм.гггг, десятичная запятая и CRLF
+type OneC struct{}
+
+func (OneC) Name() string { return "1c" }
+
+func (OneC) ContentType() string { return "text/csv; charset=windows-1251" }
+
+func (OneC) Extension() string { return "csv" }
+
+func (OneC) Write(w io.Writer, lines []domain.AccountingLine) error {
+	bw := bufio.NewWriter(w)
+	rows := [][]string{{"Период", "Пользователь", "Подписка", "Сервис", "Категория", "Периодичность", "Цена", "Месяцев", "Сумма", "Валюта"}}
+	for _, l := range lines {
+		rows = append(rows, []string{
+			l.Period.Format("02.01.2006"), l.UserID.String(), strconv.FormatInt(l.SubscriptionID, 10), l.ServiceName, category(l),
+			l.BillingPeriod, amount(l.Price, ','), strconv.Itoa(l.Months), amount(l.Amount, ','), l.Currency,
+		})
+	}
+	if err := writeRows(cp1251Writer{bw}, rows, ';', "\r\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// cp1251Writer перекодирует utf-8 в windows-1251, символы вне кодировки заменяются на ?
+type cp1251Writer struct {
+	w *bufio.Writer
+}
+
+func (c cp1251Writer) WriteString(s string) (int, error) {
+	for _, r := range s {
+		if err := c.w.WriteByte(cp1251(r)); err != nil {
+			return 0, err
+		}
+	}
+	return len(s), nil
+}
+
+func cp1251(r rune) byte {
+	switch {
+	case r < 0x80:
+		return byte(r)
+	case r >= 'А' && r <= 'я':
+		return byte(r - 'А' + 0xC0)
+	case r == 'Ё':
+		return 0xA8
+	case r == 'ё':
+		return 0xB8
+	case r == '№':
+		return 0xB9
+	case r == '«':
+		return 0xAB
+	case r == '»':
+		return 0xBB
+	case r == '—':
+		return 0x97
+	case r == '–':
+		return 0x96
+	}
+	return '?'
+}
@@ -0,0 +1,38 @@
+package apidocs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/swaggo/swag"
+)
+
+// spec - swagger.json, собранный cmd/apidocs при сборке
+type spec struct {
+	doc string
+}
+
+func (s spec) ReadDoc() string {
+	return s.doc
+}
+
+// Load читает спеку с диска и регистрирует ее для /swagger. Файла нет (сборка без
+// make docs) - false без ошибки, сервис работает без документации
+func Load(path string) (bool, error) {
+	if path == "" {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("apidocs: read %s: %w", path, err)
+	}
+
+	swag.Register(swag.Name, spec{doc: string(data)})
+	return true, nil
+}
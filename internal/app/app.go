@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/buildinfo"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/config"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/scheduler"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/storage/postgres"
+	"github.com/mmoldabe-dev/EffectiveTask/pkg/logger"
+)
+
+// сколько ждем завершения запросов в полете при остановке
+const shutdownTimeout = 10 * time.Second
+
+// App - собранный сервис: база, слои, фоновые задачи и http сервер.
+// Интеграционные тесты могут поднять его в процессе и ходить в Handler через httptest
+type App struct {
+	cfg *config.Config
+	log *slog.Logger
+	db  *sql.DB
+
+	handler http.Handler
+	srv     *http.Server
+
+	// фоновые задачи, стартуют в Run, останавливаются в Shutdown
+	workers *scheduler.Manager
+}
+
+// Load читает конфиг и настраивает логгер, name попадает в каждую запись
+func Load(name string) (*config.Config, *slog.Logger, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cant load config: %w", err)
+	}
+	return cfg, logger.SetupLogger(cfg.Logger.Level, name).With(buildinfo.LogAttrs()...), nil
+}
+
+// New накатывает миграции, подключается к базе и собирает все слои.
+// Фоновые задачи и сервер не запускаются до Run
+func New(ctx context.Context, cfg *config.Config, log *slog.Logger) (*App, error) {
+	if err := postgres.RunMigrations(cfg, log); err != nil {
+		return nil, fmt.Errorf("app: migrations: %w", err)
+	}
+
+	db, err := postgres.NewPostgres(cfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("app: db: %w", err)
+	}
+
+	a := &App{cfg: cfg, log: log, db: db, workers: scheduler.NewManager(log)}
+	if err := a.wire(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// gRPC клиентам нужен HTTP/2, а TLS снимает прокси, поэтому еще и h2c
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	a.srv = &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      a.handler,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		Protocols:    protocols,
+	}
+	return a, nil
+}
+
+// Handler - роутер со всеми мидлварами, без сервера и фоновых задач
+func (a *App) Handler() http.Handler {
+	return a.handler
+}
+
+// Run запускает фоновые задачи и сервер и ждет отмены ctx или падения сервера,
+// потом останавливает все через Shutdown
+func (a *App) Run(ctx context.Context) error {
+	a.workers.Start(context.Background())
+
+	serveErr := make(chan error, 1)
+	go func() {
+		a.log.Info("server starting...", slog.String("port", a.cfg.Server.Port))
+		if err := a.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case err, ok := <-serveErr:
+		if ok {
+			runErr = fmt.Errorf("app: listen: %w", err)
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return errors.Join(runErr, a.Shutdown(shutdownCtx))
+}
+
+// Shutdown останавливает фоновые задачи, дожидается запросов в полете и закрывает базу
+func (a *App) Shutdown(ctx context.Context) error {
+	a.log.Info("stopping server...")
+
+	var err error
+	if shutdownErr := a.srv.Shutdown(ctx); shutdownErr != nil {
+		a.log.Error("forced shutdown", slog.String("error", shutdownErr.Error()))
+		err = fmt.Errorf("app: shutdown: %w", shutdownErr)
+	}
+	// задачи могут еще ходить в базу, закрываем ее после них
+	if workersErr := a.workers.Stop(ctx); workersErr != nil {
+		a.log.Error("workers stop timeout", slog.String("error", workersErr.Error()))
+		err = errors.Join(err, workersErr)
+	}
+	a.db.Close()
+
+	a.log.Info("server stopped")
+	return err
+}
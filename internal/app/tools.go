@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/config"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/fixtures"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/selftest"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/storage/postgres"
+)
+
+var ErrFixtureChecks = errors.New("anonymization checks failed, see the report")
+
+// SelfTest - режим для деплоя: проверяет окружение без миграций и сервера и пишет json отчет в out.
+// Логи идут в stderr, чтоб в out был только отчет
+func SelfTest(cfg *config.Config, out io.Writer) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report := selftest.Run(ctx, cfg, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+	return report.OK
+}
+
+// FixtureExport - параметры выгрузки обезличенной выборки
+type FixtureExport struct {
+	Limit      int
+	Seed       string // один seed - одинаковый результат
+	Out        string
+	ReportPath string
+}
+
+// ExportFixtures выгружает обезличенную выборку в файл фикстур и отчет рядом.
+// Отчет пишется и при проваленных проверках, тогда ошибка ErrFixtureChecks
+func ExportFixtures(ctx context.Context, cfg *config.Config, log *slog.Logger, opts FixtureExport) error {
+	if opts.Seed == "" {
+		return errors.New("seed is required")
+	}
+
+	db, err := postgres.NewPostgres(cfg, log)
+	if err != nil {
+		return fmt.Errorf("app: db: %w", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewSubscriptionRepository(db, log)
+	subs, err := repo.Sample(ctx, opts.Limit, opts.Seed)
+	if err != nil {
+		return fmt.Errorf("app: sample: %w", err)
+	}
+
+	anon := fixtures.NewAnonymizer(opts.Seed)
+	data, err := json.MarshalIndent(anon.Anonymize(subs), "", "  ")
+	if err != nil {
+		return fmt.Errorf("app: marshal fixtures: %w", err)
+	}
+
+	report := anon.Verify(subs, data)
+	reportData, _ := json.MarshalIndent(report, "", "  ")
+
+	if err := os.WriteFile(opts.Out, data, 0o644); err != nil {
+		return fmt.Errorf("app: write fixtures: %w", err)
+	}
+	if err := os.WriteFile(opts.ReportPath, reportData, 0o644); err != nil {
+		return fmt.Errorf("app: write report: %w", err)
+	}
+
+	log.Info("fixtures exported",
+		slog.Int("rows", report.Rows),
+		slog.String("sha256", report.FixtureSHA256),
+		slog.Bool("checks_passed", report.AllChecksPass),
+	)
+
+	if !report.AllChecksPass {
+		return ErrFixtureChecks
+	}
+	return nil
+}
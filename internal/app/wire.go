@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/accounting"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/apidocs"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/dates"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/fx"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/handler"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/idcodec"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/policy"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/pricing"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/sheets"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/storage/blob"
+)
+
+// собранные слои, которые нужны фоновым задачам и /admin/system.
+// Необязательные сервисы nil, если выключены в конфиге
+type components struct {
+	h    *handler.HandlerSubscription
+	repo *repository.SubscriptionRepository
+
+	subscriptions *service.SubscriptionService
+	activity      *service.ActivityService
+	reminders     *service.ReminderService
+	scheduled     *service.ScheduledEventService
+	templates     *service.NotificationTemplateService
+	routeSwitches *service.RouteSwitchService
+	sheetSync     *service.SheetSyncService
+	rates         *service.ExchangeRateService
+	accounting    *service.AccountingService
+	analytics     *service.AnalyticsService
+}
+
+// wire собирает репозитории, сервисы, хендлер и фоновые задачи
+func (a *App) wire(ctx context.Context) error {
+	c, err := a.services(ctx)
+	if err != nil {
+		return err
+	}
+	a.schedule(c)
+	a.handler = c.h.SetupRouter()
+	return nil
+}
+
+func (a *App) services(ctx context.Context) (*components, error) {
+	cfg, log, db := a.cfg, a.log, a.db
+	c := &components{}
+
+	c.repo = repository.NewSubscriptionRepository(db, log)
+	c.activity = service.NewActivityService(repository.NewEventRepository(db, log), log)
+	priceCatalog, err := pricing.LoadExpectations(cfg.Pricing.CatalogFile)
+	if err != nil {
+		return nil, fmt.Errorf("app: price catalog: %w", err)
+	}
+	priceChecker, err := pricing.NewChecker(cfg.Pricing.Policy, cfg.Pricing.Tolerance, priceCatalog)
+	if err != nil {
+		return nil, fmt.Errorf("app: price checker: %w", err)
+	}
+	// без ставок налоговый слой выключен: nil интерфейс, а не пустая таблица
+	var taxNormalizer pricing.Normalizer
+	if len(cfg.Tax.Rates) > 0 {
+		rates, err := pricing.ParseTaxRates(cfg.Tax.Rates)
+		if err != nil {
+			return nil, fmt.Errorf("app: tax rates: %w", err)
+		}
+		taxTable, err := pricing.NewTaxTable(cfg.Tax.PriceBasis, cfg.Tax.DefaultCountry, rates)
+		if err != nil {
+			return nil, fmt.Errorf("app: tax table: %w", err)
+		}
+		taxNormalizer = taxTable
+	}
+	costCanary, err := service.NewCostCanary(cfg.Cost.SQLCanaryPercent, cfg.Cost.SQLCanaryUsers)
+	if err != nil {
+		return nil, fmt.Errorf("app: cost canary: %w", err)
+	}
+	catalogRepo := repository.NewCatalogRepository(db, log)
+	policySvc := service.NewPolicyService(repository.NewPolicyHookRepository(db, log), policy.NewClient(cfg.Policy.HookTimeout), log)
+	c.subscriptions = service.NewSubscriptionService(c.repo, log,
+		service.WithEventBus(c.activity),
+		service.WithClock(clock.Real{}),
+		service.WithPolicy(policySvc),
+		service.WithCatalog(catalogRepo),
+		service.WithPriceChecker(priceChecker),
+		service.WithTax(taxNormalizer),
+		service.WithCostCanary(costCanary),
+		service.WithDeleteConfirmPrice(domain.Major(int64(cfg.Server.DeleteConfirmPrice))),
+		service.WithExcludeFinalMonth(cfg.Cost.ExcludeFinalMonth),
+		service.WithCurrency(cfg.Cost.Currency),
+	)
+	svc := c.subscriptions
+	c.reminders = service.NewReminderService(repository.NewReminderRepository(db, log), c.repo, c.activity, cfg.Reminder.LeadMonths, cfg.Reminder.Repeat, clock.Real{}, log)
+	importSvc := service.NewImportService(repository.NewImportRepository(db, log), c.subscriptions, clock.Real{}, service.ImportOptions{
+		ChunkSize:     cfg.Import.ChunkSize,
+		TargetLatency: cfg.Import.TargetLatency,
+		MaxPause:      cfg.Import.MaxPause,
+	}, log)
+	ids, err := idcodec.New(cfg.API.IDEncoding, cfg.API.IDSalt)
+	if err != nil {
+		return nil, fmt.Errorf("app: id codec: %w", err)
+	}
+	idempotencySvc := service.NewIdempotencyService(repository.NewIdempotencyRepository(db, log), cfg.API.IdempotencyTTL, log)
+	h := handler.NewHandlerSubscription(svc, c.reminders, c.activity, importSvc, idempotencySvc, log,
+		handler.WithClock(clock.Real{}),
+		handler.WithDateParser(dates.Parser{Legacy: cfg.API.AcceptLegacyDates}),
+		handler.WithIDCodec(ids),
+		handler.WithAdminToken(cfg.API.AdminToken),
+		handler.WithImportMaxBytes(cfg.Import.MaxBytes),
+		handler.WithMaxBodyBytes(cfg.API.MaxBodyBytes),
+		handler.WithPolicy(policySvc),
+		handler.WithCurrency(cfg.Cost.Currency),
+	)
+	c.h = h
+
+	h.SetShadowSampleRate(cfg.API.ShadowSampleRate)
+	apiDocs, err := apidocs.Load(cfg.API.DocsFile)
+	if err != nil {
+		return nil, fmt.Errorf("app: api docs: %w", err)
+	}
+	if !apiDocs {
+		log.Warn("api docs not found, /swagger is off: run make docs", slog.String("file", cfg.API.DocsFile))
+	}
+	h.SetAPIDocs(apiDocs)
+	h.ConfigureRPC(cfg.API.RPCToken, cfg.API.RPCCORSOrigins)
+	h.SetConfigView(cfg.Redacted())
+	if cfg.API.CursorSecret != "" {
+		h.SetCursorSecret(cfg.API.CursorSecret)
+	} else {
+		log.Warn("API_CURSOR_SECRET is empty, cursors are valid only on this instance until restart")
+	}
+	store, err := blob.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("app: attachment storage: %w", err)
+	}
+	if store != nil {
+		h.SetAttachments(service.NewAttachmentService(repository.NewAttachmentRepository(db, log), c.repo, store, cfg.Storage.AttachmentMaxBytes, log), cfg.Storage.AttachmentMaxBytes)
+	}
+	c.scheduled = service.NewScheduledEventService(repository.NewScheduledEventRepository(db, log), c.repo, policySvc, clock.Real{}, cfg.Scheduled.MaxAttempts, log)
+	h.SetScheduledEvents(c.scheduled)
+	h.SetCategories(service.NewCategoryService(repository.NewCategoryRepository(db, log), log))
+	h.SetCatalog(service.NewCatalogService(catalogRepo, log))
+	if cfg.Sheets.SpreadsheetID != "" {
+		sheetsClient, err := sheets.NewClient(cfg.Sheets.CredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("app: google sheets client: %w", err)
+		}
+		c.sheetSync, err = service.NewSheetSyncService(sheetsClient.Sheet(cfg.Sheets.SpreadsheetID, cfg.Sheets.Range), svc, catalogRepo, cfg.Sheets.Columns, log)
+		if err != nil {
+			return nil, fmt.Errorf("app: sheet sync: %w", err)
+		}
+		h.SetSheetSync(c.sheetSync)
+	}
+	provider, err := fx.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("app: exchange rate provider: %w", err)
+	}
+	if provider != nil {
+		c.rates = service.NewExchangeRateService(provider, repository.NewExchangeRateRepository(db, log), log)
+		h.SetExchangeRates(c.rates)
+	}
+	if cfg.Accounting.Format != "" {
+		format, err := accounting.New(cfg.Accounting.Format)
+		if err != nil {
+			return nil, fmt.Errorf("app: accounting export: %w", err)
+		}
+		c.accounting = service.NewAccountingService(repository.NewAccountingRepository(db, log), svc, format, clock.Real{}, log)
+		if cfg.Accounting.URLSecret == "" {
+			log.Warn("ACCOUNTING_URL_SECRET is empty, download links are valid only on this instance until restart")
+		}
+		h.SetAccounting(c.accounting, cfg.Accounting.URLSecret, cfg.Accounting.URLTTL)
+	}
+	if cfg.Analytics.CohortInterval > 0 {
+		c.analytics = service.NewAnalyticsService(repository.NewAnalyticsRepository(db, log), svc, cfg.Cost.Currency, log)
+		h.SetAnalytics(c.analytics)
+	}
+	if cfg.Provisioning.Token != "" {
+		var transferTo uuid.UUID
+		if cfg.Provisioning.TransferTo != "" {
+			if transferTo, err = uuid.Parse(cfg.Provisioning.TransferTo); err != nil {
+				return nil, fmt.Errorf("app: bad PROVISIONING_TRANSFER_TO: %w", err)
+			}
+		}
+		provisioning, err := service.NewProvisioningService(repository.NewUserRepository(db, log), svc, cfg.Provisioning.OffboardPolicy, transferTo, log)
+		if err != nil {
+			return nil, fmt.Errorf("app: provisioning: %w", err)
+		}
+		h.SetProvisioning(provisioning, cfg.Provisioning.Token)
+	}
+	c.templates = service.NewNotificationTemplateService(repository.NewNotificationTemplateRepository(db, log), log)
+	h.SetNotificationTemplates(c.templates)
+	h.SetBudgets(service.NewBudgetService(repository.NewBudgetRepository(db, log), svc, log))
+	h.SetAudit(service.NewAuditService(repository.NewAuditRepository(db, log), log))
+	c.routeSwitches = service.NewRouteSwitchService(repository.NewRouteSwitchRepository(db, log), log)
+	// выключенное до рестарта остается выключенным
+	if err := c.routeSwitches.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("app: route switches: %w", err)
+	}
+	h.SetRouteSwitches(c.routeSwitches)
+
+	return c, nil
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/health"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/metrics"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/notifier"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/scheduler"
+)
+
+// schedule регистрирует фоновые задачи в менеджере, проверки /readyz и данные /admin/system.
+// Стартует задачи Run
+func (a *App) schedule(c *components) {
+	cfg, log, h, svc := a.cfg, a.log, c.h, c.subscriptions
+
+	reminderScheduler := scheduler.NewReminderScheduler(c.reminders, notifier.NewLogNotifier(log), c.templates, cfg.Reminder.Interval, clock.Real{}, log)
+	trialCheck := scheduler.NewTrialCheck(svc, cfg.Reminder.TrialInterval, log)
+	scheduledEvents := scheduler.NewScheduledEvents(c.scheduled, cfg.Scheduled.Interval, log)
+	eventRetention := scheduler.NewEventRetention(c.activity, cfg.Events.Retention, cfg.Events.CleanupInterval, log)
+	a.workers.Add("reminders", reminderScheduler.Run)
+	a.workers.Add("trials", trialCheck.Run)
+	a.workers.Add("scheduled_events", scheduledEvents.Run)
+	a.workers.Add("route_switches", scheduler.NewRouteSwitchSync(c.routeSwitches, cfg.API.RouteSwitchSync, log).Run)
+	a.workers.Add("event_retention", eventRetention.Run)
+
+	var churnCheck *scheduler.ChurnCheck
+	if cfg.Analytics.ChurnInterval > 0 {
+		churnCheck = scheduler.NewChurnCheck(svc, cfg.Analytics.ChurnInterval, log)
+		a.workers.Add("churn", churnCheck.Run)
+	}
+
+	// без интервала таблица синхронизируется только вручную
+	var sheetScheduler *scheduler.SheetSync
+	if c.sheetSync != nil && cfg.Sheets.Interval > 0 {
+		sheetScheduler = scheduler.NewSheetSync(c.sheetSync, cfg.Sheets.Interval, log)
+		a.workers.Add("sheets", sheetScheduler.Run)
+	}
+
+	var ratesRefresher *scheduler.ExchangeRates
+	if c.rates != nil {
+		ratesRefresher = scheduler.NewExchangeRates(c.rates, cfg.FX.RefreshInterval, log)
+		a.workers.Add("exchange_rates", ratesRefresher.Run)
+	}
+
+	var accountingExporter *scheduler.AccountingExport
+	if c.accounting != nil && cfg.Accounting.Interval > 0 {
+		accountingExporter = scheduler.NewAccountingExport(c.accounting, cfg.Accounting.Interval, log)
+		a.workers.Add("accounting", accountingExporter.Run)
+	}
+
+	var cohortAggregation *scheduler.CohortAggregation
+	if c.analytics != nil {
+		cohortAggregation = scheduler.NewCohortAggregation(c.analytics, cfg.Analytics.CohortInterval, log)
+		a.workers.Add("analytics", cohortAggregation.Run)
+	}
+
+	// проверки для /readyz: без базы инстанс бесполезен, отставшие задачи - деградация
+	checks := health.NewRegistry(2 * time.Second)
+	checks.Register("db", true, a.db.PingContext)
+	checks.Register("scheduler.reminders", false, health.Freshness(reminderScheduler.LastRun, 2*cfg.Reminder.Interval))
+	checks.Register("scheduler.trials", false, health.Freshness(trialCheck.LastRun, 2*cfg.Reminder.TrialInterval))
+	checks.Register("scheduler.scheduled_events", false, health.Freshness(scheduledEvents.LastRun, 2*cfg.Scheduled.Interval))
+	checks.Register("scheduler.event_retention", false, health.Freshness(eventRetention.LastRun, 2*cfg.Events.CleanupInterval))
+	if sheetScheduler != nil {
+		checks.Register("scheduler.sheets", false, health.Freshness(sheetScheduler.LastRun, 2*cfg.Sheets.Interval))
+	}
+	if ratesRefresher != nil {
+		checks.Register("scheduler.exchange_rates", false, health.Freshness(ratesRefresher.LastRun, 2*cfg.FX.RefreshInterval))
+	}
+	if churnCheck != nil {
+		checks.Register("scheduler.churn", false, health.Freshness(churnCheck.LastRun, 2*cfg.Analytics.ChurnInterval))
+	}
+	if cohortAggregation != nil {
+		checks.Register("scheduler.analytics", false, health.Freshness(cohortAggregation.LastRun, 2*cfg.Analytics.CohortInterval))
+	}
+	if accountingExporter != nil {
+		checks.Register("scheduler.accounting", false, health.Freshness(accountingExporter.LastRun, 2*cfg.Accounting.Interval))
+	}
+	// задача, упавшая с паникой, не работает до перезапуска
+	for _, st := range a.workers.Status() {
+		checks.Register("worker."+st.Name, false, a.workers.Health(st.Name))
+	}
+	h.SetHealth(checks)
+
+	// данные для /admin/system
+	h.RegisterSystemStats("db_pool", func(ctx context.Context) any {
+		return a.db.Stats()
+	})
+	h.RegisterSystemStats("scheduler", func(ctx context.Context) any {
+		return map[string]time.Time{
+			"reminders_last_run":        reminderScheduler.LastRun(),
+			"trials_last_run":           trialCheck.LastRun(),
+			"scheduled_events_last_run": scheduledEvents.LastRun(),
+			"event_retention_last_run":  eventRetention.LastRun(),
+		}
+	})
+	h.RegisterSystemStats("workers", func(ctx context.Context) any {
+		return a.workers.Status()
+	})
+	if c.sheetSync != nil {
+		h.RegisterSystemStats("sheets_sync", func(ctx context.Context) any {
+			return c.sheetSync.Last()
+		})
+	}
+	if c.rates != nil {
+		h.RegisterSystemStats("exchange_rates", func(ctx context.Context) any {
+			current, err := c.rates.Current(ctx)
+			if err != nil {
+				return map[string]string{"error": err.Error()}
+			}
+			return map[string]any{"source": current.Source, "as_of": current.AsOf, "base": current.Base, "currencies": len(current.Rates)}
+		})
+	}
+	if cohortAggregation != nil {
+		h.RegisterSystemStats("analytics", func(ctx context.Context) any {
+			return map[string]any{"cohorts_last_run": cohortAggregation.LastRun()}
+		})
+	}
+	if churnCheck != nil {
+		h.RegisterSystemStats("churn", func(ctx context.Context) any {
+			return map[string]any{"last_run": churnCheck.LastRun()}
+		})
+	}
+	if accountingExporter != nil {
+		h.RegisterSystemStats("accounting", func(ctx context.Context) any {
+			return map[string]any{"format": cfg.Accounting.Format, "last_run": accountingExporter.LastRun()}
+		})
+	}
+	h.RegisterSystemStats("subscription_get", func(ctx context.Context) any {
+		return json.RawMessage(metrics.SubscriptionGet.String())
+	})
+}
@@ -0,0 +1,61 @@
+// Package audit собирает по ходу запроса то, что попадет в его запись аудита:
+// кто прошел аутентификацию и что поменялось в подписках. Запись заводит
+// middleware.Audit, заполняют мидлвары авторизации и слои ниже через контекст
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// Scope - накопитель одного запроса
+type Scope struct {
+	mu      sync.Mutex
+	actor   string
+	changes []domain.AuditChange
+}
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, ctxKey{}, s)
+}
+
+// FromContext - накопитель запроса, nil вне аудируемого запроса (фоновые задачи)
+func FromContext(ctx context.Context) *Scope {
+	s, _ := ctx.Value(ctxKey{}).(*Scope)
+	return s
+}
+
+// SetActor запоминает, кем запрос аутентифицирован
+func SetActor(ctx context.Context, actor string) {
+	if s := FromContext(ctx); s != nil {
+		s.mu.Lock()
+		s.actor = actor
+		s.mu.Unlock()
+	}
+}
+
+// Record добавляет изменение подписки к записи запроса
+func Record(ctx context.Context, change domain.AuditChange) {
+	if s := FromContext(ctx); s != nil {
+		s.mu.Lock()
+		s.changes = append(s.changes, change)
+		s.mu.Unlock()
+	}
+}
+
+// Actor - кем аутентифицирован запрос, пусто для публичных ручек
+func (s *Scope) Actor() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.actor
+}
+
+func (s *Scope) Changes() []domain.AuditChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]domain.AuditChange(nil), s.changes...)
+}
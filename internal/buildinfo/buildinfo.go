@@ -0,0 +1,55 @@
+// Package buildinfo - версия сборки, прокидывается через ldflags:
+//
+//	go build -ldflags "-X github.com/mmoldabe-dev/EffectiveTask/internal/buildinfo.Version=v1.2.0
+//	  -X github.com/mmoldabe-dev/EffectiveTask/internal/buildinfo.Commit=$(git rev-parse HEAD)
+//	  -X github.com/mmoldabe-dev/EffectiveTask/internal/buildinfo.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import (
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+type Info struct {
+	Version   string `json:"version" example:"v1.2.0"`
+	Commit    string `json:"commit" example:"2327666"`
+	BuildDate string `json:"build_date,omitempty" example:"2026-01-15T10:00:00Z"`
+	GoVersion string `json:"go_version" example:"go1.24.6"`
+	Platform  string `json:"platform" example:"linux/amd64"`
+}
+
+// Get собирает информацию о сборке, без ldflags коммит берется из vcs данных go build
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = s.Value
+			}
+		}
+	}
+	return info
+}
+
+// атрибуты для логгера, чтоб по любой строке лога было видно сборку
+func LogAttrs() []any {
+	info := Get()
+	return []any{slog.String("version", info.Version), slog.String("commit", info.Commit)}
+}
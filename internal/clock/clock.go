@@ -0,0 +1,50 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock - источник текущего времени для логики на границах месяцев.
+// Сервис подписок и хендлеры берут время только через него, а не через time.Now
+type Clock interface {
+	Now() time.Time
+}
+
+// Real - системные часы
+type Real struct{}
+
+func (Real) Now() time.Time { return time.Now() }
+
+// Fake стоит на месте, пока его не переставят: для проверок вроде продления в последний день месяца
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// MonthStart - первое число месяца t, в UTC как все месяцы MM-YYYY
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
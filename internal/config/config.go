@@ -3,30 +3,51 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	Logger   LoggerConfig
+	Database     DatabaseConfig
+	Server       ServerConfig
+	Logger       LoggerConfig
+	Reminder     ReminderConfig
+	Scheduled    ScheduledConfig
+	API          APIConfig
+	Import       ImportConfig
+	Pricing      PricingConfig
+	Cost         CostConfig
+	Events       EventsConfig
+	Storage      StorageConfig
+	Sheets       SheetsConfig
+	FX           FXConfig
+	Accounting   AccountingConfig
+	Provisioning ProvisioningConfig
+	Analytics    AnalyticsConfig
+	Policy       PolicyConfig
+	Tax          TaxConfig
 }
 
 type DatabaseConfig struct {
 	Host     string
 	Port     int
 	User     string
-	Password string
+	Password string `secret:"true"`
 	DBName   string
 	SSLMode  string
+
+	// сколько ждать, пока миграции катит другой инстанс
+	MigrationsLockTimeout time.Duration
 }
 
 type ServerConfig struct {
 	Port         string
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+
+	DeleteConfirmPrice int
 }
 
 type LoggerConfig struct {
@@ -34,6 +55,164 @@ type LoggerConfig struct {
 	Format string
 }
 
+type APIConfig struct {
+	// принимать даты вида 2026-01, 01/2026, January 2026
+	AcceptLegacyDates bool
+
+	// plain - числовые id, obfuscated - непоследовательные строки
+	IDEncoding string
+	IDSalt     string `secret:"true"`
+
+	// токен для /admin/*, пустой - админка выключена
+	AdminToken string `secret:"true"`
+
+	// сколько храним ответы по Idempotency-Key
+	IdempotencyTTL time.Duration
+
+	// доля запросов v1, которые зеркалятся в v2 (0..1)
+	ShadowSampleRate float64
+
+	// ключ HMAC для курсоров пагинации, пустой - случайный на процесс
+	CursorSecret string `secret:"true"`
+
+	// как часто реплика перечитывает выключенные маршруты
+	RouteSwitchSync time.Duration
+
+	// Bearer токен для Connect RPC, пустой - без авторизации
+	RPCToken string `secret:"true"`
+	// origin браузерных клиентов RPC через запятую
+	RPCCORSOrigins []string
+
+	// swagger.json от make docs, без файла /swagger выключен
+	DocsFile string
+
+	// лимит json тела запроса, импорт и вложения ограничены отдельно
+	MaxBodyBytes int64
+}
+
+type PricingConfig struct {
+	// off, warn или reject
+	Policy      string
+	CatalogFile string
+	// во сколько раз цена может выйти за типичный диапазон
+	Tolerance int
+}
+
+type CostConfig struct {
+	// процент запросов GetTotalCost, которые считает SQL движок
+	SQLCanaryPercent int
+	// user_id через запятую, которые всегда идут в SQL движок
+	SQLCanaryUsers string
+	// не считать последний месяц отмененной подписки, отмена посреди месяца его не оплачивает
+	ExcludeFinalMonth bool
+	// основная валюта: ставится подпискам без валюты, в ней total_cost и выписки
+	Currency string
+}
+
+type FXConfig struct {
+	// ecb или openexchangerates, пустой - конвертации нет
+	Provider string
+	OXRAppID string `secret:"true"`
+	// как часто забирать свежие курсы
+	RefreshInterval time.Duration
+}
+
+type AccountingConfig struct {
+	// 1c или csv, пустой - выгрузки для бухгалтерии выключены
+	Format string
+	// ключ подписи ссылок на файлы, пустой - случайный до рестарта
+	URLSecret string `secret:"true"`
+	URLTTL    time.Duration
+	// как часто проверять, выгружен ли прошлый месяц, 0 - только вручную
+	Interval time.Duration
+}
+
+type AnalyticsConfig struct {
+	// как часто пересчитывать когорты, 0 - аналитика выключена
+	CohortInterval time.Duration
+	// как часто искать ушедшие подписки и писать churned в ленту, 0 - не искать
+	ChurnInterval time.Duration
+}
+
+type PolicyConfig struct {
+	// сколько ждать ответа хука политики перед create и extend
+	HookTimeout time.Duration
+}
+
+type TaxConfig struct {
+	// ставки НДС вида RU=20, пустой список - налоговый слой выключен
+	Rates []string
+	// страна подписок без tax_country
+	DefaultCountry string
+	// net или gross для подписок без price_basis
+	PriceBasis string
+}
+
+type ProvisioningConfig struct {
+	// bearer токен IdP, пустой - /provisioning выключен
+	Token string `secret:"true"`
+	// cancel или transfer: что делать с подписками деактивированного пользователя
+	OffboardPolicy string
+	// кому передавать подписки при transfer
+	TransferTo string
+}
+
+type EventsConfig struct {
+	// сколько храним события ленты, 0 - вечно
+	Retention       time.Duration
+	CleanupInterval time.Duration
+}
+
+type StorageConfig struct {
+	// local или s3, пустой - вложения выключены
+	Backend  string
+	LocalDir string
+
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string `secret:"true"`
+
+	// предельный размер одного вложения
+	AttachmentMaxBytes int64
+}
+
+type SheetsConfig struct {
+	// таблица-реестр подписок, пустой id - синхронизация выключена
+	SpreadsheetID string
+	// диапазон в A1 нотации, первая строка - заголовок
+	Range string
+	// json ключ сервисного аккаунта, таблица расшарена на его client_email
+	CredentialsFile string
+	// пары поле=заголовок колонки, поля без пары ищутся по своему имени
+	Columns  []string
+	Interval time.Duration
+}
+
+type ImportConfig struct {
+	MaxBytes      int64
+	ChunkSize     int
+	TargetLatency time.Duration
+	MaxPause      time.Duration
+}
+
+type ReminderConfig struct {
+	Interval   time.Duration
+	LeadMonths int
+	Repeat     time.Duration
+	// как часто искать триалы, которые со следующего месяца станут платными
+	TrialInterval time.Duration
+}
+
+// отложенные действия над подписками (автопродление, конец триала, смена цены)
+type ScheduledConfig struct {
+	// как часто искать наступившие действия
+	Interval time.Duration
+	// после стольких неудачных попыток действие помечается failed
+	MaxAttempts int
+}
+
 func LoadConfig() (*Config, error) {
 	_ = godotenv.Load()
 
@@ -45,16 +224,112 @@ func LoadConfig() (*Config, error) {
 			Password: getEnv("DB_PASSWORD", "postgres"),
 			DBName:   getEnv("DB_NAME", "subscription_db"),
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+
+			MigrationsLockTimeout: getEnvAsDuration("DB_MIGRATIONS_LOCK_TIMEOUT", 60),
 		},
 		Server: ServerConfig{
 			Port:         getEnv("SERVER_PORT", "8080"),
 			ReadTimeout:  getEnvAsDuration("SERVER_READ_TIMEOUT", 10),
 			WriteTimeout: getEnvAsDuration("SERVER_WRITE_TIMEOUT", 10),
+
+			DeleteConfirmPrice: getEnvAsInt("DELETE_CONFIRM_PRICE", 0),
 		},
 		Logger: LoggerConfig{
 			Level:  getEnv("LOG_LEVEL", "debug"),
 			Format: getEnv("LOG_FORMAT", "text"),
 		},
+		Reminder: ReminderConfig{
+			Interval:   getEnvAsDuration("REMINDER_INTERVAL", 3600),
+			LeadMonths: getEnvAsInt("REMINDER_LEAD_MONTHS", 1),
+			Repeat:     getEnvAsDuration("REMINDER_REPEAT", 86400),
+
+			TrialInterval: getEnvAsDuration("TRIAL_CHECK_INTERVAL", 3600),
+		},
+		Scheduled: ScheduledConfig{
+			Interval:    getEnvAsDuration("SCHEDULED_EVENTS_INTERVAL", 30),
+			MaxAttempts: getEnvAsInt("SCHEDULED_EVENTS_MAX_ATTEMPTS", 5),
+		},
+		Import: ImportConfig{
+			MaxBytes:      int64(getEnvAsInt("IMPORT_MAX_MB", 200)) << 20,
+			ChunkSize:     getEnvAsInt("IMPORT_CHUNK_SIZE", 500),
+			TargetLatency: time.Duration(getEnvAsInt("IMPORT_TARGET_LATENCY_MS", 200)) * time.Millisecond,
+			MaxPause:      time.Duration(getEnvAsInt("IMPORT_MAX_PAUSE_MS", 2000)) * time.Millisecond,
+		},
+		Storage: StorageConfig{
+			Backend:            getEnv("STORAGE_BACKEND", "local"),
+			LocalDir:           getEnv("STORAGE_LOCAL_DIR", "./data/attachments"),
+			S3Endpoint:         getEnv("S3_ENDPOINT", ""),
+			S3Bucket:           getEnv("S3_BUCKET", ""),
+			S3Region:           getEnv("S3_REGION", "us-east-1"),
+			S3AccessKey:        getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey:        getEnv("S3_SECRET_KEY", ""),
+			AttachmentMaxBytes: int64(getEnvAsInt("ATTACHMENT_MAX_MB", 10)) << 20,
+		},
+		Sheets: SheetsConfig{
+			SpreadsheetID:   getEnv("SHEETS_SPREADSHEET_ID", ""),
+			Range:           getEnv("SHEETS_RANGE", "Sheet1"),
+			CredentialsFile: getEnv("SHEETS_CREDENTIALS_FILE", ""),
+			Columns:         getEnvAsList("SHEETS_COLUMNS"),
+			Interval:        getEnvAsDuration("SHEETS_SYNC_INTERVAL", 3600),
+		},
+		Pricing: PricingConfig{
+			Policy:      getEnv("PRICE_POLICY", "warn"),
+			CatalogFile: getEnv("PRICE_CATALOG_FILE", ""),
+			Tolerance:   getEnvAsInt("PRICE_TOLERANCE", 10),
+		},
+		FX: FXConfig{
+			Provider:        getEnv("FX_PROVIDER", ""),
+			OXRAppID:        getEnv("FX_OXR_APP_ID", ""),
+			RefreshInterval: getEnvAsDuration("FX_REFRESH_INTERVAL", 21600),
+		},
+		Accounting: AccountingConfig{
+			Format:    getEnv("ACCOUNTING_FORMAT", ""),
+			URLSecret: getEnv("ACCOUNTING_URL_SECRET", ""),
+			URLTTL:    getEnvAsDuration("ACCOUNTING_URL_TTL", 3600),
+			Interval:  getEnvAsDuration("ACCOUNTING_EXPORT_INTERVAL", 86400),
+		},
+		Analytics: AnalyticsConfig{
+			CohortInterval: getEnvAsDuration("ANALYTICS_COHORT_INTERVAL", 0),
+			ChurnInterval:  getEnvAsDuration("ANALYTICS_CHURN_INTERVAL", 3600),
+		},
+		Policy: PolicyConfig{
+			HookTimeout: getEnvAsDuration("POLICY_HOOK_TIMEOUT", 5),
+		},
+		Tax: TaxConfig{
+			Rates:          getEnvAsList("TAX_RATES"),
+			DefaultCountry: getEnv("TAX_DEFAULT_COUNTRY", "RU"),
+			PriceBasis:     getEnv("TAX_PRICE_BASIS", "gross"),
+		},
+		Provisioning: ProvisioningConfig{
+			Token:          getEnv("PROVISIONING_TOKEN", ""),
+			OffboardPolicy: getEnv("PROVISIONING_OFFBOARD_POLICY", "cancel"),
+			TransferTo:     getEnv("PROVISIONING_TRANSFER_TO", ""),
+		},
+		Events: EventsConfig{
+			Retention:       time.Duration(getEnvAsInt("EVENTS_RETENTION_DAYS", 0)) * 24 * time.Hour,
+			CleanupInterval: getEnvAsDuration("EVENTS_CLEANUP_INTERVAL", 3600),
+		},
+		Cost: CostConfig{
+			SQLCanaryPercent: getEnvAsInt("COST_SQL_CANARY_PERCENT", 0),
+			SQLCanaryUsers:   getEnv("COST_SQL_CANARY_USERS", ""),
+
+			ExcludeFinalMonth: getEnvAsBool("COST_EXCLUDE_FINAL_MONTH", false),
+			Currency:          getEnv("COST_CURRENCY", "RUB"),
+		},
+		API: APIConfig{
+			AcceptLegacyDates: getEnvAsBool("API_ACCEPT_LEGACY_DATES", false),
+			IDEncoding:        getEnv("API_ID_ENCODING", "plain"),
+			IDSalt:            getEnv("API_ID_SALT", ""),
+			AdminToken:        getEnv("API_ADMIN_TOKEN", ""),
+			IdempotencyTTL:    getEnvAsDuration("API_IDEMPOTENCY_TTL", 86400),
+			ShadowSampleRate:  getEnvAsFloat("API_SHADOW_SAMPLE_RATE", 0),
+			CursorSecret:      getEnv("API_CURSOR_SECRET", ""),
+			RouteSwitchSync:   getEnvAsDuration("API_ROUTE_SWITCH_SYNC", 10),
+			RPCToken:          getEnv("API_RPC_TOKEN", ""),
+			RPCCORSOrigins:    getEnvAsList("API_RPC_CORS_ORIGINS"),
+			DocsFile:          getEnv("API_DOCS_FILE", "docs/swagger.json"),
+			MaxBodyBytes:      int64(getEnvAsInt("API_MAX_BODY_KB", 1024)) << 10,
+		},
 	}, nil
 }
 
@@ -73,6 +348,33 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// значения через запятую, пустые элементы выкидываем
+func getEnvAsList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, ""), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(key string, seconds int) time.Duration {
 	valueStr := getEnv(key, "")
 	if value, err := strconv.Atoi(valueStr); err == nil {
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// Redacted отдает конфиг в виде дерева для /admin/config: поля с тегом
+// secret:"true" скрыты, длительности строками. Новые секреты достаточно пометить тегом
+func (c *Config) Redacted() map[string]any {
+	return redactStruct(reflect.ValueOf(*c))
+}
+
+func redactStruct(v reflect.Value) map[string]any {
+	out := make(map[string]any, v.NumField())
+	t := v.Type()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+
+		switch {
+		case field.Tag.Get("secret") == "true":
+			// пустой секрет показываем как есть, чтоб было видно что он не задан
+			if fv.IsZero() {
+				out[field.Name] = ""
+			} else {
+				out[field.Name] = redacted
+			}
+		case fv.Type() == reflect.TypeOf(time.Duration(0)):
+			out[field.Name] = fv.Interface().(time.Duration).String()
+		case fv.Kind() == reflect.Struct:
+			out[field.Name] = redactStruct(fv)
+		default:
+			out[field.Name] = fv.Interface()
+		}
+	}
+	return out
+}
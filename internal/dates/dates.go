@@ -0,0 +1,176 @@
+package dates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// формат в котором даты хранятся и отдаются наружу
+const Layout = "01-2006"
+
+// полная дата с днем, день хранится отдельно от месяца в start_day/end_day
+const DayLayout = "2006-01-02"
+
+// ISO месяц, его шлют календари фронтенда
+const ISOLayout = "2006-01"
+
+var ErrBadDate = errors.New("bad date format, expected MM-YYYY, YYYY-MM or YYYY-MM-DD")
+
+var (
+	canonicalRegex = regexp.MustCompile(`^(0[1-9]|1[0-2])-\d{4}$`)
+	isoMonthRegex  = regexp.MustCompile(`^\d{4}-(0[1-9]|1[0-2])$`)
+	fullDateRegex  = regexp.MustCompile(`^\d{4}-(0[1-9]|1[0-2])-(0[1-9]|[12]\d|3[01])$`)
+	monthYearRegex = regexp.MustCompile(`^(\d{1,2})[-/.](\d{4})$`)
+	yearMonthRegex = regexp.MustCompile(`^(\d{4})-(\d{1,2})$`)
+	monthNameRegex = regexp.MustCompile(`^([A-Za-z]+)\.?\s+(\d{4})$`)
+)
+
+// Parser приводит входные даты к MM-YYYY, ISO YYYY-MM принимается всегда. Legacy включает
+// альтернативные форматы партнеров: 2026-1, 01/2026, 1-2026, January 2026, Jan 2026.
+type Parser struct {
+	Legacy bool
+}
+
+func (p Parser) Normalize(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if canonicalRegex.MatchString(s) {
+		return s, nil
+	}
+	if isoMonthRegex.MatchString(s) {
+		return s[5:] + "-" + s[:4], nil
+	}
+
+	if !p.Legacy {
+		return "", fmt.Errorf("%w: %q", ErrBadDate, raw)
+	}
+
+	var month, year int
+	switch {
+	case monthYearRegex.MatchString(s):
+		m := monthYearRegex.FindStringSubmatch(s)
+		month, _ = strconv.Atoi(m[1])
+		year, _ = strconv.Atoi(m[2])
+	case yearMonthRegex.MatchString(s):
+		m := yearMonthRegex.FindStringSubmatch(s)
+		year, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+	case monthNameRegex.MatchString(s):
+		m := monthNameRegex.FindStringSubmatch(s)
+		month = monthByName(m[1])
+		year, _ = strconv.Atoi(m[2])
+	}
+
+	if month < 1 || month > 12 {
+		return "", fmt.Errorf("%w: %q", ErrBadDate, raw)
+	}
+
+	return fmt.Sprintf("%02d-%04d", month, year), nil
+}
+
+// NormalizeDay как Normalize, но принимает и YYYY-MM-DD: тогда день возвращается
+// отдельно, для месяца без дня day пустой
+func (p Parser) NormalizeDay(raw string) (month string, day *int, err error) {
+	if month, day, ok := SplitDay(strings.TrimSpace(raw)); ok {
+		return month, day, nil
+	}
+	month, err = p.Normalize(raw)
+	return month, nil, err
+}
+
+// SplitDay разбирает YYYY-MM-DD на месяц MM-YYYY и день, несуществующие даты вроде 2026-02-30 не проходят
+func SplitDay(s string) (string, *int, bool) {
+	if !fullDateRegex.MatchString(s) {
+		return "", nil, false
+	}
+	t, err := time.Parse(DayLayout, s)
+	if err != nil {
+		return "", nil, false
+	}
+	day := t.Day()
+	return t.Format(Layout), &day, true
+}
+
+// Full собирает YYYY-MM-DD из месяца и дня, nil если день не известен
+func Full(month string, day *int) *string {
+	if day == nil {
+		return nil
+	}
+	t, err := time.Parse(Layout, month)
+	if err != nil {
+		return nil
+	}
+	full := t.AddDate(0, 0, *day-1).Format(DayLayout)
+	return &full
+}
+
+// Parse нормализует и сразу возвращает первое число месяца
+func (p Parser) Parse(raw string) (time.Time, error) {
+	s, err := p.Normalize(raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(Layout, s)
+}
+
+// формат месяцев в ответах API, выбирается параметром date_format
+type Format string
+
+const (
+	// MM-YYYY, по умолчанию
+	FormatMonthYear Format = "mm-yyyy"
+	// YYYY-MM
+	FormatISO Format = "iso"
+)
+
+var ErrBadFormat = errors.New("date_format must be mm-yyyy or iso")
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return FormatMonthYear, nil
+	case FormatMonthYear, FormatISO:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrBadFormat, s)
+	}
+}
+
+// Month переводит месяц MM-YYYY в формат ответа, нераспознанное отдает как есть
+func (f Format) Month(month string) string {
+	if f != FormatISO {
+		return month
+	}
+	t, err := time.Parse(Layout, month)
+	if err != nil {
+		return month
+	}
+	return t.Format(ISOLayout)
+}
+
+// MonthPtr как Month для необязательных дат
+func (f Format) MonthPtr(month *string) *string {
+	if month == nil {
+		return nil
+	}
+	s := f.Month(*month)
+	return &s
+}
+
+func monthByName(name string) int {
+	name = strings.ToLower(name)
+	if len(name) < 3 {
+		return 0
+	}
+
+	for m := time.January; m <= time.December; m++ {
+		full := strings.ToLower(m.String())
+		if name == full || name == full[:3] {
+			return int(m)
+		}
+	}
+	return 0
+}
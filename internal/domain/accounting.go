@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// строка бухгалтерской выгрузки: начисление по одной подписке за закрытый месяц
+type AccountingLine struct {
+	Period         time.Time
+	UserID         uuid.UUID
+	SubscriptionID int64
+	ServiceName    string
+	CategoryName   string
+	BillingPeriod  string
+	Price          Money
+	Months         int
+	Amount         Money
+	Currency       string
+}
+
+// сформированный файл выгрузки за месяц, содержимое отдается по подписанной ссылке
+type AccountingExport struct {
+	ID        int64     `json:"id" example:"1"`
+	Period    string    `json:"period" example:"03-2026"`
+	Format    string    `json:"format" example:"1c"`
+	Rows      int       `json:"rows" example:"42"`
+	Size      int       `json:"size" example:"5120"`
+	SHA256    string    `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	CreatedAt time.Time `json:"created_at"`
+}
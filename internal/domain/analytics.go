@@ -0,0 +1,66 @@
+package domain
+
+import "time"
+
+// когорта - пользователи, у которых первая подписка заведена в месяце Cohort
+type Cohort struct {
+	Cohort string        `json:"cohort" example:"01-2026"`
+	Users  int           `json:"users" example:"50"`
+	Months []CohortMonth `json:"months"`
+}
+
+// когорта в одном месяце. Средние считаются на всех пользователей когорты,
+// ушедшие тянут их вниз, так видно удержание
+type CohortMonth struct {
+	Month string `json:"month" example:"03-2026"`
+	// месяцев с прихода когорты
+	Offset        int   `json:"offset" example:"2"`
+	ActiveUsers   int   `json:"active_users" example:"40"`
+	Subscriptions int   `json:"subscriptions" example:"96"`
+	Spend         Money `json:"spend" example:"84000"`
+
+	Retention        float64 `json:"retention" example:"0.8"`
+	AvgSubscriptions float64 `json:"avg_subscriptions" example:"1.92"`
+	AvgSpend         Money   `json:"avg_spend" example:"1680"`
+}
+
+// когортная таблица из последней агрегации, расходы только в основной валюте
+type CohortReport struct {
+	Currency   string     `json:"currency" example:"RUB"`
+	ComputedAt *time.Time `json:"computed_at,omitempty"`
+	Cohorts    []Cohort   `json:"cohorts"`
+}
+
+// сервис в одном месяце по всей платформе. Subscribers - разные пользователи,
+// Revenue только в основной валюте
+type ServiceMonth struct {
+	Month         string `json:"month" example:"03-2026"`
+	ServiceName   string `json:"service_name" example:"Netflix"`
+	Subscribers   int    `json:"subscribers" example:"120"`
+	Subscriptions int    `json:"subscriptions" example:"124"`
+	Revenue       Money  `json:"revenue" example:"99076"`
+}
+
+// лидеры сервисов за месяц из последней агрегации
+type TopServicesReport struct {
+	Period        string         `json:"period" example:"03-2026"`
+	Currency      string         `json:"currency" example:"RUB"`
+	ComputedAt    *time.Time     `json:"computed_at,omitempty"`
+	BySubscribers []ServiceMonth `json:"by_subscribers"`
+	ByRevenue     []ServiceMonth `json:"by_revenue"`
+}
+
+// уход с сервиса за период: Subscriptions - подписки, которые были в периоде (с учетом льготы),
+// Churned - из них истекшие в периоде без продления, ChurnRate - их доля
+type ServiceChurn struct {
+	ServiceName   string  `json:"service_name" example:"Netflix"`
+	Subscriptions int     `json:"subscriptions" example:"120"`
+	Churned       int     `json:"churned" example:"9"`
+	ChurnRate     float64 `json:"churn_rate" example:"0.075"`
+}
+
+type ChurnReport struct {
+	From     string         `json:"from" example:"04-2025"`
+	To       string         `json:"to" example:"03-2026"`
+	Services []ServiceChurn `json:"services"`
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// вложение подписки: договор, чек. Файл в хранилище по StorageKey
+type Attachment struct {
+	ID             int64     `json:"id" example:"1"`
+	SubscriptionID int64     `json:"subscription_id" example:"10"`
+	FileName       string    `json:"file_name" example:"contract.pdf"`
+	ContentType    string    `json:"content_type" example:"application/pdf"`
+	SizeBytes      int64     `json:"size_bytes" example:"48213"`
+	SHA256         string    `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	StorageKey     string    `json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+}
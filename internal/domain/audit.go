@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// запись журнала аудита. Записи сцеплены хэшами: hash считается от prev_hash
+// и полей записи, поэтому правка или удаление строки в базе ломает цепочку
+type AuditRecord struct {
+	ID        int64           `json:"id" example:"1"`
+	At        time.Time       `json:"at"`
+	Actor     string          `json:"actor" example:"admin"`
+	Action    string          `json:"action" example:"PUT /subscriptions/{id}"`
+	EntityID  string          `json:"entity_id,omitempty" example:"10"`
+	RequestID string          `json:"request_id" example:"3f1c2a9e-6b0d-4c1e-9a57-0f5c3e2b7d41"`
+	Status    int             `json:"status" example:"200"`
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// Seal привязывает запись к предыдущей в цепочке
+func (a *AuditRecord) Seal(prevHash string) {
+	a.PrevHash = prevHash
+	a.Hash = a.digest()
+}
+
+// Valid проверяет, что запись не меняли после Seal
+func (a *AuditRecord) Valid() bool {
+	return a.Hash == a.digest()
+}
+
+func (a *AuditRecord) digest() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n%s\n%s\n%d\n",
+		a.PrevHash, a.At.UTC().Format(time.RFC3339Nano), a.Actor, a.Action, a.EntityID, a.RequestID, a.Status)
+	h.Write(a.Payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// что запрос сделал с подпиской: правка - поля до и после, создание и удаление -
+// запись целиком
+type AuditChange struct {
+	SubscriptionID int64                  `json:"subscription_id" example:"10"`
+	Action         string                 `json:"action" example:"updated"`
+	Changes        map[string]FieldChange `json:"changes,omitempty"`
+	Before         *Subscription          `json:"before,omitempty"`
+	After          *Subscription          `json:"after,omitempty"`
+}
+
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	EntityID string
+	Limit    int
+	Offset   int
+}
+
+// результат проверки цепочки, BrokenAt - id первой испорченной записи
+type AuditVerification struct {
+	OK       bool  `json:"ok" example:"true"`
+	Checked  int64 `json:"checked" example:"1500"`
+	BrokenAt int64 `json:"broken_at,omitempty" example:"0"`
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "strings"
+
+// за какой период указана цена подписки
+const (
+	BillingWeekly    = "weekly"
+	BillingMonthly   = "monthly"
+	BillingQuarterly = "quarterly"
+	BillingYearly    = "yearly"
+)
+
+var BillingPeriods = []string{BillingWeekly, BillingMonthly, BillingQuarterly, BillingYearly}
+
+// сколько двенадцатых долей цены приходится на один месяц. Через общий
+// знаменатель 12 месяцы суммируются точно, а округляется только итог
+var BillingTwelfths = map[string]int64{
+	BillingWeekly:    52,
+	BillingMonthly:   12,
+	BillingQuarterly: 4,
+	BillingYearly:    1,
+}
+
+// NormalizeBillingPeriod приводит период к нижнему регистру, false если такого нет
+func NormalizeBillingPeriod(period string) (string, bool) {
+	period = strings.ToLower(strings.TrimSpace(period))
+	_, ok := BillingTwelfths[period]
+	return period, ok
+}
+
+// ForMonths - стоимость months месяцев подписки с ценой m за period,
+// округление до копейки половиной вверх (как ROUND в Postgres). Пустой период - помесячно
+func (m Money) ForMonths(period string, months int) Money {
+	twelfths, ok := BillingTwelfths[period]
+	if !ok {
+		twelfths = BillingTwelfths[BillingMonthly]
+	}
+
+	n := int64(m) * int64(months) * twelfths
+	if n < 0 {
+		return -Money((-n + 6) / 12)
+	}
+	return Money((n + 6) / 12)
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// бюджет на месяц. Category пока совпадает с названием сервиса,
+// пустая - бюджет на все подписки пользователя
+type Budget struct {
+	ID        int64     `json:"id" example:"1"`
+	UserID    uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Period    string    `json:"period" example:"03-2026"`
+	Amount    Money     `json:"amount" example:"2000"`
+	Category  *string   `json:"category,omitempty" example:"Netflix"`
+	CreatedAt time.Time `json:"created_at,omitempty" swaggerignore:"true"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" swaggerignore:"true"`
+}
+
+// фактические расходы месяца против бюджета
+type BudgetStatus struct {
+	Budget      Budget  `json:"budget"`
+	Spent       Money   `json:"spent" example:"2398"`
+	Remaining   Money   `json:"remaining" example:"-398"`
+	UsedPercent float64 `json:"used_percent" example:"119.9"`
+	Overspent   bool    `json:"overspent" example:"true"`
+}
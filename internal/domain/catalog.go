@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// запись каталога сервисов: каноническое название и варианты, которыми его пишут пользователи
+type CatalogEntry struct {
+	ID        int64     `json:"id" example:"1"`
+	Name      string    `json:"name" example:"Spotify"`
+	Aliases   []string  `json:"aliases" example:"spotify premium,spotify family"`
+	LogoURL   string    `json:"logo_url,omitempty" example:"https://cdn.example.com/logos/spotify.png"`
+	CreatedAt time.Time `json:"created_at,omitempty" swaggerignore:"true"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" swaggerignore:"true"`
+}
+
+// CatalogKey - форма названия для сравнения: нижний регистр, одиночные пробелы
+func CatalogKey(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
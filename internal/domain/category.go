@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// категория подписок (Streaming, Cloud, Fitness...), общий справочник для всех пользователей
+type Category struct {
+	ID        int64     `json:"id" example:"1"`
+	Name      string    `json:"name" example:"Streaming"`
+	CreatedAt time.Time `json:"created_at,omitempty" swaggerignore:"true"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" swaggerignore:"true"`
+}
+
+// расходы по категории за период в одной валюте, подписки без категории идут строкой с пустыми полями
+type CategoryCost struct {
+	CategoryID *int64  `json:"category_id" example:"1"`
+	Category   *string `json:"category" example:"Streaming"`
+	Cost       Money   `json:"cost" example:"1598"`
+	Currency   string  `json:"currency" example:"RUB"`
+}
@@ -0,0 +1,115 @@
+package domain
+
+import "github.com/google/uuid"
+
+// как считать неполные месяцы в /subscriptions/total
+const (
+	// целыми месяцами, по умолчанию
+	ProrationMonthly = "monthly"
+	// первый и последний месяц по дням из start_day и end_day
+	ProrationDaily = "daily"
+)
+
+// расходы по одной подписке за период
+type CostDetail struct {
+	ServiceName string `json:"service_name" example:"Spotify Premium"`
+	Months      int    `json:"months" example:"12"`
+	Cost        Money  `json:"cost" example:"6000"`
+	Currency    string `json:"currency" example:"RUB"`
+}
+
+// итог в одной валюте
+type CurrencyCost struct {
+	Currency string `json:"currency" example:"USD"`
+	Cost     Money  `json:"cost" example:"120"`
+}
+
+// Total только в основной валюте Currency, разные валюты не складываются:
+// суммы по каждой валюте, включая основную, лежат в Totals
+type TotalCost struct {
+	Total    Money          `json:"total_cost" example:"6000"`
+	Currency string         `json:"currency" example:"RUB"`
+	Totals   []CurrencyCost `json:"totals"`
+	Details  []CostDetail   `json:"details"`
+	Months   []MonthCost    `json:"months"`
+	// итоги по категориям, подписки без категории одной строкой в конце
+	Categories []CategoryCost `json:"categories"`
+	// курсы, если суммы пересчитаны в одну валюту через convert_to
+	Converted *Conversion `json:"converted,omitempty"`
+	// на какой базе суммы, если цены приведены через tax_basis
+	Tax *TaxSummary `json:"tax,omitempty"`
+}
+
+// по каким курсам пересчитан ответ: rates - сколько единиц целевой валюты за единицу исходной
+type Conversion struct {
+	Source string             `json:"source" example:"ecb"`
+	AsOf   string             `json:"as_of" example:"2026-03-02"`
+	Rates  map[string]float64 `json:"rates"`
+}
+
+// итоги одного пользователя в пакетном расчете: total_cost в основной валюте,
+// по валютам в totals, как у /subscriptions/total
+type UserTotalCost struct {
+	UserID uuid.UUID      `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Total  Money          `json:"total_cost" example:"6000"`
+	Totals []CurrencyCost `json:"totals"`
+}
+
+// суммы ответа в Basis, итоги есть на обеих базах: total_* как total_cost в основной
+// валюте, totals_* по валютам как totals. Rates - ставки НДС в процентах по странам подписок
+type TaxSummary struct {
+	Basis       string             `json:"basis" example:"net"`
+	TotalNet    Money              `json:"total_net" example:"5000"`
+	TotalGross  Money              `json:"total_gross" example:"6000"`
+	TotalsNet   []CurrencyCost     `json:"totals_net"`
+	TotalsGross []CurrencyCost     `json:"totals_gross"`
+	Rates       map[string]float64 `json:"rates"`
+}
+
+// расходы за один месяц периода в одной валюте, для графиков.
+// Основная валюта есть за каждый месяц, другие валюты - отдельными строками того же месяца
+type MonthCost struct {
+	Month    string           `json:"month" example:"03-2026"`
+	Currency string           `json:"currency" example:"RUB"`
+	Total    Money            `json:"total" example:"1299"`
+	Services map[string]Money `json:"services"`
+}
+
+// группировки расходов для group_by
+const (
+	GroupByService = "service"
+	GroupByMonth   = "month"
+	GroupByBoth    = "both"
+)
+
+var GroupByOptions = []string{GroupByService, GroupByMonth, GroupByBoth}
+
+// расходы с группировкой: при both сверху месяцы, внутри сервисы
+type GroupedCost struct {
+	Total   Money       `json:"total_cost" example:"6000"`
+	GroupBy string      `json:"group_by" example:"month"`
+	Groups  []CostGroup `json:"groups"`
+}
+
+type CostGroup struct {
+	ServiceName string      `json:"service_name,omitempty" example:"Netflix"`
+	Month       string      `json:"month,omitempty" example:"03-2026"`
+	Months      int         `json:"months,omitempty" example:"12"`
+	Cost        Money       `json:"cost" example:"799"`
+	Services    []CostGroup `json:"services,omitempty"`
+}
+
+// прогноз расходов по месяцам вперед от текущего
+type Forecast struct {
+	Total  Money           `json:"total" example:"9588"`
+	Months []ForecastMonth `json:"months"`
+}
+
+// Assumed - сумма месяца опирается на допущения, причины в Assumptions
+type ForecastMonth struct {
+	Month       string           `json:"month" example:"03-2026"`
+	Total       Money            `json:"total" example:"799"`
+	Services    map[string]Money `json:"services"`
+	Assumed     bool             `json:"assumed" example:"true"`
+	Assumptions []string         `json:"assumptions,omitempty" example:"Netflix: open-ended, price 799 assumed unchanged"`
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "errors"
+
+// общие виды ошибок: репозиторий и сервис оборачивают их через %w,
+// хендлеры выбирают HTTP статус по errors.Is, а не по тексту
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+	ErrInvalid  = errors.New("invalid input")
+)
+
+var (
+	ErrUnknownCategory = errors.New("category does not exist")
+	// у юзера уже есть активная подписка на этот сервис
+	ErrSubscriptionExists error = &kindError{kind: ErrConflict, msg: "subscription already exists"}
+	// подписку успели поменять после того, как клиент ее прочитал
+	ErrVersionConflict error = &kindError{kind: ErrConflict, msg: "subscription was changed by another request, reload it and retry"}
+	// повтор с Idempotency-Key, но другим телом
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used with a different request")
+	// первый запрос с этим ключом еще не ответил
+	ErrIdempotencyInFlight = errors.New("request with this idempotency key is in progress")
+)
+
+// Invalid - ошибка ввода со своим текстом, errors.Is(err, ErrInvalid) для нее true
+func Invalid(msg string) error {
+	return &kindError{kind: ErrInvalid, msg: msg}
+}
+
+// NotFound - отсутствующий объект со своим текстом, errors.Is(err, ErrNotFound) для нее true
+func NotFound(msg string) error {
+	return &kindError{kind: ErrNotFound, msg: msg}
+}
+
+// Conflict - конфликт с текущим состоянием со своим текстом, errors.Is(err, ErrConflict) для нее true
+func Conflict(msg string) error {
+	return &kindError{kind: ErrConflict, msg: msg}
+}
+
+// ошибка со своим текстом, которая считается одним из общих видов
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+
+func (e *kindError) Is(target error) bool { return target == e.kind }
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventCreated   = "created"
+	EventExtended  = "extended"
+	EventUpdated   = "updated"
+	EventCancelled = "cancelled"
+	EventDeleted   = "deleted"
+	EventPaused    = "paused"
+	EventResumed   = "resumed"
+	// запланирована или отменена сезонная пауза
+	EventPauseScheduled   = "pause_scheduled"
+	EventPauseUnscheduled = "pause_unscheduled"
+	EventPriceChanged     = "price_changed"
+	EventReminderSent     = "reminder_sent"
+	EventTransferred      = "transferred"
+	EventTrialEnding      = "trial_ending"
+	// триал закончен отложенным действием trial_conversion
+	EventTrialConverted = "trial_converted"
+	// подписка истекла после льготы и не продлена
+	EventChurned = "churned"
+)
+
+type Event struct {
+	ID             int64           `json:"id" example:"1"`
+	UserID         uuid.UUID       `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	SubscriptionID *int64          `json:"subscription_id,omitempty" example:"10"`
+	Type           string          `json:"type" example:"created"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type EventFilter struct {
+	UserID uuid.UUID
+	Limit  int
+	Offset int
+}
+
+// состояние таблицы событий для админки и метрик
+type EventBacklog struct {
+	Total            int64            `json:"total" example:"12000"`
+	OldestAt         *time.Time       `json:"oldest_at,omitempty"`
+	OldestAgeSeconds int64            `json:"oldest_age_seconds" example:"86400"`
+	ByType           map[string]int64 `json:"by_type"`
+}
+
+// состояние подписки после события, собирается проигрыванием ленты
+type TimelineState struct {
+	ServiceName string  `json:"service_name,omitempty" example:"Netflix"`
+	Price       Money   `json:"price" example:"799"`
+	StartDate   string  `json:"start_date,omitempty" example:"01-2026"`
+	EndDate     *string `json:"end_date,omitempty" example:"12-2026"`
+	Status      string  `json:"status" example:"active"`
+}
+
+type TimelineEntry struct {
+	At    time.Time     `json:"at"`
+	Event string        `json:"event" example:"price_changed"`
+	State TimelineState `json:"state"`
+}
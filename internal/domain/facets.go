@@ -0,0 +1,33 @@
+package domain
+
+// нижние границы корзин цены для фасетов, цена ниже первой - бесплатные подписки
+var PriceBucketBounds = []Money{1, Major(500), Major(1000), Major(3000)}
+
+// SubscriptionFacets - сколько подписок пользователя попадает под каждое значение фильтров списка
+type SubscriptionFacets struct {
+	PriceBuckets []PriceBucketCount `json:"price_buckets"`
+	// все статусы из Statuses, в том числе с нулем
+	Statuses []StatusCount `json:"statuses"`
+	// только категории, в которых есть подписки
+	Categories []CategoryCount `json:"categories"`
+}
+
+// PriceBucketCount - корзина цены. Границы включительно, как min_price и max_price списка,
+// без max_price - все что дороже min_price
+type PriceBucketCount struct {
+	MinPrice Money  `json:"min_price" example:"500"`
+	MaxPrice *Money `json:"max_price,omitempty"`
+	Count    int    `json:"count" example:"4"`
+}
+
+type StatusCount struct {
+	Status string `json:"status" example:"active"`
+	Count  int    `json:"count" example:"7"`
+}
+
+// CategoryCount - подписки категории, nil category_id - без категории
+type CategoryCount struct {
+	CategoryID *int64 `json:"category_id" example:"1"`
+	Name       string `json:"name,omitempty" example:"Entertainment"`
+	Count      int    `json:"count" example:"3"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// старое и новое значение одного поля
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// одна правка подписки, в changes только поля, которые реально поменялись
+type HistoryEntry struct {
+	ID             int64                  `json:"id" example:"1"`
+	SubscriptionID int64                  `json:"subscription_id" example:"10"`
+	Action         string                 `json:"action" example:"extended"`
+	Changes        map[string]FieldChange `json:"changes"`
+	ChangedAt      time.Time              `json:"changed_at"`
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// сохраненный ответ на запрос с Idempotency-Key
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	StatusCode  int // 0 - запрос еще выполняется
+	ContentType string
+	Response    []byte
+	CreatedAt   time.Time
+}
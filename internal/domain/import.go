@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+const (
+	ImportModeStrict  = "strict"
+	ImportModeLenient = "lenient"
+
+	ImportStatusRunning = "running"
+	ImportStatusDone    = "done"
+)
+
+type ImportIssue struct {
+	Row     int    `json:"row" example:"3"`
+	Field   string `json:"field,omitempty" example:"start_date"`
+	Message string `json:"message" example:"month padded: 1-2026 -> 01-2026"`
+}
+
+type ImportResult struct {
+	JobID    int64         `json:"job_id" example:"7"`
+	Mode     string        `json:"mode" example:"lenient"`
+	Total    int           `json:"total" example:"10"`
+	Imported int           `json:"imported" example:"9"`
+	Skipped  int           `json:"skipped" example:"1"`
+	Warnings []ImportIssue `json:"warnings"`
+	Errors   []ImportIssue `json:"errors"`
+
+	Chunks      int  `json:"chunks" example:"10"`
+	ResumedFrom int  `json:"resumed_from,omitempty" example:"5001"`
+	Truncated   bool `json:"issues_truncated,omitempty"`
+}
+
+// состояние импорта файла, по нему продолжаем после падения
+type ImportJob struct {
+	ID           int64
+	Checksum     string
+	Mode         string
+	Status       string
+	CommittedRow int // последняя строка файла, закоммиченная в бд
+	Imported     int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (r *ImportResult) AddWarnings(issues []ImportIssue) {
+	r.Warnings, r.Truncated = appendIssues(r.Warnings, issues, r.Truncated)
+}
+
+func (r *ImportResult) AddErrors(issues []ImportIssue) {
+	r.Errors, r.Truncated = appendIssues(r.Errors, issues, r.Truncated)
+}
+
+const maxReportedIssues = 1000
+
+// больше не держим в ответе, иначе на миллионе строк он будет гигантским
+func appendIssues(dst, issues []ImportIssue, truncated bool) ([]ImportIssue, bool) {
+	for _, issue := range issues {
+		if len(dst) >= maxReportedIssues {
+			return dst, true
+		}
+		dst = append(dst, issue)
+	}
+	return dst, truncated
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// сколько минимальных единиц (копеек, центов) в основной
+const MinorUnits = 100
+
+var ErrBadMoney = errors.New("amount must be a number with at most 2 decimal places")
+
+// Money - сумма в минимальных единицах (копейках, центах). Наружу, в JSON и CSV,
+// пишется десятичным числом в основных единицах: 79900 -> 799, 999 -> 9.99,
+// так целые цены выглядят как раньше, а дробные не теряют копеек
+type Money int64
+
+// Major - сумма из целого числа основных единиц
+func Major(units int64) Money {
+	return Money(units * MinorUnits)
+}
+
+// Units - целые основные единицы, копейки отбрасываются
+func (m Money) Units() int64 {
+	return int64(m) / MinorUnits
+}
+
+// String - десятичная запись без лишних нулей: 799, 9.99, 9.90
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	if v%MinorUnits == 0 {
+		return sign + strconv.FormatInt(v/MinorUnits, 10)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/MinorUnits, v%MinorUnits)
+}
+
+// ParseMoney разбирает десятичную сумму в основных единицах ("799", "9.99", "-0.5").
+// Больше двух знаков после точки - ошибка, чтоб не округлять молча
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	// знак после снятого минуса ParseInt принял бы: "--5" стало бы 5
+	whole, frac, dot := strings.Cut(s, ".")
+	if whole == "" || (dot && frac == "") || len(frac) > 2 || strings.HasPrefix(whole, "+") || strings.HasPrefix(whole, "-") || strings.HasPrefix(frac, "+") || strings.HasPrefix(frac, "-") {
+		return 0, ErrBadMoney
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/MinorUnits {
+		return 0, ErrBadMoney
+	}
+	cents := int64(0)
+	if frac != "" {
+		if cents, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return 0, ErrBadMoney
+		}
+		if len(frac) == 1 {
+			cents *= 10
+		}
+	}
+
+	v := units*MinorUnits + cents
+	if neg {
+		v = -v
+	}
+	return Money(v), nil
+}
+
+// RoundMoney округляет сумму в основных единицах до копеек, для lenient импорта
+func RoundMoney(units float64) Money {
+	return Money(math.Round(units * MinorUnits))
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// принимает число или строку с числом, экспоненту не принимает
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if s, err := strconv.Unquote(string(data)); err == nil {
+		data = []byte(s)
+	}
+
+	v, err := ParseMoney(string(data))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBadMoney, data)
+	}
+	*m = v
+	return nil
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "799", want: 79900},
+		{in: "9.99", want: 999},
+		{in: "9.9", want: 990},
+		{in: "-0.5", want: -50},
+		{in: " 12 ", want: 1200},
+		{in: "0", want: 0},
+		{in: "--5", wantErr: true},
+		{in: "-+5", wantErr: true},
+		{in: "+5", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: "5.", wantErr: true},
+		{in: ".5", wantErr: true},
+		{in: "1.999", wantErr: true},
+		{in: "1.-5", wantErr: true},
+		{in: "1e3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMoney(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrBadMoney) {
+					t.Fatalf("ParseMoney(%q) = %d, %v, want ErrBadMoney", tt.in, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseMoney(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
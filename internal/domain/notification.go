@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// шаблон уведомления в синтаксисе Go text/template, Custom - переопределен в базе
+type NotificationTemplate struct {
+	Kind      string     `json:"kind" example:"reminder"`
+	Subject   string     `json:"subject" example:"{{.ServiceName}} subscription ends soon"`
+	Body      string     `json:"body" example:"Your {{.ServiceName}} subscription ends in {{.EndDate}}"`
+	Custom    bool       `json:"custom" example:"true"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// результат предпросмотра шаблона на примере данных
+type NotificationPreview struct {
+	Subject string `json:"subject" example:"Spotify Premium subscription ends soon"`
+	Body    string `json:"body" example:"Your Spotify Premium subscription ends in 12-2026"`
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// действия подписки, перед которыми вызываются хуки политик
+const (
+	PolicyActionCreate = "create"
+	PolicyActionExtend = "extend"
+)
+
+// синхронный хук политики: перед create и extend на URL уходит PolicyRequest,
+// ответ allow=false блокирует операцию с причиной из ответа
+type PolicyHook struct {
+	ID  int64  `json:"id" example:"1"`
+	URL string `json:"url" example:"https://procurement.example.com/hooks/subscriptions"`
+	// секрет для подписи тела в X-Signature-256, наружу не отдается
+	Secret string `json:"-"`
+	// недоступный хук пропускается, без этого операция отклоняется
+	FailOpen  bool      `json:"fail_open" example:"false"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// тело запроса к хуку. Current - подписка до продления, при create пустая
+type PolicyRequest struct {
+	Action       string        `json:"action" example:"create"`
+	Subscription Subscription  `json:"subscription"`
+	Current      *Subscription `json:"current,omitempty"`
+}
+
+// ответ хука
+type PolicyDecision struct {
+	Allow  bool   `json:"allow" example:"false"`
+	Reason string `json:"reason,omitempty" example:"yearly plans need procurement approval"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// состояние напоминания об окончании подписки для конкретного юзера
+type ReminderState struct {
+	SubscriptionID      int64      `json:"subscription_id" example:"10"`
+	UserID              uuid.UUID  `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	AcknowledgedEndDate *string    `json:"acknowledged_end_date,omitempty" example:"12-2026"`
+	AcknowledgedAt      *time.Time `json:"acknowledged_at,omitempty"`
+	SnoozedUntil        *time.Time `json:"snoozed_until,omitempty"`
+	LastNotifiedAt      *time.Time `json:"last_notified_at,omitempty"`
+	// свои сроки напоминаний в днях до конца, пусто - общий REMINDER_LEAD_MONTHS
+	DaysBefore []int `json:"days_before,omitempty" example:"7,1"`
+}
+
+// подписка которой пора отправить напоминание
+type DueReminder struct {
+	SubscriptionID int64
+	UserID         uuid.UUID
+	ServiceName    string
+	EndDate        string
+	// для напоминания по своему сроку подписки: сколько дней до конца и сама дата конца
+	DaysBefore *int
+	EndOn      time.Time
+}
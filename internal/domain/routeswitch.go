@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// маршрут, выключенный из админки. Route - шаблон mux вида "POST /subscriptions/import"
+type DisabledRoute struct {
+	Route      string    `json:"route" example:"POST /subscriptions/import"`
+	Reason     string    `json:"reason" example:"incident 42: import overloads db"`
+	DisabledAt time.Time `json:"disabled_at"`
+}
@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// виды отложенных действий
+const (
+	// продлить подписку на Months месяцев от end_date и поставить следующее продление
+	ScheduledAutoRenew = "auto_renew"
+	// закончить триал: с месяца run_at подписка платная
+	ScheduledTrialConversion = "trial_conversion"
+	// с run_at сменить цену на Price
+	ScheduledPriceChange = "price_change"
+)
+
+var ScheduledEventKinds = []string{ScheduledAutoRenew, ScheduledTrialConversion, ScheduledPriceChange}
+
+// статусы отложенного действия
+const (
+	ScheduledPending = "pending"
+	ScheduledDone    = "done"
+	// к run_at действие потеряло смысл (подписка отменена, триал уже кончился), причина в last_error
+	ScheduledSkipped   = "skipped"
+	ScheduledFailed    = "failed"
+	ScheduledCancelled = "cancelled"
+)
+
+// ScheduledEvent - действие над подпиской, отложенное до run_at. Хранится в базе и
+// выполняется поллером ровно один раз, перезапуск сервиса его не теряет
+type ScheduledEvent struct {
+	ID             int64     `json:"id" example:"1"`
+	SubscriptionID int64     `json:"-"`
+	Kind           string    `json:"kind" example:"price_change"`
+	RunAt          time.Time `json:"run_at" example:"2026-03-01T00:00:00Z"`
+	// новая цена для price_change
+	Price *Money `json:"price,omitempty" example:"799"`
+	// на сколько месяцев продлевает auto_renew
+	Months     int        `json:"months,omitempty" example:"1"`
+	Status     string     `json:"status" example:"pending"`
+	Attempts   int        `json:"attempts" example:"0"`
+	LastError  *string    `json:"last_error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExecutedAt *time.Time `json:"executed_at,omitempty"`
+}
+
+// ScheduledChange - что сделать с подпиской по наступившему событию. Решает сервис,
+// репозиторий применяет в той же транзакции, в которой закрывает событие
+type ScheduledChange struct {
+	// не пусто - действие делать нечего, событие закрывается как skipped с этой причиной
+	SkipReason string
+
+	Price        *Money
+	EndDate      *string
+	TrialEndDate *string
+
+	// действие в истории подписки и событие ленты, которое сервис пишет после коммита
+	EventType string
+	Payload   map[string]any
+
+	// следующее действие цепочки, например очередное автопродление
+	Next *ScheduledEvent
+}
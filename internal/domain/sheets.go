@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// итог одного прогона синхронизации с Google Sheets
+type SheetSyncReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// dry_run - только сравнение, в базе ничего не менялось
+	DryRun bool `json:"dry_run" example:"false"`
+	Rows   int  `json:"rows" example:"42"`
+
+	Created   []SheetRowChange `json:"created"`
+	Updated   []SheetRowChange `json:"updated"`
+	Unchanged int              `json:"unchanged" example:"38"`
+	// действующие подписки пользователей из таблицы, которых в ней нет. Не удаляются
+	Missing []SheetRowChange `json:"missing"`
+
+	Warnings []ImportIssue `json:"warnings"`
+	Errors   []ImportIssue `json:"errors"`
+}
+
+// строка отчета, row - номер строки в таблице (с заголовком)
+type SheetRowChange struct {
+	Row         int                    `json:"row,omitempty" example:"5"`
+	UserID      uuid.UUID              `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ServiceName string                 `json:"service_name" example:"Netflix"`
+	Changes     map[string]FieldChange `json:"changes,omitempty"`
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// выписка за месяц в виде счета: строка на каждую подписку и итог
+type Statement struct {
+	UserID      uuid.UUID       `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Period      string          `json:"period" example:"03-2026"`
+	Currency    string          `json:"currency" example:"RUB"`
+	Lines       []StatementLine `json:"lines"`
+	Total       Money           `json:"total" example:"1299"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
+
+type StatementLine struct {
+	SubscriptionID int64  `json:"subscription_id" example:"10"`
+	ServiceName    string `json:"service_name" example:"Netflix"`
+	Price          Money  `json:"price" example:"799"`
+	BillingPeriod  string `json:"billing_period" example:"monthly"`
+	Months         int    `json:"months" example:"1"`
+	Subtotal       Money  `json:"subtotal" example:"799"`
+	// месяц пробного периода, price - цена триала
+	Trial bool `json:"trial,omitempty" example:"false"`
+}
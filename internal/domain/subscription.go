@@ -7,22 +7,159 @@ import (
 )
 
 type Subscription struct {
-	ID          int64     `json:"id" example:"10"`
-	UserID      uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	ServiceName string    `json:"service_name" example:"Spotify Premium"`
-	Price       int       `json:"price" example:"500"`
-	StartDate   string    `json:"start_date" example:"01-2026"`
-	EndDate     *string   `json:"end_date,omitempty" example:"12-2026"`
-	CreatedAt   time.Time `json:"created_at,omitempty" swaggerignore:"true"`
-	UpdatedAt   time.Time `json:"updated_at,omitempty" swaggerignore:"true"`
+	ID          int64      `json:"id" example:"10"`
+	UserID      uuid.UUID  `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ServiceName string     `json:"service_name" example:"Spotify Premium"`
+	Price       Money      `json:"price" example:"500"`
+	StartDate   string     `json:"start_date" example:"01-2026"`
+	EndDate     *string    `json:"end_date,omitempty" example:"12-2026"`
+	CreatedAt   time.Time  `json:"created_at,omitempty" swaggerignore:"true"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty" swaggerignore:"true"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty" swaggerignore:"true"`
+	// растет на каждой правке, замена и продление передают версию, которую видели
+	Version int64 `json:"version" example:"3"`
+
+	// код ISO 4217, без него при создании - основная валюта из COST_CURRENCY
+	Currency string `json:"currency" example:"RUB"`
+	// за какой период указана price: weekly, monthly, quarterly, yearly. Без него - monthly
+	BillingPeriod string `json:"billing_period" example:"monthly"`
+
+	// price без НДС (net) или с ним (gross) и страна для ставки из TAX_RATES.
+	// Пустые - умолчания TAX_PRICE_BASIS и TAX_DEFAULT_COUNTRY
+	PriceBasis string `json:"price_basis,omitempty" example:"gross"`
+	TaxCountry string `json:"tax_country,omitempty" example:"RU"`
+
+	// пробный период: месяцы с start_date по trial_end_date включительно стоят
+	// trial_price за тот же billing_period, 0 - бесплатно
+	TrialEndDate *string `json:"trial_end_date,omitempty" example:"02-2026"`
+	TrialPrice   Money   `json:"trial_price" example:"0"`
+	// фоновая проверка отметила, что со следующего месяца триал станет платным
+	TrialFlaggedAt *time.Time `json:"-"`
+
+	// день месяца в start_date и end_date (включительно), нужны для proration=daily и дня продления.
+	// Даты можно прислать как YYYY-MM-DD, тогда день берется из них. Без дня месяц считается целиком
+	StartDay *int `json:"start_day,omitempty" example:"15"`
+	EndDay   *int `json:"end_day,omitempty" example:"14"`
+
+	// пауза: месяцы с paused_from по paused_until не оплачиваются,
+	// пока подписка на паузе paused_until пустой
+	Status      string  `json:"status" example:"active"`
+	PausedFrom  *string `json:"paused_from,omitempty" example:"03-2026"`
+	PausedUntil *string `json:"paused_until,omitempty" example:"05-2026"`
+	// расписание пауз, включая текущую ручную: месяцы всех диапазонов не оплачиваются
+	Pauses []PauseRange `json:"pauses,omitempty"`
+
+	// столько месяцев после end_date подписка в статусе grace: не оплачивается,
+	// но еще не считается истекшей
+	GracePeriodMonths int `json:"grace_period_months" example:"1"`
+
+	// nil - без категории. Название заполняется только для расчета расходов
+	CategoryID   *int64 `json:"category_id,omitempty" example:"1"`
+	CategoryName string `json:"-"`
+
+	// свободные метки вроде work, personal: в нижнем регистре, без повторов, по алфавиту
+	Tags []string `json:"tags" example:"work,shared"`
+
+	// запись каталога сервисов, если название распознано. Тогда ServiceName - каноническое
+	CatalogID *int64 `json:"catalog_id,omitempty" example:"1"`
+
+	// заметка пользователя, до 2000 символов
+	Notes string `json:"notes,omitempty" example:"family plan, shared with Anna"`
 }
 
+// в базе хранятся только active и paused, expired, upcoming и grace считаются по датам
+const (
+	StatusActive   = "active"
+	StatusPaused   = "paused"
+	StatusExpired  = "expired"
+	StatusUpcoming = "upcoming"
+	StatusGrace    = "grace"
+)
+
+var Statuses = []string{StatusActive, StatusPaused, StatusExpired, StatusUpcoming, StatusGrace}
+
 type SubscriptionFilter struct {
-	UserID      uuid.UUID
+	UserID uuid.UUID
+	// подстрока названия без учета регистра
 	ServiceName string
-	MinPrice    int
-	MaxPrice    int
+	// точные названия без учета регистра: подписка с любым из ServiceNames и ни с одним из
+	// ExcludeServiceNames, пустые - без ограничения
+	ServiceNames        []string
+	ExcludeServiceNames []string
+
+	// nil - без ограничения, 0 - именно бесплатные
+	MinPrice *Money
+	MaxPrice *Money
+	Price    *Money
+
+	// границы по датам включительно, nil - без ограничения
+	StartAfter  *time.Time
+	StartBefore *time.Time
+	EndsAfter   *time.Time
+	EndsBefore  *time.Time
 
 	Limit  int
 	Offset int
+	// keyset пагинация: только id > AfterID (id < AfterID при SortDesc), Offset тогда не используется
+	AfterID int64
+
+	// нечеткий поиск по названию сервиса, результаты по убыванию похожести
+	Query string
+
+	// статус из Statuses, пустой - любой
+	Status string
+	// месяц, на который считается Status. Ставит сервис по своим часам, а не база по NOW()
+	StatusMonth time.Time
+
+	// nil - любая категория
+	CategoryID *int64
+
+	// подписка должна иметь все метки
+	Tags []string
+
+	// поле сортировки из SortFields, пустое - по id
+	Sort     string
+	SortDesc bool
+}
+
+// поля, по которым можно сортировать список
+var SortFields = []string{"price", "start_date", "created_at"}
+
+// подписка, которая скоро закончится. Дни считаются до конца месяца end_date
+// остаток считается до end_date, а если подписка в списке только из-за триала - до его конца
+type UpcomingRenewal struct {
+	Subscription
+	DaysRemaining   int `json:"days_remaining" example:"45"`
+	MonthsRemaining int `json:"months_remaining" example:"1"`
+	// со следующего месяца триал станет платным, отмечено фоновой проверкой
+	TrialConversion *TrialConversion `json:"trial_conversion,omitempty"`
+}
+
+type TrialConversion struct {
+	// первый месяц по полной цене
+	ConvertsOn string `json:"converts_on" example:"03-2026"`
+	TrialPrice Money  `json:"trial_price" example:"0"`
+	Price      Money  `json:"price" example:"799"`
+}
+
+// ответ на удаление дорогой подписки, нужно повторить запрос с токеном
+type DeleteConfirmation struct {
+	ID        int64     `json:"id" example:"10"`
+	Token     string    `json:"confirm_token" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// BulkDeleteConfirmation - удаление пачкой задело дорогие подписки и ждет повтора с токеном
+type BulkDeleteConfirmation struct {
+	Token     string    `json:"confirm_token" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// сколько подписок сейчас подходит под фильтр
+	Matched int `json:"matched" example:"3"`
+}
+
+// диапазон паузы включительно, пустой paused_to - ручная пауза, которую еще не сняли
+type PauseRange struct {
+	ID         int64   `json:"id" example:"3"`
+	PausedFrom string  `json:"paused_from" example:"06-2026"`
+	PausedTo   *string `json:"paused_to,omitempty" example:"08-2026"`
 }
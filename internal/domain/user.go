@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// пользователь, заведенный из IdP. Подписки ссылаются на него по id,
+// пользователи без записи тут тоже работают как раньше
+type User struct {
+	ID            uuid.UUID  `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ExternalID    string     `json:"external_id,omitempty" example:"00u1abcd"`
+	Email         string     `json:"email,omitempty" example:"ivanov@example.com"`
+	DisplayName   string     `json:"display_name,omitempty" example:"Иван Иванов"`
+	Active        bool       `json:"active" example:"true"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+}
+
+// что делать с подписками уволенного пользователя
+const (
+	OffboardCancel   = "cancel"
+	OffboardTransfer = "transfer"
+)
+
+var OffboardPolicies = []string{OffboardCancel, OffboardTransfer}
+
+// что стало с подписками при деактивации
+type OffboardResult struct {
+	Policy      string     `json:"policy" example:"cancel"`
+	Cancelled   []int64    `json:"cancelled,omitempty"`
+	Transferred []int64    `json:"transferred,omitempty"`
+	TransferTo  *uuid.UUID `json:"transfer_to,omitempty"`
+}
+
+// статусы строки bulk запроса
+const (
+	ProvisionCreated     = "created"
+	ProvisionUpdated     = "updated"
+	ProvisionDeactivated = "deactivated"
+	ProvisionError       = "error"
+)
+
+// результат по одному пользователю, ошибка одного не останавливает остальных
+type ProvisionResult struct {
+	ID         uuid.UUID       `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ExternalID string          `json:"external_id,omitempty" example:"00u1abcd"`
+	Status     string          `json:"status" example:"created"`
+	Error      string          `json:"error,omitempty"`
+	Offboard   *OffboardResult `json:"offboard,omitempty"`
+}
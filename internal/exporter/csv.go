@@ -0,0 +1,33 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+func init() {
+	Register("csv", CSV{})
+}
+
+type CSV struct{}
+
+func (CSV) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (CSV) Extension() string { return "csv" }
+
+func (CSV) NewWriter(w io.Writer) (RowWriter, error) {
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WriteRow(cells []string) error {
+	return c.w.Write(cells)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,97 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// Format - формат выгрузки, плагин. Новый формат - файл в этом пакете (или свой пакет),
+// который в init регистрирует себя через Register: хендлер выгрузки и GET /export/formats
+// берут форматы только из реестра
+type Format interface {
+	ContentType() string
+	Extension() string
+	NewWriter(w io.Writer) (RowWriter, error)
+}
+
+// RowWriter пишет таблицу построчно, Close дописывает хвост файла
+type RowWriter interface {
+	WriteRow(cells []string) error
+	Close() error
+}
+
+// Columns - заголовок выгрузки в порядке Record
+var Columns = []string{"id", "user_id", "service_name", "price", "currency", "billing_period", "start_date", "end_date", "status"}
+
+// Record раскладывает подписку по колонкам, id передается уже закодированным
+func Record(id string, sub domain.Subscription) []string {
+	end := ""
+	if sub.EndDate != nil {
+		end = *sub.EndDate
+	}
+	return []string{id, sub.UserID.String(), sub.ServiceName, sub.Price.String(), sub.Currency, sub.BillingPeriod, sub.StartDate, end, sub.Status}
+}
+
+var (
+	mu      sync.RWMutex
+	formats = map[string]Format{}
+)
+
+// Register добавляет формат под именем из параметра format. Повтор имени - ошибка сборки,
+// поэтому паника, как у database/sql.Register
+func Register(name string, f Format) {
+	mu.Lock()
+	defer mu.Unlock()
+	if f == nil {
+		panic("exporter: Register format is nil")
+	}
+	if _, dup := formats[name]; dup {
+		panic("exporter: Register called twice for format " + name)
+	}
+	formats[name] = f
+}
+
+func Lookup(name string) (Format, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	f, ok := formats[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q", name)
+	}
+	return f, nil
+}
+
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Info - описание зарегистрированного формата для клиента
+type Info struct {
+	Name        string `json:"name" example:"xlsx"`
+	ContentType string `json:"content_type" example:"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"`
+	Extension   string `json:"extension" example:"xlsx"`
+}
+
+// Formats - все зарегистрированные форматы по имени
+func Formats() []Info {
+	mu.RLock()
+	defer mu.RUnlock()
+	infos := make([]Info, 0, len(formats))
+	for name, f := range formats {
+		infos = append(infos, Info{Name: name, ContentType: f.ContentType(), Extension: f.Extension()})
+	}
+	slices.SortFunc(infos, func(a, b Info) int { return strings.Compare(a.Name, b.Name) })
+	return infos
+}
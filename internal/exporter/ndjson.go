@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+func init() {
+	Register("ndjson", NDJSON{})
+}
+
+// NDJSON пишет объект на строку, ключи берутся из первой строки таблицы (заголовка).
+// Значения строками, как в csv: типизированный поток подписок - /subscriptions/export.ndjson
+type NDJSON struct{}
+
+func (NDJSON) ContentType() string { return "application/x-ndjson" }
+
+func (NDJSON) Extension() string { return "ndjson" }
+
+func (NDJSON) NewWriter(w io.Writer) (RowWriter, error) {
+	return &ndjsonWriter{buf: bufio.NewWriter(w)}, nil
+}
+
+type ndjsonWriter struct {
+	buf  *bufio.Writer
+	keys []string
+}
+
+func (n *ndjsonWriter) WriteRow(cells []string) error {
+	if n.keys == nil {
+		n.keys = cells
+		return nil
+	}
+	// json для map сортирует ключи, а нужен порядок колонок
+	n.buf.WriteByte('{')
+	for i, key := range n.keys {
+		if i > 0 {
+			n.buf.WriteByte(',')
+		}
+		value := ""
+		if i < len(cells) {
+			value = cells[i]
+		}
+		k, _ := json.Marshal(key)
+		v, _ := json.Marshal(value)
+		n.buf.Write(k)
+		n.buf.WriteByte(':')
+		n.buf.Write(v)
+	}
+	_, err := n.buf.WriteString("}\n")
+	return err
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.buf.Flush()
+}
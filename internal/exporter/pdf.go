@@ -0,0 +1,107 @@
+package exporter
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/pdf"
+)
+
+func init() {
+	Register("pdf", PDF{})
+}
+
+// самая широкая колонка, длиннее обрезается. uuid влезает целиком
+const pdfMaxCell = 36
+
+// PDF печатает таблицу на альбомных A4, заголовок повторяется на каждой странице.
+// Ширина колонок считается по странице, поэтому в памяти только одна страница строк
+type PDF struct{}
+
+func (PDF) ContentType() string { return "application/pdf" }
+
+func (PDF) Extension() string { return "pdf" }
+
+func (PDF) NewWriter(w io.Writer) (RowWriter, error) {
+	layout := pdf.A4Landscape
+	// заголовок, черта, пустая строка и номер страницы
+	return &pdfWriter{doc: pdf.NewWriter(w, layout), layout: layout, perPage: layout.Lines() - 4}, nil
+}
+
+type pdfWriter struct {
+	doc     *pdf.Writer
+	layout  pdf.Layout
+	perPage int
+	header  []string
+	rows    [][]string
+	page    int
+}
+
+func (p *pdfWriter) WriteRow(cells []string) error {
+	// после транслита все ascii, ширина в байтах равна ширине в символах
+	row := make([]string, len(cells))
+	for i, cell := range cells {
+		row[i] = pdf.Latin(cell)
+	}
+	if p.header == nil {
+		p.header = row
+		return nil
+	}
+
+	p.rows = append(p.rows, row)
+	if len(p.rows) < p.perPage {
+		return nil
+	}
+	return p.flush()
+}
+
+func (p *pdfWriter) flush() error {
+	p.page++
+	table := append([][]string{p.header}, p.rows...)
+	p.rows = p.rows[:0]
+
+	widths := make([]int, len(p.header))
+	for _, row := range table {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = min(max(widths[i], len(cell)), pdfMaxCell)
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(table)+3)
+	for i, row := range table {
+		lines = append(lines, p.line(row, widths))
+		if i == 0 {
+			lines = append(lines, strings.Repeat("-", min(len(lines[0]), p.layout.Columns())))
+		}
+	}
+	lines = append(lines, "", "Page "+strconv.Itoa(p.page))
+	return p.doc.Page(lines)
+}
+
+func (p *pdfWriter) line(row []string, widths []int) string {
+	var b strings.Builder
+	for i, w := range widths {
+		cell := ""
+		if i < len(row) {
+			cell = pdf.Truncate(row[i], w)
+		}
+		if i > 0 {
+			b.WriteString("  ")
+		}
+		b.WriteString(cell + strings.Repeat(" ", w-len(cell)))
+	}
+	return pdf.Truncate(strings.TrimRight(b.String(), " "), p.layout.Columns())
+}
+
+func (p *pdfWriter) Close() error {
+	// последняя неполная страница, или пустая таблица - тогда страница с одним заголовком
+	if len(p.rows) > 0 || p.page == 0 {
+		if err := p.flush(); err != nil {
+			return err
+		}
+	}
+	return p.doc.Close()
+}
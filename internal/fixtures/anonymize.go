@@ -0,0 +1,175 @@
+package fixtures
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// нижние границы ценовых корзин в копейках, цена округляется вниз до корзины
+var PriceBuckets = []domain.Money{0, 100_00, 250_00, 500_00, 1000_00, 2500_00, 5000_00, 10000_00}
+
+// Fixture - то что попадает в файл, без внутренних id и таймстемпов
+type Fixture struct {
+	ID          int64        `json:"id"`
+	UserID      uuid.UUID    `json:"user_id"`
+	ServiceName string       `json:"service_name"`
+	Price       domain.Money `json:"price"`
+	StartDate   string       `json:"start_date"`
+	EndDate     *string      `json:"end_date,omitempty"`
+}
+
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type Report struct {
+	GeneratedAt   time.Time      `json:"generated_at"`
+	Seed          string         `json:"seed_fingerprint"`
+	Rows          int            `json:"rows"`
+	DistinctUsers int            `json:"distinct_users"`
+	DistinctNames int            `json:"distinct_service_names"`
+	PriceBuckets  []domain.Money `json:"price_buckets"`
+	FixtureSHA256 string         `json:"fixture_sha256"`
+	Checks        []Check        `json:"checks"`
+	AllChecksPass bool           `json:"all_checks_passed"`
+}
+
+// Anonymizer детерминированно обезличивает выборку: один seed - один результат
+type Anonymizer struct {
+	seed  []byte
+	users map[uuid.UUID]uuid.UUID
+	names map[string]string
+}
+
+func NewAnonymizer(seed string) *Anonymizer {
+	return &Anonymizer{
+		seed:  []byte(seed),
+		users: make(map[uuid.UUID]uuid.UUID),
+		names: make(map[string]string),
+	}
+}
+
+func (a *Anonymizer) Anonymize(subs []domain.Subscription) []Fixture {
+	// имена нумеруем в алфавитном порядке, чтобы не зависеть от порядка строк
+	distinct := make([]string, 0)
+	for _, s := range subs {
+		key := normalizeName(s.ServiceName)
+		if _, ok := a.names[key]; !ok {
+			a.names[key] = ""
+			distinct = append(distinct, key)
+		}
+	}
+	sort.Strings(distinct)
+	for i, key := range distinct {
+		a.names[key] = fmt.Sprintf("service_%03d", i+1)
+	}
+
+	out := make([]Fixture, 0, len(subs))
+	for i, s := range subs {
+		out = append(out, Fixture{
+			ID:          int64(i + 1),
+			UserID:      a.user(s.UserID),
+			ServiceName: a.names[normalizeName(s.ServiceName)],
+			Price:       bucket(s.Price),
+			StartDate:   s.StartDate,
+			EndDate:     s.EndDate,
+		})
+	}
+	return out
+}
+
+// Verify строит отчет и проверяет что в файле нет исходных данных
+func (a *Anonymizer) Verify(original []domain.Subscription, data []byte) Report {
+	sum := sha256.Sum256(data)
+	fp := sha256.Sum256(a.seed)
+
+	rep := Report{
+		GeneratedAt:   time.Now().UTC(),
+		Seed:          hex.EncodeToString(fp[:8]),
+		Rows:          len(original),
+		DistinctUsers: len(a.users),
+		DistinctNames: len(a.names),
+		PriceBuckets:  PriceBuckets,
+		FixtureSHA256: hex.EncodeToString(sum[:]),
+	}
+
+	text := string(data)
+	leakedUsers, leakedNames := 0, 0
+	for orig := range a.users {
+		if strings.Contains(text, orig.String()) {
+			leakedUsers++
+		}
+	}
+	for _, s := range original {
+		name := strings.TrimSpace(s.ServiceName)
+		if name != "" && strings.Contains(text, fmt.Sprintf("%q", name)) {
+			leakedNames++
+		}
+	}
+
+	var fixtures []Fixture
+	parsed := json.Unmarshal(data, &fixtures) == nil
+	badPrices := 0
+	for _, f := range fixtures {
+		if bucket(f.Price) != f.Price {
+			badPrices++
+		}
+	}
+
+	rep.Checks = []Check{
+		{Name: "no_original_user_ids", Passed: leakedUsers == 0, Detail: fmt.Sprintf("%d leaked", leakedUsers)},
+		{Name: "no_original_service_names", Passed: leakedNames == 0, Detail: fmt.Sprintf("%d leaked", leakedNames)},
+		{Name: "fixture_is_valid_json", Passed: parsed},
+		{Name: "row_count_matches", Passed: len(fixtures) == len(original), Detail: fmt.Sprintf("%d/%d", len(fixtures), len(original))},
+		{Name: "prices_bucketed", Passed: badPrices == 0, Detail: fmt.Sprintf("%d not bucketed", badPrices)},
+	}
+
+	rep.AllChecksPass = true
+	for _, c := range rep.Checks {
+		rep.AllChecksPass = rep.AllChecksPass && c.Passed
+	}
+
+	return rep
+}
+
+// новый uuid считается через hmac от исходного, так что связи между строками сохраняются
+func (a *Anonymizer) user(id uuid.UUID) uuid.UUID {
+	if mapped, ok := a.users[id]; ok {
+		return mapped
+	}
+
+	mac := hmac.New(sha256.New, a.seed)
+	mac.Write(id[:])
+	var mapped uuid.UUID
+	copy(mapped[:], mac.Sum(nil))
+	mapped[6] = (mapped[6] & 0x0f) | 0x40 // v4
+	mapped[8] = (mapped[8] & 0x3f) | 0x80 // RFC 4122
+
+	a.users[id] = mapped
+	return mapped
+}
+
+func bucket(price domain.Money) domain.Money {
+	b := PriceBuckets[0]
+	for _, lower := range PriceBuckets {
+		if price >= lower {
+			b = lower
+		}
+	}
+	return b
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,79 @@
+package fx
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const ecbDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECB - ежедневные референсные курсы Европейского центробанка к евро, без ключа.
+// RUB в них нет с 2022 года
+type ECB struct {
+	url    string
+	client *http.Client
+}
+
+var _ Provider = (*ECB)(nil)
+
+func NewECB() *ECB {
+	return &ECB{url: ecbDailyURL, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (p *ECB) Name() string { return "ecb" }
+
+// <Cube><Cube time="2026-03-02"><Cube currency="USD" rate="1.0921"/>...</Cube></Cube>
+type ecbEnvelope struct {
+	Day struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string  `xml:"currency,attr"`
+			Rate     float64 `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+func (p *ECB) Latest(ctx context.Context) (*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ecb: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := check(resp, "ecb"); err != nil {
+		return nil, err
+	}
+
+	var env ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return nil, fmt.Errorf("ecb: decode: %w", err)
+	}
+	asOf, err := time.Parse(time.DateOnly, env.Day.Time)
+	if err != nil {
+		return nil, fmt.Errorf("ecb: bad date %q", env.Day.Time)
+	}
+
+	rates := &Rates{Source: p.Name(), Base: "EUR", AsOf: asOf, Rates: map[string]float64{"EUR": 1}}
+	for _, r := range env.Day.Rates {
+		if r.Rate > 0 {
+			rates.Rates[strings.ToUpper(r.Currency)] = r.Rate
+		}
+	}
+	return rates, nil
+}
+
+func check(resp *http.Response, what string) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s: %s: %s", what, resp.Status, strings.TrimSpace(string(msg)))
+}
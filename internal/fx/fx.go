@@ -0,0 +1,51 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/config"
+)
+
+// Provider - источник курсов валют
+type Provider interface {
+	// Name пишется в базу рядом с курсами, чтоб было видно, откуда они
+	Name() string
+	Latest(ctx context.Context) (*Rates, error)
+}
+
+// Rates - курсы на дату: сколько единиц валюты за одну единицу Base
+type Rates struct {
+	Source string
+	Base   string
+	AsOf   time.Time
+	Rates  map[string]float64
+}
+
+// New выбирает провайдера по FX_PROVIDER, пустой - конвертация выключена (nil)
+func New(cfg *config.Config) (Provider, error) {
+	switch cfg.FX.Provider {
+	case "":
+		return nil, nil
+	case "ecb":
+		return NewECB(), nil
+	case "openexchangerates":
+		return NewOpenExchangeRates(cfg.FX.OXRAppID)
+	default:
+		return nil, fmt.Errorf("unknown exchange rate provider %q (ecb or openexchangerates)", cfg.FX.Provider)
+	}
+}
+
+// Convert переводит сумму через базовую валюту, false если одной из валют нет в курсах
+func (r *Rates) Convert(amount float64, from, to string) (float64, bool) {
+	if from == to {
+		return amount, true
+	}
+	rFrom, okFrom := r.Rates[from]
+	rTo, okTo := r.Rates[to]
+	if !okFrom || !okTo {
+		return 0, false
+	}
+	return amount / rFrom * rTo, true
+}
@@ -0,0 +1,75 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const oxrLatestURL = "https://openexchangerates.org/api/latest.json"
+
+// OpenExchangeRates - openexchangerates.org, нужен app_id. На бесплатном плане база только USD
+type OpenExchangeRates struct {
+	appID  string
+	client *http.Client
+}
+
+var _ Provider = (*OpenExchangeRates)(nil)
+
+func NewOpenExchangeRates(appID string) (*OpenExchangeRates, error) {
+	if appID == "" {
+		return nil, errors.New("FX_OXR_APP_ID is required for openexchangerates")
+	}
+	return &OpenExchangeRates{appID: appID, client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+func (p *OpenExchangeRates) Name() string { return "openexchangerates" }
+
+func (p *OpenExchangeRates) Latest(ctx context.Context) (*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, oxrLatestURL+"?app_id="+url.QueryEscape(p.appID), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		// в тексте ошибки url с app_id, наружу его не отдаем
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("openexchangerates: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := check(resp, "openexchangerates"); err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Timestamp int64              `json:"timestamp"`
+		Base      string             `json:"base"`
+		Rates     map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("openexchangerates: decode: %w", err)
+	}
+	if body.Base == "" || len(body.Rates) == 0 {
+		return nil, errors.New("openexchangerates: empty rates")
+	}
+
+	rates := &Rates{
+		Source: p.Name(),
+		Base:   body.Base,
+		AsOf:   time.Unix(body.Timestamp, 0).UTC().Truncate(24 * time.Hour),
+		Rates:  map[string]float64{body.Base: 1},
+	}
+	for code, r := range body.Rates {
+		if r > 0 {
+			rates.Rates[code] = r
+		}
+	}
+	return rates, nil
+}
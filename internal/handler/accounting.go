@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/accounting"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+type accountingExportView struct {
+	domain.AccountingExport
+	ID any `json:"id" swaggertype:"string" example:"1"`
+	// ссылка на файл без админ токена, живет до expires_at
+	DownloadURL string    `json:"download_url" example:"/accounting/exports/1/file?expires=1775000000&signature=..."`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// выгрузка со свежей подписанной ссылкой
+func (h *HandlerSubscription) accountingExportView(e domain.AccountingExport) accountingExportView {
+	expires := h.clock.Now().Add(h.accountingURLTTL).Truncate(time.Second)
+	id := h.ids.Encode(e.ID)
+	q := url.Values{
+		"expires":   {strconv.FormatInt(expires.Unix(), 10)},
+		"signature": {h.accountingURLs.mac(accountingURLPayload(e.ID, expires.Unix()))},
+	}
+	return accountingExportView{
+		AccountingExport: e,
+		ID:               id,
+		DownloadURL:      fmt.Sprintf("/accounting/exports/%v/file?%s", id, q.Encode()),
+		ExpiresAt:        expires.UTC(),
+	}
+}
+
+func accountingURLPayload(id, expires int64) string {
+	return fmt.Sprintf("accounting:%d:%d", id, expires)
+}
+
+// @Summary List accounting exports
+// @Description Generated files for closed months, newest first, each with a fresh signed download URL
+// @Tags accounting
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=[]accountingExportView}
+// @Failure 401 {object} problem.Details
+// @Router /admin/accounting/exports [get]
+func (h *HandlerSubscription) listAccountingExports(w http.ResponseWriter, r *http.Request) {
+	exports, err := h.accounting.List(r.Context())
+	if err != nil {
+		h.log.Error("accounting exports list fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	views := make([]accountingExportView, 0, len(exports))
+	for _, e := range exports {
+		views = append(views, h.accountingExportView(e))
+	}
+	respond.JSON(w, 200, views)
+}
+
+// @Summary Generate accounting export
+// @Description Builds a new file for a closed month in the configured format (ACCOUNTING_FORMAT). Earlier files for the month are kept
+// @Tags accounting
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param period query string true "Closed month (MM-YYYY)"
+// @Success 201 {object} respond.Envelope{data=accountingExportView}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Failure 422 {object} problem.Details
+// @Router /admin/accounting/exports [post]
+func (h *HandlerSubscription) generateAccountingExport(w http.ResponseWriter, r *http.Request) {
+	export, err := h.accounting.Generate(r.Context(), r.URL.Query().Get("period"))
+	switch {
+	case errors.Is(err, service.ErrBadAccountingPeriod):
+		problem.Error(w, err, 400)
+		return
+	case errors.Is(err, service.ErrPeriodNotClosed):
+		problem.Error(w, err, 422)
+		return
+	case err != nil:
+		h.log.Error("accounting export fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 201, h.accountingExportView(*export))
+}
+
+// @Summary Download accounting export
+// @Description Signed link from /admin/accounting/exports, no admin token needed
+// @Tags accounting
+// @Produce octet-stream
+// @Param id path string true "Export ID"
+// @Param expires query int true "Link expiry, unix seconds"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file
+// @Failure 403 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /accounting/exports/{id}/file [get]
+func (h *HandlerSubscription) downloadAccountingExport(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "not found", 404)
+		return
+	}
+
+	// просроченная и чужая ссылка неотличимы для клиента
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	sig := r.URL.Query().Get("signature")
+	if err != nil || h.clock.Now().Unix() > expires || !hmac.Equal([]byte(sig), []byte(h.accountingURLs.mac(accountingURLPayload(id, expires)))) {
+		problem.Write(w, "link is invalid or expired", 403)
+		return
+	}
+
+	export, content, err := h.accounting.File(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		problem.Write(w, "not found", 404)
+		return
+	}
+	if err != nil {
+		h.log.Error("accounting export download fail", slog.Int64("id", id), slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	contentType, ext := "application/octet-stream", "csv"
+	if f, err := accounting.New(export.Format); err == nil {
+		contentType, ext = f.ContentType(), f.Extension()
+	}
+	name := fmt.Sprintf("subscriptions-%s-%s.%s", export.Period, export.Format, ext)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(content)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+// @Summary User activity feed
+// @Tags activity
+// @Produce json
+// @Param user_id query string true "User UUID"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} respond.Envelope{data=[]eventView}
+// @Failure 400 {object} problem.Details
+// @Router /activity [get]
+func (h *HandlerSubscription) listActivity(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	uID, err := uuid.Parse(q.Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	limit := 20
+	if l := q.Get("limit"); l != "" {
+		if val, err := strconv.Atoi(l); err == nil && val > 0 {
+			limit = val
+		}
+	}
+
+	if limit > 200 {
+		problem.Write(w, "limit too big", 400)
+		return
+	}
+
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	events, err := h.activity.Feed(r.Context(), domain.EventFilter{UserID: uID, Limit: limit, Offset: offset})
+	if err != nil {
+		h.log.Error("activity feed fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.List(w, h.eventViews(events), respond.Page{Limit: limit, Offset: offset, Count: len(events)})
+}
+
+// @Summary Subscription timeline
+// @Description Chronological states of the subscription rebuilt from its events
+// @Tags activity
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} respond.Envelope{data=[]domain.TimelineEntry}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /subscriptions/{id}/timeline [get]
+func (h *HandlerSubscription) getTimeline(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	// у удаленной подписки истории не отдаем
+	if _, err := h.services.GetByID(r.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			problem.Write(w, "sub not found", 404)
+			return
+		}
+		h.log.Error("timeline sub fetch fail", slog.Int64("id", id), slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	timeline, err := h.activity.Timeline(r.Context(), id)
+	if err != nil {
+		h.log.Error("timeline fail", slog.Int64("id", id), slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 200, timeline)
+}
+
+// @Summary Subscription change history
+// @Description Every modification (update, extend, cancel, pause, resume) with old and new values of changed fields
+// @Tags activity
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} respond.Envelope{data=[]domain.HistoryEntry}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /subscriptions/{id}/history [get]
+func (h *HandlerSubscription) getHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	history, err := h.services.History(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			problem.Write(w, "sub not found", 404)
+			return
+		}
+		h.log.Error("history fail", slog.Int64("id", id), slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 200, history)
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/buildinfo"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/health"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// источник данных для дашборда, каждая подсистема регистрирует свой
+type SystemStatsSource func(ctx context.Context) any
+
+// регистрировать до SetupRouter
+func (h *HandlerSubscription) RegisterSystemStats(name string, src SystemStatsSource) {
+	if h.systemStats == nil {
+		h.systemStats = make(map[string]SystemStatsSource)
+	}
+	h.systemStats[name] = src
+}
+
+// доля v1 запросов, которые дублируются в v2 для сравнения, 0 - выключено
+func (h *HandlerSubscription) SetShadowSampleRate(rate float64) {
+	h.shadowRate = rate
+}
+
+// токен для Connect ручек (пустой - без авторизации) и origin браузерных клиентов
+func (h *HandlerSubscription) ConfigureRPC(token string, corsOrigins []string) {
+	h.rpcToken = token
+	h.rpcOrigins = corsOrigins
+}
+
+// эффективный конфиг для /admin/config, секреты должны быть уже скрыты
+func (h *HandlerSubscription) SetConfigView(v any) {
+	h.configView = v
+}
+
+// журнал аудита изменяющих запросов, без него /audit не регистрируется
+func (h *HandlerSubscription) SetAudit(audit service.AuditServiceInterface) {
+	h.audit = audit
+}
+
+// проверки зависимостей для /readyz
+func (h *HandlerSubscription) SetHealth(checks *health.Registry) {
+	h.health = checks
+}
+
+// бюджеты пользователей, без них /budgets не регистрируется
+func (h *HandlerSubscription) SetBudgets(budgets service.BudgetServiceInterface) {
+	h.budgets = budgets
+}
+
+// отложенные действия над подписками, без них /subscriptions/{id}/scheduled-events нет
+func (h *HandlerSubscription) SetScheduledEvents(scheduled service.ScheduledEventServiceInterface) {
+	h.scheduled = scheduled
+}
+
+// выключатель маршрутов для инцидентов, без него /admin/routes/disabled нет
+func (h *HandlerSubscription) SetRouteSwitches(switches service.RouteSwitchServiceInterface) {
+	h.routeSwitches = switches
+}
+
+// справочник категорий, без него /categories нет
+func (h *HandlerSubscription) SetCategories(categories service.CategoryServiceInterface) {
+	h.categories = categories
+}
+
+// спека загружена в swag, без нее /swagger нет
+func (h *HandlerSubscription) SetAPIDocs(loaded bool) {
+	h.apiDocs = loaded
+}
+
+// каталог сервисов, без него /catalog нет
+func (h *HandlerSubscription) SetCatalog(catalog service.CatalogServiceInterface) {
+	h.catalog = catalog
+}
+
+// синхронизация с Google Sheets, без нее /admin/sheets/sync нет
+func (h *HandlerSubscription) SetSheetSync(sync service.SheetSyncServiceInterface) {
+	h.sheetSync = sync
+}
+
+// вложения подписок, без хранилища ручек /attachments нет
+func (h *HandlerSubscription) SetAttachments(attachments service.AttachmentServiceInterface, maxBytes int64) {
+	h.attachments = attachments
+	h.attachmentMaxBytes = maxBytes
+}
+
+// ключ подписи курсоров, общий для всех реплик
+func (h *HandlerSubscription) SetCursorSecret(secret string) {
+	h.cursors = newCursorSigner(secret)
+}
+
+// шаблоны уведомлений, без них /admin/notification-templates нет
+func (h *HandlerSubscription) SetNotificationTemplates(templates service.NotificationTemplateServiceInterface) {
+	h.templates = templates
+}
+
+// курсы валют, без них convert_to у /subscriptions/total отвечает 400
+func (h *HandlerSubscription) SetExchangeRates(rates service.ExchangeRateServiceInterface) {
+	h.rates = rates
+}
+
+// выгрузки для бухгалтерии. Ссылки на файлы подписаны secret и живут ttl,
+// без секрета ключ случайный и ссылки не переживают рестарт
+func (h *HandlerSubscription) SetAccounting(exports service.AccountingServiceInterface, secret string, ttl time.Duration) {
+	h.accounting = exports
+	h.accountingURLs = newCursorSigner(secret)
+	h.accountingURLTTL = ttl
+}
+
+// когортная аналитика для продуктовой команды
+func (h *HandlerSubscription) SetAnalytics(analytics service.AnalyticsServiceInterface) {
+	h.analytics = analytics
+}
+
+// провижининг пользователей из IdP, ручки закрыты своим токеном, не админским
+func (h *HandlerSubscription) SetProvisioning(provisioning service.ProvisioningServiceInterface, token string) {
+	h.provisioning = provisioning
+	h.provisioningKey = token
+}
+
+type SystemStatsResponse struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Sources     []string       `json:"sources" example:"db_pool,scheduler"`
+	Stats       map[string]any `json:"stats"`
+}
+
+// @Summary System health dashboard
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=SystemStatsResponse}
+// @Failure 401 {object} problem.Details
+// @Router /admin/system [get]
+func (h *HandlerSubscription) getSystemStats(w http.ResponseWriter, r *http.Request) {
+	resp := SystemStatsResponse{
+		GeneratedAt: time.Now().UTC(),
+		Stats:       make(map[string]any, len(h.systemStats)),
+	}
+
+	for name, src := range h.systemStats {
+		resp.Sources = append(resp.Sources, name)
+		resp.Stats[name] = src(r.Context())
+	}
+	sort.Strings(resp.Sources)
+
+	respond.JSON(w, 200, resp)
+}
+
+// @Summary Event table backlog
+// @Description Size of the activity event table by type and age of the oldest event
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=domain.EventBacklog}
+// @Failure 401 {object} problem.Details
+// @Router /admin/events/backlog [get]
+func (h *HandlerSubscription) getEventBacklog(w http.ResponseWriter, r *http.Request) {
+	b, err := h.activity.Backlog(r.Context())
+	if err != nil {
+		h.log.Error("event backlog fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 200, b)
+}
+
+// @Summary Build and runtime version
+// @Tags system
+// @Produce json
+// @Success 200 {object} respond.Envelope{data=buildinfo.Info}
+// @Router /version [get]
+func (h *HandlerSubscription) getVersion(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, 200, buildinfo.Get())
+}
+
+// @Summary Effective runtime configuration
+// @Description Configuration the instance actually loaded (env or defaults), secrets redacted
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=map[string]interface{}}
+// @Failure 401 {object} problem.Details
+// @Router /admin/config [get]
+func (h *HandlerSubscription) getConfig(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, 200, h.configView)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+// @Summary Cohort analytics
+// @Description Users grouped by the month of their first subscription. For every month since then: paying users, subscriptions and spend in the base currency, plus retention and averages per cohort user. Served from the last scheduled aggregation (computed_at)
+// @Tags analytics
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=domain.CohortReport}
+// @Failure 401 {object} problem.Details
+// @Router /admin/analytics/cohorts [get]
+func (h *HandlerSubscription) getCohorts(w http.ResponseWriter, r *http.Request) {
+	report, err := h.analytics.Cohorts(r.Context())
+	if err != nil {
+		h.log.Error("cohorts fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 200, report)
+}
+
+// @Summary Top services leaderboard
+// @Description Services with the most paying users and the highest revenue across the platform for one month, revenue in the base currency. Served from the last scheduled aggregation (computed_at), which covers the last 24 months
+// @Tags analytics
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param period query string false "Month MM-YYYY or YYYY-MM, default current"
+// @Param limit query int false "Services per list (default 10, max 100)"
+// @Success 200 {object} respond.Envelope{data=domain.TopServicesReport}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Router /admin/analytics/top-services [get]
+func (h *HandlerSubscription) getTopServices(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	month := clock.MonthStart(h.clock.Now())
+	if p := q.Get("period"); p != "" {
+		t, err := h.dates.Parse(p)
+		if err != nil {
+			problem.Write(w, "bad period (MM-YYYY)", 400)
+			return
+		}
+		month = t
+	}
+
+	limit := 10
+	if l := q.Get("limit"); l != "" {
+		if val, err := strconv.Atoi(l); err == nil && val > 0 {
+			limit = val
+		}
+	}
+	if limit > 100 {
+		problem.Write(w, "limit too big", 400)
+		return
+	}
+
+	report, err := h.analytics.TopServices(r.Context(), month, limit)
+	if err != nil {
+		h.log.Error("top services fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	f := dateFormat(r)
+	report.Period = f.Month(report.Period)
+	for _, list := range [][]domain.ServiceMonth{report.BySubscribers, report.ByRevenue} {
+		for i := range list {
+			list[i].Month = f.Month(list[i].Month)
+		}
+	}
+	respond.JSON(w, 200, report)
+}
+
+// @Summary Churn rate per service
+// @Description For every service: subscriptions that were running in the period (including grace months), how many of them lapsed in the period without renewal and their share. Services with the highest churn rate first
+// @Tags analytics
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param from query string false "Month MM-YYYY or YYYY-MM, default 11 months before to"
+// @Param to query string false "Month MM-YYYY or YYYY-MM, default current"
+// @Success 200 {object} respond.Envelope{data=domain.ChurnReport}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Router /admin/analytics/churn [get]
+func (h *HandlerSubscription) getChurn(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	to := clock.MonthStart(h.clock.Now())
+	if raw := q.Get("to"); raw != "" {
+		t, err := h.dates.Parse(raw)
+		if err != nil {
+			problem.Write(w, "bad to (MM-YYYY)", 400)
+			return
+		}
+		to = t
+	}
+	from := to.AddDate(0, -11, 0)
+	if raw := q.Get("from"); raw != "" {
+		t, err := h.dates.Parse(raw)
+		if err != nil {
+			problem.Write(w, "bad from (MM-YYYY)", 400)
+			return
+		}
+		from = t
+	}
+	if from.After(to) {
+		problem.Write(w, "from is later than to", 400)
+		return
+	}
+
+	report, err := h.analytics.Churn(r.Context(), from, to)
+	if err != nil {
+		h.log.Error("churn fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	f := dateFormat(r)
+	report.From, report.To = f.Month(report.From), f.Month(report.To)
+	respond.JSON(w, 200, report)
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+type attachmentView struct {
+	domain.Attachment
+	ID             any `json:"id" swaggertype:"string" example:"1"`
+	SubscriptionID any `json:"subscription_id" swaggertype:"string" example:"10"`
+}
+
+func (h *HandlerSubscription) attachmentView(a domain.Attachment) attachmentView {
+	return attachmentView{Attachment: a, ID: h.ids.Encode(a.ID), SubscriptionID: h.ids.Encode(a.SubscriptionID)}
+}
+
+// id подписки и вложения из пути
+func (h *HandlerSubscription) attachmentIDs(r *http.Request) (int64, int64, error) {
+	subID, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	if r.PathValue("attachment_id") == "" {
+		return subID, 0, nil
+	}
+	id, err := h.parseID(r.PathValue("attachment_id"))
+	return subID, id, err
+}
+
+// @Summary Upload attachment
+// @Description Multipart form with a single "file" field: contract, receipt and so on
+// @Tags attachments
+// @Accept mpfd
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param file formData file true "File"
+// @Success 201 {object} respond.Envelope{data=attachmentView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 413 {object} problem.Details
+// @Router /subscriptions/{id}/attachments [post]
+func (h *HandlerSubscription) uploadAttachment(w http.ResponseWriter, r *http.Request) {
+	subID, _, err := h.attachmentIDs(r)
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	// запас на заголовки multipart
+	r.Body = http.MaxBytesReader(w, r.Body, h.attachmentMaxBytes+1<<20)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		problem.Write(w, "multipart/form-data expected", 400)
+		return
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			problem.Write(w, `form field "file" is required`, 400)
+			return
+		}
+		if err != nil {
+			h.serviceError(w, err, "attachment multipart fail")
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		a, err := h.attachments.Upload(r.Context(), subID, part.FileName(), part.Header.Get("Content-Type"), part)
+		part.Close()
+		if err != nil {
+			h.serviceError(w, err, "attachment upload fail")
+			return
+		}
+
+		respond.JSON(w, 201, h.attachmentView(*a))
+		return
+	}
+}
+
+// @Summary List attachments
+// @Tags attachments
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} respond.Envelope{data=[]attachmentView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /subscriptions/{id}/attachments [get]
+func (h *HandlerSubscription) listAttachments(w http.ResponseWriter, r *http.Request) {
+	subID, _, err := h.attachmentIDs(r)
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	attachments, err := h.attachments.List(r.Context(), subID)
+	if err != nil {
+		h.serviceError(w, err, "attachment list fail")
+		return
+	}
+
+	views := make([]attachmentView, 0, len(attachments))
+	for _, a := range attachments {
+		views = append(views, h.attachmentView(a))
+	}
+	respond.JSON(w, 200, views)
+}
+
+// @Summary Download attachment
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path string true "Subscription ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /subscriptions/{id}/attachments/{attachment_id} [get]
+func (h *HandlerSubscription) downloadAttachment(w http.ResponseWriter, r *http.Request) {
+	subID, id, err := h.attachmentIDs(r)
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	a, body, err := h.attachments.Open(r.Context(), subID, id)
+	if err != nil {
+		h.serviceError(w, err, "attachment download fail")
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName}))
+	// тип из загрузки, браузер не должен его угадывать
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, body); err != nil {
+		h.log.Error("attachment stream fail", slog.Int64("id", id), slog.String("err", err.Error()))
+	}
+}
+
+// @Summary Delete attachment
+// @Tags attachments
+// @Param id path string true "Subscription ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Success 204
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /subscriptions/{id}/attachments/{attachment_id} [delete]
+func (h *HandlerSubscription) deleteAttachment(w http.ResponseWriter, r *http.Request) {
+	subID, id, err := h.attachmentIDs(r)
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	if err := h.attachments.Delete(r.Context(), subID, id); err != nil {
+		h.serviceError(w, err, "attachment delete fail")
+		return
+	}
+	w.WriteHeader(204)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+// @Summary Audit log
+// @Description Write requests (POST, PUT, PATCH, DELETE) newest first
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param actor query string false "Actor: admin, bearer or client ip"
+// @Param action query string false "Route pattern, e.g. PUT /subscriptions/{id}"
+// @Param entity_id query string false "Entity id"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} respond.Envelope{data=[]domain.AuditRecord}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Router /audit [get]
+func (h *HandlerSubscription) listAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit := 50
+	if l := q.Get("limit"); l != "" {
+		if val, err := strconv.Atoi(l); err == nil && val > 0 {
+			limit = val
+		}
+	}
+
+	if limit > 500 {
+		problem.Write(w, "limit too big", 400)
+		return
+	}
+
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	records, err := h.audit.List(r.Context(), domain.AuditFilter{
+		Actor:    q.Get("actor"),
+		Action:   q.Get("action"),
+		EntityID: q.Get("entity_id"),
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		h.log.Error("audit list fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.List(w, records, respond.Page{Limit: limit, Offset: offset, Count: len(records)})
+}
+
+// @Summary Verify audit log chain
+// @Description Recomputes the hash chain, broken_at is the first record that was changed or follows a removed one
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=domain.AuditVerification}
+// @Failure 401 {object} problem.Details
+// @Router /audit/verify [get]
+func (h *HandlerSubscription) verifyAudit(w http.ResponseWriter, r *http.Request) {
+	res, err := h.audit.Verify(r.Context())
+	if err != nil {
+		h.log.Error("audit verify fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 200, res)
+}
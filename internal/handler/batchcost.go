@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+type BatchTotalCostRequest struct {
+	// до 1000 пользователей, повторы считаются один раз
+	UserIDs []uuid.UUID `json:"user_ids"`
+	From    string      `json:"from" example:"01-2026"`
+	To      string      `json:"to" example:"12-2026"`
+}
+
+type BatchTotalCostResponse struct {
+	Currency string                 `json:"currency" example:"RUB"`
+	Period   PeriodV2               `json:"period"`
+	Users    []domain.UserTotalCost `json:"users"`
+}
+
+// @Summary Calculate total cost for many users
+// @Description Totals for up to 1000 users over one period, computed with a single grouped query. Users come back in request order without duplicates, users without subscriptions get zero
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param input body BatchTotalCostRequest true "Users and period"
+// @Param date_format query string false "mm-yyyy (default) or iso (YYYY-MM) for months in the response"
+// @Success 200 {object} respond.Envelope{data=BatchTotalCostResponse}
+// @Failure 400 {object} problem.Details
+// @Router /subscriptions/total/batch [post]
+func (h *HandlerSubscription) getBatchTotalCost(w http.ResponseWriter, r *http.Request) {
+	var req BatchTotalCostRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.From == "" || req.To == "" || !h.normalizeDate(&req.From) || !h.normalizeDate(&req.To) {
+		problem.Write(w, "invalid date format", 400)
+		return
+	}
+
+	users, err := h.services.BatchTotalCost(r.Context(), req.UserIDs, req.From, req.To)
+	if err != nil {
+		h.serviceError(w, err, "batch cost calc faild")
+		return
+	}
+
+	f := dateFormat(r)
+	respond.JSON(w, 200, BatchTotalCostResponse{
+		Currency: h.currency,
+		Period:   PeriodV2{From: f.Month(req.From), To: f.Month(req.To)},
+		Users:    users,
+	})
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+type BudgetRequest struct {
+	UserID   uuid.UUID    `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Period   string       `json:"period" example:"03-2026"`
+	Amount   domain.Money `json:"amount" example:"2000"`
+	Category *string      `json:"category,omitempty" example:"Netflix"`
+}
+
+func (req BudgetRequest) budget() domain.Budget {
+	return domain.Budget{UserID: req.UserID, Period: req.Period, Amount: req.Amount, Category: req.Category}
+}
+
+// @Summary Create budget
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param input body BudgetRequest true "Budget"
+// @Success 201 {object} respond.Envelope{data=budgetView}
+// @Failure 400 {object} problem.Details
+// @Router /budgets [post]
+func (h *HandlerSubscription) createBudget(w http.ResponseWriter, r *http.Request) {
+	var req BudgetRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if !h.normalizeDate(&req.Period) {
+		problem.Write(w, "bad period (MM-YYYY)", 400)
+		return
+	}
+
+	b, err := h.budgets.Create(r.Context(), req.budget())
+	if err != nil {
+		h.serviceError(w, err, "budget create fail")
+		return
+	}
+
+	respond.JSON(w, 201, h.budgetView(*b))
+}
+
+// @Summary List user budgets
+// @Tags budgets
+// @Produce json
+// @Param user_id query string true "User UUID"
+// @Success 200 {object} respond.Envelope{data=[]budgetView}
+// @Failure 400 {object} problem.Details
+// @Router /budgets [get]
+func (h *HandlerSubscription) listBudgets(w http.ResponseWriter, r *http.Request) {
+	uID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	budgets, err := h.budgets.List(r.Context(), uID)
+	if err != nil {
+		h.serviceError(w, err, "budget list fail")
+		return
+	}
+
+	views := make([]budgetView, 0, len(budgets))
+	for _, b := range budgets {
+		views = append(views, h.budgetView(b))
+	}
+	respond.JSON(w, 200, views)
+}
+
+// @Summary Get budget
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} respond.Envelope{data=budgetView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /budgets/{id} [get]
+func (h *HandlerSubscription) getBudget(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	b, err := h.budgets.GetByID(r.Context(), id)
+	if err != nil {
+		h.serviceError(w, err, "budget get fail")
+		return
+	}
+
+	respond.JSON(w, 200, h.budgetView(*b))
+}
+
+// @Summary Update budget
+// @Description Period, amount and category are replaced, owner stays the same
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param input body BudgetRequest true "Budget"
+// @Success 200 {object} respond.Envelope{data=budgetView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /budgets/{id} [put]
+func (h *HandlerSubscription) updateBudget(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	var req BudgetRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if !h.normalizeDate(&req.Period) {
+		problem.Write(w, "bad period (MM-YYYY)", 400)
+		return
+	}
+
+	b, err := h.budgets.Update(r.Context(), id, req.budget())
+	if err != nil {
+		h.serviceError(w, err, "budget update fail")
+		return
+	}
+
+	respond.JSON(w, 200, h.budgetView(*b))
+}
+
+// @Summary Delete budget
+// @Tags budgets
+// @Param id path string true "Budget ID"
+// @Success 204
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /budgets/{id} [delete]
+func (h *HandlerSubscription) deleteBudget(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	if err := h.budgets.Delete(r.Context(), id); err != nil {
+		h.serviceError(w, err, "budget delete fail")
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// @Summary Budget status
+// @Description Actual spend of the budget month (for the category if set) against the amount, overspent flags an overrun
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} respond.Envelope{data=budgetStatusView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /budgets/{id}/status [get]
+func (h *HandlerSubscription) getBudgetStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	st, err := h.budgets.Status(r.Context(), id)
+	if err != nil {
+		h.serviceError(w, err, "budget status fail")
+		return
+	}
+
+	respond.JSON(w, 200, budgetStatusView{BudgetStatus: *st, Budget: h.budgetView(st.Budget)})
+}
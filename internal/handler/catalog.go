@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+// id записей каталога идут без кодека, как и у категорий
+type CatalogEntryRequest struct {
+	Name    string   `json:"name" example:"Spotify"`
+	Aliases []string `json:"aliases" example:"spotify premium,spotify family"`
+	LogoURL string   `json:"logo_url,omitempty" example:"https://cdn.example.com/logos/spotify.png"`
+}
+
+type CatalogLinkResponse struct {
+	Linked int64 `json:"linked" example:"12"`
+}
+
+func (req CatalogEntryRequest) entry() domain.CatalogEntry {
+	return domain.CatalogEntry{Name: req.Name, Aliases: req.Aliases, LogoURL: req.LogoURL}
+}
+
+// @Summary Create catalog entry
+// @Description New subscriptions whose service_name matches the name or an alias get linked and renamed to the canonical name
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param input body CatalogEntryRequest true "Catalog entry"
+// @Success 201 {object} respond.Envelope{data=domain.CatalogEntry}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Router /catalog [post]
+func (h *HandlerSubscription) createCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	var req CatalogEntryRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	e, err := h.catalog.Create(r.Context(), req.entry())
+	if err != nil {
+		h.serviceError(w, err, "catalog create fail")
+		return
+	}
+
+	respond.JSON(w, 201, e)
+}
+
+// @Summary List catalog
+// @Tags catalog
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=[]domain.CatalogEntry}
+// @Failure 401 {object} problem.Details
+// @Router /catalog [get]
+func (h *HandlerSubscription) listCatalog(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.catalog.List(r.Context())
+	if err != nil {
+		h.serviceError(w, err, "catalog list fail")
+		return
+	}
+	respond.JSON(w, 200, entries)
+}
+
+// @Summary Get catalog entry
+// @Tags catalog
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param id path int true "Catalog entry ID"
+// @Success 200 {object} respond.Envelope{data=domain.CatalogEntry}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /catalog/{id} [get]
+func (h *HandlerSubscription) getCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := parseDictionaryID(r)
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	e, err := h.catalog.GetByID(r.Context(), id)
+	if err != nil {
+		h.serviceError(w, err, "catalog get fail")
+		return
+	}
+	respond.JSON(w, 200, e)
+}
+
+// @Summary Update catalog entry
+// @Description Already linked subscriptions keep their names
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param id path int true "Catalog entry ID"
+// @Param input body CatalogEntryRequest true "Catalog entry"
+// @Success 200 {object} respond.Envelope{data=domain.CatalogEntry}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Router /catalog/{id} [put]
+func (h *HandlerSubscription) updateCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := parseDictionaryID(r)
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	var req CatalogEntryRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	e, err := h.catalog.Update(r.Context(), id, req.entry())
+	if err != nil {
+		h.serviceError(w, err, "catalog update fail")
+		return
+	}
+	respond.JSON(w, 200, e)
+}
+
+// @Summary Delete catalog entry
+// @Description Linked subscriptions stay with their names, they just lose the link
+// @Tags catalog
+// @Param X-Admin-Token header string true "Admin token"
+// @Param id path int true "Catalog entry ID"
+// @Success 204
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /catalog/{id} [delete]
+func (h *HandlerSubscription) deleteCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := parseDictionaryID(r)
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	if err := h.catalog.Delete(r.Context(), id); err != nil {
+		h.serviceError(w, err, "catalog delete fail")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// @Summary Link existing subscriptions to catalog entry
+// @Description Unlinked subscriptions whose service_name matches the name or an alias get linked and renamed, each change goes to subscription history
+// @Tags catalog
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param id path int true "Catalog entry ID"
+// @Success 200 {object} respond.Envelope{data=CatalogLinkResponse}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /catalog/{id}/link [post]
+func (h *HandlerSubscription) linkCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := parseDictionaryID(r)
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	n, err := h.catalog.Link(r.Context(), id)
+	if err != nil {
+		h.serviceError(w, err, "catalog link fail")
+		return
+	}
+	respond.JSON(w, 200, CatalogLinkResponse{Linked: n})
+}
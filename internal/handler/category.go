@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+// id категорий идут без кодека: это общий справочник, а не данные пользователей
+type CategoryRequest struct {
+	Name string `json:"name" example:"Streaming"`
+}
+
+// id справочников (категории, каталог) в пути, без кодека
+func parseDictionaryID(r *http.Request) (int64, error) {
+	return strconv.ParseInt(r.PathValue("id"), 10, 64)
+}
+
+// @Summary Create category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param input body CategoryRequest true "Category"
+// @Success 201 {object} respond.Envelope{data=domain.Category}
+// @Failure 400 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Router /categories [post]
+func (h *HandlerSubscription) createCategory(w http.ResponseWriter, r *http.Request) {
+	var req CategoryRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	c, err := h.categories.Create(r.Context(), req.Name)
+	if err != nil {
+		h.serviceError(w, err, "category create fail")
+		return
+	}
+
+	respond.JSON(w, 201, c)
+}
+
+// @Summary List categories
+// @Tags categories
+// @Produce json
+// @Success 200 {object} respond.Envelope{data=[]domain.Category}
+// @Router /categories [get]
+func (h *HandlerSubscription) listCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.categories.List(r.Context())
+	if err != nil {
+		h.serviceError(w, err, "category list fail")
+		return
+	}
+	respond.JSON(w, 200, categories)
+}
+
+// @Summary Get category
+// @Tags categories
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} respond.Envelope{data=domain.Category}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /categories/{id} [get]
+func (h *HandlerSubscription) getCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseDictionaryID(r)
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	c, err := h.categories.GetByID(r.Context(), id)
+	if err != nil {
+		h.serviceError(w, err, "category get fail")
+		return
+	}
+	respond.JSON(w, 200, c)
+}
+
+// @Summary Rename category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param input body CategoryRequest true "Category"
+// @Success 200 {object} respond.Envelope{data=domain.Category}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Router /categories/{id} [put]
+func (h *HandlerSubscription) updateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseDictionaryID(r)
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	var req CategoryRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	c, err := h.categories.Update(r.Context(), id, req.Name)
+	if err != nil {
+		h.serviceError(w, err, "category update fail")
+		return
+	}
+	respond.JSON(w, 200, c)
+}
+
+// @Summary Delete category
+// @Description Subscriptions of the category stay, they just lose the category
+// @Tags categories
+// @Param id path int true "Category ID"
+// @Success 204
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /categories/{id} [delete]
+func (h *HandlerSubscription) deleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseDictionaryID(r)
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	if err := h.categories.Delete(r.Context(), id); err != nil {
+		h.serviceError(w, err, "category delete fail")
+		return
+	}
+
+	w.WriteHeader(204)
+}
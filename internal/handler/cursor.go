@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+var (
+	errBadCursor      = errors.New("invalid cursor")
+	errCursorMismatch = errors.New("cursor was issued for a different filter, start again with an empty cursor")
+)
+
+// типы курсоров, курсор одного списка не подходит к другому
+const cursorKindSubscriptions = "subs"
+
+// курсор keyset пагинации, клиенту отдается непрозрачной строкой
+// вида base64(json).base64(hmac)
+type listCursor struct {
+	Kind    string `json:"k"`
+	AfterID int64  `json:"a"`
+	// хэш фильтра, с которым выдан курсор
+	Filter string `json:"f"`
+}
+
+// cursorSigner подписывает курсоры, чтоб клиент не мог подменить id или фильтр
+type cursorSigner struct {
+	key []byte
+}
+
+// без секрета ключ случайный: курсоры живут до рестарта и только на этом инстансе
+func newCursorSigner(secret string) cursorSigner {
+	if secret != "" {
+		return cursorSigner{key: []byte(secret)}
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	return cursorSigner{key: key}
+}
+
+func (s cursorSigner) mac(payload string) string {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:16])
+}
+
+func (s cursorSigner) encode(c listCursor) string {
+	data, _ := json.Marshal(c)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + s.mac(payload)
+}
+
+// пустая строка - первая страница. kind и filter должны совпасть с выданными
+func (s cursorSigner) decode(str, kind, filter string) (listCursor, error) {
+	var c listCursor
+	if str == "" {
+		return c, nil
+	}
+
+	payload, sig, ok := strings.Cut(str, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.mac(payload))) {
+		return c, errBadCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return c, errBadCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.AfterID < 0 || c.Kind != kind {
+		return c, errBadCursor
+	}
+	if c.Filter != filter {
+		return c, errCursorMismatch
+	}
+	return c, nil
+}
+
+// filterHash - отпечаток фильтра без полей пагинации
+func filterHash(f domain.SubscriptionFilter) string {
+	f.Limit, f.Offset, f.AfterID = 0, 0, 0
+	data, _ := json.Marshal(f)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/i18n"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+)
+
+// decodeJSON читает тело запроса в dst строго: неизвестное поле (опечатка вроде servise_name),
+// поле не того типа, мусор после объекта - 400 с названием поля, тело больше maxBodyBytes - 413.
+// false - ответ с ошибкой уже отправлен
+func (h *HandlerSubscription) decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	return h.decodeBody(w, r, dst, false)
+}
+
+// decodeOptionalJSON как decodeJSON, но пустое тело не ошибка: dst остается как был
+func (h *HandlerSubscription) decodeOptionalJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	return h.decodeBody(w, r, dst, true)
+}
+
+func (h *HandlerSubscription) decodeBody(w http.ResponseWriter, r *http.Request, dst any, optional bool) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err == nil {
+		// второй объект или мусор после первого
+		if err = dec.Decode(&struct{}{}); err == io.EOF {
+			return true
+		}
+		if err == nil {
+			err = errTrailingData
+		}
+	}
+	if optional && err == io.EOF {
+		return true
+	}
+
+	loc := i18n.FromContext(r.Context())
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		problem.Write(w, loc.Sprintf("request body too large (max %d bytes)", maxErr.Limit), 413)
+		return false
+	}
+	problem.Write(w, bodyError(loc, err), 400)
+	return false
+}
+
+var errTrailingData = errors.New("request body must contain a single json object")
+
+// текст ошибки разбора для клиента на его языке
+func bodyError(loc i18n.Localizer, err error) string {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.Is(err, errTrailingData):
+		return loc.Error(err)
+	case errors.Is(err, io.EOF):
+		return loc.T("request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return loc.T("request body is truncated json")
+	case errors.As(err, &syntaxErr):
+		return loc.Sprintf("malformed json at offset %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return loc.Sprintf("request body must be a json object, got %s", typeErr.Value)
+		}
+		return loc.Sprintf("field %q must be %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// у encoding/json нет своего типа для этой ошибки
+		return loc.Sprintf("unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	}
+	// ошибки UnmarshalJSON самих полей (Money, даты) уже понятные
+	return loc.Sprintf("invalid request body: %s", strings.TrimPrefix(loc.Error(err), "json: "))
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/pricing"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// errorStatus - HTTP статус для ошибки сервиса. Общие виды из domain и ошибки сервиса
+// разбираются только тут, хендлеры не угадывают статус по тексту ошибки
+func errorStatus(err error) int {
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case isUnavailable(err), errors.Is(err, service.ErrPolicyUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, domain.ErrInvalid), errors.Is(err, domain.ErrUnknownCategory),
+		errors.Is(err, service.ErrBadTags), errors.Is(err, service.ErrBadCurrency), errors.Is(err, service.ErrBadBillingPeriod),
+		errors.Is(err, pricing.ErrBadBasis), errors.Is(err, pricing.ErrBadCountry):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrAttachmentTooLarge), errors.As(err, &maxErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, pricing.ErrPriceOutOfRange), errors.Is(err, pricing.ErrNoTaxRate),
+		errors.Is(err, service.ErrPolicyRejected):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// serviceError отвечает статусом из errorStatus. Текст ошибки уходит клиенту только для
+// его собственных ошибок, внутренние и недоступность базы логируются с msg
+func (h *HandlerSubscription) serviceError(w http.ResponseWriter, err error, msg string) {
+	status := errorStatus(err)
+	switch {
+	case status == http.StatusNotFound:
+		problem.Write(w, "not found", status)
+	case status == http.StatusInternalServerError:
+		h.log.Error(msg, slog.String("err", err.Error()))
+		problem.Write(w, "internal error", status)
+	case isUnavailable(err):
+		h.log.Error(msg, slog.String("err", err.Error()))
+		problem.Write(w, "service unavailable", status)
+	default:
+		problem.Error(w, err, status)
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// пересчет итогов по convert_to, false - ответ с ошибкой уже записан
+func (h *HandlerSubscription) convertTotal(w http.ResponseWriter, r *http.Request, total *domain.TotalCost) (*domain.TotalCost, bool) {
+	to := r.URL.Query().Get("convert_to")
+	if to == "" {
+		return total, true
+	}
+	if h.rates == nil {
+		problem.Write(w, "convert_to is not available: exchange rates are not configured", 400)
+		return nil, false
+	}
+
+	converted, err := h.rates.ConvertTotal(r.Context(), total, to)
+	switch {
+	case err == nil:
+		return converted, true
+	case errors.Is(err, service.ErrBadCurrency):
+		problem.Write(w, "bad convert_to", 400)
+	case errors.Is(err, service.ErrNoRate):
+		problem.Error(w, err, 422)
+	case errors.Is(err, service.ErrNoRates):
+		problem.Error(w, err, 503)
+	default:
+		h.log.Error("cost conversion faild", slog.String("err", err.Error()))
+		problem.Write(w, "failed to convert cost", 500)
+	}
+	return nil, false
+}
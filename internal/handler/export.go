@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/exporter"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/i18n"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+// сколько подписок тянем из базы за раз при выгрузке
+const exportPageSize = 500
+
+// @Summary Export subscriptions as a file
+// @Tags subscriptions
+// @Produce octet-stream
+// @Param user_id query string true "User UUID"
+// @Param format query string false "Format name from GET /export/formats (default csv)"
+// @Param service_name query string false "Service filter"
+// @Success 200 {file} file
+// @Failure 400 {object} problem.Details
+// @Router /subscriptions/export [get]
+func (h *HandlerSubscription) exportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	uID, err := uuid.Parse(q.Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	name := q.Get("format")
+	if name == "" {
+		name = "csv"
+	}
+	format, err := exporter.Lookup(name)
+	if err != nil {
+		loc := i18n.FromContext(r.Context())
+		problem.Write(w, loc.Sprintf("format must be one of: %s", strings.Join(exporter.Names(), ", ")), 400)
+		return
+	}
+
+	filter := domain.SubscriptionFilter{UserID: uID, ServiceName: q.Get("service_name"), Limit: exportPageSize}
+
+	// первую страницу берем до заголовков, чтоб ошибку базы отдать нормальным кодом
+	subs, err := h.services.List(r.Context(), uID, filter)
+	if err != nil {
+		h.log.Error("export list fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	filename := fmt.Sprintf("subscriptions-%s.%s", h.clock.Now().Format("2006-01-02"), format.Extension())
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	out, err := format.NewWriter(w)
+	if err != nil {
+		h.log.Error("export writer fail", slog.String("error", err.Error()))
+		return
+	}
+	defer func() {
+		if err := out.Close(); err != nil {
+			h.log.Error("export close fail", slog.String("error", err.Error()))
+		}
+	}()
+
+	if err := out.WriteRow(exporter.Columns); err != nil {
+		return
+	}
+
+	for {
+		for _, sub := range subs {
+			if err := out.WriteRow(exporter.Record(fmt.Sprint(h.ids.Encode(sub.ID)), sub)); err != nil {
+				// клиент отвалился, дальше писать некуда
+				h.log.Warn("export write fail", slog.String("error", err.Error()))
+				return
+			}
+		}
+
+		if len(subs) < exportPageSize {
+			return
+		}
+
+		filter.Offset += exportPageSize
+		if subs, err = h.services.List(r.Context(), uID, filter); err != nil {
+			// заголовки уже ушли, остается только оборвать файл
+			h.log.Error("export list fail", slog.String("error", err.Error()), slog.Int("offset", filter.Offset))
+			return
+		}
+	}
+}
+
+// @Summary List export formats
+// @Description Форматы, зарегистрированные в exporter, годятся для параметра format выгрузки
+// @Tags subscriptions
+// @Produce json
+// @Success 200 {object} respond.Envelope{data=[]exporter.Info}
+// @Router /export/formats [get]
+func (h *HandlerSubscription) listExportFormats(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, 200, exporter.Formats())
+}
+
+// через сколько строк сбрасываем буфер клиенту
+const ndjsonFlushEvery = 100
+
+// @Summary Stream subscriptions as NDJSON
+// @Description Одна подписка на строку, строки идут прямо из курсора базы
+// @Tags subscriptions
+// @Produce application/x-ndjson
+// @Param user_id query string true "User UUID"
+// @Param service_name query string false "Service filter"
+// @Param min_price query int false "Min price"
+// @Param max_price query int false "Max price"
+// @Param price query int false "Exact price"
+// @Success 200 {object} respond.Envelope{data=subscriptionView}
+// @Failure 400 {object} problem.Details
+// @Router /subscriptions/export.ndjson [get]
+func (h *HandlerSubscription) exportNDJSON(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	uID, err := uuid.Parse(q.Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	filter := domain.SubscriptionFilter{UserID: uID, ServiceName: q.Get("service_name")}
+	if msg := parsePriceFilter(i18n.FromContext(r.Context()), q, &filter); msg != "" {
+		problem.Write(w, msg, 400)
+		return
+	}
+
+	rows, err := h.services.Stream(r.Context(), uID, filter)
+	if err != nil {
+		problem.Error(w, err, 400)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+
+	n := 0
+	for sub, err := range rows {
+		if err != nil {
+			// если еще ничего не ушло, можно ответить нормальной ошибкой
+			h.log.Error("ndjson stream fail", slog.String("error", err.Error()), slog.Int("rows", n))
+			if n == 0 {
+				problem.Write(w, "internal error", 500)
+			}
+			return
+		}
+
+		if err := enc.Encode(h.subscriptionView(*sub, dateFormat(r))); err != nil {
+			h.log.Warn("ndjson write fail", slog.String("error", err.Error()))
+			return
+		}
+
+		n++
+		if n%ndjsonFlushEvery == 0 {
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/importer"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// @Summary Import subscriptions from CSV
+// @Description CSV columns: user_id,service_name,price,start_date,end_date. Lenient mode normalizes recoverable values and reports warnings.
+// @Description Rows are committed in chunks; re-uploading the same file after a failure resumes from the last committed chunk.
+// @Tags import
+// @Accept text/csv
+// @Produce json
+// @Param mode query string false "strict (default) or lenient"
+// @Success 200 {object} respond.Envelope{data=domain.ImportResult}
+// @Failure 400 {object} problem.Details
+// @Failure 422 {object} domain.ImportResult
+// @Router /subscriptions/import [post]
+func (h *HandlerSubscription) importSubscriptions(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, h.importMaxBytes)
+
+	res, err := h.imports.ImportCSV(r.Context(), body, r.URL.Query().Get("mode"))
+	if err != nil {
+		h.log.Error("import fail", slog.String("err", err.Error()))
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxErr):
+			problem.Write(w, "file too large", 413)
+		case errors.Is(err, service.ErrBadImportMode), errors.Is(err, importer.ErrMissingColumn), errors.Is(err, importer.ErrEmptyFile):
+			problem.Error(w, err, 400)
+		default:
+			problem.Write(w, "invalid csv file", 400)
+		}
+		return
+	}
+
+	// строгий режим и файл отклонен целиком, до записи дело не дошло
+	status := 200
+	if res.Mode == domain.ImportModeStrict && res.JobID == 0 && len(res.Errors) > 0 {
+		status = 422
+	}
+
+	respond.JSON(w, status, res)
+}
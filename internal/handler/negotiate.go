@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/dates"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/exporter"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/i18n"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+)
+
+// форматы ответа, которые умеют списки и расходы
+const (
+	mediaJSON   = "application/json"
+	mediaCSV    = "text/csv"
+	mediaNDJSON = "application/x-ndjson"
+)
+
+var tableMedia = []string{mediaJSON, mediaCSV, mediaNDJSON}
+
+// negotiate выбирает из offers лучший по заголовку Accept (с учетом q).
+// Без заголовка - первый из offers, если ничего не подходит - пустая строка
+func negotiate(r *http.Request, offers ...string) string {
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(accept, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// вес offer в Accept: берется самый точный подходящий диапазон, 0 - не принимается
+func acceptQuality(accept, offer string) float64 {
+	offerType, _, _ := strings.Cut(offer, "/")
+
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		s := -1
+		switch {
+		case mediaType == offer:
+			s = 2
+		case mediaType == offerType+"/*":
+			s = 1
+		case mediaType == "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+
+		specificity, q = s, 1
+		if raw, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(raw, 64); err == nil {
+				q = v
+			}
+		}
+	}
+	return q
+}
+
+func notAcceptable(w http.ResponseWriter, r *http.Request, offers []string) {
+	loc := i18n.FromContext(r.Context())
+	problem.Write(w, loc.Sprintf("not acceptable, supported: %s", strings.Join(offers, ", ")), http.StatusNotAcceptable)
+}
+
+// подписки построчно: csv с колонками выгрузки или ndjson по одной на строку
+func (h *HandlerSubscription) writeSubscriptionRows(w http.ResponseWriter, media string, subs []domain.Subscription, f dates.Format) {
+	switch media {
+	case mediaCSV:
+		rows := make([][]string, 0, len(subs))
+		for _, sub := range subs {
+			rows = append(rows, exporter.Record(fmt.Sprint(h.ids.Encode(sub.ID)), sub))
+		}
+		h.writeCSV(w, exporter.Columns, rows)
+	case mediaNDJSON:
+		w.Header().Set("Content-Type", mediaNDJSON)
+		enc := json.NewEncoder(w)
+		for _, sub := range subs {
+			if err := enc.Encode(h.subscriptionView(sub, f)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// расходы построчно по сервисам, в csv последними строками итоги по валютам
+func (h *HandlerSubscription) writeCostRows(w http.ResponseWriter, media string, total *domain.TotalCost) {
+	switch media {
+	case mediaCSV:
+		rows := make([][]string, 0, len(total.Details)+len(total.Totals))
+		for _, d := range total.Details {
+			rows = append(rows, []string{d.ServiceName, strconv.Itoa(d.Months), d.Cost.String(), d.Currency})
+		}
+		for _, t := range total.Totals {
+			rows = append(rows, []string{"total", "", t.Cost.String(), t.Currency})
+		}
+		h.writeCSV(w, []string{"service_name", "months", "cost", "currency"}, rows)
+	case mediaNDJSON:
+		w.Header().Set("Content-Type", mediaNDJSON)
+		enc := json.NewEncoder(w)
+		for _, d := range total.Details {
+			if err := enc.Encode(d); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *HandlerSubscription) writeCSV(w http.ResponseWriter, header []string, rows [][]string) {
+	csvFormat := exporter.CSV{}
+	w.Header().Set("Content-Type", csvFormat.ContentType())
+
+	out, _ := csvFormat.NewWriter(w)
+	out.WriteRow(header)
+	for _, row := range rows {
+		if err := out.WriteRow(row); err != nil {
+			break
+		}
+	}
+	if err := out.Close(); err != nil {
+		h.log.Warn("csv write fail", slog.String("error", err.Error()))
+	}
+}
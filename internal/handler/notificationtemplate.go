@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/notifier"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+// subject и body в синтаксисе Go text/template, поля данных зависят от вида уведомления
+type NotificationTemplateRequest struct {
+	Subject string `json:"subject" example:"Скоро закончится {{.ServiceName}}"`
+	Body    string `json:"body" example:"Подписка {{.ServiceName}} заканчивается в {{.EndDate}}"`
+}
+
+// @Summary List notification templates
+// @Description Every notification kind with its effective template, custom=false means the built-in one
+// @Tags notifications
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=[]domain.NotificationTemplate}
+// @Failure 401 {object} problem.Details
+// @Router /admin/notification-templates [get]
+func (h *HandlerSubscription) listNotificationTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.templates.List(r.Context())
+	if err != nil {
+		h.serviceError(w, err, "notification template list fail")
+		return
+	}
+	respond.JSON(w, 200, templates)
+}
+
+// @Summary Get notification template
+// @Tags notifications
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param kind path string true "Notification kind" Enums(reminder)
+// @Success 200 {object} respond.Envelope{data=domain.NotificationTemplate}
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /admin/notification-templates/{kind} [get]
+func (h *HandlerSubscription) getNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := h.templates.Get(r.Context(), r.PathValue("kind"))
+	if err != nil {
+		h.serviceError(w, err, "notification template get fail")
+		return
+	}
+	respond.JSON(w, 200, t)
+}
+
+// @Summary Override notification template
+// @Description The template is rendered against sample data before saving, unknown fields or syntax errors give 400
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param kind path string true "Notification kind" Enums(reminder)
+// @Param input body NotificationTemplateRequest true "Template"
+// @Success 200 {object} respond.Envelope{data=domain.NotificationTemplate}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /admin/notification-templates/{kind} [put]
+func (h *HandlerSubscription) setNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	var req NotificationTemplateRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	t, err := h.templates.Set(r.Context(), r.PathValue("kind"), req.Subject, req.Body)
+	if err != nil {
+		h.serviceError(w, err, "notification template save fail")
+		return
+	}
+	respond.JSON(w, 200, t)
+}
+
+// @Summary Reset notification template
+// @Description Drops the override, the built-in template is returned
+// @Tags notifications
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param kind path string true "Notification kind" Enums(reminder)
+// @Success 200 {object} respond.Envelope{data=domain.NotificationTemplate}
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /admin/notification-templates/{kind} [delete]
+func (h *HandlerSubscription) resetNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := h.templates.Reset(r.Context(), r.PathValue("kind"))
+	if err != nil {
+		h.serviceError(w, err, "notification template reset fail")
+		return
+	}
+	respond.JSON(w, 200, t)
+}
+
+// @Summary Preview notification template
+// @Description Renders the template from the body against sample data, with an empty body - the current one
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param kind path string true "Notification kind" Enums(reminder)
+// @Param input body NotificationTemplateRequest false "Draft template"
+// @Success 200 {object} respond.Envelope{data=domain.NotificationPreview}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /admin/notification-templates/{kind}/preview [post]
+func (h *HandlerSubscription) previewNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	// тело необязательное, без него рендерится текущий шаблон
+	var req NotificationTemplateRequest
+	if !h.decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	var draft *notifier.Template
+	// пустое поле черновика берем из текущего шаблона
+	if req.Subject != "" || req.Body != "" {
+		current, err := h.templates.Get(r.Context(), r.PathValue("kind"))
+		if err != nil {
+			h.serviceError(w, err, "notification template preview fail")
+			return
+		}
+		draft = &notifier.Template{Subject: current.Subject, Body: current.Body}
+		if req.Subject != "" {
+			draft.Subject = req.Subject
+		}
+		if req.Body != "" {
+			draft.Body = req.Body
+		}
+	}
+
+	preview, err := h.templates.Preview(r.Context(), r.PathValue("kind"), draft)
+	if err != nil {
+		h.serviceError(w, err, "notification template preview fail")
+		return
+	}
+	respond.JSON(w, 200, preview)
+}
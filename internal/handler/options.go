@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/dates"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/idcodec"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// Option настраивает HandlerSubscription при создании. Без опций: реальные часы,
+// числовые id, даты только MM-YYYY, админ ручки закрыты, импорт до 200 МБ, json тело до 1 МБ.
+// Зависимости, которые собираются позже (вложения, бюджеты, /readyz), ставятся через Set*
+type Option func(*HandlerSubscription)
+
+func WithClock(clk clock.Clock) Option {
+	return func(h *HandlerSubscription) { h.clock = clk }
+}
+
+// разбор дат запроса, Legacy принимает старые форматы
+func WithDateParser(p dates.Parser) Option {
+	return func(h *HandlerSubscription) { h.dates = p }
+}
+
+// внешнее представление id подписок
+func WithIDCodec(ids idcodec.Codec) Option {
+	return func(h *HandlerSubscription) { h.ids = ids }
+}
+
+// токен /admin ручек, пустой - они закрыты
+func WithAdminToken(token string) Option {
+	return func(h *HandlerSubscription) { h.adminToken = token }
+}
+
+// лимит тела POST /subscriptions/import
+func WithImportMaxBytes(n int64) Option {
+	return func(h *HandlerSubscription) { h.importMaxBytes = n }
+}
+
+// лимит json тела остальных ручек
+func WithMaxBodyBytes(n int64) Option {
+	return func(h *HandlerSubscription) { h.maxBodyBytes = n }
+}
+
+// хуки политик, без них /admin/policy-hooks нет
+func WithPolicy(hooks service.PolicyServiceInterface) Option {
+	return func(h *HandlerSubscription) { h.policyHooks = hooks }
+}
+
+// валюта, в которой хранятся цены, для выписок
+func WithCurrency(code string) Option {
+	return func(h *HandlerSubscription) { h.currency = code }
+}
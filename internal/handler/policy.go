@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// secret только на запись, в ответах его нет
+type PolicyHookRequest struct {
+	URL      string `json:"url" example:"https://procurement.example.com/hooks/subscriptions"`
+	Secret   string `json:"secret,omitempty" example:"s3cr3t"`
+	FailOpen bool   `json:"fail_open,omitempty" example:"false"`
+}
+
+// @Summary List policy hooks
+// @Tags policy
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=[]domain.PolicyHook}
+// @Failure 401 {object} problem.Details
+// @Router /admin/policy-hooks [get]
+func (h *HandlerSubscription) listPolicyHooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.policyHooks.List(r.Context())
+	if err != nil {
+		h.log.Error("policy hooks list fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 200, hooks)
+}
+
+// @Summary Register policy hook
+// @Description Before every create and extend the proposed subscription is POSTed to the URL as {"action", "subscription", "current"}. The hook answers 200 {"allow": bool, "reason": string}; allow=false blocks the operation with 422 and the reason. With a secret the body is signed in X-Signature-256 (sha256=hex HMAC). An unreachable or failing hook blocks with 503 unless fail_open
+// @Tags policy
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param input body PolicyHookRequest true "Hook"
+// @Success 201 {object} respond.Envelope{data=domain.PolicyHook}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Router /admin/policy-hooks [post]
+func (h *HandlerSubscription) createPolicyHook(w http.ResponseWriter, r *http.Request) {
+	var req PolicyHookRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	hook, err := h.policyHooks.Create(r.Context(), domain.PolicyHook{URL: req.URL, Secret: req.Secret, FailOpen: req.FailOpen})
+	if err != nil {
+		if errors.Is(err, service.ErrBadPolicyHook) {
+			problem.Error(w, err, 400)
+			return
+		}
+		h.log.Error("policy hook create fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 201, hook)
+}
+
+// @Summary Delete policy hook
+// @Tags policy
+// @Param X-Admin-Token header string true "Admin token"
+// @Param id path int true "Hook ID"
+// @Success 204
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /admin/policy-hooks/{id} [delete]
+func (h *HandlerSubscription) deletePolicyHook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseDictionaryID(r)
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	if err := h.policyHooks.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			problem.Write(w, "policy hook not found", 404)
+			return
+		}
+		h.log.Error("policy hook delete fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	w.WriteHeader(204)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+type ProvisionRequest struct {
+	Users []service.ProvisionUser `json:"users"`
+}
+
+// policy и transfer_to не заданы - берутся из PROVISIONING_OFFBOARD_POLICY и PROVISIONING_TRANSFER_TO
+type DeactivateUsersRequest struct {
+	UserIDs    []uuid.UUID `json:"user_ids"`
+	Policy     string      `json:"policy" example:"transfer"`
+	TransferTo uuid.UUID   `json:"transfer_to" example:"6ba7b810-9dad-11d1-80b4-00c04fd430c8"`
+}
+
+// @Summary Provision users
+// @Description Bulk create or update users from the IdP. Users are matched by external_id, then by id. active=false deactivates the user with the default offboarding policy. Each user gets its own status, one failure does not stop the rest
+// @Tags provisioning
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body ProvisionRequest true "Users"
+// @Success 200 {object} respond.Envelope{data=[]domain.ProvisionResult}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Router /provisioning/users [post]
+func (h *HandlerSubscription) provisionUsers(w http.ResponseWriter, r *http.Request) {
+	var req ProvisionRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	results, err := h.provisioning.Provision(r.Context(), req.Users)
+	if err != nil {
+		h.serviceError(w, err, "provisioning fail")
+		return
+	}
+	respond.JSON(w, 200, results)
+}
+
+// @Summary Deactivate users
+// @Description Offboarding: live subscriptions of each user are cancelled from the current month or transferred to transfer_to, then the user is marked inactive. Safe to repeat
+// @Tags provisioning
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body DeactivateUsersRequest true "Users and policy"
+// @Success 200 {object} respond.Envelope{data=[]domain.ProvisionResult}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Router /provisioning/users/deactivate [post]
+func (h *HandlerSubscription) deactivateUsers(w http.ResponseWriter, r *http.Request) {
+	var req DeactivateUsersRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	results, err := h.provisioning.Deactivate(r.Context(), req.UserIDs, req.Policy, req.TransferTo)
+	if err != nil {
+		h.serviceError(w, err, "deactivation fail")
+		return
+	}
+	respond.JSON(w, 200, results)
+}
+
+// @Summary List provisioned users
+// @Tags provisioning
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param active query bool false "Only active or only deactivated"
+// @Success 200 {object} respond.Envelope{data=[]domain.User}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Router /provisioning/users [get]
+func (h *HandlerSubscription) listProvisionedUsers(w http.ResponseWriter, r *http.Request) {
+	var active *bool
+	if raw := r.URL.Query().Get("active"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			problem.Write(w, "bad active", 400)
+			return
+		}
+		active = &v
+	}
+
+	users, err := h.provisioning.List(r.Context(), active)
+	if err != nil {
+		h.serviceError(w, err, "provisioned users list fail")
+		return
+	}
+	respond.JSON(w, 200, users)
+}
+
+// @Summary Get provisioned user
+// @Tags provisioning
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "User UUID"
+// @Success 200 {object} respond.Envelope{data=domain.User}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /provisioning/users/{id} [get]
+func (h *HandlerSubscription) getProvisionedUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "invalid id", 400)
+		return
+	}
+
+	u, err := h.provisioning.Get(r.Context(), id)
+	if err != nil {
+		h.serviceError(w, err, "provisioned user get fail")
+		return
+	}
+	respond.JSON(w, 200, u)
+}
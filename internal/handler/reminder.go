@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+type ReminderAckInput struct {
+	UserID uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+type ReminderSnoozeInput struct {
+	UserID uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Days   int       `json:"days" example:"7"`
+}
+
+type ReminderOffsetsInput struct {
+	UserID     uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	DaysBefore []int     `json:"days_before" example:"7,1"`
+}
+
+// @Summary Acknowledge expiration reminder
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param input body ReminderAckInput true "User info"
+// @Success 200 {object} respond.Envelope{data=reminderStateView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /subscriptions/{id}/reminders/ack [post]
+func (h *HandlerSubscription) acknowledgeReminder(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	var req ReminderAckInput
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == uuid.Nil {
+		problem.Write(w, "user_id is required", 400)
+		return
+	}
+
+	state, err := h.reminders.Acknowledge(r.Context(), id, req.UserID)
+	if err != nil {
+		h.serviceError(w, err, "reminder ack fail")
+		return
+	}
+
+	respond.JSON(w, 200, h.reminderStateView(*state))
+}
+
+// @Summary Snooze expiration reminder
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param input body ReminderSnoozeInput true "Snooze period"
+// @Success 200 {object} respond.Envelope{data=reminderStateView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /subscriptions/{id}/reminders/snooze [post]
+func (h *HandlerSubscription) snoozeReminder(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	var req ReminderSnoozeInput
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == uuid.Nil {
+		problem.Write(w, "user_id is required", 400)
+		return
+	}
+
+	state, err := h.reminders.Snooze(r.Context(), id, req.UserID, req.Days)
+	if err != nil {
+		h.serviceError(w, err, "reminder snooze fail")
+		return
+	}
+
+	respond.JSON(w, 200, h.reminderStateView(*state))
+}
+
+// @Summary Set reminder offsets
+// @Description Days before the subscription ends when reminders are sent, overriding the global lead time. An empty list restores the default
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param input body ReminderOffsetsInput true "Reminder offsets"
+// @Success 200 {object} respond.Envelope{data=reminderStateView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /subscriptions/{id}/reminders [patch]
+func (h *HandlerSubscription) setReminderOffsets(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	var req ReminderOffsetsInput
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == uuid.Nil {
+		problem.Write(w, "user_id is required", 400)
+		return
+	}
+
+	state, err := h.reminders.SetOffsets(r.Context(), id, req.UserID, req.DaysBefore)
+	if err != nil {
+		h.serviceError(w, err, "reminder offsets fail")
+		return
+	}
+
+	respond.JSON(w, 200, h.reminderStateView(*state))
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+type RouteSwitchRequest struct {
+	Route  string `json:"route" example:"POST /subscriptions/import"`
+	Reason string `json:"reason,omitempty" example:"incident 42: import overloads db"`
+}
+
+// @Summary List disabled routes
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=[]domain.DisabledRoute}
+// @Failure 401 {object} problem.Details
+// @Router /admin/routes/disabled [get]
+func (h *HandlerSubscription) listDisabledRoutes(w http.ResponseWriter, r *http.Request) {
+	routes, err := h.routeSwitches.List(r.Context())
+	if err != nil {
+		h.serviceError(w, err, "disabled routes list fail")
+		return
+	}
+	respond.JSON(w, 200, routes)
+}
+
+// @Summary Disable route
+// @Description Route is a mux pattern, e.g. "POST /subscriptions/import". Requests to it get 503 with the reason until it is enabled again
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param input body RouteSwitchRequest true "Route to disable"
+// @Success 200 {object} respond.Envelope{data=[]domain.DisabledRoute}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Router /admin/routes/disabled [put]
+func (h *HandlerSubscription) disableRoute(w http.ResponseWriter, r *http.Request) {
+	var req RouteSwitchRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.routeSwitches.Disable(r.Context(), req.Route, req.Reason); err != nil {
+		h.serviceError(w, err, "route disable fail")
+		return
+	}
+	h.listDisabledRoutes(w, r)
+}
+
+// @Summary Enable route
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param input body RouteSwitchRequest true "Route to enable, reason is ignored"
+// @Success 200 {object} respond.Envelope{data=[]domain.DisabledRoute}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /admin/routes/disabled [delete]
+func (h *HandlerSubscription) enableRoute(w http.ResponseWriter, r *http.Request) {
+	var req RouteSwitchRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.routeSwitches.Enable(r.Context(), req.Route); err != nil {
+		h.serviceError(w, err, "route enable fail")
+		return
+	}
+	h.listDisabledRoutes(w, r)
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"github.com/google/uuid"
+	subscriptionv1 "github.com/mmoldabe-dev/EffectiveTask/gen/subscription/v1"
+	"github.com/mmoldabe-dev/EffectiveTask/gen/subscription/v1/subscriptionv1connect"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/pricing"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/rpc"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// максимальный размер входящего сообщения
+const rpcMaxMessageBytes = 4 << 20
+
+// rpcService реализует сгенерированный SubscriptionServiceHandler поверх тех же
+// сервисов, что и HTTP ручки. Суммы без _minor - целые единицы валюты
+type rpcService struct {
+	h *HandlerSubscription
+}
+
+var _ subscriptionv1connect.SubscriptionServiceHandler = (*rpcService)(nil)
+
+// RPCHandler отдает путь и хендлер SubscriptionService: gRPC, gRPC-Web и Connect
+func (h *HandlerSubscription) RPCHandler() (string, http.Handler) {
+	return subscriptionv1connect.NewSubscriptionServiceHandler(&rpcService{h: h},
+		connect.WithInterceptors(rpc.Logging(h.log), rpc.Auth(h.rpcToken)),
+		rpc.Recover(h.log),
+		connect.WithReadMaxBytes(rpcMaxMessageBytes),
+	)
+}
+
+// фильтр цены в proto в целых единицах
+func rpcPriceFilter(units *int32) *domain.Money {
+	if units == nil {
+		return nil
+	}
+	m := domain.Major(int64(*units))
+	return &m
+}
+
+func (s *rpcService) subscription(sub domain.Subscription) *subscriptionv1.Subscription {
+	return &subscriptionv1.Subscription{
+		Id:          fmt.Sprint(s.h.ids.Encode(sub.ID)),
+		UserId:      sub.UserID.String(),
+		ServiceName: sub.ServiceName,
+		Price:       int32(sub.Price.Units()),
+		StartDate:   sub.StartDate,
+		EndDate:     sub.EndDate,
+		Status:      sub.Status,
+		PriceMinor:  int64(sub.Price),
+	}
+}
+
+func rpcInvalid(msg string) error {
+	return connect.NewError(connect.CodeInvalidArgument, errors.New(msg))
+}
+
+// ошибки сервиса в коды Connect, по тем же правилам что и HTTP статусы.
+// Детали внутренних ошибок наружу не отдаем
+func (s *rpcService) error(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return connect.NewError(connect.CodeNotFound, errors.New("subscription not found"))
+	case errors.Is(err, service.ErrSubscriptionExists):
+		return connect.NewError(connect.CodeAlreadyExists, err)
+	case errors.Is(err, service.ErrBadConfirmToken), errors.Is(err, service.ErrPolicyRejected):
+		return connect.NewError(connect.CodeFailedPrecondition, err)
+	case errors.Is(err, domain.ErrConflict):
+		return connect.NewError(connect.CodeAborted, err)
+	case errors.Is(err, domain.ErrInvalid):
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	case errors.Is(err, service.ErrBadPeriod), errors.Is(err, service.ErrPeriodTooLong):
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	case errors.Is(err, pricing.ErrPriceOutOfRange):
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	case isUnavailable(err), errors.Is(err, service.ErrPolicyUnavailable):
+		s.h.log.Error("rpc service unavailable", slog.String("err", err.Error()))
+		return connect.NewError(connect.CodeUnavailable, errors.New("service temporarily unavailable"))
+	default:
+		s.h.log.Error("rpc internal error", slog.String("err", err.Error()))
+		return connect.NewError(connect.CodeInternal, errors.New("internal error"))
+	}
+}
+
+func (s *rpcService) CreateSubscription(ctx context.Context, req *connect.Request[subscriptionv1.CreateSubscriptionRequest]) (*connect.Response[subscriptionv1.CreateSubscriptionResponse], error) {
+	uID, err := uuid.Parse(req.Msg.GetUserId())
+	if err != nil {
+		return nil, rpcInvalid("invalid userId")
+	}
+
+	// те же правила, что и у POST /subscriptions
+	body := CreateSubscriptionRequest{
+		UserID:      uID,
+		ServiceName: req.Msg.GetServiceName(),
+		Price:       domain.Major(int64(req.Msg.GetPrice())),
+		StartDate:   req.Msg.GetStartDate(),
+		EndDate:     req.Msg.EndDate,
+	}
+	if req.Msg.GetPriceMinor() != 0 {
+		body.Price = domain.Money(req.Msg.GetPriceMinor())
+	}
+	sub := body.subscription()
+	if errs := s.h.validateSubscription(body, &sub); len(errs) > 0 {
+		return nil, rpcInvalid(errs.Error())
+	}
+
+	id, err := s.h.services.Create(ctx, sub)
+	if err != nil {
+		return nil, s.error(err)
+	}
+
+	return connect.NewResponse(&subscriptionv1.CreateSubscriptionResponse{
+		Id:      fmt.Sprint(s.h.ids.Encode(id)),
+		Warning: s.h.services.PriceWarning(sub),
+	}), nil
+}
+
+func (s *rpcService) GetSubscription(ctx context.Context, req *connect.Request[subscriptionv1.GetSubscriptionRequest]) (*connect.Response[subscriptionv1.GetSubscriptionResponse], error) {
+	id, err := s.h.parseID(req.Msg.GetId())
+	if err != nil {
+		return nil, rpcInvalid("invalid id")
+	}
+
+	sub, err := s.h.services.GetByID(ctx, id)
+	if err != nil {
+		return nil, s.error(err)
+	}
+
+	return connect.NewResponse(&subscriptionv1.GetSubscriptionResponse{Subscription: s.subscription(*sub)}), nil
+}
+
+func (s *rpcService) ListSubscriptions(ctx context.Context, req *connect.Request[subscriptionv1.ListSubscriptionsRequest]) (*connect.Response[subscriptionv1.ListSubscriptionsResponse], error) {
+	uID, err := uuid.Parse(req.Msg.GetUserId())
+	if err != nil {
+		return nil, rpcInvalid("invalid userId")
+	}
+
+	limit := int(req.Msg.GetLimit())
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 200 {
+		return nil, rpcInvalid("limit too big")
+	}
+
+	subs, err := s.h.services.List(ctx, uID, domain.SubscriptionFilter{
+		UserID:      uID,
+		ServiceName: req.Msg.GetServiceName(),
+		MinPrice:    rpcPriceFilter(req.Msg.MinPrice), MaxPrice: rpcPriceFilter(req.Msg.MaxPrice), Price: rpcPriceFilter(req.Msg.Price),
+		Limit: limit, Offset: int(req.Msg.GetOffset()),
+	})
+	if err != nil {
+		return nil, s.error(err)
+	}
+
+	resp := &subscriptionv1.ListSubscriptionsResponse{Subscriptions: make([]*subscriptionv1.Subscription, 0, len(subs))}
+	for _, sub := range subs {
+		resp.Subscriptions = append(resp.Subscriptions, s.subscription(sub))
+	}
+	return connect.NewResponse(resp), nil
+}
+
+func (s *rpcService) StreamSubscriptions(ctx context.Context, req *connect.Request[subscriptionv1.StreamSubscriptionsRequest], stream *connect.ServerStream[subscriptionv1.StreamSubscriptionsResponse]) error {
+	uID, err := uuid.Parse(req.Msg.GetUserId())
+	if err != nil {
+		return rpcInvalid("invalid userId")
+	}
+
+	rows, err := s.h.services.Stream(ctx, uID, domain.SubscriptionFilter{
+		UserID:      uID,
+		ServiceName: req.Msg.GetServiceName(),
+		MinPrice:    rpcPriceFilter(req.Msg.MinPrice), MaxPrice: rpcPriceFilter(req.Msg.MaxPrice), Price: rpcPriceFilter(req.Msg.Price),
+	})
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	for sub, err := range rows {
+		if err != nil {
+			return s.error(err)
+		}
+		if err := stream.Send(&subscriptionv1.StreamSubscriptionsResponse{Subscription: s.subscription(*sub)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *rpcService) DeleteSubscription(ctx context.Context, req *connect.Request[subscriptionv1.DeleteSubscriptionRequest]) (*connect.Response[subscriptionv1.DeleteSubscriptionResponse], error) {
+	id, err := s.h.parseID(req.Msg.GetId())
+	if err != nil {
+		return nil, rpcInvalid("invalid id")
+	}
+
+	confirm, err := s.h.services.Delete(ctx, id, req.Msg.GetConfirmToken())
+	if err != nil {
+		return nil, s.error(err)
+	}
+
+	resp := &subscriptionv1.DeleteSubscriptionResponse{}
+	if confirm != nil {
+		resp.ConfirmToken = confirm.Token
+	}
+	return connect.NewResponse(resp), nil
+}
+
+func (s *rpcService) GetTotalCost(ctx context.Context, req *connect.Request[subscriptionv1.GetTotalCostRequest]) (*connect.Response[subscriptionv1.GetTotalCostResponse], error) {
+	uID, err := uuid.Parse(req.Msg.GetUserId())
+	if err != nil {
+		return nil, rpcInvalid("invalid userId")
+	}
+
+	from, to := req.Msg.GetFrom(), req.Msg.GetTo()
+	if from == "" || to == "" || !s.h.normalizeDate(&from) || !s.h.normalizeDate(&to) {
+		return nil, rpcInvalid("invalid date format")
+	}
+
+	total, err := s.h.services.GetTotalCost(ctx, uID, req.Msg.GetServiceName(), from, to)
+	if err != nil {
+		return nil, s.error(err)
+	}
+
+	resp := &subscriptionv1.GetTotalCostResponse{
+		TotalCost:      total.Total.Units(),
+		TotalCostMinor: int64(total.Total),
+		Details:        make([]*subscriptionv1.CostDetail, 0, len(total.Details)),
+		Warning:        s.h.futureWarning(to),
+	}
+	for _, d := range total.Details {
+		resp.Details = append(resp.Details, &subscriptionv1.CostDetail{ServiceName: d.ServiceName, Months: int32(d.Months), Cost: d.Cost.Units(), CostMinor: int64(d.Cost)})
+	}
+	return connect.NewResponse(resp), nil
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+type ScheduledEventInput struct {
+	UserID uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	// auto_renew, trial_conversion или price_change
+	Kind  string        `json:"kind" example:"price_change"`
+	RunAt time.Time     `json:"run_at" example:"2026-03-01T00:00:00Z"`
+	Price *domain.Money `json:"price,omitempty" example:"799"`
+	// только для auto_renew, по умолчанию период оплаты подписки
+	Months int `json:"months,omitempty" example:"1"`
+}
+
+// @Summary Schedule a subscription action
+// @Description Stores an action that runs once at run_at: auto_renew extends end_date and schedules the next renewal, trial_conversion ends the trial so the run_at month is paid, price_change sets the new price. A pending action of the same kind and time is replaced
+// @Tags scheduled-events
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param input body ScheduledEventInput true "Action"
+// @Success 201 {object} respond.Envelope{data=scheduledEventView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /subscriptions/{id}/scheduled-events [post]
+func (h *HandlerSubscription) createScheduledEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	var req ScheduledEventInput
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == uuid.Nil {
+		problem.Write(w, "user_id is required", 400)
+		return
+	}
+
+	ev, err := h.scheduled.Schedule(r.Context(), id, req.UserID, domain.ScheduledEvent{
+		Kind:   req.Kind,
+		RunAt:  req.RunAt,
+		Price:  req.Price,
+		Months: req.Months,
+	})
+	if err != nil {
+		h.serviceError(w, err, "scheduled event create fail")
+		return
+	}
+
+	respond.JSON(w, 201, h.scheduledEventView(*ev))
+}
+
+// @Summary List subscription scheduled actions
+// @Description All actions of the subscription including done, skipped, failed and cancelled ones, ordered by run_at
+// @Tags scheduled-events
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param user_id query string true "User UUID"
+// @Success 200 {object} respond.Envelope{data=[]scheduledEventView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /subscriptions/{id}/scheduled-events [get]
+func (h *HandlerSubscription) listScheduledEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+	uID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	events, err := h.scheduled.List(r.Context(), id, uID)
+	if err != nil {
+		h.serviceError(w, err, "scheduled event list fail")
+		return
+	}
+
+	views := make([]scheduledEventView, 0, len(events))
+	for _, ev := range events {
+		views = append(views, h.scheduledEventView(ev))
+	}
+	respond.JSON(w, 200, views)
+}
+
+// @Summary Cancel a scheduled action
+// @Description Only pending actions can be cancelled. Cancelling a pending auto_renew stops the renewal chain
+// @Tags scheduled-events
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param event_id path int true "Scheduled event ID"
+// @Param user_id query string true "User UUID"
+// @Success 200 {object} respond.Envelope{data=scheduledEventView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Router /subscriptions/{id}/scheduled-events/{event_id} [delete]
+func (h *HandlerSubscription) cancelScheduledEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+	eventID, err := strconv.ParseInt(r.PathValue("event_id"), 10, 64)
+	if err != nil {
+		problem.Write(w, "bad event_id", 400)
+		return
+	}
+	uID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	ev, err := h.scheduled.Cancel(r.Context(), id, uID, eventID)
+	if err != nil {
+		h.serviceError(w, err, "scheduled event cancel fail")
+		return
+	}
+
+	respond.JSON(w, 200, h.scheduledEventView(*ev))
+}
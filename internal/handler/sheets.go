@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// @Summary Last Google Sheets sync report
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} respond.Envelope{data=domain.SheetSyncReport}
+// @Failure 401 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /admin/sheets/sync [get]
+func (h *HandlerSubscription) getSheetSync(w http.ResponseWriter, r *http.Request) {
+	report := h.sheetSync.Last()
+	if report == nil {
+		problem.Write(w, "sheet was not synced yet", 404)
+		return
+	}
+	respond.JSON(w, 200, report)
+}
+
+// @Summary Sync subscriptions with Google Sheets now
+// @Description With dry_run=true only the diff is reported, nothing is written
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Param dry_run query bool false "Report the diff without applying it"
+// @Success 200 {object} respond.Envelope{data=domain.SheetSyncReport}
+// @Failure 400 {object} problem.Details
+// @Failure 401 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 502 {object} problem.Details
+// @Router /admin/sheets/sync [post]
+func (h *HandlerSubscription) runSheetSync(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if raw := r.URL.Query().Get("dry_run"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			problem.Write(w, "bad dry_run", 400)
+			return
+		}
+		dryRun = v
+	}
+
+	report, err := h.sheetSync.Sync(r.Context(), dryRun)
+	if err != nil {
+		if errors.Is(err, service.ErrSheetSyncRunning) {
+			problem.Error(w, err, 409)
+			return
+		}
+		// чаще всего недоступна таблица или неверный ключ
+		h.log.Error("sheet sync fail", slog.String("error", err.Error()))
+		problem.Write(w, "sheet sync failed", 502)
+		return
+	}
+	respond.JSON(w, 200, report)
+}
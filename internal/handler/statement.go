@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/statement"
+)
+
+// @Summary Monthly statement
+// @Description Invoice-like statement for one month: a line per billed subscription, grand total and currency
+// @Tags subscriptions
+// @Produce json
+// @Produce application/pdf
+// @Param month path string true "Month (MM-YYYY)"
+// @Param user_id query string true "User UUID"
+// @Param format query string false "json (default) or pdf"
+// @Success 200 {object} respond.Envelope{data=statementView}
+// @Failure 400 {object} problem.Details
+// @Router /statements/{month} [get]
+func (h *HandlerSubscription) getStatement(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	uID, err := uuid.Parse(q.Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	month := r.PathValue("month")
+	if month == "" || !h.normalizeDate(&month) {
+		problem.Write(w, "invalid month format", 400)
+		return
+	}
+
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "pdf" {
+		problem.Write(w, "unsupported format", 400)
+		return
+	}
+
+	st, err := h.services.Statement(r.Context(), uID, month)
+	if err != nil {
+		h.log.Error("statement fail", slog.String("month", month), slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+	st.Currency = h.currency
+
+	if format != "pdf" {
+		respond.JSON(w, 200, h.statementView(*st))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "statement-"+month+".pdf"))
+	if err := statement.WritePDF(w, st); err != nil {
+		h.log.Error("statement pdf write fail", slog.String("error", err.Error()))
+	}
+}
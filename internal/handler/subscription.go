@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -10,51 +12,326 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/mmoldabe-dev/EffectiveTask/docs"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/dates"
 	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/health"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/i18n"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/idcodec"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/metrics"
 	"github.com/mmoldabe-dev/EffectiveTask/internal/middleware"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/rpc"
 	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 type HandlerSubscription struct {
-	services service.SubscriptionServiceInterface
-	log      *slog.Logger
+	services    service.SubscriptionServiceInterface
+	reminders   service.ReminderServiceInterface
+	activity    service.ActivityServiceInterface
+	imports     service.ImportServiceInterface
+	idempotency service.IdempotencyServiceInterface
+	dates       dates.Parser
+	ids         idcodec.Codec
+	clock       clock.Clock
+	log         *slog.Logger
+
+	adminToken     string
+	importMaxBytes int64
+	maxBodyBytes   int64
+	systemStats    map[string]SystemStatsSource
+	shadowRate     float64
+	rpcToken       string
+	rpcOrigins     []string
+	configView     any
+	audit          service.AuditServiceInterface
+	currency       string
+	budgets        service.BudgetServiceInterface
+	categories     service.CategoryServiceInterface
+	catalog        service.CatalogServiceInterface
+	sheetSync      service.SheetSyncServiceInterface
+	rates          service.ExchangeRateServiceInterface
+	templates      service.NotificationTemplateServiceInterface
+	scheduled      service.ScheduledEventServiceInterface
+
+	accounting       service.AccountingServiceInterface
+	analytics        service.AnalyticsServiceInterface
+	policyHooks      service.PolicyServiceInterface
+	provisioning     service.ProvisioningServiceInterface
+	provisioningKey  string
+	accountingURLs   cursorSigner
+	accountingURLTTL time.Duration
+
+	cursors cursorSigner
+
+	// swagger.json загружен, иначе /swagger не регистрируется
+	apiDocs bool
+
+	attachments        service.AttachmentServiceInterface
+	attachmentMaxBytes int64
+	health             *health.Registry
+	routeSwitches      service.RouteSwitchServiceInterface
 }
 
-func NewHandlerSubscription(services service.SubscriptionServiceInterface, log *slog.Logger) *HandlerSubscription {
-	return &HandlerSubscription{
-		services: services,
-		log:      log.With(slog.String("component", "delivery/http")),
+// лимиты по умолчанию, как IMPORT_MAX_MB и API_MAX_BODY_KB в конфиге
+const (
+	defaultImportMaxBytes = 200 << 20
+	defaultMaxBodyBytes   = 1 << 20
+)
+
+func NewHandlerSubscription(services service.SubscriptionServiceInterface, reminders service.ReminderServiceInterface, activity service.ActivityServiceInterface, imports service.ImportServiceInterface, idempotency service.IdempotencyServiceInterface, log *slog.Logger, opts ...Option) *HandlerSubscription {
+	h := &HandlerSubscription{
+		services:       services,
+		reminders:      reminders,
+		activity:       activity,
+		imports:        imports,
+		idempotency:    idempotency,
+		ids:            idcodec.Plain{},
+		clock:          clock.Real{},
+		importMaxBytes: defaultImportMaxBytes,
+		maxBodyBytes:   defaultMaxBodyBytes,
+		cursors:        newCursorSigner(""),
+		log:            log.With(slog.String("component", "delivery/http")),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 func (h *HandlerSubscription) SetupRouter() http.Handler {
 	mux := http.NewServeMux()
+	// замена и продление сверяют версию атомарно в базе и отвечают 409, остальное по одной
+	// подписке проверяется If-Match/If-None-Match до хендлера
+	conditional := middleware.Conditional(h.subscriptionETag, h.log)
+	// повторы мобильных клиентов по таймауту: Idempotency-Key отдает первый ответ
+	idempotent := func(scope middleware.IdempotencyScope) func(http.Handler) http.Handler {
+		return middleware.Idempotency(h.idempotency, scope, h.maxBodyBytes, h.log)
+	}
 
-	mux.HandleFunc("POST /subscriptions", h.createSubscription)
-	mux.HandleFunc("GET /subscriptions/{id}", h.getSubscription)
-	mux.HandleFunc("DELETE /subscriptions/{id}", h.deleteSubscription)
+	mux.Handle("POST /subscriptions", idempotent(createScope)(http.HandlerFunc(h.createSubscription)))
+	mux.Handle("GET /subscriptions/{id}", conditional(http.HandlerFunc(h.getSubscription)))
+	mux.HandleFunc("PUT /subscriptions/{id}", h.replaceSubscription)
+	mux.Handle("DELETE /subscriptions/{id}", conditional(http.HandlerFunc(h.deleteSubscription)))
 	mux.HandleFunc("GET /subscriptions", h.listSubscription)
+	mux.HandleFunc("DELETE /subscriptions", h.bulkDeleteSubscriptions)
 	mux.HandleFunc("GET /subscriptions/total", h.getTotalCost)
-	mux.HandleFunc("PUT /subscriptions/{id}/extend", h.extendSubscription)
-	mux.Handle("/swagger/", httpSwagger.WrapHandler)
+	mux.HandleFunc("POST /subscriptions/total/batch", h.getBatchTotalCost)
+	mux.HandleFunc("GET /subscriptions/upcoming", h.listUpcoming)
+	mux.HandleFunc("GET /subscriptions/facets", h.getFacets)
+	mux.HandleFunc("GET /subscriptions/forecast", h.getForecast)
+	mux.HandleFunc("POST /subscriptions/import", h.importSubscriptions)
+	mux.HandleFunc("GET /subscriptions/export", h.exportSubscriptions)
+	mux.HandleFunc("GET /subscriptions/export.ndjson", h.exportNDJSON)
+	mux.HandleFunc("GET /export/formats", h.listExportFormats)
+	mux.Handle("PUT /subscriptions/{id}/extend", idempotent(func(r *http.Request, _ []byte) string { return "extend:" + r.PathValue("id") })(http.HandlerFunc(h.extendSubscription)))
+	mux.HandleFunc("GET /subscriptions/{id}/timeline", h.getTimeline)
+	mux.HandleFunc("GET /subscriptions/{id}/history", h.getHistory)
+	mux.Handle("PATCH /subscriptions/{id}/tags", conditional(http.HandlerFunc(h.patchTags)))
+	mux.Handle("POST /subscriptions/{id}/cancel", conditional(http.HandlerFunc(h.cancelSubscription)))
+	mux.Handle("POST /subscriptions/{id}/pause", conditional(http.HandlerFunc(h.pauseSubscription)))
+	mux.Handle("POST /subscriptions/{id}/resume", conditional(http.HandlerFunc(h.resumeSubscription)))
+	mux.Handle("POST /subscriptions/{id}/pauses", conditional(http.HandlerFunc(h.schedulePause)))
+	mux.Handle("DELETE /subscriptions/{id}/pauses/{pause_id}", conditional(http.HandlerFunc(h.unschedulePause)))
+	mux.HandleFunc("PATCH /subscriptions/{id}/reminders", h.setReminderOffsets)
+	mux.HandleFunc("POST /subscriptions/{id}/reminders/ack", h.acknowledgeReminder)
+	mux.HandleFunc("POST /subscriptions/{id}/reminders/snooze", h.snoozeReminder)
+	if h.scheduled != nil {
+		mux.HandleFunc("POST /subscriptions/{id}/scheduled-events", h.createScheduledEvent)
+		mux.HandleFunc("GET /subscriptions/{id}/scheduled-events", h.listScheduledEvents)
+		mux.HandleFunc("DELETE /subscriptions/{id}/scheduled-events/{event_id}", h.cancelScheduledEvent)
+	}
+	if h.attachments != nil {
+		mux.HandleFunc("POST /subscriptions/{id}/attachments", h.uploadAttachment)
+		mux.HandleFunc("GET /subscriptions/{id}/attachments", h.listAttachments)
+		mux.HandleFunc("GET /subscriptions/{id}/attachments/{attachment_id}", h.downloadAttachment)
+		mux.HandleFunc("DELETE /subscriptions/{id}/attachments/{attachment_id}", h.deleteAttachment)
+	}
+	if h.categories != nil {
+		mux.HandleFunc("POST /categories", h.createCategory)
+		mux.HandleFunc("GET /categories", h.listCategories)
+		mux.HandleFunc("GET /categories/{id}", h.getCategory)
+		mux.HandleFunc("PUT /categories/{id}", h.updateCategory)
+		mux.HandleFunc("DELETE /categories/{id}", h.deleteCategory)
+	}
+	mux.HandleFunc("GET /users/{user_id}/services", h.listUserServices)
+	mux.HandleFunc("GET /statements/{month}", h.getStatement)
+	mux.HandleFunc("GET /v2/subscriptions/total", h.getTotalCostV2)
+	mux.HandleFunc("GET /activity", h.listActivity)
+	if h.budgets != nil {
+		mux.HandleFunc("POST /budgets", h.createBudget)
+		mux.HandleFunc("GET /budgets", h.listBudgets)
+		mux.HandleFunc("GET /budgets/{id}", h.getBudget)
+		mux.HandleFunc("PUT /budgets/{id}", h.updateBudget)
+		mux.HandleFunc("DELETE /budgets/{id}", h.deleteBudget)
+		mux.HandleFunc("GET /budgets/{id}/status", h.getBudgetStatus)
+	}
+	rpcPath, rpcHandler := h.RPCHandler()
+	mux.Handle(rpcPath, rpc.CORS(h.rpcOrigins)(rpcHandler))
+	if h.apiDocs {
+		mux.Handle("/swagger/", httpSwagger.WrapHandler)
+	}
+	mux.HandleFunc("GET /version", h.getVersion)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"status":"up"}`)) })
+	if h.health != nil {
+		mux.Handle("GET /readyz", h.health)
+	}
+
+	// админка закрыта токеном
+	admin := middleware.AdminAuth(h.adminToken)
+	mux.Handle("GET /admin/system", admin(http.HandlerFunc(h.getSystemStats)))
+	// expvar отдает cmdline и memstats, это не для всех
+	mux.Handle("GET /debug/vars", admin(metrics.Handler()))
+	mux.Handle("GET /admin/config", admin(http.HandlerFunc(h.getConfig)))
+	mux.Handle("GET /admin/events/backlog", admin(http.HandlerFunc(h.getEventBacklog)))
+	if h.routeSwitches != nil {
+		mux.Handle("GET /admin/routes/disabled", admin(http.HandlerFunc(h.listDisabledRoutes)))
+		mux.Handle("PUT /admin/routes/disabled", admin(http.HandlerFunc(h.disableRoute)))
+		mux.Handle("DELETE /admin/routes/disabled", admin(http.HandlerFunc(h.enableRoute)))
+	}
+	if h.sheetSync != nil {
+		mux.Handle("GET /admin/sheets/sync", admin(http.HandlerFunc(h.getSheetSync)))
+		mux.Handle("POST /admin/sheets/sync", admin(http.HandlerFunc(h.runSheetSync)))
+	}
+	if h.provisioning != nil {
+		idp := middleware.BearerAuth(h.provisioningKey)
+		mux.Handle("POST /provisioning/users", idp(http.HandlerFunc(h.provisionUsers)))
+		mux.Handle("GET /provisioning/users", idp(http.HandlerFunc(h.listProvisionedUsers)))
+		mux.Handle("GET /provisioning/users/{id}", idp(http.HandlerFunc(h.getProvisionedUser)))
+		mux.Handle("POST /provisioning/users/deactivate", idp(http.HandlerFunc(h.deactivateUsers)))
+	}
+	if h.analytics != nil {
+		mux.Handle("GET /admin/analytics/cohorts", admin(http.HandlerFunc(h.getCohorts)))
+		mux.Handle("GET /admin/analytics/top-services", admin(http.HandlerFunc(h.getTopServices)))
+		mux.Handle("GET /admin/analytics/churn", admin(http.HandlerFunc(h.getChurn)))
+	}
+	if h.policyHooks != nil {
+		mux.Handle("GET /admin/policy-hooks", admin(http.HandlerFunc(h.listPolicyHooks)))
+		mux.Handle("POST /admin/policy-hooks", admin(http.HandlerFunc(h.createPolicyHook)))
+		mux.Handle("DELETE /admin/policy-hooks/{id}", admin(http.HandlerFunc(h.deletePolicyHook)))
+	}
+	if h.accounting != nil {
+		mux.Handle("GET /admin/accounting/exports", admin(http.HandlerFunc(h.listAccountingExports)))
+		mux.Handle("POST /admin/accounting/exports", admin(http.HandlerFunc(h.generateAccountingExport)))
+		// без админ токена, доступ по подписи в ссылке
+		mux.HandleFunc("GET /accounting/exports/{id}/file", h.downloadAccountingExport)
+	}
+	if h.templates != nil {
+		mux.Handle("GET /admin/notification-templates", admin(http.HandlerFunc(h.listNotificationTemplates)))
+		mux.Handle("GET /admin/notification-templates/{kind}", admin(http.HandlerFunc(h.getNotificationTemplate)))
+		mux.Handle("PUT /admin/notification-templates/{kind}", admin(http.HandlerFunc(h.setNotificationTemplate)))
+		mux.Handle("DELETE /admin/notification-templates/{kind}", admin(http.HandlerFunc(h.resetNotificationTemplate)))
+		mux.Handle("POST /admin/notification-templates/{kind}/preview", admin(http.HandlerFunc(h.previewNotificationTemplate)))
+	}
+	if h.catalog != nil {
+		mux.Handle("POST /catalog", admin(http.HandlerFunc(h.createCatalogEntry)))
+		mux.Handle("GET /catalog", admin(http.HandlerFunc(h.listCatalog)))
+		mux.Handle("GET /catalog/{id}", admin(http.HandlerFunc(h.getCatalogEntry)))
+		mux.Handle("PUT /catalog/{id}", admin(http.HandlerFunc(h.updateCatalogEntry)))
+		mux.Handle("DELETE /catalog/{id}", admin(http.HandlerFunc(h.deleteCatalogEntry)))
+		mux.Handle("POST /catalog/{id}/link", admin(http.HandlerFunc(h.linkCatalogEntry)))
+	}
 
 	var handler http.Handler = mux
-	// накидываем мидлвары
+	// накидываем мидлвары, аудит первым - ему нужен маршрут, который выбрал mux
+	if h.audit != nil {
+		mux.Handle("GET /audit", admin(http.HandlerFunc(h.listAudit)))
+		mux.Handle("GET /audit/verify", admin(http.HandlerFunc(h.verifyAudit)))
+		handler = middleware.Audit(h.audit.Record)(handler)
+	}
+	// выключенные маршруты отсекаются до хендлера и аудита
+	if h.routeSwitches != nil {
+		handler = middleware.KillSwitch(mux, h.routeSwitches.Disabled)(handler)
+	}
+	handler = middleware.Shadow(h.log, h.shadowRate, map[string]middleware.ShadowRoute{
+		"GET /subscriptions/total": {Target: http.HandlerFunc(h.getTotalCostV2), Compare: []string{"total_cost", "warning"}},
+	})(handler)
+	handler = checkDateFormat(handler)
 	handler = middleware.JSONMiddleware(handler)
 	handler = middleware.LogginMiddleware(h.log)(handler)
 	handler = middleware.RecoverMiddleware(h.log)(handler)
+	// язык нужен и ошибке после паники
+	handler = middleware.Language(handler)
+	// снаружи всех, чтоб id был и в аудите, и в ошибке после паники
+	handler = middleware.RequestID(handler)
 
 	return handler
 }
 
+// ключи идемпотентности создания у каждого юзера свои. Без user_id в теле ручка
+// ответит 400, и общая область для таких запросов безвредна
+func createScope(_ *http.Request, body []byte) string {
+	var req struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	if json.Unmarshal(body, &req) != nil || req.UserID == uuid.Nil {
+		return "create"
+	}
+	return "create:" + req.UserID.String()
+}
+
+// тело создания. Простые правила полей в тегах validate, даты и связи между полями
+// проверяет validateSubscription
 type CreateSubscriptionRequest struct {
-	UserID      uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	ServiceName string    `json:"service_name" example:"Spotify Premium"`
-	Price       int       `json:"price" example:"500"`
-	StartDate   string    `json:"start_date" example:"01-2026"`
-	EndDate     *string   `json:"end_date,omitempty" example:"12-2026"`
+	UserID      uuid.UUID    `json:"user_id" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ServiceName string       `json:"service_name" validate:"required,max=100" example:"Spotify Premium"`
+	Price       domain.Money `json:"price" validate:"min=0" example:"500"`
+	StartDate   string       `json:"start_date" validate:"required" example:"01-2026"`
+	EndDate     *string      `json:"end_date,omitempty" example:"12-2026"`
+	// код ISO 4217, без него - COST_CURRENCY
+	Currency string `json:"currency,omitempty" example:"RUB"`
+	// месяцы льготы после end_date
+	GracePeriodMonths int `json:"grace_period_months,omitempty" validate:"min=0,max=24" example:"1"`
+	// id из /categories, без него подписка без категории
+	CategoryID *int64 `json:"category_id,omitempty" validate:"gt=0" example:"1"`
+	// свободные метки, приводятся к нижнему регистру
+	Tags []string `json:"tags,omitempty" example:"work"`
+	// заметка, до 2000 символов
+	Notes string `json:"notes,omitempty" validate:"max=2000" example:"family plan"`
+	// за какой период указана price: weekly, monthly (по умолчанию), quarterly, yearly
+	BillingPeriod string `json:"billing_period,omitempty" example:"yearly"`
+	// price без НДС (net) или с ним (gross), без него - TAX_PRICE_BASIS
+	PriceBasis string `json:"price_basis,omitempty" example:"net"`
+	// страна для ставки НДС, без нее - TAX_DEFAULT_COUNTRY
+	TaxCountry string `json:"tax_country,omitempty" example:"KZ"`
+	// последний месяц пробного периода, до него включительно цена trial_price
+	TrialEndDate *string `json:"trial_end_date,omitempty" example:"02-2026"`
+	// цена за billing_period во время триала, 0 - бесплатный
+	TrialPrice domain.Money `json:"trial_price,omitempty" validate:"min=0" example:"0"`
+	// день месяца start_date и end_date, для proration=daily
+	StartDay *int `json:"start_day,omitempty" example:"15"`
+	EndDay   *int `json:"end_day,omitempty" example:"14"`
+}
+
+// тело замены: поля как при создании плюс версия из последнего чтения
+type ReplaceSubscriptionRequest struct {
+	CreateSubscriptionRequest
+	// вместо нее можно If-Match
+	Version int64 `json:"version" example:"3"`
+}
+
+func (req CreateSubscriptionRequest) subscription() domain.Subscription {
+	return domain.Subscription{
+		UserID:            req.UserID,
+		ServiceName:       req.ServiceName,
+		Price:             req.Price,
+		StartDate:         req.StartDate,
+		EndDate:           req.EndDate,
+		Currency:          req.Currency,
+		GracePeriodMonths: req.GracePeriodMonths,
+		CategoryID:        req.CategoryID,
+		Tags:              req.Tags,
+		Notes:             req.Notes,
+		BillingPeriod:     req.BillingPeriod,
+		PriceBasis:        req.PriceBasis,
+		TaxCountry:        req.TaxCountry,
+		TrialEndDate:      req.TrialEndDate,
+		TrialPrice:        req.TrialPrice,
+		StartDay:          req.StartDay,
+		EndDay:            req.EndDay,
+	}
 }
 
 // @Summary Create subscription
@@ -62,140 +339,244 @@ type CreateSubscriptionRequest struct {
 // @Accept json
 // @Produce json
 // @Param input body CreateSubscriptionRequest true "Subscription info"
-// @Success 201 {object} map[string]int64
-// @Failure 400 {string} string
-// @Failure 409 {string} string
+// @Param Idempotency-Key header string false "Repeated requests with the same key replay the stored response"
+// @Success 201 {object} respond.Envelope{data=map[string]any}
+// @Failure 400 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 422 {object} problem.Details
+// @Failure 503 {object} problem.Details
 // @Router /subscriptions [post]
 func (h *HandlerSubscription) createSubscription(w http.ResponseWriter, r *http.Request) {
-	var input domain.Subscription
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		h.log.Error("body decode fail", slog.String("err", err.Error()))
-		http.Error(w, "invalid request body", 400)
+	var req CreateSubscriptionRequest
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
 
-	// валидация входных данных
-	if input.UserID == uuid.Nil {
-		http.Error(w, "user_id is required", 400)
+	// валидация входных данных, клиент получает все нарушения сразу
+	input := req.subscription()
+	if errs := h.validateSubscription(req, &input); len(errs) > 0 {
+		problem.WriteInvalid(w, errs)
 		return
 	}
 
-	if strings.TrimSpace(input.ServiceName) == "" || len(input.ServiceName) > 100 {
-		http.Error(w, "service_name too long or empty", 400)
+	id, err := h.services.Create(r.Context(), input)
+	if err != nil {
+		h.serviceError(w, err, "create failed")
 		return
 	}
 
-	if input.Price < 0 {
-		http.Error(w, "price cant be negative", 400)
-		return
+	resp := map[string]any{"id": h.ids.Encode(id)}
+	if warning := h.services.PriceWarning(input); warning != "" {
+		resp["warning"] = warning
 	}
 
-	if isInvalidDate(input.StartDate) {
-		http.Error(w, "bad start_date (MM-YYYY)", 400)
+	respond.JSON(w, 201, resp)
+}
+
+// @Summary Replace subscription
+// @Description Full replace of all mutable fields, validation is the same as on create. The expected version (from the last read) goes into the version field or If-Match, a stale one gets 409
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param input body ReplaceSubscriptionRequest true "Subscription info"
+// @Param If-Match header string false "ETag of the subscription, takes precedence over version in the body"
+// @Success 200 {object} respond.Envelope{data=subscriptionView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Router /subscriptions/{id} [put]
+func (h *HandlerSubscription) replaceSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
 		return
 	}
 
-	if input.EndDate != nil {
-		if isInvalidDate(*input.EndDate) {
-			http.Error(w, "bad end_date", 400)
-			return
-		}
+	var req ReplaceSubscriptionRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
 
-		sDate, _ := time.Parse("01-2006", input.StartDate)
-		eDate, _ := time.Parse("01-2006", *input.EndDate)
+	input := req.subscription()
+	input.Version = req.Version
+	if errs := h.validateSubscription(req, &input); len(errs) > 0 {
+		problem.WriteInvalid(w, errs)
+		return
+	}
 
-		if eDate.Before(sDate) {
-			http.Error(w, "end date before start date", 400)
+	if input.Version, err = expectedVersion(r, input.Version); err != nil {
+		if errors.Is(err, errVersionRequired) {
+			problem.Error(w, err, 428)
 			return
 		}
+		problem.Error(w, err, 400)
+		return
 	}
 
-	id, err := h.services.Create(r.Context(), input)
+	sub, err := h.services.Update(r.Context(), id, input)
 	if err != nil {
-		if errors.Is(err, service.ErrSubscriptionExists) {
-			http.Error(w, err.Error(), 409)
-			return
-		}
-		h.log.Error("create failed", slog.String("err", err.Error()))
-		http.Error(w, "internal error", 500)
+		h.serviceError(w, err, "update failed")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(201)
-	json.NewEncoder(w).Encode(map[string]int64{"id": id})
+	setETag(w, sub)
+	respond.JSON(w, 200, h.subscriptionView(*sub, dateFormat(r)))
 }
 
 // @Summary Get subscription details
 // @Tags subscriptions
 // @Produce json
-// @Param id path int true "Subscription ID"
-// @Success 200 {object} domain.Subscription
-// @Failure 404 {string} string
+// @Param id path string true "Subscription ID"
+// @Param date_format query string false "mm-yyyy (default) or iso (YYYY-MM) for months in the response"
+// @Param If-None-Match header string false "ETag from the last read; 304 when unchanged"
+// @Success 200 {object} respond.Envelope{data=subscriptionView}
+// @Success 304 {string} string
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 503 {object} problem.Details
 // @Router /subscriptions/{id} [get]
 func (h *HandlerSubscription) getSubscription(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
-	id, err := parseID(idStr)
+	id, err := h.parseID(idStr)
 	if err != nil {
 		h.log.Error("bad id param", slog.String("id", idStr))
-		http.Error(w, "id must be positive", 400)
+		problem.Write(w, "id must be positive", 400)
 		return
 	}
 
 	sub, err := h.services.GetByID(r.Context(), id)
 	if err != nil {
-		h.log.Error("get sub fail", slog.Int64("id", id), slog.String("err", err.Error()))
-		http.Error(w, "sub not found", 404)
+		switch errorStatus(err) {
+		case http.StatusNotFound:
+			metrics.SubscriptionGet.Add("not_found", 1)
+		case http.StatusServiceUnavailable:
+			// база недоступна или пул исчерпан - это не 404
+			metrics.SubscriptionGet.Add("unavailable", 1)
+		default:
+			metrics.SubscriptionGet.Add("error", 1)
+		}
+		h.serviceError(w, err, "get sub fail")
 		return
 	}
+	metrics.SubscriptionGet.Add("ok", 1)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(sub)
+	setETag(w, sub)
+	respond.JSON(w, 200, h.subscriptionView(*sub, dateFormat(r)))
 }
 
 // @Summary Delete subscription
+// @Description Expensive subscriptions require a second call with confirm_token
 // @Tags subscriptions
-// @Param id path int true "Subscription ID"
-// @Success 200 {object} map[string]string
-// @Failure 404 {string} string
+// @Param id path string true "Subscription ID"
+// @Param confirm_token query string false "Token from the first call"
+// @Param If-Match header string false "ETag from the last read; 412 when the subscription changed"
+// @Success 200 {object} respond.Envelope{data=map[string]string}
+// @Success 202 {object} respond.Envelope{data=deleteConfirmationView}
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
 // @Router /subscriptions/{id} [delete]
 func (h *HandlerSubscription) deleteSubscription(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
-	id, err := parseID(idStr)
+	id, err := h.parseID(idStr)
 	if err != nil {
-		http.Error(w, "invalid id", 400)
+		problem.Write(w, "invalid id", 400)
 		return
 	}
 
-	if err := h.services.Delete(r.Context(), id); err != nil {
-		h.log.Error("delete fail", slog.Int64("id", id), slog.String("error", err.Error()))
-		http.Error(w, "not found", 404)
+	confirm, err := h.services.Delete(r.Context(), id, r.URL.Query().Get("confirm_token"))
+	if err != nil {
+		h.serviceError(w, err, "delete fail")
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+	// подписка дорогая, ждем подтверждения
+	if confirm != nil {
+		respond.JSON(w, 202, deleteConfirmationView{DeleteConfirmation: *confirm, ID: h.ids.Encode(confirm.ID)})
+		return
+	}
+
+	respond.JSON(w, 200, map[string]string{"status": "deleted"})
 }
 
-// @Summary List subscriptions
+// @Summary Bulk delete subscriptions
+// @Description Deletes all subscriptions of the user, optionally only for one service (exact name). When any of them is more expensive than the confirmation threshold, the first call deletes nothing and returns confirm_token for a second call with the same filter
 // @Tags subscriptions
 // @Produce json
 // @Param user_id query string true "User UUID"
-// @Param service_name query string false "Service filter"
+// @Param service_name query string false "Exact service name"
+// @Param confirm_token query string false "Token from the first call"
+// @Success 200 {object} respond.Envelope{data=map[string]int64}
+// @Success 202 {object} respond.Envelope{data=domain.BulkDeleteConfirmation}
+// @Failure 400 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Router /subscriptions [delete]
+func (h *HandlerSubscription) bulkDeleteSubscriptions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	uID, err := uuid.Parse(q.Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	n, confirm, err := h.services.DeleteByFilter(r.Context(), uID, q.Get("service_name"), q.Get("confirm_token"))
+	if err != nil {
+		h.serviceError(w, err, "bulk delete fail")
+		return
+	}
+
+	// среди подписок есть дорогие, ждем подтверждения
+	if confirm != nil {
+		respond.JSON(w, 202, confirm)
+		return
+	}
+
+	respond.JSON(w, 200, map[string]int64{"deleted": n})
+}
+
+// @Summary List subscriptions
+// @Tags subscriptions
+// @Produce json,text/csv,application/x-ndjson
+// @Param user_id query string true "User UUID"
+// @Param service_name query []string false "Service filter: one value matches a substring, several match exact names (case-insensitive)" collectionFormat(multi)
+// @Param exclude_service_name query []string false "Exact service names to leave out (case-insensitive), repeatable" collectionFormat(multi)
 // @Param limit query int false "Limit"
 // @Param offset query int false "Offset"
 // @Param min_price query int false "Min price"
 // @Param max_price query int false "Max price"
-// @Success 200 {array} domain.Subscription
-// @Failure 400 {string} string
+// @Param price query int false "Exact price, 0 - free subscriptions"
+// @Param start_after query string false "Started in or after month (MM-YYYY)"
+// @Param start_before query string false "Started in or before month (MM-YYYY)"
+// @Param ends_after query string false "Ends in or after month (MM-YYYY), open-ended included"
+// @Param ends_before query string false "Ends in or before month (MM-YYYY)"
+// @Param q query string false "Fuzzy search by service name, ranked by similarity"
+// @Param status query string false "active, paused, expired or upcoming"
+// @Param category_id query int false "Category id from /categories"
+// @Param tag query []string false "Tag, repeat to require several" collectionFormat(multi)
+// @Param sort query string false "price, start_date or created_at"
+// @Param order query string false "asc (default) or desc"
+// @Param cursor query string false "Signed keyset cursor; pass it empty for the first page, the next one comes in meta.page.next_cursor. Valid only with the same filters it was issued for"
+// @Param date_format query string false "mm-yyyy (default) or iso (YYYY-MM) for months in the response"
+// @Success 200 {object} respond.Envelope{data=[]subscriptionView}
+// @Failure 400 {object} problem.Details
 // @Router /subscriptions [get]
 func (h *HandlerSubscription) listSubscription(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
+	media := negotiate(r, tableMedia...)
+	if media == "" {
+		notAcceptable(w, r, tableMedia)
+		return
+	}
+
 	uIDStr := q.Get("user_id")
 	uID, err := uuid.Parse(uIDStr)
 	if err != nil {
 		h.log.Error("bad user_id", slog.String("val", uIDStr))
-		http.Error(w, "invalid user_id", 400)
+		problem.Write(w, "invalid user_id", 400)
 		return
 	}
 
@@ -207,137 +588,625 @@ func (h *HandlerSubscription) listSubscription(w http.ResponseWriter, r *http.Re
 	}
 
 	if limit > 200 {
-		http.Error(w, "limit too big", 400)
+		problem.Write(w, "limit too big", 400)
 		return
 	}
 
 	offset, _ := strconv.Atoi(q.Get("offset"))
-	minP, _ := strconv.Atoi(q.Get("min_price"))
-	maxP, _ := strconv.Atoi(q.Get("max_price"))
 
 	filter := domain.SubscriptionFilter{
-		UserID:      uID,
-		ServiceName: q.Get("service_name"),
-		MinPrice:    minP, MaxPrice: maxP,
-		Limit: limit, Offset: offset,
+		UserID: uID,
+		Limit:  limit, Offset: offset,
+		Query:  strings.TrimSpace(q.Get("q")),
+		Sort:   q.Get("sort"),
+		Status: q.Get("status"),
+	}
+
+	loc := i18n.FromContext(r.Context())
+	if msg := parseServiceNames(loc, q, &filter); msg != "" {
+		problem.Write(w, msg, 400)
+		return
+	}
+
+	if msg := parsePriceFilter(loc, q, &filter); msg != "" {
+		problem.Write(w, msg, 400)
+		return
+	}
+	if msg := h.parseDateRange(loc, q, &filter); msg != "" {
+		problem.Write(w, msg, 400)
+		return
+	}
+	if raw := q.Get("category_id"); raw != "" {
+		categoryID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || categoryID <= 0 {
+			problem.Write(w, "bad category_id", 400)
+			return
+		}
+		filter.CategoryID = &categoryID
+	}
+	for _, tag := range q["tag"] {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.SortDesc = true
+	default:
+		problem.Write(w, "order must be asc or desc", 400)
+		return
+	}
+
+	// с параметром cursor переключаемся на keyset пагинацию
+	_, keyset := q["cursor"]
+	if keyset {
+		// курсор держится на порядке по id
+		if filter.Sort != "" || filter.Query != "" {
+			problem.Write(w, "cursor cant be combined with sort or q", 400)
+			return
+		}
+		cur, err := h.cursors.decode(q.Get("cursor"), cursorKindSubscriptions, filterHash(filter))
+		if err != nil {
+			problem.Error(w, err, 400)
+			return
+		}
+		filter.AfterID = cur.AfterID
+		filter.Offset = 0
 	}
 
 	subs, err := h.services.List(r.Context(), uID, filter)
 	if err != nil {
-		h.log.Error("list fail", slog.String("error", err.Error()))
-		http.Error(w, "internal error", 500)
+		h.serviceError(w, err, "list fail")
 		return
 	}
 
-	json.NewEncoder(w).Encode(subs)
+	// неполная страница - дальше ничего нет
+	var next string
+	if keyset && len(subs) == limit {
+		next = h.cursors.encode(listCursor{
+			Kind:    cursorKindSubscriptions,
+			AfterID: subs[len(subs)-1].ID,
+			Filter:  filterHash(filter),
+		})
+	}
+
+	// в csv и ndjson некуда положить курсор, он уходит заголовком
+	if media != mediaJSON {
+		if next != "" {
+			w.Header().Set("X-Next-Cursor", next)
+		}
+		h.writeSubscriptionRows(w, media, subs, dateFormat(r))
+		return
+	}
+
+	respond.List(w, h.subscriptionViews(subs, dateFormat(r)), respond.Page{Limit: limit, Offset: filter.Offset, Count: len(subs), NextCursor: next})
 }
 
 type TotalCostResponse struct {
-	TotalCost int64             `json:"total_cost" example:"6000"`
-	Details   []string          `json:"details" example:"Spotify Premium: 6000"`
-	Period    map[string]string `json:"period"`
-	Warning   string            `json:"warning,omitempty"`
+	TotalCost domain.Money          `json:"total_cost" example:"6000"`
+	Currency  string                `json:"currency" example:"RUB"`
+	Totals    []domain.CurrencyCost `json:"totals"`
+	Details   []string              `json:"details" example:"Spotify Premium: 6000"`
+	Period    map[string]string     `json:"period"`
+	Months    []domain.MonthCost    `json:"months"`
+	Converted *domain.Conversion    `json:"converted,omitempty"`
+	Tax       *domain.TaxSummary    `json:"tax,omitempty"`
+	Warning   string                `json:"warning,omitempty"`
 }
 
 // @Summary Calculate total cost
 // @Tags subscriptions
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson
 // @Param user_id query string true "User UUID"
 // @Param from query string true "Start date (MM-YYYY)"
 // @Param to query string true "End date (MM-YYYY)"
 // @Param service_name query string false "Service filter(не обязатльно)"
-// @Success 200 {object} TotalCostResponse
-// @Failure 400 {string} string
+// @Param group_by query string false "service, month or both - nested aggregates instead of the flat response"
+// @Param convert_to query string false "ISO 4217 code, all amounts are converted before summing"
+// @Param proration query string false "monthly (default) or daily: first and last month by start_day and end_day"
+// @Param tax_basis query string false "net or gross: every price is brought to this basis by TAX_RATES before summing"
+// @Param date_format query string false "mm-yyyy (default) or iso (YYYY-MM) for months in the response"
+// @Success 200 {object} respond.Envelope{data=TotalCostResponse}
+// @Failure 400 {object} problem.Details
+// @Failure 422 {object} problem.Details
 // @Router /subscriptions/total [get]
 func (h *HandlerSubscription) getTotalCost(w http.ResponseWriter, r *http.Request) {
 	params := r.URL.Query()
+
+	media := negotiate(r, tableMedia...)
+	if media == "" {
+		notAcceptable(w, r, tableMedia)
+		return
+	}
 	uIDStr := params.Get("user_id")
 	fromStr := params.Get("from")
 	toStr := params.Get("to")
 
 	uID, err := uuid.Parse(uIDStr)
 	if err != nil {
-		http.Error(w, "bad user_id", 400)
+		problem.Write(w, "bad user_id", 400)
 		return
 	}
 
-	if isInvalidDate(fromStr) || isInvalidDate(toStr) {
-		http.Error(w, "invalid date format", 400)
+	if !h.normalizeDate(&fromStr) || !h.normalizeDate(&toStr) {
+		problem.Write(w, "invalid date format", 400)
+		return
+	}
+
+	// с group_by ответ другой формы, считается группировкой в базе
+	if groupBy := params.Get("group_by"); groupBy != "" {
+		if params.Get("proration") == domain.ProrationDaily {
+			problem.Write(w, "proration=daily is not supported with group_by", 400)
+			return
+		}
+		if params.Get("tax_basis") != "" {
+			problem.Write(w, "tax_basis is not supported with group_by", 400)
+			return
+		}
+		if media != mediaJSON {
+			notAcceptable(w, r, []string{mediaJSON})
+			return
+		}
+		h.getGroupedCost(w, r, uID, fromStr, toStr, groupBy)
 		return
 	}
 
-	total, details, err := h.services.GetTotalCost(r.Context(), uID, params.Get("service_name"), fromStr, toStr)
+	total, err := h.totalCost(r, uID, fromStr, toStr)
 	if err != nil {
-		h.log.Error("cost calc faild", slog.String("err", err.Error()))
-		http.Error(w, "failed to calculate cost", 400)
+		h.serviceError(w, err, "cost calc faild")
+		return
+	}
+	total, ok := h.convertTotal(w, r, total)
+	if !ok {
+		return
+	}
+
+	if media != mediaJSON {
+		h.writeCostRows(w, media, total)
 		return
 	}
 
+	// в v1 детали - плоские строки "сервис: сумма", валюта дописывается если не основная
+	details := make([]string, 0, len(total.Details))
+	for _, d := range total.Details {
+		line := fmt.Sprintf("%s: %s", d.ServiceName, d.Cost)
+		if d.Currency != total.Currency {
+			line += " " + d.Currency
+		}
+		details = append(details, line)
+	}
+
 	resp := map[string]interface{}{
-		"total_cost": total,
+		"total_cost": total.Total,
+		"currency":   total.Currency,
+		"totals":     total.Totals,
 		"details":    details,
 		"period": map[string]string{
-			"from": fromStr, "to": toStr,
+			"from": dateFormat(r).Month(fromStr), "to": dateFormat(r).Month(toStr),
 		},
+		"months":     monthCostsView(total.Months, dateFormat(r)),
+		"categories": total.Categories,
+	}
+	if total.Converted != nil {
+		resp["converted"] = total.Converted
+	}
+	if total.Tax != nil {
+		resp["tax"] = total.Tax
 	}
 
 	// чекаем если дата в будущем, кидаем ворнинг
-	if toStr != "" {
-		if tDate, e := time.Parse("01-2006", toStr); e == nil {
-			now := time.Now()
-			curr := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
-			if tDate.After(curr) {
-				resp["warning"] = "Period includes future dates - forecast based on active subs"
-			}
-		}
+	if warning := h.futureWarning(toStr); warning != "" {
+		resp["warning"] = warning
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	respond.JSON(w, 200, resp)
 }
 
+func (h *HandlerSubscription) getGroupedCost(w http.ResponseWriter, r *http.Request, uID uuid.UUID, fromStr, toStr, groupBy string) {
+	grouped, err := h.services.GroupedCost(r.Context(), uID, r.URL.Query().Get("service_name"), fromStr, toStr, groupBy)
+	if err != nil {
+		h.serviceError(w, err, "grouped cost faild")
+		return
+	}
+
+	grouped.Groups = costGroupsView(grouped.Groups, dateFormat(r))
+	respond.JSON(w, 200, grouped)
+}
+
+// end_date в MM-YYYY или YYYY-MM-DD, день попадает в end_day
 type ExtendInput struct {
-	EndDate string `json:"end_date" example:"12-2027"`
-	Price   int    `json:"price" example:"600"`
+	EndDate string       `json:"end_date" example:"12-2027"`
+	Price   domain.Money `json:"price" example:"600"`
+	// версия подписки из последнего чтения, вместо нее можно If-Match
+	Version int64 `json:"version,omitempty" example:"3"`
+}
+
+type ExtendResponse struct {
+	Status       string            `json:"status" example:"success"`
+	Subscription *subscriptionView `json:"subscription,omitempty"`
 }
 
 // @Summary Extend subscription
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param id path int true "Subscription ID"
+// @Param id path string true "Subscription ID"
 // @Param input body ExtendInput true "New data"
-// @Success 200 {object} map[string]string
-// @Failure 400 {string} string
-// @Failure 404 {string} string
+// @Param If-Match header string false "ETag of the subscription, takes precedence over version in the body"
+// @Param Idempotency-Key header string false "Repeated requests with the same key replay the stored response"
+// @Success 200 {object} respond.Envelope{data=ExtendResponse}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 422 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Failure 503 {object} problem.Details
 // @Router /subscriptions/{id}/extend [put]
 func (h *HandlerSubscription) extendSubscription(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(r.PathValue("id"))
+	id, err := h.parseID(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "bad id", 400)
+		problem.Write(w, "bad id", 400)
 		return
 	}
 
 	var req ExtendInput
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid body", 400)
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
 
-	if isInvalidDate(req.EndDate) || req.Price < 0 {
-		http.Error(w, "invalid data", 400)
+	if !h.normalizeMonthOrDay(&req.EndDate) || req.Price < 0 {
+		problem.Write(w, "invalid data", 400)
 		return
 	}
 
-	if err := h.services.Extend(r.Context(), id, req.EndDate, req.Price); err != nil {
-		h.log.Error("extend fail", slog.String("err", err.Error()))
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, "not found", 404)
+	version, err := expectedVersion(r, req.Version)
+	if err != nil {
+		if errors.Is(err, errVersionRequired) {
+			problem.Error(w, err, 428)
+			return
+		}
+		problem.Error(w, err, 400)
+		return
+	}
+
+	if err := h.services.Extend(r.Context(), id, req.EndDate, req.Price, version); err != nil {
+		h.serviceError(w, err, "extend fail")
+		return
+	}
+
+	// отдаем итоговое состояние, оно же сохраняется для повторов по Idempotency-Key
+	sub, err := h.services.GetByID(r.Context(), id)
+	if err != nil {
+		h.log.Error("get after extend fail", slog.Int64("id", id), slog.String("err", err.Error()))
+		respond.JSON(w, 200, ExtendResponse{Status: "success"})
+		return
+	}
+
+	setETag(w, sub)
+	view := h.subscriptionView(*sub, dateFormat(r))
+	respond.JSON(w, 200, ExtendResponse{Status: "success", Subscription: &view})
+}
+
+// month в MM-YYYY или YYYY-MM-DD
+type CancelInput struct {
+	Month string `json:"month,omitempty" example:"06-2026"`
+}
+
+// сезонная пауза, обе границы включительно
+type PauseInput struct {
+	PausedFrom string `json:"paused_from" example:"06-2026"`
+	PausedTo   string `json:"paused_to" example:"08-2026"`
+}
+
+type TagsPatch struct {
+	Add    []string `json:"add,omitempty" example:"work"`
+	Remove []string `json:"remove,omitempty" example:"personal"`
+}
+
+// @Summary Add or remove tags
+// @Description Tags are lowercased, duplicates are dropped, other fields stay the same
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param input body TagsPatch true "Tags to add and remove"
+// @Param If-Match header string false "ETag from the last read; 412 when the subscription changed"
+// @Success 200 {object} respond.Envelope{data=subscriptionView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Router /subscriptions/{id}/tags [patch]
+func (h *HandlerSubscription) patchTags(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	var req TagsPatch
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		problem.Write(w, "nothing to change: add or remove is required", 400)
+		return
+	}
+
+	sub, err := h.services.UpdateTags(r.Context(), id, req.Add, req.Remove)
+	if err != nil {
+		h.serviceError(w, err, "tags update fail")
+		return
+	}
+
+	setETag(w, sub)
+	respond.JSON(w, 200, h.subscriptionView(*sub, dateFormat(r)))
+}
+
+// @Summary Cancel subscription
+// @Description Sets end_date to the given month (current month by default) and records the cancellation. A YYYY-MM-DD date also sets end_day
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param input body CancelInput false "Cancel month"
+// @Param If-Match header string false "ETag from the last read; 412 when the subscription changed"
+// @Success 200 {object} respond.Envelope{data=subscriptionView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Router /subscriptions/{id}/cancel [post]
+func (h *HandlerSubscription) cancelSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	// тело необязательное
+	var req CancelInput
+	if !h.decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	if !h.normalizeMonthOrDay(&req.Month) {
+		problem.Write(w, "bad month (MM-YYYY or YYYY-MM-DD)", 400)
+		return
+	}
+
+	sub, err := h.services.Cancel(r.Context(), id, req.Month)
+	if err != nil {
+		h.serviceError(w, err, "cancel fail")
+		return
+	}
+
+	setETag(w, sub)
+	respond.JSON(w, 200, h.subscriptionView(*sub, dateFormat(r)))
+}
+
+// @Summary Pause subscription
+// @Description Paused months are excluded from total cost
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag from the last read; 412 when the subscription changed"
+// @Success 200 {object} respond.Envelope{data=subscriptionView}
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Router /subscriptions/{id}/pause [post]
+func (h *HandlerSubscription) pauseSubscription(w http.ResponseWriter, r *http.Request) {
+	h.changePause(w, r, h.services.Pause)
+}
+
+// @Summary Resume subscription
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag from the last read; 412 when the subscription changed"
+// @Success 200 {object} respond.Envelope{data=subscriptionView}
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Router /subscriptions/{id}/resume [post]
+func (h *HandlerSubscription) resumeSubscription(w http.ResponseWriter, r *http.Request) {
+	h.changePause(w, r, h.services.Resume)
+}
+
+func (h *HandlerSubscription) changePause(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, id int64) (*domain.Subscription, error)) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	sub, err := change(r.Context(), id)
+	if err != nil {
+		h.serviceError(w, err, "pause change fail")
+		return
+	}
+
+	setETag(w, sub)
+	respond.JSON(w, 200, h.subscriptionView(*sub, dateFormat(r)))
+}
+
+// @Summary Schedule seasonal pause
+// @Description Months from paused_from to paused_to inclusive are excluded from total cost. The schedule is returned in pauses
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param input body PauseInput true "Pause range"
+// @Param If-Match header string false "ETag from the last read; 412 when the subscription changed"
+// @Success 200 {object} respond.Envelope{data=subscriptionView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Router /subscriptions/{id}/pauses [post]
+func (h *HandlerSubscription) schedulePause(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+
+	var req PauseInput
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.PausedFrom == "" || req.PausedTo == "" || !h.normalizeDate(&req.PausedFrom) || !h.normalizeDate(&req.PausedTo) {
+		problem.Write(w, "paused_from and paused_to are required (MM-YYYY)", 400)
+		return
+	}
+
+	sub, err := h.services.SchedulePause(r.Context(), id, req.PausedFrom, req.PausedTo)
+	h.writePauseChange(w, r, id, sub, err)
+}
+
+// @Summary Cancel scheduled pause
+// @Description Only pauses that have not started yet, a running pause is ended with resume
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param pause_id path int true "Pause ID"
+// @Param If-Match header string false "ETag from the last read; 412 when the subscription changed"
+// @Success 200 {object} respond.Envelope{data=subscriptionView}
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Router /subscriptions/{id}/pauses/{pause_id} [delete]
+func (h *HandlerSubscription) unschedulePause(w http.ResponseWriter, r *http.Request) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, "bad id", 400)
+		return
+	}
+	pauseID, err := strconv.ParseInt(r.PathValue("pause_id"), 10, 64)
+	if err != nil {
+		problem.Write(w, "bad pause id", 400)
+		return
+	}
+
+	sub, err := h.services.UnschedulePause(r.Context(), id, pauseID)
+	h.writePauseChange(w, r, id, sub, err)
+}
+
+func (h *HandlerSubscription) writePauseChange(w http.ResponseWriter, r *http.Request, id int64, sub *domain.Subscription, err error) {
+	if err != nil {
+		h.serviceError(w, err, "pause schedule fail")
+		return
+	}
+
+	setETag(w, sub)
+	respond.JSON(w, 200, h.subscriptionView(*sub, dateFormat(r)))
+}
+
+// @Summary Upcoming renewals
+// @Description Subscriptions whose end_date falls between the current month and within_months ahead, nearest first
+// @Tags subscriptions
+// @Produce json
+// @Param user_id query string true "User UUID"
+// @Param within_months query int false "Window in months (0..24, default 3)"
+// @Param date_format query string false "mm-yyyy (default) or iso (YYYY-MM) for months in the response"
+// @Success 200 {object} respond.Envelope{data=[]upcomingRenewalView}
+// @Failure 400 {object} problem.Details
+// @Router /subscriptions/upcoming [get]
+func (h *HandlerSubscription) listUpcoming(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	uID, err := uuid.Parse(q.Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	within := 3
+	if v := q.Get("within_months"); v != "" {
+		if within, err = strconv.Atoi(v); err != nil {
+			problem.Write(w, "invalid within_months", 400)
+			return
+		}
+	}
+
+	items, err := h.services.Upcoming(r.Context(), uID, within)
+	if err != nil {
+		if errors.Is(err, service.ErrBadWindow) {
+			problem.Error(w, err, 400)
+			return
+		}
+		h.log.Error("upcoming fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 200, h.upcomingRenewalViews(items, dateFormat(r)))
+}
+
+// @Summary Filter facets
+// @Description Subscription counts per price bucket, status and category in one query. Price bounds are inclusive and match min_price/max_price of the list
+// @Tags subscriptions
+// @Produce json
+// @Param user_id query string true "User UUID"
+// @Success 200 {object} respond.Envelope{data=domain.SubscriptionFacets}
+// @Failure 400 {object} problem.Details
+// @Router /subscriptions/facets [get]
+func (h *HandlerSubscription) getFacets(w http.ResponseWriter, r *http.Request) {
+	uID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	facets, err := h.services.Facets(r.Context(), uID)
+	if err != nil {
+		h.log.Error("facets fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 200, facets)
+}
+
+// @Summary Spend forecast
+// @Description Projected cost for the next months starting with the current one. Months relying on assumptions (open-ended subscriptions, open pauses) are marked
+// @Tags subscriptions
+// @Produce json
+// @Param user_id query string true "User UUID"
+// @Param months query int false "Horizon in months (1..24, default 12)"
+// @Success 200 {object} respond.Envelope{data=domain.Forecast}
+// @Failure 400 {object} problem.Details
+// @Router /subscriptions/forecast [get]
+func (h *HandlerSubscription) getForecast(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	uID, err := uuid.Parse(q.Get("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	months := 12
+	if v := q.Get("months"); v != "" {
+		if months, err = strconv.Atoi(v); err != nil {
+			problem.Write(w, "invalid months", 400)
+			return
+		}
+	}
+
+	forecast, err := h.services.Forecast(r.Context(), uID, months)
+	if err != nil {
+		if errors.Is(err, service.ErrBadHorizon) {
+			problem.Error(w, err, 400)
 			return
 		}
-		http.Error(w, err.Error(), 400)
+		h.log.Error("forecast fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+	respond.JSON(w, 200, forecast)
 }
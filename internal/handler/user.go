@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+// @Summary Distinct services of a user
+// @Description Each service once: number of subscriptions, latest price and whether one is active this month
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User UUID"
+// @Success 200 {object} respond.Envelope{data=[]service.ServiceSummary}
+// @Failure 400 {object} problem.Details
+// @Router /users/{user_id}/services [get]
+func (h *HandlerSubscription) listUserServices(w http.ResponseWriter, r *http.Request) {
+	uID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		problem.Write(w, "invalid user_id", 400)
+		return
+	}
+
+	summary, err := h.services.Services(r.Context(), uID)
+	if err != nil {
+		h.log.Error("services summary fail", slog.String("error", err.Error()))
+		problem.Write(w, "internal error", 500)
+		return
+	}
+
+	respond.JSON(w, 200, summary)
+}
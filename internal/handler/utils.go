@@ -1,37 +1,299 @@
 package handler
 
 import (
-	"fmt"
-	"regexp"
-	"strconv"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/dates"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/i18n"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/validate"
 )
 
-var monthYearRegex = regexp.MustCompile(`^(0[1-9]|1[0-2])-\d{4}$`)
+// общая проверка тела подписки для create и replace: правила из тегов dto, потом даты
+// и связи полей. Даты приводятся к MM-YYYY в input. Пустой список - все ок
+func (h *HandlerSubscription) validateSubscription(dto any, input *domain.Subscription) validate.Errors {
+	errs := validate.Struct(dto)
+
+	// полная дата раскладывается на месяц и start_day/end_day
+	if !errs.Has("start_date") && !h.normalizeDayDate(&input.StartDate, &input.StartDay) {
+		errs.Add("start_date", "date", "bad start_date (MM-YYYY or YYYY-MM-DD, day must match start_day)")
+	}
+	if input.EndDate != nil && !h.normalizeDayDate(input.EndDate, &input.EndDay) {
+		errs.Add("end_date", "date", "bad end_date (MM-YYYY or YYYY-MM-DD, day must match end_day)")
+	}
+	// дальше сравниваются даты, с кривыми это бессмысленно
+	if errs.Has("start_date") || errs.Has("end_date") {
+		return errs
+	}
+
+	sDate, _ := time.Parse(dates.Layout, input.StartDate)
+	if input.EndDate != nil {
+		if eDate, _ := time.Parse(dates.Layout, *input.EndDate); eDate.Before(sDate) {
+			errs.Add("end_date", "after_start_date", "end date before start date")
+		}
+	}
+
+	validateDays(input, &errs)
+
+	if input.TrialEndDate != nil && *input.TrialEndDate == "" {
+		input.TrialEndDate = nil
+	}
+	if input.TrialEndDate == nil {
+		if input.TrialPrice != 0 && !errs.Has("trial_price") {
+			errs.Add("trial_price", "requires_trial_end_date", "trial_price needs trial_end_date")
+		}
+		return errs
+	}
+	if !h.normalizeDate(input.TrialEndDate) {
+		errs.Add("trial_end_date", "date", "bad trial_end_date")
+		return errs
+	}
+	// триал внутри срока подписки
+	tDate, _ := time.Parse(dates.Layout, *input.TrialEndDate)
+	if tDate.Before(sDate) {
+		errs.Add("trial_end_date", "after_start_date", "trial_end_date before start date")
+	} else if input.EndDate != nil {
+		if eDate, _ := time.Parse(dates.Layout, *input.EndDate); tDate.After(eDate) {
+			errs.Add("trial_end_date", "before_end_date", "trial_end_date after end date")
+		}
+	}
+
+	return errs
+}
+
+// start_day и end_day должны быть днями своих месяцев, даты уже приведены к MM-YYYY
+func validateDays(input *domain.Subscription, errs *validate.Errors) {
+	sDate, _ := time.Parse(dates.Layout, input.StartDate)
+	if input.StartDay != nil && (*input.StartDay < 1 || *input.StartDay > daysIn(sDate)) {
+		errs.Add("start_day", "day", "bad start_day")
+	}
+	if input.EndDay == nil {
+		return
+	}
+	if input.EndDate == nil {
+		errs.Add("end_day", "requires_end_date", "end_day needs end_date")
+		return
+	}
+	eDate, _ := time.Parse(dates.Layout, *input.EndDate)
+	switch {
+	case *input.EndDay < 1 || *input.EndDay > daysIn(eDate):
+		errs.Add("end_day", "day", "bad end_day")
+	case input.StartDay != nil && !errs.Has("start_day") && eDate.Equal(sDate) && *input.EndDay < *input.StartDay:
+		errs.Add("end_day", "after_start_day", "end_day before start_day")
+	}
+}
+
+func daysIn(month time.Time) int {
+	return time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// приводит дату к MM-YYYY на месте, false если формат не распознан
+func (h *HandlerSubscription) normalizeDate(dateStr *string) bool {
+	if *dateStr == "" {
+		return true
+	}
+
+	normalized, err := h.dates.Normalize(*dateStr)
+	if err != nil {
+		return false
+	}
 
-func isInvalidDate(dateStr string) bool {
-	if dateStr == "" {
+	*dateStr = normalized
+	return true
+}
+
+// как normalizeDate, но YYYY-MM-DD тоже принимает: месяц остается в dateStr, день
+// уходит в day. false если формат не распознан или день не совпал с уже указанным
+func (h *HandlerSubscription) normalizeDayDate(dateStr *string, day **int) bool {
+	month, d, err := h.dates.NormalizeDay(*dateStr)
+	if err != nil {
 		return false
 	}
+	if d != nil {
+		if *day != nil && **day != *d {
+			return false
+		}
+		*day = d
+	}
 
-	if !monthYearRegex.MatchString(dateStr) {
+	*dateStr = month
+	return true
+}
+
+// для дат, которые сервис разбирает сам: YYYY-MM-DD оставляет как есть, остальное приводит к MM-YYYY
+func (h *HandlerSubscription) normalizeMonthOrDay(dateStr *string) bool {
+	if _, _, ok := dates.SplitDay(strings.TrimSpace(*dateStr)); ok {
+		*dateStr = strings.TrimSpace(*dateStr)
 		return true
 	}
+	return h.normalizeDate(dateStr)
+}
+
+// формат месяцев в ответе из date_format, сам параметр уже проверил checkDateFormat
+func dateFormat(r *http.Request) dates.Format {
+	f, err := dates.ParseFormat(r.URL.Query().Get("date_format"))
+	if err != nil {
+		return dates.FormatMonthYear
+	}
+	return f
+}
+
+// отсекает неизвестный date_format до хендлеров, он общий для всех маршрутов
+func checkDateFormat(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := dates.ParseFormat(r.URL.Query().Get("date_format")); err != nil {
+			problem.Error(w, dates.ErrBadFormat, 400)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// внешний id в int64, формат зависит от кодека
+func (h *HandlerSubscription) parseID(idStr string) (int64, error) {
+	return h.ids.Decode(idStr)
+}
+
+var errBadProration = domain.Invalid("proration must be monthly or daily")
+
+// расходы по proration из запроса: пусто или monthly - целыми месяцами, daily - по дням
+func (h *HandlerSubscription) totalCost(r *http.Request, uID uuid.UUID, fromStr, toStr string) (*domain.TotalCost, error) {
+	params := r.URL.Query()
+	proration := params.Get("proration")
+	if proration != "" && proration != domain.ProrationMonthly && proration != domain.ProrationDaily {
+		return nil, errBadProration
+	}
+
+	// с tax_basis цены приводятся к одной базе, расчет отдельный
+	if basis := params.Get("tax_basis"); basis != "" {
+		return h.services.TaxedTotalCost(r.Context(), uID, params.Get("service_name"), fromStr, toStr, proration == domain.ProrationDaily, basis)
+	}
+	if proration == domain.ProrationDaily {
+		return h.services.ProratedTotalCost(r.Context(), uID, params.Get("service_name"), fromStr, toStr)
+	}
+	return h.services.GetTotalCost(r.Context(), uID, params.Get("service_name"), fromStr, toStr)
+}
+
+// ошибки инфраструктуры: таймауты, пул соединений, сеть
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.As(err, &netErr)
+}
+
+func (h *HandlerSubscription) futureWarning(toStr string) string {
+	if toStr == "" {
+		return ""
+	}
+
+	tDate, err := time.Parse(dates.Layout, toStr)
+	if err != nil {
+		return ""
+	}
+
+	if tDate.After(clock.MonthStart(h.clock.Now())) {
+		return "Period includes future dates - forecast based on active subs"
+	}
+	return ""
+}
+
+// разбирает границы дат списка, пустая строка ошибки - все ок
+func (h *HandlerSubscription) parseDateRange(loc i18n.Localizer, q url.Values, filter *domain.SubscriptionFilter) string {
+	bounds := []struct {
+		param string
+		dst   **time.Time
+	}{
+		{"start_after", &filter.StartAfter},
+		{"start_before", &filter.StartBefore},
+		{"ends_after", &filter.EndsAfter},
+		{"ends_before", &filter.EndsBefore},
+	}
+
+	for _, b := range bounds {
+		raw := q.Get(b.param)
+		if raw == "" {
+			continue
+		}
+		t, err := h.dates.Parse(raw)
+		if err != nil {
+			return loc.Sprintf("bad %s (MM-YYYY)", b.param)
+		}
+		*b.dst = &t
+	}
+
+	if filter.StartAfter != nil && filter.StartBefore != nil && filter.StartAfter.After(*filter.StartBefore) {
+		return loc.T("start_after is later than start_before")
+	}
+	if filter.EndsAfter != nil && filter.EndsBefore != nil && filter.EndsAfter.After(*filter.EndsBefore) {
+		return loc.T("ends_after is later than ends_before")
+	}
+	return ""
+}
 
-	_, err := time.Parse("01-2006", dateStr)
-	return err != nil
+// сколько названий можно перечислить в service_name и exclude_service_name
+const maxServiceNames = 50
+
+// service_name можно повторять: одно значение ищется подстрокой, как раньше, несколько -
+// точными названиями. exclude_service_name убирает точные названия
+func parseServiceNames(loc i18n.Localizer, q url.Values, filter *domain.SubscriptionFilter) string {
+	names := nonEmpty(q["service_name"])
+	if len(names) == 1 {
+		filter.ServiceName = names[0]
+	} else {
+		filter.ServiceNames = names
+	}
+	filter.ExcludeServiceNames = nonEmpty(q["exclude_service_name"])
+
+	if len(names) > maxServiceNames || len(filter.ExcludeServiceNames) > maxServiceNames {
+		return loc.Sprintf("too many service names (max %d)", maxServiceNames)
+	}
+	return ""
 }
 
-func parseID(idStr string) (int64, error) {
-	if idStr == "" || strings.ContainsAny(idStr, "/.\\-+") {
-		return 0, fmt.Errorf("invalid id format")
+func nonEmpty(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
 	}
+	return out
+}
 
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil || id <= 0 {
-		return 0, fmt.Errorf("id must be positive integer")
+// разбирает фильтры цены: пустой параметр - без фильтра, 0 - бесплатные
+func parsePriceFilter(loc i18n.Localizer, q url.Values, filter *domain.SubscriptionFilter) string {
+	prices := []struct {
+		param string
+		dst   **domain.Money
+	}{
+		{"min_price", &filter.MinPrice},
+		{"max_price", &filter.MaxPrice},
+		{"price", &filter.Price},
 	}
 
-	return id, nil
+	for _, p := range prices {
+		raw := q.Get(p.param)
+		if raw == "" {
+			continue
+		}
+		v, err := domain.ParseMoney(raw)
+		if err != nil || v < 0 {
+			return loc.Sprintf("bad %s", p.param)
+		}
+		*p.dst = &v
+	}
+	return ""
 }
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/respond"
+)
+
+// v2 отдает структурированные ответы вместо плоских строк
+
+type PeriodV2 struct {
+	From string `json:"from" example:"01-2026"`
+	To   string `json:"to" example:"12-2026"`
+}
+
+type TotalCostV2Response struct {
+	// total_cost в основной валюте, суммы по всем валютам в totals
+	TotalCost domain.Money          `json:"total_cost" example:"6000"`
+	Currency  string                `json:"currency" example:"RUB"`
+	Totals    []domain.CurrencyCost `json:"totals"`
+	Details   []domain.CostDetail   `json:"details"`
+	Period    PeriodV2              `json:"period"`
+	Months    []domain.MonthCost    `json:"months"`
+	// итоги по категориям, без категории - строка с null
+	Categories []domain.CategoryCost `json:"categories"`
+	// курсы, если был convert_to
+	Converted *domain.Conversion `json:"converted,omitempty"`
+	// база цен и итоги net и gross, если был tax_basis
+	Tax     *domain.TaxSummary `json:"tax,omitempty"`
+	Warning string             `json:"warning,omitempty"`
+}
+
+// @Summary Calculate total cost (v2)
+// @Tags v2
+// @Produce json,text/csv,application/x-ndjson
+// @Param user_id query string true "User UUID"
+// @Param from query string true "Start date (MM-YYYY)"
+// @Param to query string true "End date (MM-YYYY)"
+// @Param service_name query string false "Service filter"
+// @Param convert_to query string false "ISO 4217 code, all amounts are converted before summing"
+// @Param proration query string false "monthly (default) or daily: first and last month by start_day and end_day"
+// @Param tax_basis query string false "net or gross: every price is brought to this basis by TAX_RATES before summing"
+// @Param date_format query string false "mm-yyyy (default) or iso (YYYY-MM) for months in the response"
+// @Success 200 {object} respond.Envelope{data=TotalCostV2Response}
+// @Failure 400 {object} problem.Details
+// @Failure 422 {object} problem.Details
+// @Router /v2/subscriptions/total [get]
+func (h *HandlerSubscription) getTotalCostV2(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	fromStr := params.Get("from")
+
+	media := negotiate(r, tableMedia...)
+	if media == "" {
+		notAcceptable(w, r, tableMedia)
+		return
+	}
+	toStr := params.Get("to")
+
+	uID, err := uuid.Parse(params.Get("user_id"))
+	if err != nil {
+		problem.Write(w, "bad user_id", 400)
+		return
+	}
+
+	if fromStr == "" || toStr == "" || !h.normalizeDate(&fromStr) || !h.normalizeDate(&toStr) {
+		problem.Write(w, "invalid date format", 400)
+		return
+	}
+
+	total, err := h.totalCost(r, uID, fromStr, toStr)
+	if err != nil {
+		h.serviceError(w, err, "cost calc v2 faild")
+		return
+	}
+	total, ok := h.convertTotal(w, r, total)
+	if !ok {
+		return
+	}
+
+	if media != mediaJSON {
+		h.writeCostRows(w, media, total)
+		return
+	}
+
+	respond.JSON(w, 200, TotalCostV2Response{
+		TotalCost:  total.Total,
+		Currency:   total.Currency,
+		Totals:     total.Totals,
+		Details:    total.Details,
+		Period:     PeriodV2{From: dateFormat(r).Month(fromStr), To: dateFormat(r).Month(toStr)},
+		Months:     monthCostsView(total.Months, dateFormat(r)),
+		Categories: total.Categories,
+		Converted:  total.Converted,
+		Tax:        total.Tax,
+		Warning:    h.futureWarning(toStr),
+	})
+}
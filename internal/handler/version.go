@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+var errVersionRequired = errors.New("version is required: send the version from the last read in the body or If-Match")
+
+// ожидаемая версия подписки для замены и продления: If-Match важнее поля version в теле.
+// If-Match: * - правка поверх любой версии, отдается 0
+func expectedVersion(r *http.Request, bodyVersion int64) (int64, error) {
+	match := strings.TrimSpace(r.Header.Get("If-Match"))
+	if match == "" {
+		if bodyVersion <= 0 {
+			return 0, errVersionRequired
+		}
+		return bodyVersion, nil
+	}
+	if match == "*" {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(match, "W/"), `"`), 10, 64)
+	if err != nil || v <= 0 {
+		return 0, errors.New("bad If-Match: expected the ETag of the subscription")
+	}
+	return v, nil
+}
+
+// версия подписки в ETag, ее можно вернуть в If-Match
+func setETag(w http.ResponseWriter, sub *domain.Subscription) {
+	w.Header().Set("ETag", etagOf(sub))
+}
+
+func etagOf(sub *domain.Subscription) string {
+	return `"` + strconv.FormatInt(sub.Version, 10) + `"`
+}
+
+// subscriptionETag - текущий ETag подписки из пути для middleware.Conditional.
+// Плохой id и несуществующая подписка дают пустой ETag, их разбирает хендлер
+func (h *HandlerSubscription) subscriptionETag(r *http.Request) (string, error) {
+	id, err := h.parseID(r.PathValue("id"))
+	if err != nil {
+		return "", nil
+	}
+	sub, err := h.services.GetByID(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("subscription %d: %w", id, err)
+	}
+	return etagOf(sub), nil
+}
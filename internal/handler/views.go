@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"github.com/mmoldabe-dev/EffectiveTask/internal/dates"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// внешние представления сущностей: id отдаются через кодек
+
+type subscriptionView struct {
+	domain.Subscription
+	ID any `json:"id" swaggertype:"string" example:"10"`
+	// полные даты YYYY-MM-DD, если известен день (start_day, end_day)
+	StartOn *string `json:"start_on,omitempty" example:"2026-01-15"`
+	EndOn   *string `json:"end_on,omitempty" example:"2026-12-14"`
+}
+
+type eventView struct {
+	domain.Event
+	SubscriptionID any `json:"subscription_id,omitempty" swaggertype:"string" example:"10"`
+}
+
+type reminderStateView struct {
+	domain.ReminderState
+	SubscriptionID any `json:"subscription_id" swaggertype:"string" example:"10"`
+}
+
+type scheduledEventView struct {
+	domain.ScheduledEvent
+	SubscriptionID any `json:"subscription_id" swaggertype:"string" example:"10"`
+}
+
+type budgetView struct {
+	domain.Budget
+	ID any `json:"id" swaggertype:"string" example:"1"`
+}
+
+type budgetStatusView struct {
+	domain.BudgetStatus
+	Budget budgetView `json:"budget"`
+}
+
+type upcomingRenewalView struct {
+	subscriptionView
+	DaysRemaining   int `json:"days_remaining" example:"45"`
+	MonthsRemaining int `json:"months_remaining" example:"1"`
+}
+
+type statementLineView struct {
+	domain.StatementLine
+	SubscriptionID any `json:"subscription_id" swaggertype:"string" example:"10"`
+}
+
+type statementView struct {
+	domain.Statement
+	Lines []statementLineView `json:"lines"`
+}
+
+type deleteConfirmationView struct {
+	domain.DeleteConfirmation
+	ID any `json:"id" swaggertype:"string" example:"10"`
+}
+
+// месяцы подписки отдаются в формате f, полные даты start_on/end_on всегда YYYY-MM-DD
+func (h *HandlerSubscription) subscriptionView(sub domain.Subscription, f dates.Format) subscriptionView {
+	view := subscriptionView{Subscription: sub, ID: h.ids.Encode(sub.ID), StartOn: dates.Full(sub.StartDate, sub.StartDay)}
+	if sub.EndDate != nil {
+		view.EndOn = dates.Full(*sub.EndDate, sub.EndDay)
+	}
+
+	view.StartDate = f.Month(sub.StartDate)
+	view.EndDate = f.MonthPtr(sub.EndDate)
+	view.TrialEndDate = f.MonthPtr(sub.TrialEndDate)
+	view.PausedFrom = f.MonthPtr(sub.PausedFrom)
+	view.PausedUntil = f.MonthPtr(sub.PausedUntil)
+	if len(sub.Pauses) > 0 {
+		view.Pauses = make([]domain.PauseRange, 0, len(sub.Pauses))
+		for _, p := range sub.Pauses {
+			view.Pauses = append(view.Pauses, domain.PauseRange{ID: p.ID, PausedFrom: f.Month(p.PausedFrom), PausedTo: f.MonthPtr(p.PausedTo)})
+		}
+	}
+	return view
+}
+
+func (h *HandlerSubscription) subscriptionViews(subs []domain.Subscription, f dates.Format) []subscriptionView {
+	views := make([]subscriptionView, 0, len(subs))
+	for _, sub := range subs {
+		views = append(views, h.subscriptionView(sub, f))
+	}
+	return views
+}
+
+func monthCostsView(months []domain.MonthCost, f dates.Format) []domain.MonthCost {
+	views := make([]domain.MonthCost, 0, len(months))
+	for _, m := range months {
+		m.Month = f.Month(m.Month)
+		views = append(views, m)
+	}
+	return views
+}
+
+func costGroupsView(groups []domain.CostGroup, f dates.Format) []domain.CostGroup {
+	if groups == nil {
+		return nil
+	}
+	views := make([]domain.CostGroup, 0, len(groups))
+	for _, g := range groups {
+		if g.Month != "" {
+			g.Month = f.Month(g.Month)
+		}
+		g.Services = costGroupsView(g.Services, f)
+		views = append(views, g)
+	}
+	return views
+}
+
+func (h *HandlerSubscription) eventViews(events []domain.Event) []eventView {
+	views := make([]eventView, 0, len(events))
+	for _, ev := range events {
+		v := eventView{Event: ev}
+		if ev.SubscriptionID != nil {
+			v.SubscriptionID = h.ids.Encode(*ev.SubscriptionID)
+		}
+		views = append(views, v)
+	}
+	return views
+}
+
+func (h *HandlerSubscription) reminderStateView(st domain.ReminderState) reminderStateView {
+	return reminderStateView{ReminderState: st, SubscriptionID: h.ids.Encode(st.SubscriptionID)}
+}
+
+func (h *HandlerSubscription) scheduledEventView(ev domain.ScheduledEvent) scheduledEventView {
+	return scheduledEventView{ScheduledEvent: ev, SubscriptionID: h.ids.Encode(ev.SubscriptionID)}
+}
+
+func (h *HandlerSubscription) statementView(st domain.Statement) statementView {
+	lines := make([]statementLineView, 0, len(st.Lines))
+	for _, l := range st.Lines {
+		lines = append(lines, statementLineView{StatementLine: l, SubscriptionID: h.ids.Encode(l.SubscriptionID)})
+	}
+	return statementView{Statement: st, Lines: lines}
+}
+
+func (h *HandlerSubscription) upcomingRenewalViews(items []domain.UpcomingRenewal, f dates.Format) []upcomingRenewalView {
+	views := make([]upcomingRenewalView, 0, len(items))
+	for _, item := range items {
+		views = append(views, upcomingRenewalView{
+			subscriptionView: h.subscriptionView(item.Subscription, f),
+			DaysRemaining:    item.DaysRemaining,
+			MonthsRemaining:  item.MonthsRemaining,
+		})
+	}
+	return views
+}
+
+func (h *HandlerSubscription) budgetView(b domain.Budget) budgetView {
+	return budgetView{Budget: b, ID: h.ids.Encode(b.ID)}
+}
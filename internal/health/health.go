@@ -0,0 +1,140 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Checker проверяет одну зависимость, nil - жива
+type Checker func(ctx context.Context) error
+
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+type CheckResult struct {
+	Name      string  `json:"name" example:"db"`
+	Status    string  `json:"status" example:"up"`
+	Critical  bool    `json:"critical" example:"true"`
+	LatencyMs float64 `json:"latency_ms" example:"1.7"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// итог: down - упала критичная зависимость, degraded - только некритичные
+type Report struct {
+	Status string        `json:"status" example:"up"`
+	Checks []CheckResult `json:"checks"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       Checker
+}
+
+// Registry собирает проверки подсистем, каждая регистрирует свою при старте
+type Registry struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// critical - без этой зависимости инстанс не может обслуживать запросы
+func (r *Registry) Register(name string, critical bool, fn Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Check гоняет все проверки параллельно, каждая ограничена таймаутом реестра
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := Report{Status: StatusUp, Checks: results}
+	for _, res := range results {
+		if res.Status == StatusUp {
+			continue
+		}
+		if res.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c check) (res CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	res = CheckResult{Name: c.name, Critical: c.critical, Status: StatusUp}
+	start := time.Now()
+	defer func() {
+		// упавшая проверка не должна ронять /readyz
+		if p := recover(); p != nil {
+			res.Status, res.Error = StatusDown, fmt.Sprint("panic: ", p)
+		}
+		res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	}()
+
+	if err := c.fn(ctx); err != nil {
+		res.Status, res.Error = StatusDown, err.Error()
+	}
+	return res
+}
+
+// ServeHTTP отдает отчет, 503 только если упала критичная зависимость:
+// при деградации инстанс остается в балансировке
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	report := r.Check(req.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == StatusDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// Freshness для фоновых задач: последний проход не старше maxAge.
+// Пока задача ни разу не отработала, ждем maxAge от регистрации
+func Freshness(lastRun func() time.Time, maxAge time.Duration) Checker {
+	registered := time.Now()
+	return func(ctx context.Context) error {
+		last := lastRun()
+		if last.IsZero() {
+			last = registered
+		}
+		if age := time.Since(last); age > maxAge {
+			return fmt.Errorf("last run %s ago, expected within %s", age.Round(time.Second), maxAge)
+		}
+		return nil
+	}
+}
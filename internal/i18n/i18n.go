@@ -0,0 +1,120 @@
+// Package i18n переводит тексты ошибок API. Ключ каталога - исходный английский текст
+// или формат для fmt, поэтому без перевода клиент получает английский как раньше
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Lang string
+
+const (
+	EN Lang = "en"
+	RU Lang = "ru"
+)
+
+// язык без Accept-Language или когда клиент не просит поддерживаемый
+const Default = EN
+
+// переводы с английского, у EN каталога нет
+var catalogs = map[Lang]map[string]string{
+	RU: ru,
+}
+
+// Match выбирает язык по заголовку Accept-Language: поддерживаемый с наибольшим q,
+// при равных q - первый в заголовке. ru-RU считается ru, * - язык по умолчанию
+func Match(acceptLanguage string) Lang {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= bestQ {
+			continue
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		lang := Lang(primary)
+		if primary == "*" {
+			lang = Default
+		}
+		if lang == Default || catalogs[lang] != nil {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// Localizer переводит тексты на один язык. Нулевое значение - язык по умолчанию
+type Localizer struct {
+	lang Lang
+}
+
+// For - переводчик на язык lang, неизвестный язык - язык по умолчанию
+func For(lang Lang) Localizer {
+	if catalogs[lang] == nil {
+		return Localizer{}
+	}
+	return Localizer{lang: lang}
+}
+
+func (l Localizer) Lang() Lang {
+	if l.lang == "" {
+		return Default
+	}
+	return l.lang
+}
+
+// T - перевод текста, без перевода текст как есть
+func (l Localizer) T(msg string) string {
+	if t, ok := catalogs[l.lang][msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// Sprintf переводит формат и подставляет в него args
+func (l Localizer) Sprintf(format string, args ...any) string {
+	if len(args) == 0 {
+		return l.T(format)
+	}
+	return fmt.Sprintf(l.T(format), args...)
+}
+
+// Error - текст ошибки с переведенной причиной. Сервисы оборачивают свои ошибки
+// как "op: причина: подробности", переводится самая внешняя ошибка из цепочки,
+// у которой есть перевод, остальной текст остается
+func (l Localizer) Error(err error) string {
+	msg := err.Error()
+	if catalogs[l.lang] == nil {
+		return msg
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if t, ok := catalogs[l.lang][e.Error()]; ok {
+			return strings.Replace(msg, e.Error(), t, 1)
+		}
+	}
+	return msg
+}
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, l Localizer) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext - переводчик запроса, его кладет middleware.Language. Без него - язык по умолчанию
+func FromContext(ctx context.Context) Localizer {
+	l, _ := ctx.Value(ctxKey{}).(Localizer)
+	return l
+}
@@ -0,0 +1,182 @@
+package i18n
+
+// ru - тексты ошибок API на русском. Новая ошибка без перевода уходит по-английски,
+// поэтому сюда стоит добавлять и ее
+var ru = map[string]string{
+	// общие
+	"internal error":                   "внутренняя ошибка",
+	"internal server error":            "внутренняя ошибка сервера",
+	"service unavailable":              "сервис временно недоступен",
+	"not found":                        "не найдено",
+	"conflict":                         "конфликт",
+	"invalid input":                    "некорректные данные",
+	"unauthorized":                     "нужна авторизация",
+	"api disabled":                     "api отключено",
+	"admin api disabled":               "админское api отключено",
+	"route %s is temporarily disabled": "маршрут %s временно отключен",
+	"not acceptable, supported: %s":    "формат ответа не поддерживается, доступны: %s",
+	"unsupported format":               "формат не поддерживается",
+	"limit too big":                    "слишком большой limit",
+	"order must be asc or desc":        "order должен быть asc или desc",
+	"invalid data":                     "некорректные данные",
+
+	// тело запроса
+	"invalid body":                                      "некорректное тело запроса",
+	"request body is empty":                             "тело запроса пустое",
+	"request body is truncated json":                    "тело запроса - оборванный json",
+	"request body must contain a single json object":    "тело запроса должно содержать один json объект",
+	"request body must be a json object, got %s":        "тело запроса должно быть json объектом, а не %s",
+	"request body too large (max %d bytes)":             "тело запроса слишком большое (не больше %d байт)",
+	"malformed json at offset %d":                       "ошибка в json на позиции %d",
+	"field %q must be %s, got %s":                       "поле %q должно быть %s, а не %s",
+	"unknown field %s":                                  "неизвестное поле %s",
+	"invalid request body: %s":                          "некорректное тело запроса: %s",
+	"multipart/form-data expected":                      "ожидается multipart/form-data",
+	"file too large":                                    "файл слишком большой",
+	"invalid csv file":                                  "некорректный csv файл",
+	"idempotency key too long":                          "слишком длинный ключ идемпотентности",
+	"idempotency key was used with a different request": "ключ идемпотентности уже использован с другим запросом",
+	"request with this idempotency key is in progress":  "запрос с этим ключом идемпотентности еще выполняется",
+
+	// проверка полей
+	"validation failed":                 "ошибка валидации",
+	"%s is required":                    "поле %s обязательно",
+	"%s must be at least %s":            "поле %s должно быть не меньше %s",
+	"%s must be at least %s characters": "поле %s должно быть не короче %s символов",
+	"%s must be at least %s items":      "в %s должно быть не меньше %s элементов",
+	"%s must be at most %s":             "поле %s должно быть не больше %s",
+	"%s must be at most %s characters":  "поле %s должно быть не длиннее %s символов",
+	"%s must be at most %s items":       "в %s должно быть не больше %s элементов",
+	"%s must be greater than %s":        "поле %s должно быть больше %s",
+	"bad start_date (MM-YYYY or YYYY-MM-DD, day must match start_day)": "некорректная start_date (MM-YYYY или YYYY-MM-DD, день должен совпадать со start_day)",
+	"bad end_date (MM-YYYY or YYYY-MM-DD, day must match end_day)":     "некорректная end_date (MM-YYYY или YYYY-MM-DD, день должен совпадать с end_day)",
+	"end date before start date":                                       "дата окончания раньше даты начала",
+	"trial_price needs trial_end_date":                                 "для trial_price нужна trial_end_date",
+	"bad trial_end_date":                                               "некорректная trial_end_date",
+	"trial_end_date before start date":                                 "trial_end_date раньше даты начала",
+	"trial_end_date after end date":                                    "trial_end_date позже даты окончания",
+	"bad start_day":                                                    "некорректный start_day",
+	"end_day needs end_date":                                           "для end_day нужна end_date",
+	"bad end_day":                                                      "некорректный end_day",
+	"end_day before start_day":                                         "end_day раньше start_day",
+	"version is required: send the version from the last read in the body or If-Match": "нужна версия: передайте версию из последнего чтения в теле или в If-Match",
+	"bad If-Match: expected the ETag of the subscription":                              "некорректный If-Match: ожидается ETag подписки",
+
+	// параметры запроса
+	"bad id":                                 "некорректный id",
+	"invalid id":                             "некорректный id",
+	"id must be positive":                    "id должен быть положительным",
+	"bad user_id":                            "некорректный user_id",
+	"invalid user_id":                        "некорректный user_id",
+	"user_id is required":                    "user_id обязателен",
+	"bad active":                             "некорректный active",
+	"bad category_id":                        "некорректный category_id",
+	"bad convert_to":                         "некорректный convert_to",
+	"bad dry_run":                            "некорректный dry_run",
+	"bad pause id":                           "некорректный id паузы",
+	"bad %s":                                 "некорректный %s",
+	"bad %s (MM-YYYY)":                       "некорректный %s (MM-YYYY)",
+	"bad from (MM-YYYY)":                     "некорректный from (MM-YYYY)",
+	"bad to (MM-YYYY)":                       "некорректный to (MM-YYYY)",
+	"bad period (MM-YYYY)":                   "некорректный period (MM-YYYY)",
+	"bad month (MM-YYYY or YYYY-MM-DD)":      "некорректный month (MM-YYYY или YYYY-MM-DD)",
+	"invalid date format":                    "некорректный формат даты",
+	"invalid month format":                   "некорректный формат месяца",
+	"invalid months":                         "некорректный months",
+	"invalid within_months":                  "некорректный within_months",
+	"from is later than to":                  "from позже to",
+	"start_after is later than start_before": "start_after позже start_before",
+	"ends_after is later than ends_before":   "ends_after позже ends_before",
+	"too many service names (max %d)":        "слишком много названий сервисов (не больше %d)",
+	"format must be one of: %s":              "format должен быть одним из: %s",
+	"invalid cursor":                         "некорректный cursor",
+	"cursor cant be combined with sort or q": "cursor нельзя сочетать с sort или q",
+	"cursor was issued for a different filter, start again with an empty cursor": "cursor выдан для другого фильтра, начните заново с пустым cursor",
+	"proration must be monthly or daily":                                         "proration должен быть monthly или daily",
+	"proration=daily is not supported with group_by":                             "proration=daily не поддерживается вместе с group_by",
+	"tax_basis is not supported with group_by":                                   "tax_basis не поддерживается вместе с group_by",
+	"nothing to change: add or remove is required":                               "нечего менять: нужен add или remove",
+	"paused_from and paused_to are required (MM-YYYY)":                           "нужны paused_from и paused_to (MM-YYYY)",
+	"date_format must be mm-yyyy or iso":                                         "date_format должен быть mm-yyyy или iso",
+	"bad date format, expected MM-YYYY, YYYY-MM or YYYY-MM-DD":                   "некорректная дата, ожидается MM-YYYY, YYYY-MM или YYYY-MM-DD",
+	"amount must be a number with at most 2 decimal places":                      "сумма должна быть числом не больше чем с 2 знаками после запятой",
+	"convert_to is not available: exchange rates are not configured":             "convert_to недоступен: курсы валют не настроены",
+	"precondition failed: resource was changed, reload it and retry":             "условие не выполнено: ресурс изменился, перечитайте его и повторите",
+
+	// не найдено
+	"sub not found":              "подписка не найдена",
+	"policy hook not found":      "хук политики не найден",
+	"category does not exist":    "категории не существует",
+	"link is invalid or expired": "ссылка неверна или устарела",
+
+	// подписки
+	"confirm token is invalid or expired":                         "токен подтверждения неверен или устарел",
+	"price must not be negative":                                  "цена не может быть отрицательной",
+	"subscription already ended":                                  "подписка уже закончилась",
+	"cancel month is outside of subscription period":              "месяц отмены вне периода подписки",
+	"transition is not allowed in current status":                 "переход недоступен в текущем статусе",
+	"unsupported sort field":                                      "сортировка по этому полю не поддерживается",
+	"unsupported status":                                          "статус не поддерживается",
+	"from must not be later than to":                              "from не может быть позже to",
+	"period is longer than 10 years":                              "период длиннее 10 лет",
+	"within_months must be in 0..24":                              "within_months должен быть от 0 до 24",
+	"group_by must be service, month or both":                     "group_by должен быть service, month или both",
+	"months must be in 1..24":                                     "months должен быть от 1 до 24",
+	"tags must be 1..30 chars, at most 20 per subscription":       "теги от 1 до 30 символов, не больше 20 на подписку",
+	"billing_period must be weekly, monthly, quarterly or yearly": "billing_period должен быть weekly, monthly, quarterly или yearly",
+	"currency must be an ISO 4217 code like RUB or USD":           "currency должен быть кодом ISO 4217, например RUB или USD",
+	"failed to convert cost":                                      "не удалось пересчитать стоимость",
+	"paused_from and paused_to must be MM-YYYY, from not later than to and not in the past": "paused_from и paused_to в формате MM-YYYY, from не позже to и не в прошлом",
+	"pause overlaps another pause of the subscription":                                      "пауза пересекается с другой паузой подписки",
+	"pause already started, resume the subscription instead":                                "пауза уже началась, возобновите подписку",
+
+	// напоминания
+	"subscription has no end date":                            "у подписки нет даты окончания",
+	"subscription belongs to another user":                    "подписка принадлежит другому пользователю",
+	"snooze days must be between 1 and 90":                    "отложить можно на 1..90 дней",
+	"days_before must hold up to 10 values between 0 and 365": "в days_before не больше 10 значений от 0 до 365",
+
+	// цены и налоги
+	"price_basis must be net or gross":                         "price_basis должен быть net или gross",
+	"tax_country must be an ISO 3166 code like RU or KZ":       "tax_country должен быть кодом ISO 3166, например RU или KZ",
+	"no tax rate for country":                                  "нет ставки налога для страны",
+	"tax_basis is not available: tax rates are not configured": "tax_basis недоступен: ставки налогов не настроены",
+	"price is far outside of typical range for this service":   "цена сильно выходит за обычный диапазон для этого сервиса",
+	"exchange rates are not loaded yet":                        "курсы валют еще не загружены",
+	"no exchange rate for currency":                            "нет курса для валюты",
+
+	// отложенные действия
+	"invalid scheduled event":                      "некорректное отложенное действие",
+	"scheduled event already ran or was cancelled": "отложенное действие уже выполнено или отменено",
+	"bad event_id":                                 "некорректный event_id",
+
+	// справочники, бюджеты, каталог
+	"category name must be 1..50 chars":                   "название категории от 1 до 50 символов",
+	"category already exists":                             "категория уже существует",
+	"invalid budget":                                      "некорректный бюджет",
+	"bad catalog entry":                                   "некорректная запись каталога",
+	"name or alias already used by another catalog entry": "название или синоним уже заняты другой записью каталога",
+
+	// вложения, импорт, выгрузки
+	"attachment is too large":                                    "вложение слишком большое",
+	"attachment is empty":                                        "вложение пустое",
+	"mode must be strict or lenient":                             "mode должен быть strict или lenient",
+	"period must be in MM-YYYY format":                           "period должен быть в формате MM-YYYY",
+	"period is not closed yet, only past months can be exported": "период еще не закрыт, выгрузить можно только прошедшие месяцы",
+
+	// админка и интеграции
+	"unknown notification kind":         "неизвестный вид уведомления",
+	"bad notification template":         "некорректный шаблон уведомления",
+	"policy must be cancel or transfer": "policy должен быть cancel или transfer",
+	"transfer_to is required for transfer policy and must differ from the user": "для transfer нужен transfer_to, отличный от пользователя",
+	"url must be an absolute http or https URL":                                 "url должен быть абсолютным http или https адресом",
+	"rejected by policy":                                "отклонено политикой",
+	"policy hook unavailable":                           "хук политики недоступен",
+	"id or external_id is required":                     "нужен id или external_id",
+	"external_id already belongs to another user":       "external_id уже принадлежит другому пользователю",
+	`route must look like "POST /subscriptions/import"`: `маршрут должен выглядеть как "POST /subscriptions/import"`,
+	"admin routes cant be disabled":                     "админские маршруты нельзя отключить",
+	"sheet sync is already running":                     "синхронизация таблицы уже идет",
+	"sheet sync failed":                                 "синхронизация таблицы не удалась",
+	"sheet was not synced yet":                          "таблица еще не синхронизировалась",
+}
@@ -0,0 +1,156 @@
+package idcodec
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrBadID = errors.New("invalid id")
+
+const (
+	ModePlain      = "plain"
+	ModeObfuscated = "obfuscated"
+)
+
+// Codec переводит внутренний int64 id во внешнее представление и обратно
+type Codec interface {
+	// Encode отдает значение для json: число или строку
+	Encode(id int64) any
+	Decode(s string) (int64, error)
+}
+
+func New(mode, salt string) (Codec, error) {
+	switch mode {
+	case "", ModePlain:
+		return Plain{}, nil
+	case ModeObfuscated:
+		if salt == "" {
+			return nil, fmt.Errorf("idcodec: salt is required for %s mode", mode)
+		}
+		return NewObfuscated(salt), nil
+	default:
+		return nil, fmt.Errorf("idcodec: unknown mode %q", mode)
+	}
+}
+
+// Plain - обычные числовые id как раньше
+type Plain struct{}
+
+func (Plain) Encode(id int64) any { return id }
+
+func (Plain) Decode(s string) (int64, error) {
+	if s == "" || strings.ContainsAny(s, "/.\\-+") {
+		return 0, ErrBadID
+	}
+
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrBadID
+	}
+	return id, nil
+}
+
+const (
+	baseAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	minLength    = 8
+)
+
+// Obfuscated прячет последовательные id: перемешиваем биты обратимым
+// умножением по модулю 2^64 и кодируем алфавитом, перетасованным по соли
+type Obfuscated struct {
+	alphabet []byte
+	index    map[byte]int
+	mul      uint64
+	inv      uint64
+	key      uint64
+}
+
+func NewObfuscated(salt string) *Obfuscated {
+	sum := sha256.Sum256([]byte(salt))
+
+	alphabet := []byte(baseAlphabet)
+	// детерминированная тасовка Фишера-Йетса от хеша соли
+	seed := binary.BigEndian.Uint64(sum[16:24])
+	for i := len(alphabet) - 1; i > 0; i-- {
+		seed = seed*6364136223846793005 + 1442695040888963407
+		j := int(seed>>33) % (i + 1)
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+	}
+
+	index := make(map[byte]int, len(alphabet))
+	for i, c := range alphabet {
+		index[c] = i
+	}
+
+	mul := binary.BigEndian.Uint64(sum[0:8]) | 1 // нечетное - значит обратимо
+	return &Obfuscated{
+		alphabet: alphabet,
+		index:    index,
+		mul:      mul,
+		inv:      modInverse(mul),
+		key:      binary.BigEndian.Uint64(sum[8:16]),
+	}
+}
+
+func (o *Obfuscated) Encode(id int64) any {
+	x := (uint64(id) * o.mul) ^ o.key
+
+	base := uint64(len(o.alphabet))
+	var out []byte
+	for x > 0 || len(out) < minLength {
+		out = append(out, o.alphabet[x%base])
+		x /= base
+	}
+	return string(out)
+}
+
+func (o *Obfuscated) Decode(s string) (int64, error) {
+	if len(s) < minLength || len(s) > 16 {
+		return 0, ErrBadID
+	}
+
+	base := uint64(len(o.alphabet))
+	var x uint64
+	for i := len(s) - 1; i >= 0; i-- {
+		d, ok := o.index[s[i]]
+		if !ok {
+			return 0, ErrBadID
+		}
+		next, ok := mulChecked(x, base)
+		if !ok {
+			return 0, ErrBadID
+		}
+		x = next + uint64(d)
+	}
+
+	id := int64((x ^ o.key) * o.inv)
+	if id <= 0 {
+		return 0, ErrBadID
+	}
+
+	// отсекаем неканоничные записи одного и того же числа
+	if enc, _ := o.Encode(id).(string); enc != s {
+		return 0, ErrBadID
+	}
+	return id, nil
+}
+
+// обратный элемент по модулю 2^64 методом Ньютона
+func modInverse(a uint64) uint64 {
+	x := a
+	for i := 0; i < 5; i++ {
+		x *= 2 - a*x
+	}
+	return x
+}
+
+func mulChecked(a, b uint64) (uint64, bool) {
+	if a != 0 && a*b/a != b {
+		return 0, false
+	}
+	return a * b, true
+}
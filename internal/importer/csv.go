@@ -0,0 +1,256 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/dates"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+var (
+	ErrMissingColumn = errors.New("missing required column")
+	ErrEmptyFile     = errors.New("file is empty")
+)
+
+var (
+	strictDates  = dates.Parser{}
+	lenientDates = dates.Parser{Legacy: true}
+)
+
+var requiredColumns = []string{"user_id", "service_name", "price", "start_date"}
+
+// Fields - все колонки, которые разбирает Reader
+var Fields = []string{"user_id", "service_name", "price", "currency", "billing_period", "start_date", "end_date"}
+
+// строка файла после разбора, Row - номер строки в файле (с заголовком)
+type Row struct {
+	Row      int
+	Sub      domain.Subscription
+	Warnings []domain.ImportIssue
+	Errors   []domain.ImportIssue
+}
+
+// Reader читает csv построчно, чтобы большие файлы не грузить в память целиком
+type Reader struct {
+	read    func() ([]string, error)
+	cols    map[string]int
+	lenient bool
+	line    int
+
+	// пустые строки пропускаются, а не считаются ошибочными
+	skipBlank bool
+}
+
+// NewReader читает заголовок, в lenient режиме строки чинятся где это возможно
+func NewReader(r io.Reader, mode string) (*Reader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, ErrEmptyFile
+		}
+		return nil, fmt.Errorf("bad csv header: %w", err)
+	}
+
+	return newReader(header, reader.Read, mode)
+}
+
+// NewTableReader - то же для уже загруженной таблицы, например Google Sheets.
+// Первая строка - заголовок, пустые строки между записями пропускаются
+func NewTableReader(rows [][]string, mode string) (*Reader, error) {
+	if len(rows) == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	rest := rows[1:]
+	r, err := newReader(rows[0], func() ([]string, error) {
+		if len(rest) == 0 {
+			return nil, io.EOF
+		}
+		record := rest[0]
+		rest = rest[1:]
+		return record, nil
+	}, mode)
+	if err != nil {
+		return nil, err
+	}
+	r.skipBlank = true
+	return r, nil
+}
+
+func newReader(header []string, read func() ([]string, error), mode string) (*Reader, error) {
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, name)
+		}
+	}
+
+	return &Reader{
+		read:    read,
+		cols:    cols,
+		lenient: mode == domain.ImportModeLenient,
+		line:    1,
+	}, nil
+}
+
+// Next отдает следующую строку, io.EOF когда файл закончился.
+// Ошибки разбора строки лежат в Row.Errors, а не в err
+func (r *Reader) Next() (Row, error) {
+	record, err := r.read()
+	for err == nil && r.skipBlank && blank(record) {
+		r.line++
+		record, err = r.read()
+	}
+	if err == io.EOF {
+		return Row{}, io.EOF
+	}
+	r.line++
+	if err != nil {
+		return Row{Row: r.line, Errors: []domain.ImportIssue{{Row: r.line, Message: err.Error()}}}, nil
+	}
+
+	get := func(name string) string {
+		idx, ok := r.cols[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return record[idx]
+	}
+
+	p := rowParser{row: r.line, lenient: r.lenient}
+	sub := p.parse(get)
+
+	return Row{Row: r.line, Sub: sub, Warnings: p.warnings, Errors: p.errors}, nil
+}
+
+func blank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type rowParser struct {
+	row      int
+	lenient  bool
+	warnings []domain.ImportIssue
+	errors   []domain.ImportIssue
+}
+
+func (p *rowParser) warn(field, format string, args ...any) {
+	p.warnings = append(p.warnings, domain.ImportIssue{Row: p.row, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *rowParser) fail(field, format string, args ...any) {
+	p.errors = append(p.errors, domain.ImportIssue{Row: p.row, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *rowParser) parse(get func(string) string) domain.Subscription {
+	var sub domain.Subscription
+
+	uid, err := uuid.Parse(strings.TrimSpace(get("user_id")))
+	if err != nil || uid == uuid.Nil {
+		p.fail("user_id", "invalid user_id")
+	}
+	sub.UserID = uid
+
+	name := get("service_name")
+	if trimmed := strings.TrimSpace(name); trimmed != name {
+		p.warn("service_name", "trimmed spaces")
+		name = trimmed
+	}
+	if name == "" || len(name) > 100 {
+		p.fail("service_name", "service_name too long or empty")
+	}
+	sub.ServiceName = name
+
+	sub.Price = p.price(strings.TrimSpace(get("price")))
+
+	// колонка необязательная, пустая - основная валюта
+	if raw := strings.TrimSpace(get("currency")); raw != "" {
+		code, ok := domain.NormalizeCurrency(raw)
+		if !ok {
+			p.fail("currency", "unknown currency (ISO 4217): %q", raw)
+		}
+		sub.Currency = code
+	}
+	// тоже необязательная, пустая - цена помесячная
+	if raw := strings.TrimSpace(get("billing_period")); raw != "" {
+		period, ok := domain.NormalizeBillingPeriod(raw)
+		if !ok {
+			p.fail("billing_period", "billing_period must be one of %s: %q", strings.Join(domain.BillingPeriods, ", "), raw)
+		}
+		sub.BillingPeriod = period
+	}
+	sub.StartDate = p.month("start_date", strings.TrimSpace(get("start_date")))
+
+	if end := strings.TrimSpace(get("end_date")); end != "" {
+		endDate := p.month("end_date", end)
+		sub.EndDate = &endDate
+
+		s, errS := time.Parse(dates.Layout, sub.StartDate)
+		e, errE := time.Parse(dates.Layout, endDate)
+		if errS == nil && errE == nil && e.Before(s) {
+			p.fail("end_date", "end date before start date")
+		}
+	}
+
+	return sub
+}
+
+func (p *rowParser) price(raw string) domain.Money {
+	if v, err := domain.ParseMoney(raw); err == nil {
+		if v < 0 {
+			p.fail("price", "price cant be negative")
+		}
+		return v
+	}
+
+	// цена через запятую или с долями копеек
+	f, err := strconv.ParseFloat(strings.Replace(raw, ",", ".", 1), 64)
+	if err != nil || !p.lenient {
+		p.fail("price", "price must be a number with at most 2 decimal places: %q", raw)
+		return 0
+	}
+	if f < 0 {
+		p.fail("price", "price cant be negative")
+		return 0
+	}
+
+	rounded := domain.RoundMoney(f)
+	if rounded.String() != raw {
+		p.warn("price", "price normalized: %s -> %s", raw, rounded)
+	}
+	return rounded
+}
+
+func (p *rowParser) month(field, raw string) string {
+	if normalized, err := strictDates.Normalize(raw); err == nil {
+		return normalized
+	}
+
+	normalized, err := lenientDates.Normalize(raw)
+	if !p.lenient || err != nil {
+		p.fail(field, "bad %s (MM-YYYY): %q", field, raw)
+		return raw
+	}
+
+	p.warn(field, "date normalized: %s -> %s", raw, normalized)
+	return normalized
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/buildinfo"
+)
+
+// счетчики отдаются через expvar на /debug/vars
+var (
+	// исходы чтения подписки по id: ok, not_found, error, unavailable
+	SubscriptionGet = expvar.NewMap("subscription_get_total")
+
+	// очистка ленты событий: сколько удалено всего и возраст самого старого события
+	EventsPruned    = expvar.NewInt("events_pruned_total")
+	EventsOldestAge = expvar.NewInt("events_oldest_age_seconds")
+	EventsBacklog   = expvar.NewInt("events_backlog")
+)
+
+func init() {
+	// версия сборки рядом со счетчиками
+	expvar.Publish("build_info", expvar.Func(func() any { return buildinfo.Get() }))
+}
+
+func Handler() http.Handler {
+	return expvar.Handler()
+}
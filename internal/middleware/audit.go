@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/audit"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// сколько тела ответа держим в памяти, чтобы достать id созданной записи
+const auditBodyLimit = 64 << 10
+
+// куда уходят записи аудита
+type AuditRecorder func(ctx context.Context, rec domain.AuditRecord)
+
+// Audit пишет запись на каждый изменяющий запрос (POST, PUT, PATCH, DELETE).
+// Стоит сразу над mux: после обработки в запросе уже есть шаблон маршрута и {id},
+// так новые ручки попадают в аудит без доработок
+func Audit(record AuditRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestID := r.Header.Get("X-Request-ID")
+			if requestID == "" {
+				requestID = uuid.NewString()
+			}
+			w.Header().Set("X-Request-ID", requestID)
+
+			// тело только считаем: в журнал идут изменения подписок, а не то, что прислал клиент
+			body := &countingBody{ReadCloser: r.Body}
+			r.Body = body
+			resp := &auditWriter{ResponseWriter: w}
+			scope := &audit.Scope{}
+			r = r.WithContext(audit.NewContext(r.Context(), scope))
+			at := time.Now()
+
+			next.ServeHTTP(resp, r)
+
+			status := resp.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			// запись в аудит не должна зависеть от того, что клиент отвалился
+			record(context.WithoutCancel(r.Context()), domain.AuditRecord{
+				At:        at,
+				Actor:     auditActor(r, scope),
+				Action:    auditAction(r),
+				EntityID:  auditEntity(r, resp.body.Bytes()),
+				RequestID: requestID,
+				Status:    status,
+				Payload:   auditPayload(body, scope),
+			})
+		})
+	}
+}
+
+// кто прошел AdminAuth или BearerAuth, иначе ip. Заголовкам клиента не верим
+func auditActor(r *http.Request, scope *audit.Scope) string {
+	if actor := scope.Actor(); actor != "" {
+		return actor
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// шаблон маршрута, чтоб записи группировались; у поддеревьев вроде rpc - полный путь
+func auditAction(r *http.Request) string {
+	if r.Pattern == "" || strings.HasSuffix(r.Pattern, "/") {
+		return r.Method + " " + r.URL.Path
+	}
+	return r.Pattern
+}
+
+// id из пути, а при создании - из data.id ответа. Ответы вне конверта (rpc) несут id сверху
+func auditEntity(r *http.Request, resp []byte) string {
+	if id := r.PathValue("id"); id != "" {
+		return id
+	}
+
+	var created struct {
+		ID   json.RawMessage `json:"id"`
+		Data struct {
+			ID json.RawMessage `json:"id"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(resp, &created) != nil {
+		return ""
+	}
+	id := created.Data.ID
+	if len(id) == 0 {
+		id = created.ID
+	}
+	return strings.Trim(string(id), `"`)
+}
+
+// изменения подписок до и после и размер тела запроса
+func auditPayload(body *countingBody, scope *audit.Scope) json.RawMessage {
+	payload := struct {
+		Changes   []domain.AuditChange `json:"changes,omitempty"`
+		BodyBytes int64                `json:"body_bytes"`
+	}{Changes: scope.Changes(), BodyBytes: body.total}
+
+	raw, _ := json.Marshal(payload)
+	return raw
+}
+
+// считает байты тела запроса, пока handler его читает
+type countingBody struct {
+	io.ReadCloser
+	total int64
+}
+
+func (c *countingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.total += int64(n)
+	return n, err
+}
+
+// как captureWriter, но копит не больше лимита
+type auditWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (a *auditWriter) WriteHeader(code int) {
+	a.status = code
+	a.ResponseWriter.WriteHeader(code)
+}
+
+func (a *auditWriter) Write(b []byte) (int, error) {
+	if room := auditBodyLimit - a.body.Len(); room > 0 {
+		a.body.Write(b[:min(len(b), room)])
+	}
+	return a.ResponseWriter.Write(b)
+}
+
+// http.ResponseController добирается через Unwrap до Flush и дедлайнов
+func (a *auditWriter) Unwrap() http.ResponseWriter {
+	return a.ResponseWriter
+}
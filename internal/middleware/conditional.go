@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+)
+
+// текущий ETag ресурса запроса, пустой - ресурса нет, ответ за хендлером
+type CurrentETag func(r *http.Request) (string, error)
+
+// Conditional - условные запросы к одному ресурсу: GET и HEAD с совпавшим If-None-Match
+// получают 304, правки с If-Match, который не совпал с текущим ETag, - 412. Без заголовков
+// запрос идет дальше как есть, ETag читается только если заголовок пришел
+func Conditional(current CurrentETag, log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := "If-Match"
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				header = "If-None-Match"
+			}
+			cond := r.Header.Get(header)
+			if cond == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			etag, err := current(r)
+			if err != nil {
+				// не смогли прочитать ресурс - пусть хендлер сам ответит ошибкой
+				log.Warn("conditional request: etag lookup failed", slog.String("path", r.URL.Path), slog.String("err", err.Error()))
+				next.ServeHTTP(w, r)
+				return
+			}
+			if etag == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			matched := etagMatch(cond, etag, header == "If-None-Match")
+			switch {
+			case header == "If-None-Match" && matched:
+				w.Header().Set("ETag", etag)
+				w.WriteHeader(http.StatusNotModified)
+			case header == "If-Match" && !matched:
+				w.Header().Set("ETag", etag)
+				problem.Write(w, "precondition failed: resource was changed, reload it and retry", http.StatusPreconditionFailed)
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// список ETag из заголовка содержит etag. If-None-Match сравнивает слабо (без W/),
+// If-Match - строго. * совпадает с любым существующим ресурсом
+func etagMatch(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate, etag = strings.TrimPrefix(candidate, "W/"), strings.TrimPrefix(etag, "W/")
+		} else if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/i18n"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+)
+
+const idempotencyHeader = "Idempotency-Key"
+
+// IdempotencyStore хранит ответы по ключу, обычно это service.IdempotencyService
+type IdempotencyStore interface {
+	// Begin отдает сохраненный ответ для повтора или nil если запрос надо выполнить
+	Begin(ctx context.Context, scope, key, requestHash string) (*domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, scope, key string, status int, contentType string, response []byte) error
+	Abort(ctx context.Context, scope, key string)
+}
+
+// область ключей ручки: один и тот же ключ в разных областях - разные запросы. Тело уже
+// прочитано, из него можно взять владельца, чтоб ключи разных юзеров не пересекались
+type IdempotencyScope func(r *http.Request, body []byte) string
+
+// Idempotency запоминает ответ на запрос с Idempotency-Key, повтор с тем же ключом и телом
+// получает сохраненный ответ с Idempotent-Replayed: true. Без заголовка ручка работает как обычно.
+// Тело читается целиком для хеша, поэтому больше maxBodyBytes - 413 еще до ручки
+func Idempotency(store IdempotencyStore, scope IdempotencyScope, maxBodyBytes int64, log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > 255 {
+				problem.Write(w, "idempotency key too long", 400)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					problem.Write(w, i18n.FromContext(r.Context()).Sprintf("request body too large (max %d bytes)", maxErr.Limit), 413)
+					return
+				}
+				problem.Write(w, "invalid body", 400)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+			hash := hex.EncodeToString(sum[:])
+			sc := scope(r, body)
+
+			rec, err := store.Begin(r.Context(), sc, key, hash)
+			if err != nil {
+				switch {
+				case errors.Is(err, domain.ErrIdempotencyKeyReused):
+					problem.Error(w, err, 422)
+				case errors.Is(err, domain.ErrIdempotencyInFlight):
+					problem.Error(w, err, 409)
+				default:
+					log.Error("idempotency begin fail", slog.String("scope", sc), slog.String("err", err.Error()))
+					problem.Write(w, "internal error", 500)
+				}
+				return
+			}
+
+			if rec != nil {
+				if rec.ContentType != "" {
+					w.Header().Set("Content-Type", rec.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(rec.StatusCode)
+				w.Write(rec.Response)
+				return
+			}
+
+			rw := &recordingWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			// клиент мог отвалиться, а ключ все равно надо закрыть
+			ctx := context.WithoutCancel(r.Context())
+
+			// серверные ошибки не запоминаем, клиент должен иметь возможность повторить
+			if rw.status >= 500 || rw.status == 0 {
+				store.Abort(ctx, sc, key)
+				return
+			}
+
+			if err := store.Complete(ctx, sc, key, rw.status, rw.Header().Get("Content-Type"), rw.body.Bytes()); err != nil {
+				log.Error("idempotency save fail", slog.String("scope", sc), slog.String("err", err.Error()))
+			}
+		})
+	}
+}
+
+// пишет ответ и одновременно запоминает его для сохранения
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
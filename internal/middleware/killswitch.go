@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/i18n"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+)
+
+// выключенный маршрут: причина и флаг
+type RouteDisabled func(route string) (string, bool)
+
+// KillSwitch отвечает 503 на маршруты, выключенные из админки. Маршрут берется
+// из mux до вызова хендлера, поэтому мидлвара должна знать сам mux
+func KillSwitch(mux *http.ServeMux, disabled RouteDisabled) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				if reason, off := disabled(pattern); off {
+					msg := i18n.FromContext(r.Context()).Sprintf("route %s is temporarily disabled", pattern)
+					if reason != "" {
+						msg += ": " + reason
+					}
+					w.Header().Set("Retry-After", "60")
+					problem.Write(w, msg, http.StatusServiceUnavailable)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
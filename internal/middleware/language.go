@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/i18n"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+)
+
+// Language выбирает язык ответа по Accept-Language: переводчик уходит в контекст запроса
+// для хендлеров, язык - в заголовок Content-Language, по нему problem переводит ошибки
+func Language(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.Match(r.Header.Get("Accept-Language"))
+		w.Header().Set(problem.LanguageHeader, string(lang))
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(i18n.NewContext(r.Context(), i18n.For(lang))))
+	})
+}
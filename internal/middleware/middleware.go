@@ -1,9 +1,14 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/audit"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
 )
 
 func LogginMiddleware(log *slog.Logger) func(http.Handler) http.Handler {
@@ -32,7 +37,7 @@ func RecoverMiddleware(log *slog.Logger) func(http.Handler) http.Handler {
 						slog.Any("err", err),
 						slog.String("url", r.URL.Path))
 
-					http.Error(w, "internal server error", 500)
+					problem.Write(w, "internal server error", 500)
 				}
 			}()
 
@@ -48,3 +53,45 @@ func JSONMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// закрывает админские ручки токеном, без токена в конфиге они выключены
+func AdminAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				problem.Write(w, "admin api disabled", 403)
+				return
+			}
+
+			got := r.Header.Get("X-Admin-Token")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				problem.Write(w, "unauthorized", 401)
+				return
+			}
+			audit.SetActor(r.Context(), "admin")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// закрывает ручки для внешних систем токеном Authorization: Bearer, без токена в конфиге они выключены
+func BearerAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				problem.Write(w, "api disabled", 403)
+				return
+			}
+
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				problem.Write(w, "unauthorized", 401)
+				return
+			}
+			audit.SetActor(r.Context(), "bearer")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+)
+
+// RequestID выдает запросу id: берет X-Request-ID клиента или генерирует новый.
+// id уходит в заголовок ответа и в запрос, его видят аудит и тела ошибок
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(problem.RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+			r.Header.Set(problem.RequestIDHeader, id)
+		}
+		w.Header().Set(problem.RequestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
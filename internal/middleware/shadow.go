@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// ShadowRoute - куда зеркалить запрос и какие поля ответа сравнивать
+type ShadowRoute struct {
+	Target  http.Handler
+	Compare []string
+}
+
+// Shadow дублирует долю GET запросов в новые ручки. Ответ теневой ручки
+// клиенту не уходит, расхождения только пишутся в лог
+func Shadow(log *slog.Logger, rate float64, routes map[string]ShadowRoute) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if rate <= 0 || len(routes) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := routes[r.Method+" "+r.URL.Path]
+			if !ok || r.Method != http.MethodGet || rand.Float64() >= rate {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			primary := &captureWriter{ResponseWriter: w}
+			next.ServeHTTP(primary, r)
+
+			// теневой запрос не должен зависеть от отмены основного
+			shadowReq := r.Clone(context.WithoutCancel(r.Context()))
+			go runShadow(log, route, shadowReq, primary.status, primary.body.Bytes())
+		})
+	}
+}
+
+func runShadow(log *slog.Logger, route ShadowRoute, r *http.Request, status int, body []byte) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Error("shadow handler panic", slog.Any("err", err), slog.String("path", r.URL.Path))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	shadow := &bufferWriter{header: make(http.Header)}
+	started := time.Now()
+	route.Target.ServeHTTP(shadow, r.WithContext(ctx))
+
+	if shadow.status == 0 {
+		shadow.status = http.StatusOK
+	}
+
+	diffs := compareJSON(body, shadow.body.Bytes(), route.Compare)
+	if status != shadow.status {
+		diffs = append(diffs, "status")
+	}
+
+	if len(diffs) > 0 {
+		log.Warn("shadow mismatch",
+			slog.String("path", r.URL.Path),
+			slog.String("query", r.URL.RawQuery),
+			slog.Int("primary_status", status),
+			slog.Int("shadow_status", shadow.status),
+			slog.Any("fields", diffs),
+		)
+		return
+	}
+
+	log.Debug("shadow match", slog.String("path", r.URL.Path), slog.Duration("shadow_duration", time.Since(started)))
+}
+
+func compareJSON(a, b []byte, fields []string) []string {
+	var left, right map[string]any
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		if !bytes.Equal(a, b) {
+			return []string{"body"}
+		}
+		return nil
+	}
+
+	// ответы в конверте respond сравниваются по data
+	left, right = envelopeData(left), envelopeData(right)
+
+	var diffs []string
+	for _, f := range fields {
+		if !reflect.DeepEqual(left[f], right[f]) {
+			diffs = append(diffs, f)
+		}
+	}
+	return diffs
+}
+
+func envelopeData(body map[string]any) map[string]any {
+	if data, ok := body["data"].(map[string]any); ok {
+		return data
+	}
+	return body
+}
+
+// пишет клиенту и копит тело для сравнения
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *captureWriter) WriteHeader(code int) {
+	c.status = code
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *captureWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// никуда не пишет, только копит ответ теневой ручки
+type bufferWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferWriter) Header() http.Header { return b.header }
+
+func (b *bufferWriter) WriteHeader(code int) { b.status = code }
+
+func (b *bufferWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
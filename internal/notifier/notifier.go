@@ -0,0 +1,39 @@
+package notifier
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+type Notification struct {
+	UserID         uuid.UUID
+	SubscriptionID int64
+	Subject        string
+	Body           string
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// пока реальной доставки нет, просто пишем в лог
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+var _ Notifier = (*LogNotifier)(nil)
+
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{log: log.With(slog.String("component", "notifier"))}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, msg Notification) error {
+	n.log.Info("notification sent",
+		slog.String("user_id", msg.UserID.String()),
+		slog.Int64("subscription_id", msg.SubscriptionID),
+		slog.String("subject", msg.Subject),
+	)
+	return nil
+}
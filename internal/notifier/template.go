@@ -0,0 +1,123 @@
+package notifier
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"text/template"
+
+	"github.com/google/uuid"
+)
+
+// виды уведомлений, у каждого свой шаблон
+const KindReminder = "reminder"
+
+const (
+	maxSubjectBytes = 200
+	maxBodyBytes    = 10000
+)
+
+var errTooLong = errors.New("rendered text is too long")
+
+// Template - тема и текст уведомления в синтаксисе text/template
+type Template struct {
+	Subject string
+	Body    string
+}
+
+// данные шаблона напоминания об окончании подписки
+type ReminderData struct {
+	SubscriptionID int64
+	UserID         uuid.UUID
+	ServiceName    string
+	EndDate        string
+}
+
+type kindSpec struct {
+	def    Template
+	sample any
+}
+
+var kinds = map[string]kindSpec{
+	KindReminder: {
+		def: Template{
+			Subject: "{{.ServiceName}} subscription ends soon",
+			Body:    "Your {{.ServiceName}} subscription ends in {{.EndDate}}",
+		},
+		sample: ReminderData{
+			SubscriptionID: 42,
+			UserID:         uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+			ServiceName:    "Spotify Premium",
+			EndDate:        "12-2026",
+		},
+	},
+}
+
+// все виды уведомлений по алфавиту
+func Kinds() []string {
+	out := make([]string, 0, len(kinds))
+	for k := range kinds {
+		out = append(out, k)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// встроенный шаблон вида, false если вида нет
+func Default(kind string) (Template, bool) {
+	spec, ok := kinds[kind]
+	return spec.def, ok
+}
+
+// пример данных вида для предпросмотра и проверки
+func Sample(kind string) any {
+	return kinds[kind].sample
+}
+
+// Validate прогоняет шаблон на примере данных вида,
+// так опечатки в полях ловятся при сохранении, а не при рассылке
+func (t Template) Validate(kind string) error {
+	spec, ok := kinds[kind]
+	if !ok {
+		return fmt.Errorf("unknown notification kind %q", kind)
+	}
+	_, _, err := t.Render(spec.sample)
+	return err
+}
+
+func (t Template) Render(data any) (subject, body string, err error) {
+	if subject, err = render("subject", t.Subject, maxSubjectBytes, data); err != nil {
+		return "", "", err
+	}
+	if body, err = render("body", t.Body, maxBodyBytes, data); err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
+
+func render(name, text string, limit int, data any) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	out := &limitedBuffer{limit: limit}
+	if err := tmpl.Execute(out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// буфер с потолком, чтоб шаблон с range не раздул уведомление
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errTooLong
+	}
+	return b.Buffer.Write(p)
+}
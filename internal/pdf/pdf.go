@@ -0,0 +1,183 @@
+// Package pdf пишет минимальные текстовые PDF: встроенный Courier, без внешних шрифтов
+// и картинок. Страницы уходят в writer сразу, в памяти держится только таблица смещений
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Layout - размер страницы и положение текста в пунктах
+type Layout struct {
+	Width, Height float64
+	FontSize      float64
+	// расстояние между строками
+	Leading float64
+	// начало первой строки
+	X, Y float64
+}
+
+var (
+	A4          = Layout{Width: 595, Height: 842, FontSize: 10, Leading: 12, X: 50, Y: 800}
+	A4Landscape = Layout{Width: 842, Height: 595, FontSize: 7, Leading: 9, X: 30, Y: 560}
+)
+
+// Lines - сколько строк влезает на страницу
+func (l Layout) Lines() int {
+	return int((l.Y - l.X) / l.Leading)
+}
+
+// Columns - сколько символов моноширинного шрифта влезает в строку
+func (l Layout) Columns() int {
+	// ширина символа Courier 600/1000 кегля
+	return int((l.Width - 2*l.X) / (l.FontSize * 0.6))
+}
+
+// объекты 1 - каталог, 2 - дерево страниц, 3 - шрифт, дальше пары страница + поток
+const firstPageObject = 4
+
+// Writer пишет документ постранично, каталог и дерево страниц дописываются в Close.
+// Ошибка записи запоминается, следующие вызовы ее же и возвращают
+type Writer struct {
+	w       *countingWriter
+	buf     *bufio.Writer
+	layout  Layout
+	offsets []int64
+	pages   []int
+	err     error
+}
+
+func NewWriter(w io.Writer, layout Layout) *Writer {
+	buf := bufio.NewWriter(w)
+	d := &Writer{
+		w:       &countingWriter{w: buf},
+		buf:     buf,
+		layout:  layout,
+		offsets: make([]int64, firstPageObject-1),
+	}
+	io.WriteString(d.w, "%PDF-1.4\n")
+	d.object(3, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	return d
+}
+
+// Page пишет страницу из строк текста. Строки должны быть в WinAnsi, кириллицу - через Latin
+func (d *Writer) Page(lines []string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "BT /F1 %g Tf %g TL %g %g Td\n", d.layout.FontSize, d.layout.Leading, d.layout.X, d.layout.Y)
+	for _, line := range lines {
+		fmt.Fprintf(&b, "(%s) Tj T*\n", escape(line))
+	}
+	b.WriteString("ET")
+	content := b.String()
+
+	pageID := len(d.offsets) + 1
+	d.offsets = append(d.offsets, 0, 0)
+	d.object(pageID, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] "+
+		"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", d.layout.Width, d.layout.Height, pageID+1))
+	d.object(pageID+1, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	d.pages = append(d.pages, pageID)
+	return d.err
+}
+
+// Close дописывает каталог, дерево страниц и таблицу xref. Документ без страниц
+// получает одну пустую, иначе читалки его не открывают
+func (d *Writer) Close() error {
+	if len(d.pages) == 0 {
+		d.Page(nil)
+	}
+
+	kids := make([]string, 0, len(d.pages))
+	for _, id := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", id))
+	}
+	d.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	d.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	xref := d.w.n
+	fmt.Fprintf(d.w, "xref\n0 %d\n0000000000 65535 f \n", len(d.offsets)+1)
+	for _, off := range d.offsets {
+		fmt.Fprintf(d.w, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(d.w, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.offsets)+1, xref)
+
+	if d.err != nil {
+		return d.err
+	}
+	if d.err = d.w.err; d.err != nil {
+		return d.err
+	}
+	return d.buf.Flush()
+}
+
+// объекты в файле могут идти в любом порядке, важно только смещение в xref
+func (d *Writer) object(id int, body string) {
+	if d.err != nil {
+		return
+	}
+	d.offsets[id-1] = d.w.n
+	fmt.Fprintf(d.w, "%d 0 obj\n%s\nendobj\n", id, body)
+	d.err = d.w.err
+}
+
+// считает записанные байты для таблицы xref
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// скобки и обратный слэш в строках PDF экранируются
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(s)
+}
+
+// Truncate обрезает строку до n байт, обрезанная кончается на ~
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "~"
+}
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// Latin оставляет только ascii: кириллицу транслитом, остальное знаком вопроса.
+// WinAnsi кириллицу не покрывает
+func Latin(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		lower := unicode.ToLower(r)
+		t, ok := translit[lower]
+		switch {
+		case r < unicode.MaxASCII:
+			b.WriteRune(r)
+		case ok:
+			if r != lower && t != "" {
+				t = strings.ToUpper(t[:1]) + t[1:]
+			}
+			b.WriteString(t)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
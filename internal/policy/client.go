@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// заголовок с подписью тела, если у хука есть секрет: sha256=<hex hmac>
+const SignatureHeader = "X-Signature-256"
+
+// Client вызывает хуки политик. Хук отвечает 200 и PolicyDecision, все остальное -
+// ошибка, что с ней делать решает вызывающий по fail_open
+type Client struct {
+	client *http.Client
+}
+
+func NewClient(timeout time.Duration) *Client {
+	return &Client{client: &http.Client{Timeout: timeout}}
+}
+
+func (c *Client) Check(ctx context.Context, hook domain.PolicyHook, req domain.PolicyRequest) (*domain.PolicyDecision, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("policy hook %d: marshal: %w", hook.ID, err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("policy hook %d: %w", hook.ID, err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if hook.Secret != "" {
+		httpReq.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("policy hook %d: %w", hook.ID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("policy hook %d: %s: %s", hook.ID, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var decision domain.PolicyDecision
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&decision); err != nil {
+		return nil, fmt.Errorf("policy hook %d: decode: %w", hook.ID, err)
+	}
+	return &decision, nil
+}
+
+// Sign - подпись тела для SignatureHeader, хук может сверить ее тем же секретом
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
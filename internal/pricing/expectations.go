@@ -0,0 +1,104 @@
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+var ErrPriceOutOfRange = errors.New("price is far outside of typical range for this service")
+
+const (
+	PolicyOff    = "off"
+	PolicyWarn   = "warn"
+	PolicyReject = "reject"
+)
+
+// типичная цена сервиса из каталога
+type Expectation struct {
+	ServiceName string       `json:"service_name"`
+	Aliases     []string     `json:"aliases,omitempty"`
+	MinPrice    domain.Money `json:"min_price"`
+	MaxPrice    domain.Money `json:"max_price"`
+	Currency    string       `json:"currency"`
+}
+
+// Checker сверяет цену с каталогом. Цена в Tolerance раз выше/ниже диапазона
+// считается ошибкой ввода (500000 вместо 500)
+type Checker struct {
+	policy    string
+	tolerance int
+	entries   map[string]Expectation
+}
+
+func NewChecker(policy string, tolerance int, entries []Expectation) (*Checker, error) {
+	switch policy {
+	case "", PolicyOff, PolicyWarn, PolicyReject:
+	default:
+		return nil, fmt.Errorf("pricing: unknown policy %q", policy)
+	}
+	if tolerance < 1 {
+		tolerance = 10
+	}
+
+	c := &Checker{policy: policy, tolerance: tolerance, entries: make(map[string]Expectation)}
+	for _, e := range entries {
+		c.entries[key(e.ServiceName)] = e
+		for _, alias := range e.Aliases {
+			c.entries[key(alias)] = e
+		}
+	}
+	return c, nil
+}
+
+// LoadExpectations читает каталог из json файла, пустой путь - пустой каталог
+func LoadExpectations(path string) ([]Expectation, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("pricing: read catalog: %w", err)
+	}
+
+	var entries []Expectation
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("pricing: parse catalog: %w", err)
+	}
+	return entries, nil
+}
+
+// Check отдает текст предупреждения если цена подозрительная.
+// При политике reject вместо предупреждения возвращается ErrPriceOutOfRange
+func (c *Checker) Check(serviceName string, price domain.Money) (string, error) {
+	if c == nil || c.policy == "" || c.policy == PolicyOff {
+		return "", nil
+	}
+
+	e, ok := c.entries[key(serviceName)]
+	if !ok {
+		return "", nil
+	}
+
+	tolerance := domain.Money(c.tolerance)
+	tooLow := e.MinPrice > 0 && price > 0 && price*tolerance < e.MinPrice
+	tooHigh := e.MaxPrice > 0 && price > e.MaxPrice*tolerance
+	if !tooLow && !tooHigh {
+		return "", nil
+	}
+
+	msg := fmt.Sprintf("price %s is unusual for %s (typical %s-%s %s)", price, e.ServiceName, e.MinPrice, e.MaxPrice, e.Currency)
+	if c.policy == PolicyReject {
+		return "", fmt.Errorf("%w: %s", ErrPriceOutOfRange, msg)
+	}
+	return msg, nil
+}
+
+func key(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,132 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// на какой базе указана цена подписки: без налога или с ним
+const (
+	BasisNet   = "net"
+	BasisGross = "gross"
+)
+
+var (
+	ErrBadBasis   = errors.New("price_basis must be net or gross")
+	ErrBadCountry = errors.New("tax_country must be an ISO 3166 code like RU or KZ")
+	ErrNoTaxRate  = errors.New("no tax rate for country")
+)
+
+// Normalizer приводит цены к одной базе, чтоб net и gross подписки можно было складывать.
+// Пустые basis и country у подписки - умолчания деплоя
+type Normalizer interface {
+	// Resolve проверяет basis и country подписки и подставляет умолчания в пустые
+	Resolve(basis, country string) (string, string, error)
+	// Normalize переводит цену из базы from в базу to, отдает и примененную ставку в процентах
+	Normalize(price domain.Money, from, to, country string) (domain.Money, float64, error)
+}
+
+// TaxTable - ставки НДС по странам из конфига
+type TaxTable struct {
+	basis   string
+	country string
+	rates   map[string]float64
+}
+
+var _ Normalizer = (*TaxTable)(nil)
+
+func NewTaxTable(basis, country string, rates map[string]float64) (*TaxTable, error) {
+	t := &TaxTable{rates: rates}
+
+	var err error
+	if t.basis, t.country, err = ValidateTax(basis, country); err != nil {
+		return nil, fmt.Errorf("pricing: tax defaults: %w", err)
+	}
+	if t.basis == "" || t.country == "" {
+		return nil, fmt.Errorf("pricing: default tax basis and country are required")
+	}
+	if _, ok := rates[t.country]; !ok {
+		return nil, fmt.Errorf("pricing: %w: %s", ErrNoTaxRate, t.country)
+	}
+	return t, nil
+}
+
+// ParseTaxRates читает ставки вида RU=20, KZ=12 (проценты, можно дробные)
+func ParseTaxRates(items []string) (map[string]float64, error) {
+	rates := make(map[string]float64, len(items))
+	for _, item := range items {
+		code, rateStr, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("pricing: bad tax rate %q, expected COUNTRY=PERCENT", item)
+		}
+		_, country, err := ValidateTax("", strings.TrimSpace(code))
+		if err != nil {
+			return nil, fmt.Errorf("pricing: bad tax rate %q: %w", item, err)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+		if err != nil || rate < 0 || rate >= 100 {
+			return nil, fmt.Errorf("pricing: bad tax rate %q, percent must be in 0..100", item)
+		}
+		rates[country] = rate
+	}
+	return rates, nil
+}
+
+// ValidateTax приводит basis к нижнему регистру и country к верхнему, пустые остаются пустыми
+func ValidateTax(basis, country string) (string, string, error) {
+	basis = strings.ToLower(strings.TrimSpace(basis))
+	switch basis {
+	case "", BasisNet, BasisGross:
+	default:
+		return "", "", ErrBadBasis
+	}
+
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country != "" {
+		if len(country) != 2 || country[0] < 'A' || country[0] > 'Z' || country[1] < 'A' || country[1] > 'Z' {
+			return "", "", ErrBadCountry
+		}
+	}
+	return basis, country, nil
+}
+
+func (t *TaxTable) Resolve(basis, country string) (string, string, error) {
+	basis, country, err := ValidateTax(basis, country)
+	if err != nil {
+		return "", "", err
+	}
+	if basis == "" {
+		basis = t.basis
+	}
+	if country == "" {
+		country = t.country
+	}
+	if _, ok := t.rates[country]; !ok {
+		return "", "", fmt.Errorf("%w: %s", ErrNoTaxRate, country)
+	}
+	return basis, country, nil
+}
+
+// Normalize округляет до копейки: gross = net * (1 + rate), net = gross / (1 + rate)
+func (t *TaxTable) Normalize(price domain.Money, from, to, country string) (domain.Money, float64, error) {
+	from, country, err := t.Resolve(from, country)
+	if err != nil {
+		return 0, 0, err
+	}
+	rate := t.rates[country]
+
+	switch {
+	case from == to:
+		return price, rate, nil
+	case to == BasisGross:
+		return domain.Money(math.Round(float64(price) * (100 + rate) / 100)), rate, nil
+	case to == BasisNet:
+		return domain.Money(math.Round(float64(price) * 100 / (100 + rate))), rate, nil
+	}
+	return 0, 0, ErrBadBasis
+}
@@ -0,0 +1,65 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/i18n"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/validate"
+)
+
+const ContentType = "application/problem+json"
+
+// заголовок с id запроса, его ставит middleware.RequestID
+const RequestIDHeader = "X-Request-ID"
+
+// язык текстов ошибок, его ставит middleware.Language
+const LanguageHeader = "Content-Language"
+
+// Details - тело ошибки по RFC 7807. type всегда about:blank: смысл ошибки
+// несут status и title, текст для человека в detail
+type Details struct {
+	Type      string `json:"type" example:"about:blank"`
+	Title     string `json:"title" example:"Not Found"`
+	Status    int    `json:"status" example:"404"`
+	Detail    string `json:"detail,omitempty" example:"sub not found"`
+	RequestID string `json:"request_id,omitempty" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	// нарушения по полям, только у ответа на невалидное тело
+	Errors validate.Errors `json:"errors,omitempty"`
+}
+
+// Write отвечает ошибкой в application/problem+json. Аргументы как у http.Error,
+// request_id и язык берутся из заголовков ответа, которые уже выставили middleware.RequestID
+// и middleware.Language. detail переводится целиком, текст с подстановками хендлер
+// переводит сам через i18n.Localizer.Sprintf
+func Write(w http.ResponseWriter, detail string, status int) {
+	write(w, Details{Detail: localizer(w).T(detail), Status: status})
+}
+
+// Error как Write, но detail - текст err с переведенной причиной
+func Error(w http.ResponseWriter, err error, status int) {
+	write(w, Details{Detail: localizer(w).Error(err), Status: status})
+}
+
+// WriteInvalid отвечает 400 со всеми нарушениями тела в errors
+func WriteInvalid(w http.ResponseWriter, errs validate.Errors) {
+	loc := localizer(w)
+	write(w, Details{Detail: loc.T("validation failed"), Status: http.StatusBadRequest, Errors: errs.Localize(loc.Sprintf)})
+}
+
+func localizer(w http.ResponseWriter) i18n.Localizer {
+	return i18n.For(i18n.Lang(w.Header().Get(LanguageHeader)))
+}
+
+func write(w http.ResponseWriter, d Details) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", ContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(d.Status)
+
+	d.Type = "about:blank"
+	d.Title = http.StatusText(d.Status)
+	d.RequestID = h.Get(RequestIDHeader)
+	json.NewEncoder(w).Encode(d)
+}
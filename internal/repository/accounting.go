@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type AccountingInterface interface {
+	Create(ctx context.Context, period time.Time, export *domain.AccountingExport, content []byte) error
+	List(ctx context.Context) ([]domain.AccountingExport, error)
+	// метаданные и содержимое файла, domain.ErrNotFound если нет
+	Get(ctx context.Context, id int64) (*domain.AccountingExport, []byte, error)
+	Exists(ctx context.Context, period time.Time, format string) (bool, error)
+}
+
+type AccountingRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ AccountingInterface = (*AccountingRepository)(nil)
+
+func NewAccountingRepository(db *sql.DB, log *slog.Logger) *AccountingRepository {
+	return &AccountingRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/accounting")),
+	}
+}
+
+// Create сохраняет файл, id и created_at пишутся в export
+func (r *AccountingRepository) Create(ctx context.Context, period time.Time, export *domain.AccountingExport, content []byte) error {
+	const op = "repository.postgres.accounting.Create"
+
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO accounting_exports(period, format, content, row_count, sha256)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at`,
+		period, export.Format, content, export.Rows, export.SHA256).Scan(&export.ID, &export.CreatedAt)
+	if err != nil {
+		r.log.Error("accounting export save failed", slog.String("op", op), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// List - выгрузки без содержимого, свежие сверху
+func (r *AccountingRepository) List(ctx context.Context) ([]domain.AccountingExport, error) {
+	const op = "repository.postgres.accounting.List"
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, period, format, row_count, OCTET_LENGTH(content), sha256, created_at
+        FROM accounting_exports
+        ORDER BY period DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	exports := []domain.AccountingExport{}
+	for rows.Next() {
+		var (
+			e      domain.AccountingExport
+			period time.Time
+		)
+		if err := rows.Scan(&e.ID, &period, &e.Format, &e.Rows, &e.Size, &e.SHA256, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		e.Period = period.Format("01-2006")
+		exports = append(exports, e)
+	}
+	return exports, rows.Err()
+}
+
+func (r *AccountingRepository) Get(ctx context.Context, id int64) (*domain.AccountingExport, []byte, error) {
+	const op = "repository.postgres.accounting.Get"
+
+	var (
+		e       domain.AccountingExport
+		period  time.Time
+		content []byte
+	)
+	err := r.db.QueryRowContext(ctx, `
+        SELECT id, period, format, row_count, content, sha256, created_at
+        FROM accounting_exports
+        WHERE id = $1`, id).Scan(&e.ID, &period, &e.Format, &e.Rows, &content, &e.SHA256, &e.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	e.Period = period.Format("01-2006")
+	e.Size = len(content)
+	return &e, content, nil
+}
+
+func (r *AccountingRepository) Exists(ctx context.Context, period time.Time, format string) (bool, error) {
+	const op = "repository.postgres.accounting.Exists"
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+        SELECT EXISTS(SELECT 1 FROM accounting_exports WHERE period = $1 AND format = $2)`, period, format).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type AnalyticsInterface interface {
+	// ReplaceCohorts заменяет когортную таблицу целиком, в одной транзакции
+	ReplaceCohorts(ctx context.Context, currency string, cohorts []domain.Cohort) error
+	// Cohorts - последняя агрегация, средние не заполнены
+	Cohorts(ctx context.Context) (*domain.CohortReport, error)
+	// ReplaceServiceStats заменяет статистику сервисов целиком, в одной транзакции
+	ReplaceServiceStats(ctx context.Context, currency string, stats []domain.ServiceMonth) error
+	// TopServices - limit сервисов месяца по подписчикам и по выручке
+	TopServices(ctx context.Context, month time.Time, limit int) (*domain.TopServicesReport, error)
+	// Churn - подписки и ушедшие по сервисам за [from, to], доля не заполнена
+	Churn(ctx context.Context, from, to time.Time) ([]domain.ServiceChurn, error)
+}
+
+type AnalyticsRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ AnalyticsInterface = (*AnalyticsRepository)(nil)
+
+func NewAnalyticsRepository(db *sql.DB, log *slog.Logger) *AnalyticsRepository {
+	return &AnalyticsRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/analytics")),
+	}
+}
+
+func (r *AnalyticsRepository) ReplaceCohorts(ctx context.Context, currency string, cohorts []domain.Cohort) error {
+	const op = "repository.postgres.analytics.ReplaceCohorts"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM analytics_cohorts`); err != nil {
+		return fmt.Errorf("%s: clear: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO analytics_cohorts(cohort, month, cohort_users, active_users, subscriptions, spend, currency)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`)
+	if err != nil {
+		return fmt.Errorf("%s: prepare: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, c := range cohorts {
+		cohort, _ := time.Parse("01-2006", c.Cohort)
+		for _, m := range c.Months {
+			month, _ := time.Parse("01-2006", m.Month)
+			if _, err := stmt.ExecContext(ctx, cohort, month, c.Users, m.ActiveUsers, m.Subscriptions, m.Spend, currency); err != nil {
+				r.log.Error("cohort insert failed", slog.String("op", op), slog.String("error", err.Error()))
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+	return nil
+}
+
+func (r *AnalyticsRepository) Cohorts(ctx context.Context) (*domain.CohortReport, error) {
+	const op = "repository.postgres.analytics.Cohorts"
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT cohort, month, cohort_users, active_users, subscriptions, spend, currency, computed_at
+        FROM analytics_cohorts
+        ORDER BY cohort, month`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	report := &domain.CohortReport{Cohorts: []domain.Cohort{}}
+	for rows.Next() {
+		var (
+			cohort, month, computedAt time.Time
+			users                     int
+			m                         domain.CohortMonth
+		)
+		if err := rows.Scan(&cohort, &month, &users, &m.ActiveUsers, &m.Subscriptions, &m.Spend, &report.Currency, &computedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		m.Month = month.Format("01-2006")
+		m.Offset = (month.Year()-cohort.Year())*12 + int(month.Month()) - int(cohort.Month())
+
+		// строки идут по когортам, новая когорта - новый элемент
+		key := cohort.Format("01-2006")
+		if n := len(report.Cohorts); n == 0 || report.Cohorts[n-1].Cohort != key {
+			report.Cohorts = append(report.Cohorts, domain.Cohort{Cohort: key, Users: users})
+		}
+		last := &report.Cohorts[len(report.Cohorts)-1]
+		last.Months = append(last.Months, m)
+
+		if report.ComputedAt == nil || computedAt.After(*report.ComputedAt) {
+			report.ComputedAt = &computedAt
+		}
+	}
+	return report, rows.Err()
+}
+
+func (r *AnalyticsRepository) ReplaceServiceStats(ctx context.Context, currency string, stats []domain.ServiceMonth) error {
+	const op = "repository.postgres.analytics.ReplaceServiceStats"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM analytics_services`); err != nil {
+		return fmt.Errorf("%s: clear: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO analytics_services(month, service_name, subscribers, subscriptions, revenue, currency)
+        VALUES ($1, $2, $3, $4, $5, $6)`)
+	if err != nil {
+		return fmt.Errorf("%s: prepare: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, st := range stats {
+		month, _ := time.Parse("01-2006", st.Month)
+		if _, err := stmt.ExecContext(ctx, month, st.ServiceName, st.Subscribers, st.Subscriptions, st.Revenue, currency); err != nil {
+			r.log.Error("service stats insert failed", slog.String("op", op), slog.String("error", err.Error()))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+	return nil
+}
+
+func (r *AnalyticsRepository) TopServices(ctx context.Context, month time.Time, limit int) (*domain.TopServicesReport, error) {
+	const op = "repository.postgres.analytics.TopServices"
+
+	report := &domain.TopServicesReport{Period: month.Format("01-2006")}
+	var err error
+	// при равенстве выше тот, у кого больше второй показатель
+	report.BySubscribers, err = r.topServices(ctx, report, month, limit, "subscribers DESC, revenue DESC")
+	if err != nil {
+		return nil, fmt.Errorf("%s: by subscribers: %w", op, err)
+	}
+	report.ByRevenue, err = r.topServices(ctx, report, month, limit, "revenue DESC, subscribers DESC")
+	if err != nil {
+		return nil, fmt.Errorf("%s: by revenue: %w", op, err)
+	}
+	return report, nil
+}
+
+// order подставляется только из констант TopServices
+func (r *AnalyticsRepository) topServices(ctx context.Context, report *domain.TopServicesReport, month time.Time, limit int, order string) ([]domain.ServiceMonth, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT service_name, subscribers, subscriptions, revenue, currency, computed_at
+        FROM analytics_services
+        WHERE month = $1
+        ORDER BY `+order+`, service_name
+        LIMIT $2`, month, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	top := []domain.ServiceMonth{}
+	for rows.Next() {
+		var (
+			st         = domain.ServiceMonth{Month: report.Period}
+			computedAt time.Time
+		)
+		if err := rows.Scan(&st.ServiceName, &st.Subscribers, &st.Subscriptions, &st.Revenue, &report.Currency, &computedAt); err != nil {
+			return nil, err
+		}
+		if report.ComputedAt == nil || computedAt.After(*report.ComputedAt) {
+			report.ComputedAt = &computedAt
+		}
+		top = append(top, st)
+	}
+	return top, rows.Err()
+}
+
+// подписка ушла в том месяце, на который пришелся последний месяц ее льготы
+func (r *AnalyticsRepository) Churn(ctx context.Context, from, to time.Time) ([]domain.ServiceChurn, error) {
+	const op = "repository.postgres.analytics.Churn"
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT service_name, COUNT(*),
+               COUNT(*) FILTER (WHERE churned_at IS NOT NULL
+                   AND end_date + MAKE_INTERVAL(months => grace_period_months) <= $2)
+        FROM subscriptions
+        WHERE start_date <= $2
+          AND (end_date IS NULL OR end_date + MAKE_INTERVAL(months => grace_period_months) >= $1)
+        GROUP BY service_name
+        ORDER BY service_name`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	services := []domain.ServiceChurn{}
+	for rows.Next() {
+		var c domain.ServiceChurn
+		if err := rows.Scan(&c.ServiceName, &c.Subscriptions, &c.Churned); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		services = append(services, c)
+	}
+	return services, rows.Err()
+}
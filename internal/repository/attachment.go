@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type AttachmentInterface interface {
+	Create(ctx context.Context, a domain.Attachment) (int64, error)
+	GetByID(ctx context.Context, subscriptionID, id int64) (*domain.Attachment, error)
+	List(ctx context.Context, subscriptionID int64) ([]domain.Attachment, error)
+	Delete(ctx context.Context, subscriptionID, id int64) error
+}
+
+type AttachmentRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ AttachmentInterface = (*AttachmentRepository)(nil)
+
+func NewAttachmentRepository(db *sql.DB, log *slog.Logger) *AttachmentRepository {
+	return &AttachmentRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/attachment")),
+	}
+}
+
+const attachmentColumns = `id, subscription_id, file_name, content_type, size_bytes, sha256, storage_key, created_at`
+
+func scanAttachment(row rowScanner) (*domain.Attachment, error) {
+	var a domain.Attachment
+	err := row.Scan(&a.ID, &a.SubscriptionID, &a.FileName, &a.ContentType, &a.SizeBytes, &a.SHA256, &a.StorageKey, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *AttachmentRepository) Create(ctx context.Context, a domain.Attachment) (int64, error) {
+	const op = "repository.postgres.attachment.Create"
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO attachments(subscription_id, file_name, content_type, size_bytes, sha256, storage_key)
+        VALUES($1, $2, $3, $4, $5, $6) RETURNING id`,
+		a.SubscriptionID, a.FileName, a.ContentType, a.SizeBytes, a.SHA256, a.StorageKey).Scan(&id)
+	if err != nil {
+		r.log.Error("attachment create failed", slog.String("op", op), slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+// вложение ищется в рамках подписки, чужой id дает ErrNotFound
+func (r *AttachmentRepository) GetByID(ctx context.Context, subscriptionID, id int64) (*domain.Attachment, error) {
+	const op = "repository.postgres.attachment.GetByID"
+
+	a, err := scanAttachment(r.db.QueryRowContext(ctx,
+		`SELECT `+attachmentColumns+` FROM attachments WHERE id = $1 AND subscription_id = $2`, id, subscriptionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: attachment %d: %w", op, id, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return a, nil
+}
+
+func (r *AttachmentRepository) List(ctx context.Context, subscriptionID int64) ([]domain.Attachment, error) {
+	const op = "repository.postgres.attachment.List"
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+attachmentColumns+` FROM attachments WHERE subscription_id = $1 ORDER BY id`, subscriptionID)
+	if err != nil {
+		r.log.Error("attachment list failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	attachments := []domain.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		attachments = append(attachments, *a)
+	}
+	return attachments, rows.Err()
+}
+
+func (r *AttachmentRepository) Delete(ctx context.Context, subscriptionID, id int64) error {
+	const op = "repository.postgres.attachment.Delete"
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM attachments WHERE id = $1 AND subscription_id = $2`, id, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: attachment %d: %w", op, id, domain.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type AuditInterface interface {
+	Append(ctx context.Context, rec domain.AuditRecord) (int64, error)
+	List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditRecord, error)
+	Chain(ctx context.Context, afterID int64, limit int) ([]domain.AuditRecord, error)
+}
+
+// ключ advisory lock, под ним записи встают в цепочку по одной
+const auditLockKey = 0x61756474
+
+type AuditRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ AuditInterface = (*AuditRepository)(nil)
+
+func NewAuditRepository(db *sql.DB, log *slog.Logger) *AuditRepository {
+	return &AuditRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/audit")),
+	}
+}
+
+const auditColumns = `id, at, actor, action, entity_id, request_id, status, payload, prev_hash, hash`
+
+func scanAudit(row rowScanner) (*domain.AuditRecord, error) {
+	var (
+		rec     domain.AuditRecord
+		payload []byte
+	)
+	err := row.Scan(&rec.ID, &rec.At, &rec.Actor, &rec.Action, &rec.EntityID, &rec.RequestID,
+		&rec.Status, &payload, &rec.PrevHash, &rec.Hash)
+	if err != nil {
+		return nil, err
+	}
+	rec.Payload = payload
+	return &rec, nil
+}
+
+// Append дописывает запись в конец цепочки. Берем хэш последней записи и вставляем
+// новую под блокировкой, иначе две параллельные записи сошлются на один prev_hash
+func (r *AuditRepository) Append(ctx context.Context, rec domain.AuditRecord) (int64, error) {
+	const op = "repository.postgres.audit.Append"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, auditLockKey); err != nil {
+		return 0, fmt.Errorf("%s: lock: %w", op, err)
+	}
+
+	var prev string
+	err = tx.QueryRowContext(ctx, `SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&prev)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("%s: last hash: %w", op, err)
+	}
+
+	// в базе время хранится до микросекунд, хэш должен сойтись после чтения
+	rec.At = rec.At.UTC().Truncate(time.Microsecond)
+	rec.Seal(prev)
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO audit_log(at, actor, action, entity_id, request_id, status, payload, prev_hash, hash)
+        VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id`,
+		rec.At, rec.Actor, rec.Action, rec.EntityID, rec.RequestID, rec.Status, []byte(rec.Payload), rec.PrevHash, rec.Hash,
+	).Scan(&id)
+	if err != nil {
+		r.log.Error("audit insert failed", slog.String("op", op), slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: insert: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: commit: %w", op, err)
+	}
+	return id, nil
+}
+
+// List отдает записи от новых к старым
+func (r *AuditRepository) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditRecord, error) {
+	const op = "repository.postgres.audit.List"
+
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE 1 = 1`
+	var args []interface{}
+
+	if filter.Actor != "" {
+		args = append(args, filter.Actor)
+		query += fmt.Sprintf(" AND actor = $%d", len(args))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		query += fmt.Sprintf(" AND action = $%d", len(args))
+	}
+	if filter.EntityID != "" {
+		args = append(args, filter.EntityID)
+		query += fmt.Sprintf(" AND entity_id = $%d", len(args))
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+	args = append(args, filter.Offset)
+	query += fmt.Sprintf(" OFFSET $%d", len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.log.Error("audit list failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	records := []domain.AuditRecord{}
+	for rows.Next() {
+		rec, err := scanAudit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		records = append(records, *rec)
+	}
+	return records, rows.Err()
+}
+
+// Chain отдает кусок цепочки по возрастанию id, для проверки целостности
+func (r *AuditRepository) Chain(ctx context.Context, afterID int64, limit int) ([]domain.AuditRecord, error) {
+	const op = "repository.postgres.audit.Chain"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+auditColumns+` FROM audit_log WHERE id > $1 ORDER BY id LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var records []domain.AuditRecord
+	for rows.Next() {
+		rec, err := scanAudit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		records = append(records, *rec)
+	}
+	return records, rows.Err()
+}
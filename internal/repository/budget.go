@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type BudgetInterface interface {
+	Create(ctx context.Context, b domain.Budget) (int64, error)
+	GetByID(ctx context.Context, id int64) (*domain.Budget, error)
+	List(ctx context.Context, userID uuid.UUID) ([]domain.Budget, error)
+	Update(ctx context.Context, id int64, b domain.Budget) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type BudgetRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ BudgetInterface = (*BudgetRepository)(nil)
+
+func NewBudgetRepository(db *sql.DB, log *slog.Logger) *BudgetRepository {
+	return &BudgetRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/budget")),
+	}
+}
+
+const budgetColumns = `id, user_id, period, amount, category, created_at, updated_at`
+
+func scanBudget(row rowScanner) (*domain.Budget, error) {
+	var b domain.Budget
+	if err := row.Scan(&b.ID, &b.UserID, &b.Period, &b.Amount, &b.Category, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *BudgetRepository) Create(ctx context.Context, b domain.Budget) (int64, error) {
+	const op = "repository.postgres.budget.Create"
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, `INSERT INTO budgets(user_id, period, amount, category) VALUES($1, $2, $3, $4) RETURNING id`,
+		b.UserID, b.Period, b.Amount, b.Category).Scan(&id)
+	if err != nil {
+		r.log.Error("budget create failed", slog.String("op", op), slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+func (r *BudgetRepository) GetByID(ctx context.Context, id int64) (*domain.Budget, error) {
+	const op = "repository.postgres.budget.GetByID"
+
+	b, err := scanBudget(r.db.QueryRowContext(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: budget %d: %w", op, id, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return b, nil
+}
+
+func (r *BudgetRepository) List(ctx context.Context, userID uuid.UUID) ([]domain.Budget, error) {
+	const op = "repository.postgres.budget.List"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE user_id = $1
+        ORDER BY TO_DATE(period, 'MM-YYYY') DESC, id`, userID)
+	if err != nil {
+		r.log.Error("budget list failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	budgets := []domain.Budget{}
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		budgets = append(budgets, *b)
+	}
+	return budgets, rows.Err()
+}
+
+func (r *BudgetRepository) Update(ctx context.Context, id int64, b domain.Budget) error {
+	const op = "repository.postgres.budget.Update"
+
+	res, err := r.db.ExecContext(ctx, `UPDATE budgets SET period = $1, amount = $2, category = $3, updated_at = NOW() WHERE id = $4`,
+		b.Period, b.Amount, b.Category, id)
+	if err != nil {
+		r.log.Error("budget update failed", slog.String("op", op), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: budget %d: %w", op, id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *BudgetRepository) Delete(ctx context.Context, id int64) error {
+	const op = "repository.postgres.budget.Delete"
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: budget %d: %w", op, id, domain.ErrNotFound)
+	}
+	return nil
+}
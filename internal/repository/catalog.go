@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/lib/pq"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type CatalogInterface interface {
+	Create(ctx context.Context, e domain.CatalogEntry) (int64, error)
+	GetByID(ctx context.Context, id int64) (*domain.CatalogEntry, error)
+	List(ctx context.Context) ([]domain.CatalogEntry, error)
+	Update(ctx context.Context, id int64, e domain.CatalogEntry) error
+	Delete(ctx context.Context, id int64) error
+	Match(ctx context.Context, key string) (*domain.CatalogEntry, error)
+	Conflicts(ctx context.Context, keys []string, exceptID int64) (bool, error)
+	Link(ctx context.Context, e domain.CatalogEntry) (int64, error)
+}
+
+type CatalogRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ CatalogInterface = (*CatalogRepository)(nil)
+
+func NewCatalogRepository(db *sql.DB, log *slog.Logger) *CatalogRepository {
+	return &CatalogRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/catalog")),
+	}
+}
+
+const catalogColumns = `id, name, aliases, logo_url, created_at, updated_at`
+
+func scanCatalogEntry(row rowScanner) (*domain.CatalogEntry, error) {
+	var e domain.CatalogEntry
+	if err := row.Scan(&e.ID, &e.Name, pq.Array(&e.Aliases), &e.LogoURL, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// название подписки в форме domain.CatalogKey, но на SQL
+const sqlCatalogKey = `REGEXP_REPLACE(LOWER(TRIM(service_name)), '\s+', ' ', 'g')`
+
+func (r *CatalogRepository) Create(ctx context.Context, e domain.CatalogEntry) (int64, error) {
+	const op = "repository.postgres.catalog.Create"
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, `INSERT INTO service_catalog(name, aliases, logo_url) VALUES($1, $2, $3) RETURNING id`,
+		e.Name, pq.Array(e.Aliases), e.LogoURL).Scan(&id)
+	if err != nil {
+		r.log.Error("catalog create failed", slog.String("op", op), slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+func (r *CatalogRepository) GetByID(ctx context.Context, id int64) (*domain.CatalogEntry, error) {
+	const op = "repository.postgres.catalog.GetByID"
+
+	e, err := scanCatalogEntry(r.db.QueryRowContext(ctx, `SELECT `+catalogColumns+` FROM service_catalog WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: catalog entry %d: %w", op, id, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return e, nil
+}
+
+func (r *CatalogRepository) List(ctx context.Context) ([]domain.CatalogEntry, error) {
+	const op = "repository.postgres.catalog.List"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+catalogColumns+` FROM service_catalog ORDER BY name, id`)
+	if err != nil {
+		r.log.Error("catalog list failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	entries := []domain.CatalogEntry{}
+	for rows.Next() {
+		e, err := scanCatalogEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *CatalogRepository) Update(ctx context.Context, id int64, e domain.CatalogEntry) error {
+	const op = "repository.postgres.catalog.Update"
+
+	res, err := r.db.ExecContext(ctx, `UPDATE service_catalog SET name = $1, aliases = $2, logo_url = $3, updated_at = NOW() WHERE id = $4`,
+		e.Name, pq.Array(e.Aliases), e.LogoURL, id)
+	if err != nil {
+		r.log.Error("catalog update failed", slog.String("op", op), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: catalog entry %d: %w", op, id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// подписки записи остаются со своими названиями, catalog_id обнуляется внешним ключом
+func (r *CatalogRepository) Delete(ctx context.Context, id int64) error {
+	const op = "repository.postgres.catalog.Delete"
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM service_catalog WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: catalog entry %d: %w", op, id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// Match ищет запись по названию или алиасу, nil без ошибки - не распознано
+func (r *CatalogRepository) Match(ctx context.Context, key string) (*domain.CatalogEntry, error) {
+	const op = "repository.postgres.catalog.Match"
+
+	e, err := scanCatalogEntry(r.db.QueryRowContext(ctx, `SELECT `+catalogColumns+` FROM service_catalog
+        WHERE LOWER(name) = $1 OR $1 = ANY(aliases)
+        ORDER BY id LIMIT 1`, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return e, nil
+}
+
+// Conflicts - какое-то из keys уже занято названием или алиасом другой записи
+func (r *CatalogRepository) Conflicts(ctx context.Context, keys []string, exceptID int64) (bool, error) {
+	const op = "repository.postgres.catalog.Conflicts"
+
+	var taken bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM service_catalog
+        WHERE id <> $2 AND (LOWER(name) = ANY($1::text[]) OR aliases && $1::text[]))`,
+		pq.Array(keys), exceptID).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return taken, nil
+}
+
+// Link привязывает к записи еще не привязанные подписки с ее названием или алиасом,
+// переименовывает их в каноническое название и пишет правку в историю
+func (r *CatalogRepository) Link(ctx context.Context, e domain.CatalogEntry) (int64, error) {
+	const op = "repository.postgres.catalog.Link"
+
+	keys := append([]string{domain.CatalogKey(e.Name)}, e.Aliases...)
+	res, err := r.db.ExecContext(ctx, `
+        WITH matched AS (
+            SELECT id, service_name FROM subscriptions
+            WHERE catalog_id IS NULL AND `+sqlCatalogKey+` = ANY($3::text[])
+            FOR UPDATE
+        ), updated AS (
+            UPDATE subscriptions s SET catalog_id = $1, service_name = $2, updated_at = NOW()
+            FROM matched m WHERE s.id = m.id
+            RETURNING s.id, m.service_name AS old_name
+        )
+        INSERT INTO subscription_history(subscription_id, action, changes)
+        SELECT id, $4, jsonb_build_object('catalog_id', jsonb_build_object('old', NULL, 'new', $1::bigint)) ||
+            CASE WHEN old_name <> $2 THEN jsonb_build_object('service_name', jsonb_build_object('old', old_name, 'new', $2::text))
+            ELSE '{}'::jsonb END
+        FROM updated`,
+		e.ID, e.Name, pq.Array(keys), domain.EventUpdated)
+	if err != nil {
+		r.log.Error("catalog link failed", slog.String("op", op), slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/lib/pq"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type CategoryInterface interface {
+	Create(ctx context.Context, name string) (int64, error)
+	GetByID(ctx context.Context, id int64) (*domain.Category, error)
+	List(ctx context.Context) ([]domain.Category, error)
+	Update(ctx context.Context, id int64, name string) error
+	Delete(ctx context.Context, id int64) error
+	NameTaken(ctx context.Context, name string, exceptID int64) (bool, error)
+}
+
+type CategoryRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ CategoryInterface = (*CategoryRepository)(nil)
+
+func NewCategoryRepository(db *sql.DB, log *slog.Logger) *CategoryRepository {
+	return &CategoryRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/category")),
+	}
+}
+
+const categoryColumns = `id, name, created_at, updated_at`
+
+func scanCategory(row rowScanner) (*domain.Category, error) {
+	var c domain.Category
+	if err := row.Scan(&c.ID, &c.Name, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// подписка ссылается на несуществующую категорию
+func isUnknownCategory(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "subscriptions_category_id_fkey"
+}
+
+// isOpenDuplicate - вторая бессрочная подписка юзера на тот же сервис
+func isOpenDuplicate(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_subscriptions_open_unique"
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, name string) (int64, error) {
+	const op = "repository.postgres.category.Create"
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, `INSERT INTO categories(name) VALUES($1) RETURNING id`, name).Scan(&id)
+	if err != nil {
+		r.log.Error("category create failed", slog.String("op", op), slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id int64) (*domain.Category, error) {
+	const op = "repository.postgres.category.GetByID"
+
+	c, err := scanCategory(r.db.QueryRowContext(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: category %d: %w", op, id, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return c, nil
+}
+
+func (r *CategoryRepository) List(ctx context.Context) ([]domain.Category, error) {
+	const op = "repository.postgres.category.List"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+categoryColumns+` FROM categories ORDER BY name, id`)
+	if err != nil {
+		r.log.Error("category list failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	categories := []domain.Category{}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		categories = append(categories, *c)
+	}
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, id int64, name string) error {
+	const op = "repository.postgres.category.Update"
+
+	res, err := r.db.ExecContext(ctx, `UPDATE categories SET name = $1, updated_at = NOW() WHERE id = $2`, name, id)
+	if err != nil {
+		r.log.Error("category update failed", slog.String("op", op), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: category %d: %w", op, id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// подписки категории остаются, category_id у них обнуляется внешним ключом
+func (r *CategoryRepository) Delete(ctx context.Context, id int64) error {
+	const op = "repository.postgres.category.Delete"
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: category %d: %w", op, id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// имя занято другой категорией, регистр не важен
+func (r *CategoryRepository) NameTaken(ctx context.Context, name string, exceptID int64) (bool, error) {
+	const op = "repository.postgres.category.NameTaken"
+
+	var taken bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM categories WHERE LOWER(name) = LOWER($1) AND id <> $2)`,
+		name, exceptID).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return taken, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// ConfirmCheck сверяет сохраненный хеш токена и срок. Нет токена - пустой хеш и нулевое
+// время. Ошибка отменяет удаление, токен при этом остается
+type ConfirmCheck func(tokenHash string, expiresAt time.Time) error
+
+// BulkConfirmCheck - ConfirmCheck для удаления пачкой, видит все удаляемые подписки.
+// Ошибка отменяет удаление всей пачки
+type BulkConfirmCheck func(matched []domain.Subscription, tokenHash string, expiresAt time.Time) error
+
+// IssueDeleteConfirmation сохраняет токен подтверждения для subject, прошлый токен того же
+// subject заменяется. Заодно чистит просроченные
+func (r *SubscriptionRepository) IssueDeleteConfirmation(ctx context.Context, subject, tokenHash string, expiresAt, now time.Time) error {
+	const op = "repository.postgres.IssueDeleteConfirmation"
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM delete_confirmations WHERE expires_at < $1`, now); err != nil {
+		r.log.Warn("expired confirmations cleanup failed", slog.String("op", op), slog.String("error", err.Error()))
+	}
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO delete_confirmations(subject, token_hash, expires_at) VALUES($1, $2, $3)
+    ON CONFLICT (subject) DO UPDATE SET token_hash = EXCLUDED.token_hash, expires_at = EXCLUDED.expires_at, created_at = NOW()`,
+		subject, tokenHash, expiresAt)
+	if err != nil {
+		r.log.Error("confirmation insert failed", slog.String("op", op), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteConfirmed удаляет подписку по токену: токен гасится в той же транзакции, поэтому
+// сбой удаления его не сжигает, а две реплики не примут один токен дважды
+func (r *SubscriptionRepository) DeleteConfirmed(ctx context.Context, id int64, subject string, check ConfirmCheck) error {
+	const op = "repository.postgres.DeleteConfirmed"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := consumeConfirmation(ctx, tx, subject, check); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM subscriptions WHERE id = $1`, id)
+	if err != nil {
+		r.log.Error("db error during delete", slog.String("op", op), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: subscription %d: %w", op, id, domain.ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+	return nil
+}
+
+// consumeConfirmation блокирует токен subject, проверяет его check и удаляет
+func consumeConfirmation(ctx context.Context, tx *sql.Tx, subject string, check ConfirmCheck) error {
+	var hash string
+	var expiresAt time.Time
+	err := tx.QueryRowContext(ctx, `SELECT token_hash, expires_at FROM delete_confirmations WHERE subject = $1 FOR UPDATE`, subject).
+		Scan(&hash, &expiresAt)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("confirmation: %w", err)
+	}
+	if err := check(hash, expiresAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM delete_confirmations WHERE subject = $1`, subject); err != nil {
+		return fmt.Errorf("confirmation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type EventInterface interface {
+	Add(ctx context.Context, ev domain.Event) (int64, error)
+	List(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error)
+	ListBySubscription(ctx context.Context, subID int64) ([]domain.Event, error)
+	Prune(ctx context.Context, before time.Time, batch int) (int64, error)
+	Backlog(ctx context.Context) (*domain.EventBacklog, error)
+}
+
+type EventRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ EventInterface = (*EventRepository)(nil)
+
+func NewEventRepository(db *sql.DB, log *slog.Logger) *EventRepository {
+	return &EventRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/event")),
+	}
+}
+
+// запись в ленту, ее же пишут транзакции, которым событие нужно вместе с изменением
+const insertEvent = `INSERT INTO subscription_events(user_id, subscription_id, type, payload)
+    VALUES($1, $2, $3, $4)
+    RETURNING id`
+
+func (r *EventRepository) Add(ctx context.Context, ev domain.Event) (int64, error) {
+	const op = "repository.postgres.event.Add"
+
+	payload := ev.Payload
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, insertEvent, ev.UserID, ev.SubscriptionID, ev.Type, []byte(payload)).Scan(&id)
+	if err != nil {
+		r.log.Error("faild to store event", slog.String("op", op), slog.String("error", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+func (r *EventRepository) List(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error) {
+	const op = "repository.postgres.event.List"
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := `SELECT id, user_id, subscription_id, type, payload, created_at
+              FROM subscription_events
+              WHERE user_id = $1
+              ORDER BY created_at DESC, id DESC
+              LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.UserID, limit, filter.Offset)
+	if err != nil {
+		r.log.Error("events fetch failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var events []domain.Event
+	for rows.Next() {
+		var ev domain.Event
+		var payload []byte
+		if err := rows.Scan(&ev.ID, &ev.UserID, &ev.SubscriptionID, &ev.Type, &payload, &ev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		ev.Payload = payload
+		events = append(events, ev)
+	}
+
+	return events, rows.Err()
+}
+
+// ListBySubscription отдает все события подписки от старых к новым
+func (r *EventRepository) ListBySubscription(ctx context.Context, subID int64) ([]domain.Event, error) {
+	const op = "repository.postgres.event.ListBySubscription"
+
+	query := `SELECT id, user_id, subscription_id, type, payload, created_at
+              FROM subscription_events
+              WHERE subscription_id = $1
+              ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, subID)
+	if err != nil {
+		r.log.Error("events fetch failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var events []domain.Event
+	for rows.Next() {
+		var ev domain.Event
+		var payload []byte
+		if err := rows.Scan(&ev.ID, &ev.UserID, &ev.SubscriptionID, &ev.Type, &payload, &ev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		ev.Payload = payload
+		events = append(events, ev)
+	}
+
+	return events, rows.Err()
+}
+
+// Prune удаляет пачку событий старше before, возвращает сколько удалено.
+// Пачками, чтоб не держать долгую блокировку на большой таблице
+func (r *EventRepository) Prune(ctx context.Context, before time.Time, batch int) (int64, error) {
+	const op = "repository.postgres.event.Prune"
+
+	res, err := r.db.ExecContext(ctx, `
+        DELETE FROM subscription_events
+        WHERE id IN (
+            SELECT id FROM subscription_events
+            WHERE created_at < $1
+            ORDER BY created_at, id
+            LIMIT $2
+        )`, before, batch)
+	if err != nil {
+		r.log.Error("events prune failed", slog.String("op", op), slog.String("err", err.Error()))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return res.RowsAffected()
+}
+
+func (r *EventRepository) Backlog(ctx context.Context) (*domain.EventBacklog, error) {
+	const op = "repository.postgres.event.Backlog"
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT type, COUNT(*), MIN(created_at)
+        FROM subscription_events
+        GROUP BY type`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	b := &domain.EventBacklog{ByType: make(map[string]int64)}
+	for rows.Next() {
+		var typ string
+		var n int64
+		var oldest time.Time
+		if err := rows.Scan(&typ, &n, &oldest); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		b.ByType[typ] = n
+		b.Total += n
+		if b.OldestAt == nil || oldest.Before(*b.OldestAt) {
+			b.OldestAt = &oldest
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if b.OldestAt != nil {
+		b.OldestAgeSeconds = int64(time.Since(*b.OldestAt).Seconds())
+	}
+	return b, nil
+}
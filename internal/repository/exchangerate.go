@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/lib/pq"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/fx"
+)
+
+type ExchangeRateInterface interface {
+	Save(ctx context.Context, rates *fx.Rates) error
+	// последние загруженные курсы, nil если таблица пустая
+	Latest(ctx context.Context) (*fx.Rates, error)
+}
+
+type ExchangeRateRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ ExchangeRateInterface = (*ExchangeRateRepository)(nil)
+
+func NewExchangeRateRepository(db *sql.DB, log *slog.Logger) *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/exchangerate")),
+	}
+}
+
+// Save пишет курсы за дату одним запросом, повторная загрузка той же даты их обновляет
+func (r *ExchangeRateRepository) Save(ctx context.Context, rates *fx.Rates) error {
+	const op = "repository.postgres.exchangerate.Save"
+
+	codes := make([]string, 0, len(rates.Rates))
+	values := make([]float64, 0, len(rates.Rates))
+	for code, rate := range rates.Rates {
+		codes = append(codes, code)
+		values = append(values, rate)
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO exchange_rates(source, as_of, base, currency, rate)
+        SELECT $1, $2, $3, c, v FROM UNNEST($4::text[], $5::float8[]) AS t(c, v)
+        ON CONFLICT (source, as_of, currency)
+        DO UPDATE SET base = EXCLUDED.base, rate = EXCLUDED.rate, fetched_at = NOW()`,
+		rates.Source, rates.AsOf, rates.Base, pq.Array(codes), pq.Array(values))
+	if err != nil {
+		r.log.Error("exchange rates save failed", slog.String("op", op), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *ExchangeRateRepository) Latest(ctx context.Context) (*fx.Rates, error) {
+	const op = "repository.postgres.exchangerate.Latest"
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT source, as_of, base, currency, rate::float8
+        FROM exchange_rates
+        WHERE (source, as_of) = (
+            SELECT source, as_of FROM exchange_rates ORDER BY fetched_at DESC, as_of DESC LIMIT 1
+        )`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var rates *fx.Rates
+	for rows.Next() {
+		var (
+			row  fx.Rates
+			code string
+			rate float64
+		)
+		if err := rows.Scan(&row.Source, &row.AsOf, &row.Base, &code, &rate); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		if rates == nil {
+			row.Rates = map[string]float64{}
+			rates = &row
+		}
+		rates.Rates[code] = rate
+	}
+	return rates, rows.Err()
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/audit"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// mutate меняет подписку и пишет правку в subscription_history в одной транзакции,
+// строка блокируется, чтоб старые значения не устарели до записи. version != 0 -
+// правка только поверх этой версии, иначе ErrVersionConflict. Непустая правка поднимает версию
+func (r *SubscriptionRepository) mutate(ctx context.Context, op string, id int64, version int64, action string, query string, args ...any) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	changes, err := mutateTx(ctx, tx, r.log, op, id, version, action, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+	// в аудит только то, что действительно записано
+	if len(changes) > 0 {
+		audit.Record(ctx, domain.AuditChange{SubscriptionID: id, Action: action, Changes: changes})
+	}
+	return nil
+}
+
+// mutateTx - mutate внутри чужой транзакции, коммит и запись в аудит за вызывающим.
+// Возвращает измененные поля, пусто - правка ничего не поменяла
+func mutateTx(ctx context.Context, tx *sql.Tx, log *slog.Logger, op string, id int64, version int64, action string, query string, args ...any) (map[string]domain.FieldChange, error) {
+	selectRow := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1`
+
+	before, err := scanSubscription(tx.QueryRowContext(ctx, selectRow+` FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: subscription %d: %w", op, id, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("%s: lock: %w", op, err)
+	}
+	if version != 0 && before.Version != version {
+		return nil, fmt.Errorf("%s: subscription %d at version %d, expected %d: %w", op, id, before.Version, version, domain.ErrVersionConflict)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		if isUnknownCategory(err) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrUnknownCategory)
+		}
+		if isOpenDuplicate(err) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrSubscriptionExists)
+		}
+		log.Error("mutation exec failed", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	after, err := scanSubscription(tx.QueryRowContext(ctx, selectRow, id))
+	if err != nil {
+		return nil, fmt.Errorf("%s: reread: %w", op, err)
+	}
+
+	// пустые правки (те же значения) в историю не пишем
+	changes := diffSubscription(before, after)
+	if len(changes) > 0 {
+		raw, err := json.Marshal(changes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: marshal changes: %w", op, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO subscription_history(subscription_id, action, changes) VALUES($1, $2, $3)`,
+			id, action, raw)
+		if err != nil {
+			log.Error("history insert failed", slog.String("op", op), slog.String("error", err.Error()))
+			return nil, fmt.Errorf("%s: history: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE subscriptions SET version = version + 1 WHERE id = $1`, id); err != nil {
+			return nil, fmt.Errorf("%s: version: %w", op, err)
+		}
+	}
+	return changes, nil
+}
+
+// поля, которые попадают в историю, updated_at не считается правкой
+func diffSubscription(before, after *domain.Subscription) map[string]domain.FieldChange {
+	changes := make(map[string]domain.FieldChange)
+	add := func(field string, old, cur any) {
+		if old != cur {
+			changes[field] = domain.FieldChange{Old: old, New: cur}
+		}
+	}
+
+	add("service_name", before.ServiceName, after.ServiceName)
+	add("price", before.Price, after.Price)
+	add("currency", before.Currency, after.Currency)
+	add("billing_period", before.BillingPeriod, after.BillingPeriod)
+	add("price_basis", before.PriceBasis, after.PriceBasis)
+	add("tax_country", before.TaxCountry, after.TaxCountry)
+	add("trial_end_date", deref(before.TrialEndDate), deref(after.TrialEndDate))
+	add("trial_price", before.TrialPrice, after.TrialPrice)
+	add("user_id", before.UserID.String(), after.UserID.String())
+	add("start_date", before.StartDate, after.StartDate)
+	add("end_date", deref(before.EndDate), deref(after.EndDate))
+	add("start_day", derefDay(before.StartDay), derefDay(after.StartDay))
+	add("end_day", derefDay(before.EndDay), derefDay(after.EndDay))
+	add("status", before.Status, after.Status)
+	add("paused_from", deref(before.PausedFrom), deref(after.PausedFrom))
+	add("paused_until", deref(before.PausedUntil), deref(after.PausedUntil))
+	add("grace_period_months", before.GracePeriodMonths, after.GracePeriodMonths)
+	add("category_id", derefID(before.CategoryID), derefID(after.CategoryID))
+	add("notes", before.Notes, after.Notes)
+	add("catalog_id", derefID(before.CatalogID), derefID(after.CatalogID))
+	// слайсы через any не сравнить
+	if !slices.Equal(before.Tags, after.Tags) {
+		changes["tags"] = domain.FieldChange{Old: before.Tags, New: after.Tags}
+	}
+	if !slices.EqualFunc(before.Pauses, after.Pauses, samePause) {
+		changes["pauses"] = domain.FieldChange{Old: before.Pauses, New: after.Pauses}
+	}
+	if (before.CancelledAt == nil) != (after.CancelledAt == nil) {
+		changes["cancelled_at"] = domain.FieldChange{Old: before.CancelledAt, New: after.CancelledAt}
+	}
+
+	return changes
+}
+
+func samePause(a, b domain.PauseRange) bool {
+	return a.ID == b.ID && a.PausedFrom == b.PausedFrom && deref(a.PausedTo) == deref(b.PausedTo)
+}
+
+// nil остается nil в json, а не пустой строкой
+func deref(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+func derefID(id *int64) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+func derefDay(d *int) any {
+	if d == nil {
+		return nil
+	}
+	return *d
+}
+
+// History отдает правки подписки от старых к новым
+func (r *SubscriptionRepository) History(ctx context.Context, id int64) ([]domain.HistoryEntry, error) {
+	const op = "repository.postgres.History"
+
+	query := `SELECT id, subscription_id, action, changes, changed_at
+              FROM subscription_history
+              WHERE subscription_id = $1
+              ORDER BY changed_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		r.log.Error("history fetch failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	history := []domain.HistoryEntry{}
+	for rows.Next() {
+		var (
+			e   domain.HistoryEntry
+			raw []byte
+		)
+		if err := rows.Scan(&e.ID, &e.SubscriptionID, &e.Action, &raw, &e.ChangedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		if err := json.Unmarshal(raw, &e.Changes); err != nil {
+			return nil, fmt.Errorf("%s: changes: %w", op, err)
+		}
+		history = append(history, e)
+	}
+	return history, rows.Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type IdempotencyInterface interface {
+	Claim(ctx context.Context, scope, key, requestHash string, ttl time.Duration) (*domain.IdempotencyRecord, bool, error)
+	Save(ctx context.Context, scope, key string, status int, contentType string, response []byte) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+type IdempotencyRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ IdempotencyInterface = (*IdempotencyRepository)(nil)
+
+func NewIdempotencyRepository(db *sql.DB, log *slog.Logger) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/idempotency")),
+	}
+}
+
+// Claim занимает ключ. Если ключ уже занят - возвращает существующую запись и false.
+// Просроченные записи перезаписываются
+func (r *IdempotencyRepository) Claim(ctx context.Context, scope, key, requestHash string, ttl time.Duration) (*domain.IdempotencyRecord, bool, error) {
+	const op = "repository.postgres.idempotency.Claim"
+
+	res, err := r.db.ExecContext(ctx, `
+        INSERT INTO idempotency_keys(scope, key, request_hash) VALUES($1, $2, $3)
+        ON CONFLICT (scope, key) DO UPDATE
+        SET request_hash = EXCLUDED.request_hash, status_code = NULL, content_type = '', response = NULL, created_at = NOW()
+        WHERE idempotency_keys.created_at < NOW() - $4 * INTERVAL '1 second'`,
+		scope, key, requestHash, int64(ttl.Seconds()))
+	if err != nil {
+		r.log.Error("idempotency claim failed", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil, true, nil
+	}
+
+	rec := domain.IdempotencyRecord{Scope: scope, Key: key}
+	var status sql.NullInt64
+	err = r.db.QueryRowContext(ctx, `
+        SELECT request_hash, status_code, content_type, response, created_at
+        FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key).Scan(
+		&rec.RequestHash, &status, &rec.ContentType, &rec.Response, &rec.CreatedAt,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+	rec.StatusCode = int(status.Int64)
+
+	return &rec, false, nil
+}
+
+func (r *IdempotencyRepository) Save(ctx context.Context, scope, key string, status int, contentType string, response []byte) error {
+	const op = "repository.postgres.idempotency.Save"
+
+	_, err := r.db.ExecContext(ctx, `UPDATE idempotency_keys SET status_code = $1, content_type = $2, response = $3 WHERE scope = $4 AND key = $5`,
+		status, contentType, response, scope, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Release освобождает ключ, если запрос упал и его можно повторить
+func (r *IdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	const op = "repository.postgres.idempotency.Release"
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status_code IS NULL`, scope, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type ImportInterface interface {
+	StartJob(ctx context.Context, checksum, mode string) (*domain.ImportJob, bool, error)
+	CommitChunk(ctx context.Context, jobID int64, subs []domain.Subscription, committedRow int, month time.Time) ([]int64, error)
+	FinishJob(ctx context.Context, jobID int64) error
+}
+
+type ImportRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ ImportInterface = (*ImportRepository)(nil)
+
+func NewImportRepository(db *sql.DB, log *slog.Logger) *ImportRepository {
+	return &ImportRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/import")),
+	}
+}
+
+// StartJob находит незавершенный импорт того же файла или заводит новый.
+// Второе значение true если продолжаем старый
+func (r *ImportRepository) StartJob(ctx context.Context, checksum, mode string) (*domain.ImportJob, bool, error) {
+	const op = "repository.postgres.import.StartJob"
+
+	var job domain.ImportJob
+	err := r.db.QueryRowContext(ctx, `
+        SELECT id, checksum, mode, status, committed_row, imported, created_at, updated_at
+        FROM import_jobs
+        WHERE checksum = $1 AND mode = $2 AND status = 'running'
+        ORDER BY id DESC LIMIT 1`, checksum, mode).Scan(
+		&job.ID, &job.Checksum, &job.Mode, &job.Status,
+		&job.CommittedRow, &job.Imported, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err == nil {
+		return &job, true, nil
+	}
+	if err != sql.ErrNoRows {
+		r.log.Error("cant look up import job", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+        INSERT INTO import_jobs(checksum, mode) VALUES($1, $2)
+        RETURNING id, checksum, mode, status, committed_row, imported, created_at, updated_at`, checksum, mode).Scan(
+		&job.ID, &job.Checksum, &job.Mode, &job.Status,
+		&job.CommittedRow, &job.Imported, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		r.log.Error("cant create import job", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &job, false, nil
+}
+
+// CommitChunk вставляет пачку и двигает точку продолжения в одной транзакции.
+// Возвращает id по каждой строке, 0 - такая подписка, не закончившаяся до month, уже есть
+func (r *ImportRepository) CommitChunk(ctx context.Context, jobID int64, subs []domain.Subscription, committedRow int, month time.Time) ([]int64, error) {
+	const op = "repository.postgres.import.CommitChunk"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// колонки как в Create: строка приходит уже нормализованной сервисом
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO subscriptions(service_name, price, user_id, start_date, end_date, grace_period_months, category_id, tags, notes, catalog_id, currency, billing_period, trial_end_date, trial_price, start_day, end_day, price_basis, tax_country)
+        SELECT $1, $2, $3, $4, $5, $6, $7, COALESCE($8::text[], '{}'), $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+        WHERE NOT EXISTS (
+            SELECT 1 FROM subscriptions
+            WHERE user_id = $3 AND service_name = $1
+              AND (end_date IS NULL OR end_date >= $19::date)
+        )
+        ON CONFLICT (user_id, service_name) WHERE end_date IS NULL DO NOTHING
+        RETURNING id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare: %w", op, err)
+	}
+	defer stmt.Close()
+
+	ids := make([]int64, len(subs))
+	imported := 0
+	for i, sub := range subs {
+		err := stmt.QueryRowContext(ctx, sub.ServiceName, sub.Price, sub.UserID, monthParam(sub.StartDate), nullMonthParam(sub.EndDate), sub.GracePeriodMonths, sub.CategoryID, pq.Array(sub.Tags), sub.Notes, sub.CatalogID, sub.Currency, sub.BillingPeriod, sub.TrialEndDate, sub.TrialPrice, sub.StartDay, sub.EndDay, sub.PriceBasis, sub.TaxCountry, month).Scan(&ids[i])
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			r.log.Error("chunk insert failed", slog.String("op", op), slog.String("error", err.Error()))
+			return nil, fmt.Errorf("%s: insert: %w", op, err)
+		}
+		imported++
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE import_jobs SET committed_row = $1, imported = imported + $2, updated_at = NOW()
+        WHERE id = $3`, committedRow, imported, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: job progress: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+
+	return ids, nil
+}
+
+func (r *ImportRepository) FinishJob(ctx context.Context, jobID int64) error {
+	const op = "repository.postgres.import.FinishJob"
+
+	_, err := r.db.ExecContext(ctx, `UPDATE import_jobs SET status = 'done', updated_at = NOW() WHERE id = $1`, jobID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
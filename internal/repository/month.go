@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// start_date и end_date лежат в DATE колонках первым числом месяца,
+// а в домене и API остаются строками MM-YYYY. Переводим на границе с базой
+const monthLayout = "01-2006"
+
+// monthArg - MM-YYYY как параметр запроса, nil строка - NULL
+type monthArg struct{ s *string }
+
+func monthParam(s string) monthArg      { return monthArg{&s} }
+func nullMonthParam(s *string) monthArg { return monthArg{s} }
+
+func (m monthArg) Value() (driver.Value, error) {
+	if m.s == nil {
+		return nil, nil
+	}
+	t, err := time.Parse(monthLayout, *m.s)
+	if err != nil {
+		return nil, fmt.Errorf("bad month %q: %w", *m.s, err)
+	}
+	return t, nil
+}
+
+// monthScan читает DATE в обязательную строку MM-YYYY
+type monthScan struct{ dst *string }
+
+func (m monthScan) Scan(src any) error {
+	t, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("month: unexpected %T", src)
+	}
+	*m.dst = t.Format(monthLayout)
+	return nil
+}
+
+// nullMonthScan читает DATE в необязательную строку, NULL - nil
+type nullMonthScan struct{ dst **string }
+
+func (m nullMonthScan) Scan(src any) error {
+	if src == nil {
+		*m.dst = nil
+		return nil
+	}
+	t, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("month: unexpected %T", src)
+	}
+	s := t.Format(monthLayout)
+	*m.dst = &s
+	return nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type NotificationTemplateInterface interface {
+	List(ctx context.Context) ([]domain.NotificationTemplate, error)
+	Get(ctx context.Context, kind string) (*domain.NotificationTemplate, error)
+	Upsert(ctx context.Context, t domain.NotificationTemplate) (*domain.NotificationTemplate, error)
+	Delete(ctx context.Context, kind string) error
+}
+
+type NotificationTemplateRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ NotificationTemplateInterface = (*NotificationTemplateRepository)(nil)
+
+func NewNotificationTemplateRepository(db *sql.DB, log *slog.Logger) *NotificationTemplateRepository {
+	return &NotificationTemplateRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/notificationtemplate")),
+	}
+}
+
+const notificationTemplateColumns = `kind, subject, body, updated_at`
+
+func scanNotificationTemplate(row rowScanner) (*domain.NotificationTemplate, error) {
+	t := domain.NotificationTemplate{Custom: true}
+	if err := row.Scan(&t.Kind, &t.Subject, &t.Body, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// только переопределенные шаблоны
+func (r *NotificationTemplateRepository) List(ctx context.Context) ([]domain.NotificationTemplate, error) {
+	const op = "repository.postgres.notificationtemplate.List"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+notificationTemplateColumns+` FROM notification_templates ORDER BY kind`)
+	if err != nil {
+		r.log.Error("notification template list failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	templates := []domain.NotificationTemplate{}
+	for rows.Next() {
+		t, err := scanNotificationTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		templates = append(templates, *t)
+	}
+	return templates, rows.Err()
+}
+
+func (r *NotificationTemplateRepository) Get(ctx context.Context, kind string) (*domain.NotificationTemplate, error) {
+	const op = "repository.postgres.notificationtemplate.Get"
+
+	t, err := scanNotificationTemplate(r.db.QueryRowContext(ctx,
+		`SELECT `+notificationTemplateColumns+` FROM notification_templates WHERE kind = $1`, kind))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: template %s: %w", op, kind, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return t, nil
+}
+
+func (r *NotificationTemplateRepository) Upsert(ctx context.Context, t domain.NotificationTemplate) (*domain.NotificationTemplate, error) {
+	const op = "repository.postgres.notificationtemplate.Upsert"
+
+	saved, err := scanNotificationTemplate(r.db.QueryRowContext(ctx, `
+		INSERT INTO notification_templates(kind, subject, body) VALUES($1, $2, $3)
+		ON CONFLICT (kind) DO UPDATE SET subject = EXCLUDED.subject, body = EXCLUDED.body, updated_at = NOW()
+		RETURNING `+notificationTemplateColumns, t.Kind, t.Subject, t.Body))
+	if err != nil {
+		r.log.Error("notification template save failed", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return saved, nil
+}
+
+// удаление возвращает встроенный шаблон
+func (r *NotificationTemplateRepository) Delete(ctx context.Context, kind string) error {
+	const op = "repository.postgres.notificationtemplate.Delete"
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM notification_templates WHERE kind = $1`, kind)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: template %s: %w", op, kind, domain.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type PolicyHookInterface interface {
+	Create(ctx context.Context, hook domain.PolicyHook) (*domain.PolicyHook, error)
+	List(ctx context.Context) ([]domain.PolicyHook, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type PolicyHookRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ PolicyHookInterface = (*PolicyHookRepository)(nil)
+
+func NewPolicyHookRepository(db *sql.DB, log *slog.Logger) *PolicyHookRepository {
+	return &PolicyHookRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/policyhook")),
+	}
+}
+
+const policyHookColumns = `id, url, secret, fail_open, created_at`
+
+func scanPolicyHook(row rowScanner) (*domain.PolicyHook, error) {
+	var h domain.PolicyHook
+	if err := row.Scan(&h.ID, &h.URL, &h.Secret, &h.FailOpen, &h.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *PolicyHookRepository) Create(ctx context.Context, hook domain.PolicyHook) (*domain.PolicyHook, error) {
+	const op = "repository.postgres.policyhook.Create"
+
+	h, err := scanPolicyHook(r.db.QueryRowContext(ctx, `
+        INSERT INTO policy_hooks(url, secret, fail_open) VALUES ($1, $2, $3)
+        RETURNING `+policyHookColumns, hook.URL, hook.Secret, hook.FailOpen))
+	if err != nil {
+		r.log.Error("policy hook create failed", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return h, nil
+}
+
+func (r *PolicyHookRepository) List(ctx context.Context) ([]domain.PolicyHook, error) {
+	const op = "repository.postgres.policyhook.List"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+policyHookColumns+` FROM policy_hooks ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	hooks := []domain.PolicyHook{}
+	for rows.Next() {
+		h, err := scanPolicyHook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		hooks = append(hooks, *h)
+	}
+	return hooks, rows.Err()
+}
+
+func (r *PolicyHookRepository) Delete(ctx context.Context, id int64) error {
+	const op = "repository.postgres.policyhook.Delete"
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM policy_hooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%s: policy hook %d: %w", op, id, domain.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type ReminderInterface interface {
+	Get(ctx context.Context, subID int64, userID uuid.UUID) (*domain.ReminderState, error)
+	Acknowledge(ctx context.Context, subID int64, userID uuid.UUID, endDate string) error
+	Snooze(ctx context.Context, subID int64, userID uuid.UUID, until time.Time) error
+	ListDue(ctx context.Context, now, until, notifiedBefore time.Time) ([]domain.DueReminder, error)
+	MarkNotified(ctx context.Context, subID int64, userID uuid.UUID, at time.Time) error
+	// SetOffsets заменяет свои сроки напоминаний подписки, пустой список их убирает
+	SetOffsets(ctx context.Context, subID int64, daysBefore []int) error
+	// ListDueOffsets - подписки со своими сроками, у которых на день now наступил срок
+	ListDueOffsets(ctx context.Context, now time.Time) ([]domain.DueReminder, error)
+	// MarkOffsetSent отмечает сроки от daysBefore и дальше отправленными для даты конца endOn
+	MarkOffsetSent(ctx context.Context, subID int64, daysBefore int, endOn time.Time) error
+}
+
+type ReminderRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ ReminderInterface = (*ReminderRepository)(nil)
+
+func NewReminderRepository(db *sql.DB, log *slog.Logger) *ReminderRepository {
+	return &ReminderRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/reminder")),
+	}
+}
+
+func (r *ReminderRepository) Get(ctx context.Context, subID int64, userID uuid.UUID) (*domain.ReminderState, error) {
+	const op = "repository.postgres.reminder.Get"
+	query := `SELECT subscription_id, user_id, acknowledged_end_date, acknowledged_at, snoozed_until, last_notified_at
+    FROM subscription_reminders WHERE subscription_id = $1 AND user_id = $2`
+
+	var st domain.ReminderState
+	err := r.db.QueryRowContext(ctx, query, subID, userID).Scan(
+		&st.SubscriptionID, &st.UserID, &st.AcknowledgedEndDate,
+		&st.AcknowledgedAt, &st.SnoozedUntil, &st.LastNotifiedAt,
+	)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.log.Error("cant get reminder state", slog.String("op", op), slog.String("error", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		// состояния еще нет, отдаем пустое
+		st = domain.ReminderState{SubscriptionID: subID, UserID: userID}
+	}
+
+	if st.DaysBefore, err = r.offsets(ctx, subID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &st, nil
+}
+
+func (r *ReminderRepository) offsets(ctx context.Context, subID int64) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT days_before FROM subscription_reminder_offsets
+    WHERE subscription_id = $1 ORDER BY days_before DESC`, subID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []int
+	for rows.Next() {
+		var d int
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+func (r *ReminderRepository) Acknowledge(ctx context.Context, subID int64, userID uuid.UUID, endDate string) error {
+	const op = "repository.postgres.reminder.Acknowledge"
+	// подтверждение привязано к end_date, после продления напоминания снова пойдут
+	query := `INSERT INTO subscription_reminders(subscription_id, user_id, acknowledged_end_date, acknowledged_at)
+    VALUES($1, $2, $3, NOW())
+    ON CONFLICT (subscription_id, user_id) DO UPDATE
+    SET acknowledged_end_date = EXCLUDED.acknowledged_end_date,
+        acknowledged_at = EXCLUDED.acknowledged_at,
+        snoozed_until = NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, subID, userID, endDate); err != nil {
+		r.log.Error("acknowledge failed", slog.String("op", op), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *ReminderRepository) Snooze(ctx context.Context, subID int64, userID uuid.UUID, until time.Time) error {
+	const op = "repository.postgres.reminder.Snooze"
+	query := `INSERT INTO subscription_reminders(subscription_id, user_id, snoozed_until)
+    VALUES($1, $2, $3)
+    ON CONFLICT (subscription_id, user_id) DO UPDATE
+    SET snoozed_until = EXCLUDED.snoozed_until`
+
+	if _, err := r.db.ExecContext(ctx, query, subID, userID, until); err != nil {
+		r.log.Error("snooze failed", slog.String("op", op), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *ReminderRepository) ListDue(ctx context.Context, now, until, notifiedBefore time.Time) ([]domain.DueReminder, error) {
+	const op = "repository.postgres.reminder.ListDue"
+
+	// подписки которые заканчиваются в окне, без подтверждения и не отложенные.
+	// Подписки со своими сроками идут через ListDueOffsets
+	query := `
+        SELECT s.id, s.user_id, s.service_name, s.end_date
+        FROM subscriptions s
+        LEFT JOIN subscription_reminders r
+          ON r.subscription_id = s.id AND r.user_id = s.user_id
+        WHERE s.end_date IS NOT NULL
+          AND s.end_date >= DATE_TRUNC('month', $1::timestamptz)
+          AND s.end_date <= $2
+          AND (r.acknowledged_end_date IS NULL OR r.acknowledged_end_date <> TO_CHAR(s.end_date, 'MM-YYYY'))
+          AND (r.snoozed_until IS NULL OR r.snoozed_until <= $1)
+          AND (r.last_notified_at IS NULL OR r.last_notified_at < $3)
+          AND NOT EXISTS (SELECT 1 FROM subscription_reminder_offsets o WHERE o.subscription_id = s.id)`
+
+	rows, err := r.db.QueryContext(ctx, query, now, until, notifiedBefore)
+	if err != nil {
+		r.log.Error("due reminders fetch failed", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var due []domain.DueReminder
+	for rows.Next() {
+		var d domain.DueReminder
+		if err := rows.Scan(&d.SubscriptionID, &d.UserID, &d.ServiceName, monthScan{&d.EndDate}); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		due = append(due, d)
+	}
+
+	return due, rows.Err()
+}
+
+func (r *ReminderRepository) MarkNotified(ctx context.Context, subID int64, userID uuid.UUID, at time.Time) error {
+	const op = "repository.postgres.reminder.MarkNotified"
+	query := `INSERT INTO subscription_reminders(subscription_id, user_id, last_notified_at)
+    VALUES($1, $2, $3)
+    ON CONFLICT (subscription_id, user_id) DO UPDATE
+    SET last_notified_at = EXCLUDED.last_notified_at`
+
+	if _, err := r.db.ExecContext(ctx, query, subID, userID, at); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *ReminderRepository) SetOffsets(ctx context.Context, subID int64, daysBefore []int) error {
+	const op = "repository.postgres.reminder.SetOffsets"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM subscription_reminder_offsets WHERE subscription_id = $1`, subID); err != nil {
+		return fmt.Errorf("%s: delete: %w", op, err)
+	}
+	for _, d := range daysBefore {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO subscription_reminder_offsets(subscription_id, days_before) VALUES($1, $2)`, subID, d); err != nil {
+			r.log.Error("reminder offset insert failed", slog.String("op", op), slog.String("error", err.Error()))
+			return fmt.Errorf("%s: insert: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+	return nil
+}
+
+// последний день подписки: end_day в месяце end_date, без него конец месяца
+const reminderEndOn = `LEAST(s.end_date + COALESCE(s.end_day, 31) - 1,
+            (s.end_date + INTERVAL '1 month' - INTERVAL '1 day')::date)`
+
+func (r *ReminderRepository) ListDueOffsets(ctx context.Context, now time.Time) ([]domain.DueReminder, error) {
+	const op = "repository.postgres.reminder.ListDueOffsets"
+
+	// для каждой подписки берем самый близкий к концу наступивший срок, который еще не отправлен
+	// для этой даты конца. Подтверждение и отложенные напоминания работают как и для общего срока
+	query := `
+        SELECT DISTINCT ON (s.id) s.id, s.user_id, s.service_name, s.end_date, o.days_before, e.end_on
+        FROM subscriptions s
+        CROSS JOIN LATERAL (SELECT ` + reminderEndOn + ` AS end_on) e
+        JOIN subscription_reminder_offsets o ON o.subscription_id = s.id
+        LEFT JOIN subscription_reminders r
+          ON r.subscription_id = s.id AND r.user_id = s.user_id
+        WHERE s.end_date IS NOT NULL
+          AND $1::date BETWEEN e.end_on - o.days_before AND e.end_on
+          AND o.sent_for IS DISTINCT FROM e.end_on
+          AND (r.acknowledged_end_date IS NULL OR r.acknowledged_end_date <> TO_CHAR(s.end_date, 'MM-YYYY'))
+          AND (r.snoozed_until IS NULL OR r.snoozed_until <= $1)
+        ORDER BY s.id, o.days_before`
+
+	rows, err := r.db.QueryContext(ctx, query, now)
+	if err != nil {
+		r.log.Error("due offset reminders fetch failed", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var due []domain.DueReminder
+	for rows.Next() {
+		var (
+			d    domain.DueReminder
+			days int
+		)
+		if err := rows.Scan(&d.SubscriptionID, &d.UserID, &d.ServiceName, monthScan{&d.EndDate}, &days, &d.EndOn); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		d.DaysBefore = &days
+		due = append(due, d)
+	}
+
+	return due, rows.Err()
+}
+
+func (r *ReminderRepository) MarkOffsetSent(ctx context.Context, subID int64, daysBefore int, endOn time.Time) error {
+	const op = "repository.postgres.reminder.MarkOffsetSent"
+	// более ранние сроки тоже закрываем, иначе после простоя они уйдут пачкой
+	query := `UPDATE subscription_reminder_offsets SET sent_for = $3
+    WHERE subscription_id = $1 AND days_before >= $2`
+
+	if _, err := r.db.ExecContext(ctx, query, subID, daysBefore, endOn); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
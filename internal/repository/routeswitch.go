@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type RouteSwitchInterface interface {
+	List(ctx context.Context) ([]domain.DisabledRoute, error)
+	Disable(ctx context.Context, route, reason string) error
+	Enable(ctx context.Context, route string) error
+}
+
+type RouteSwitchRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ RouteSwitchInterface = (*RouteSwitchRepository)(nil)
+
+func NewRouteSwitchRepository(db *sql.DB, log *slog.Logger) *RouteSwitchRepository {
+	return &RouteSwitchRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/routeswitch")),
+	}
+}
+
+func (r *RouteSwitchRepository) List(ctx context.Context) ([]domain.DisabledRoute, error) {
+	const op = "repository.postgres.routeswitch.List"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT route, reason, disabled_at FROM disabled_routes ORDER BY route`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	routes := []domain.DisabledRoute{}
+	for rows.Next() {
+		var d domain.DisabledRoute
+		if err := rows.Scan(&d.Route, &d.Reason, &d.DisabledAt); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		routes = append(routes, d)
+	}
+	return routes, rows.Err()
+}
+
+// повторное выключение обновляет причину
+func (r *RouteSwitchRepository) Disable(ctx context.Context, route, reason string) error {
+	const op = "repository.postgres.routeswitch.Disable"
+
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO disabled_routes(route, reason) VALUES($1, $2)
+        ON CONFLICT (route) DO UPDATE SET reason = EXCLUDED.reason, disabled_at = NOW()`, route, reason)
+	if err != nil {
+		r.log.Error("route disable failed", slog.String("op", op), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *RouteSwitchRepository) Enable(ctx context.Context, route string) error {
+	const op = "repository.postgres.routeswitch.Enable"
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM disabled_routes WHERE route = $1`, route)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: route %q: %w", op, route, domain.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/audit"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// ScheduledPlan решает, что сделать с подпиской по наступившему действию. Ошибка -
+// попытка не удалась, действие повторится позже
+type ScheduledPlan func(ctx context.Context, ev domain.ScheduledEvent, sub domain.Subscription) (*domain.ScheduledChange, error)
+
+type ScheduledEventInterface interface {
+	// Create ставит действие. Ждущее действие того же вида на то же время у подписки одно,
+	// повтор заменяет его параметры
+	Create(ctx context.Context, ev domain.ScheduledEvent) (*domain.ScheduledEvent, error)
+	Get(ctx context.Context, id int64) (*domain.ScheduledEvent, error)
+	ListBySubscription(ctx context.Context, subID int64) ([]domain.ScheduledEvent, error)
+	// Cancel отменяет ждущее действие, false - оно уже выполнено или отменено
+	Cancel(ctx context.Context, id int64) (bool, error)
+	// RunNext берет одно наступившее к now действие и применяет решение plan в той же
+	// транзакции, где меняет статус и пишет событие в ленту. nil - выполнять нечего
+	RunNext(ctx context.Context, now time.Time, maxAttempts int, plan ScheduledPlan) (*domain.ScheduledEvent, error)
+}
+
+type ScheduledEventRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ ScheduledEventInterface = (*ScheduledEventRepository)(nil)
+
+func NewScheduledEventRepository(db *sql.DB, log *slog.Logger) *ScheduledEventRepository {
+	return &ScheduledEventRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/scheduledevent")),
+	}
+}
+
+const scheduledEventColumns = `id, subscription_id, kind, run_at, payload, status, attempts, last_error, created_at, executed_at`
+
+// параметры действия в колонке payload
+type scheduledPayload struct {
+	Price  *domain.Money `json:"price,omitempty"`
+	Months int           `json:"months,omitempty"`
+}
+
+func scanScheduledEvent(row rowScanner) (*domain.ScheduledEvent, error) {
+	var ev domain.ScheduledEvent
+	var raw []byte
+	err := row.Scan(&ev.ID, &ev.SubscriptionID, &ev.Kind, &ev.RunAt, &raw, &ev.Status,
+		&ev.Attempts, &ev.LastError, &ev.CreatedAt, &ev.ExecutedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var p scheduledPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+	ev.Price, ev.Months = p.Price, p.Months
+	return &ev, nil
+}
+
+const insertScheduledEvent = `INSERT INTO scheduled_events(subscription_id, kind, run_at, payload)
+    VALUES($1, $2, $3, $4)
+    ON CONFLICT (subscription_id, kind, run_at) WHERE status = 'pending'
+    DO UPDATE SET payload = EXCLUDED.payload
+    RETURNING ` + scheduledEventColumns
+
+// аргументы insertScheduledEvent
+func scheduledEventArgs(ev domain.ScheduledEvent) ([]any, error) {
+	raw, err := json.Marshal(scheduledPayload{Price: ev.Price, Months: ev.Months})
+	if err != nil {
+		return nil, err
+	}
+	return []any{ev.SubscriptionID, ev.Kind, ev.RunAt, raw}, nil
+}
+
+func (r *ScheduledEventRepository) Create(ctx context.Context, ev domain.ScheduledEvent) (*domain.ScheduledEvent, error) {
+	const op = "repository.postgres.scheduledevent.Create"
+
+	args, err := scheduledEventArgs(ev)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := scanScheduledEvent(r.db.QueryRowContext(ctx, insertScheduledEvent, args...))
+	if err != nil {
+		r.log.Error("scheduled event insert failed", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return created, nil
+}
+
+func (r *ScheduledEventRepository) Get(ctx context.Context, id int64) (*domain.ScheduledEvent, error) {
+	const op = "repository.postgres.scheduledevent.Get"
+
+	ev, err := scanScheduledEvent(r.db.QueryRowContext(ctx, `SELECT `+scheduledEventColumns+` FROM scheduled_events WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: scheduled event %d: %w", op, id, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ev, nil
+}
+
+func (r *ScheduledEventRepository) ListBySubscription(ctx context.Context, subID int64) ([]domain.ScheduledEvent, error) {
+	const op = "repository.postgres.scheduledevent.ListBySubscription"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+scheduledEventColumns+` FROM scheduled_events
+    WHERE subscription_id = $1
+    ORDER BY run_at, id`, subID)
+	if err != nil {
+		r.log.Error("scheduled events fetch failed", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	events := []domain.ScheduledEvent{}
+	for rows.Next() {
+		ev, err := scanScheduledEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		events = append(events, *ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return events, nil
+}
+
+func (r *ScheduledEventRepository) Cancel(ctx context.Context, id int64) (bool, error) {
+	const op = "repository.postgres.scheduledevent.Cancel"
+
+	res, err := r.db.ExecContext(ctx, `UPDATE scheduled_events SET status = 'cancelled' WHERE id = $1 AND status = 'pending'`, id)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return n > 0, nil
+}
+
+// пауза перед повтором неудачной попытки: 1, 2, 4... минуты, не больше часа
+func scheduledRetryDelay(attempts int) time.Duration {
+	return min(time.Minute<<min(attempts-1, 6), time.Hour)
+}
+
+func (r *ScheduledEventRepository) RunNext(ctx context.Context, now time.Time, maxAttempts int, plan ScheduledPlan) (*domain.ScheduledEvent, error) {
+	const op = "repository.postgres.scheduledevent.RunNext"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// SKIP LOCKED: соседний инстанс берет следующее действие, а не ждет это
+	ev, err := scanScheduledEvent(tx.QueryRowContext(ctx, `SELECT `+scheduledEventColumns+` FROM scheduled_events
+    WHERE status = 'pending' AND run_at <= $1
+    ORDER BY run_at, id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED`, now))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: claim: %w", op, err)
+	}
+
+	status, reason, changed, err := r.run(ctx, tx, ev, now, maxAttempts, plan)
+	if err == nil {
+		if err = tx.Commit(); err != nil {
+			err = fmt.Errorf("commit: %w", err)
+		} else if changed != nil {
+			// в аудит только после коммита, как в mutate
+			audit.Record(ctx, *changed)
+		}
+	}
+	if err != nil {
+		// действие, на котором падает сама транзакция, снова взялось бы первым и
+		// останавливало всю пачку: закрываем его как failed отдельным запросом
+		tx.Rollback()
+		r.log.Error("scheduled event broke its transaction", slog.Int64("id", ev.ID), slog.String("kind", ev.Kind),
+			slog.String("error", err.Error()))
+
+		status, reason = domain.ScheduledFailed, err.Error()
+		ev.ExecutedAt = nil
+		_, err = r.db.ExecContext(ctx, `UPDATE scheduled_events SET status = $2, attempts = attempts + 1, last_error = $3
+        WHERE id = $1 AND status = 'pending'`, ev.ID, status, reason)
+		if err != nil {
+			return nil, fmt.Errorf("%s: mark failed: %w", op, err)
+		}
+	}
+
+	ev.Status = status
+	if reason != "" {
+		ev.LastError = &reason
+	}
+	return ev, nil
+}
+
+// run выполняет взятое действие в транзакции RunNext и пишет его новый статус.
+// Ошибка - сломалась сама транзакция, а не действие
+func (r *ScheduledEventRepository) run(ctx context.Context, tx *sql.Tx, ev *domain.ScheduledEvent, now time.Time, maxAttempts int, plan ScheduledPlan) (string, string, *domain.AuditChange, error) {
+	sub, err := scanSubscription(tx.QueryRowContext(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1 FOR UPDATE`, ev.SubscriptionID))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("subscription %d: %w", ev.SubscriptionID, err)
+	}
+
+	// неудачное действие откатывается до точки сохранения, а попытка все равно записывается
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT scheduled_action`); err != nil {
+		return "", "", nil, fmt.Errorf("savepoint: %w", err)
+	}
+
+	ev.Attempts++
+	status, reason, changed, actionErr := r.apply(ctx, tx, *ev, *sub, plan)
+	if actionErr != nil {
+		if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT scheduled_action`); err != nil {
+			return "", "", nil, fmt.Errorf("rollback action: %w", err)
+		}
+		r.log.Warn("scheduled event failed", slog.Int64("id", ev.ID), slog.String("kind", ev.Kind),
+			slog.Int("attempt", ev.Attempts), slog.String("error", actionErr.Error()))
+
+		status, reason = domain.ScheduledPending, actionErr.Error()
+		if ev.Attempts >= maxAttempts {
+			status = domain.ScheduledFailed
+		} else {
+			ev.RunAt = now.Add(scheduledRetryDelay(ev.Attempts))
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE scheduled_events SET status = $2, attempts = $3, last_error = $4, run_at = $5 WHERE id = $1`,
+			ev.ID, status, ev.Attempts, reason, ev.RunAt)
+	} else {
+		ev.ExecutedAt = &now
+		_, err = tx.ExecContext(ctx, `UPDATE scheduled_events SET status = $2, attempts = $3, last_error = NULLIF($4, ''), executed_at = $5 WHERE id = $1`,
+			ev.ID, status, ev.Attempts, reason, now)
+	}
+	if err != nil {
+		return "", "", nil, fmt.Errorf("status: %w", err)
+	}
+	return status, reason, changed, nil
+}
+
+// apply меняет подписку по решению plan, пишет историю, событие в ленту и следующее действие цепочки.
+// Возвращает итоговый статус, причину пропуска и правку для аудита, ее пишут после коммита
+func (r *ScheduledEventRepository) apply(ctx context.Context, tx *sql.Tx, ev domain.ScheduledEvent, sub domain.Subscription, plan ScheduledPlan) (string, string, *domain.AuditChange, error) {
+	const op = "repository.postgres.scheduledevent.apply"
+
+	change, err := plan(ctx, ev, sub)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if change.SkipReason != "" {
+		return domain.ScheduledSkipped, change.SkipReason, nil, nil
+	}
+
+	set := []string{"updated_at = NOW()"}
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	if change.Price != nil {
+		set = append(set, "price = "+arg(*change.Price))
+	}
+	if change.EndDate != nil {
+		// продленная подписка больше не считается ушедшей, день конца остается прежним
+		set = append(set, "end_date = "+arg(monthParam(*change.EndDate)), "churned_at = NULL")
+	}
+	if change.TrialEndDate != nil {
+		set = append(set, "trial_end_date = "+arg(*change.TrialEndDate))
+	}
+	query := `UPDATE subscriptions SET ` + strings.Join(set, ", ") + ` WHERE id = ` + arg(sub.ID)
+
+	changes, err := mutateTx(ctx, tx, r.log, op, sub.ID, 0, change.EventType, query, args...)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	raw, err := json.Marshal(change.Payload)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("%s: marshal payload: %w", op, err)
+	}
+	if _, err := tx.ExecContext(ctx, insertEvent, sub.UserID, sub.ID, change.EventType, raw); err != nil {
+		return "", "", nil, fmt.Errorf("%s: event: %w", op, err)
+	}
+
+	if change.Next != nil {
+		args, err := scheduledEventArgs(*change.Next)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("%s: next: %w", op, err)
+		}
+		if _, err := tx.ExecContext(ctx, insertScheduledEvent, args...); err != nil {
+			return "", "", nil, fmt.Errorf("%s: next: %w", op, err)
+		}
+	}
+	var changed *domain.AuditChange
+	if len(changes) > 0 {
+		changed = &domain.AuditChange{SubscriptionID: sub.ID, Action: change.EventType, Changes: changes}
+	}
+	return domain.ScheduledDone, "", changed, nil
+}
@@ -3,22 +3,101 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"iter"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
 )
 
 type SubscriptionInterface interface {
 	Create(ctx context.Context, sub domain.Subscription) (int64, error)
 	GetByID(ctx context.Context, id int64) (*domain.Subscription, error)
+	Update(ctx context.Context, id int64, sub domain.Subscription) error
+	Cancel(ctx context.Context, id int64, endDate string, endDay *int) error
+	Transfer(ctx context.Context, id int64, userID uuid.UUID) error
+	FlagTrialConversions(ctx context.Context, month string) ([]domain.Subscription, error)
+	FlagChurned(ctx context.Context, month time.Time) ([]domain.Subscription, error)
+	SetPause(ctx context.Context, id int64, status string, pausedFrom, pausedUntil *string) error
+	AddPause(ctx context.Context, id int64, from, to string) error
+	DeletePause(ctx context.Context, id, pauseID int64) error
 	Delete(ctx context.Context, id int64) error
+	IssueDeleteConfirmation(ctx context.Context, subject, tokenHash string, expiresAt, now time.Time) error
+	DeleteConfirmed(ctx context.Context, id int64, subject string, check ConfirmCheck) error
+	DeleteByFilter(ctx context.Context, userID uuid.UUID, serviceName, subject string, check BulkConfirmCheck) ([]domain.Subscription, error)
 	List(ctx context.Context, userID uuid.UUID, filter domain.SubscriptionFilter) ([]domain.Subscription, error)
+	Stream(ctx context.Context, userID uuid.UUID, filter domain.SubscriptionFilter) iter.Seq2[*domain.Subscription, error]
 	GetTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, from, to time.Time) ([]domain.Subscription, error)
-	Exists(ctx context.Context, userID uuid.UUID, serviceName string) (bool, error)
-	Extend(ctx context.Context, id int64, newEndDate string, newPrice int) error
+	ForPeriod(ctx context.Context, from, to time.Time) ([]domain.Subscription, error)
+	Signups(ctx context.Context) (map[uuid.UUID]time.Time, error)
+	AggregateCost(ctx context.Context, userID uuid.UUID, serviceName string, from, to time.Time, excludeFinalMonth bool) ([]domain.CostDetail, error)
+	BatchCost(ctx context.Context, userIDs []uuid.UUID, from, to time.Time, excludeFinalMonth bool) (map[uuid.UUID][]domain.CurrencyCost, error)
+	GroupCost(ctx context.Context, userID uuid.UUID, serviceName string, from, to time.Time, excludeFinalMonth, byService, byMonth bool) ([]CostRow, error)
+	Exists(ctx context.Context, userID uuid.UUID, serviceName string, month time.Time) (bool, error)
+	ServiceTotals(ctx context.Context, userID uuid.UUID, month time.Time) ([]ServiceRow, error)
+	Facets(ctx context.Context, userID uuid.UUID, bounds []domain.Money, month time.Time) ([]FacetRow, error)
+	Extend(ctx context.Context, id int64, newEndDate string, endDay *int, newPrice domain.Money, version int64) error
+	History(ctx context.Context, id int64) ([]domain.HistoryEntry, error)
+	Upcoming(ctx context.Context, userID uuid.UUID, from, until time.Time) ([]domain.Subscription, error)
+	UpdateTags(ctx context.Context, id int64, add, remove []string) error
+}
+
+// колонки подписки в порядке scanSubscription
+var subscriptionColumns = `id, service_name, price, user_id, start_date, end_date, created_at, updated_at, cancelled_at,
+    status, paused_from, paused_until, grace_period_months, category_id, tags, notes, catalog_id, currency, billing_period,
+    trial_end_date, trial_price, trial_flagged_at, start_day, end_day, price_basis, tax_country, version, ` + pausesColumn("subscriptions")
+
+// расписание пауз подписки одним json массивом, по порядку начала
+func pausesColumn(table string) string {
+	return `(SELECT COALESCE(json_agg(json_build_object('id', p.id, 'paused_from', p.paused_from, 'paused_to', p.paused_to)
+        ORDER BY TO_DATE(p.paused_from, 'MM-YYYY'), p.id), '[]')
+        FROM subscription_pauses p WHERE p.subscription_id = ` + table + `.id)`
+}
+
+// месяц monthExpr попадает в одну из пауз подписки, открытая пауза тянется бесконечно
+func sqlPausedAt(idExpr, monthExpr string) string {
+	return `EXISTS (SELECT 1 FROM subscription_pauses p WHERE p.subscription_id = ` + idExpr + `
+        AND ` + monthExpr + ` BETWEEN TO_DATE(p.paused_from, 'MM-YYYY') AND COALESCE(TO_DATE(p.paused_to, 'MM-YYYY'), 'infinity'::date))`
+}
+
+// сколько месяцев из [from, to] приходится на паузы, пересечения пауз считаются один раз
+func sqlPausedMonths(idExpr, from, to string) string {
+	return `(SELECT COUNT(*)::int FROM generate_series(` + from + `, ` + to + `, INTERVAL '1 month') AS pm(m)
+        WHERE ` + sqlPausedAt(idExpr, "pm.m::date") + `)`
+}
+
+// подписка на паузе в месяце month: ручная пауза или запланированная, которая уже идет
+func sqlPausedIn(month string) string {
+	return sqlPausedAt("subscriptions.id", month)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner) (*domain.Subscription, error) {
+	var sub domain.Subscription
+	var pauses []byte
+	err := row.Scan(
+		&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID,
+		monthScan{&sub.StartDate}, nullMonthScan{&sub.EndDate}, &sub.CreatedAt, &sub.UpdatedAt,
+		&sub.CancelledAt, &sub.Status, &sub.PausedFrom, &sub.PausedUntil,
+		&sub.GracePeriodMonths, &sub.CategoryID, pq.Array(&sub.Tags), &sub.Notes, &sub.CatalogID, &sub.Currency,
+		&sub.BillingPeriod, &sub.TrialEndDate, &sub.TrialPrice, &sub.TrialFlaggedAt,
+		&sub.StartDay, &sub.EndDay, &sub.PriceBasis, &sub.TaxCountry, &sub.Version, &pauses,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(pauses, &sub.Pauses); err != nil {
+		return nil, fmt.Errorf("pauses: %w", err)
+	}
+	return &sub, nil
 }
 
 type SubscriptionRepository struct {
@@ -37,12 +116,24 @@ func NewSubscriptionRepository(db *sql.DB, log *slog.Logger) *SubscriptionReposi
 
 func (r *SubscriptionRepository) Create(ctx context.Context, sub domain.Subscription) (int64, error) {
 	const op = "repository.postgres.Create"
-	query := `INSERT INTO subscriptions(service_name, price, user_id, start_date, end_date)  VALUES($1, $2, $3, $4,$5)
+	query := `INSERT INTO subscriptions(service_name, price, user_id, start_date, end_date, grace_period_months, category_id, tags, notes, catalog_id, currency, billing_period, trial_end_date, trial_price, start_day, end_day, price_basis, tax_country)
+    VALUES($1, $2, $3, $4, $5, $6, $7, COALESCE($8::text[], '{}'), $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+    ON CONFLICT (user_id, service_name) WHERE end_date IS NULL DO NOTHING
     RETURNING id
     `
 	var id int64
-	err := r.db.QueryRowContext(ctx, query, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate).Scan(&id)
+	err := r.db.QueryRowContext(ctx, query, sub.ServiceName, sub.Price, sub.UserID, monthParam(sub.StartDate), nullMonthParam(sub.EndDate), sub.GracePeriodMonths, sub.CategoryID, pq.Array(sub.Tags), sub.Notes, sub.CatalogID, sub.Currency, sub.BillingPeriod, sub.TrialEndDate, sub.TrialPrice, sub.StartDay, sub.EndDay, sub.PriceBasis, sub.TaxCountry).Scan(&id)
+	if err == sql.ErrNoRows {
+		// параллельный запрос успел вставить такую же бессрочную подписку
+		return 0, fmt.Errorf("%s: %w", op, domain.ErrSubscriptionExists)
+	}
 	if err != nil {
+		if isUnknownCategory(err) {
+			return 0, fmt.Errorf("%s: %w", op, domain.ErrUnknownCategory)
+		}
+		if isOpenDuplicate(err) {
+			return 0, fmt.Errorf("%s: %w", op, domain.ErrSubscriptionExists)
+		}
 		// чекаем если база отвалилась на инсерте
 		r.log.Error("faild to create sub", slog.String("op:", op), slog.String("error", err.Error()))
 		return 0, err
@@ -52,25 +143,12 @@ func (r *SubscriptionRepository) Create(ctx context.Context, sub domain.Subscrip
 
 func (r *SubscriptionRepository) GetByID(ctx context.Context, id int64) (*domain.Subscription, error) {
 	const op = "repository.postgres.GetByID"
-	query := `
-    SELECT id, service_name, price,user_id, start_date, end_date,created_at, updated_at from subscriptions 
-    WHERE id=$1`
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1`
 
-	var sub domain.Subscription
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&sub.ID,
-		&sub.ServiceName,
-		&sub.Price,
-		&sub.UserID,
-		&sub.StartDate,
-		&sub.EndDate,
-		&sub.CreatedAt,
-		&sub.UpdatedAt,
-	)
+	sub, err := scanSubscription(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("%s: subscription not found: %w", op, err)
+			return nil, fmt.Errorf("%s: subscription %d: %w", op, id, domain.ErrNotFound)
 		}
 
 		r.log.Error("cant get sub by id",
@@ -81,7 +159,82 @@ func (r *SubscriptionRepository) GetByID(ctx context.Context, id int64) (*domain
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &sub, nil
+	return sub, nil
+}
+
+func (r *SubscriptionRepository) Update(ctx context.Context, id int64, sub domain.Subscription) error {
+	const op = "repository.postgres.Update"
+	query := `UPDATE subscriptions
+    SET service_name = $1, price = $2, user_id = $3, start_date = $4, end_date = $5, grace_period_months = $7, category_id = $8, tags = COALESCE($9::text[], '{}'), notes = $10, catalog_id = $11, currency = $12, billing_period = $13,
+        trial_flagged_at = CASE WHEN trial_end_date IS DISTINCT FROM $14 THEN NULL ELSE trial_flagged_at END,
+        churned_at = CASE WHEN end_date IS DISTINCT FROM $5 OR grace_period_months <> $7 THEN NULL ELSE churned_at END,
+        trial_end_date = $14, trial_price = $15, start_day = $16, end_day = $17, price_basis = $18, tax_country = $19, updated_at = NOW()
+    WHERE id = $6`
+
+	return r.mutate(ctx, op, id, sub.Version, domain.EventUpdated, query,
+		sub.ServiceName, sub.Price, sub.UserID, monthParam(sub.StartDate), nullMonthParam(sub.EndDate), id, sub.GracePeriodMonths, sub.CategoryID, pq.Array(sub.Tags), sub.Notes, sub.CatalogID, sub.Currency, sub.BillingPeriod, sub.TrialEndDate, sub.TrialPrice, sub.StartDay, sub.EndDay, sub.PriceBasis, sub.TaxCountry)
+}
+
+func (r *SubscriptionRepository) Cancel(ctx context.Context, id int64, endDate string, endDay *int) error {
+	const op = "repository.postgres.Cancel"
+	query := `UPDATE subscriptions SET end_date = $1, end_day = $3, cancelled_at = NOW(), updated_at = NOW() WHERE id = $2`
+
+	return r.mutate(ctx, op, id, 0, domain.EventCancelled, query, monthParam(endDate), id, endDay)
+}
+
+// Transfer передает подписку другому пользователю, история остается у подписки
+func (r *SubscriptionRepository) Transfer(ctx context.Context, id int64, userID uuid.UUID) error {
+	const op = "repository.postgres.Transfer"
+	query := `UPDATE subscriptions SET user_id = $1, updated_at = NOW() WHERE id = $2`
+
+	return r.mutate(ctx, op, id, 0, domain.EventTransferred, query, userID, id)
+}
+
+// SetPause ставит или снимает ручную паузу. Она же ведется в subscription_pauses:
+// постановка добавляет открытый диапазон, снятие закрывает его, а пустой
+// (сняли в том же месяце) удаляет
+func (r *SubscriptionRepository) SetPause(ctx context.Context, id int64, status string, pausedFrom, pausedUntil *string) error {
+	const op = "repository.postgres.SetPause"
+
+	// возобновление без pausedUntil удаляет открытую паузу: она так и не наступила
+	action := domain.EventResumed
+	pauses := `
+        WITH closed AS (
+            UPDATE subscription_pauses SET paused_to = $3
+            WHERE subscription_id = $4 AND paused_to IS NULL
+              AND TO_DATE($3::varchar, 'MM-YYYY') >= TO_DATE(paused_from, 'MM-YYYY')
+        ), dropped AS (
+            DELETE FROM subscription_pauses
+            WHERE subscription_id = $4 AND paused_to IS NULL
+              AND ($3::varchar IS NULL OR TO_DATE($3::varchar, 'MM-YYYY') < TO_DATE(paused_from, 'MM-YYYY'))
+        )`
+	if status == domain.StatusPaused {
+		action = domain.EventPaused
+		pauses = `
+        WITH opened AS (
+            INSERT INTO subscription_pauses(subscription_id, paused_from) VALUES($4, $2)
+        )`
+	}
+	query := pauses + `
+        UPDATE subscriptions SET status = $1, paused_from = $2, paused_until = $3, updated_at = NOW() WHERE id = $4`
+
+	return r.mutate(ctx, op, id, 0, action, query, status, pausedFrom, pausedUntil, id)
+}
+
+// AddPause планирует паузу [from, to], пересечения проверяет сервис
+func (r *SubscriptionRepository) AddPause(ctx context.Context, id int64, from, to string) error {
+	const op = "repository.postgres.AddPause"
+	query := `INSERT INTO subscription_pauses(subscription_id, paused_from, paused_to) VALUES($1, $2, $3)`
+
+	return r.mutate(ctx, op, id, 0, domain.EventPauseScheduled, query, id, from, to)
+}
+
+// DeletePause убирает запланированную паузу подписки
+func (r *SubscriptionRepository) DeletePause(ctx context.Context, id, pauseID int64) error {
+	const op = "repository.postgres.DeletePause"
+	query := `DELETE FROM subscription_pauses WHERE id = $1 AND subscription_id = $2`
+
+	return r.mutate(ctx, op, id, 0, domain.EventPauseUnscheduled, query, pauseID, id)
 }
 
 func (r *SubscriptionRepository) Delete(ctx context.Context, id int64) error {
@@ -101,15 +254,76 @@ func (r *SubscriptionRepository) Delete(ctx context.Context, id int64) error {
 		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
 	}
 	if rows == 0 {
-		return fmt.Errorf("%s: subscription with id %d not found", op, id)
+		return fmt.Errorf("%s: subscription %d: %w", op, id, domain.ErrNotFound)
 	}
 	return nil
 }
 
-func (r *SubscriptionRepository) List(ctx context.Context, userID uuid.UUID, filter domain.SubscriptionFilter) ([]domain.Subscription, error) {
-	const op = "repository.postgres.List"
+// DeleteByFilter удаляет подписки юзера (только сервиса serviceName, если он задан) и отдает
+// удаленные. Найденные строки и токен subject блокируются, check решает по ним, можно ли
+// удалять; токен гасится в той же транзакции, что и удаление
+func (r *SubscriptionRepository) DeleteByFilter(ctx context.Context, userID uuid.UUID, serviceName, subject string, check BulkConfirmCheck) ([]domain.Subscription, error) {
+	const op = "repository.postgres.DeleteByFilter"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE user_id = $1`
+	args := []interface{}{userID}
+
+	// тут точное совпадение, ILIKE для удаления слишком опасен
+	if serviceName != "" {
+		args = append(args, serviceName)
+		query += fmt.Sprintf(" AND service_name = $%d", len(args))
+	}
+
+	rows, err := tx.QueryContext(ctx, query+` ORDER BY id FOR UPDATE`, args...)
+	if err != nil {
+		r.log.Error("db error during bulk delete", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var matched []domain.Subscription
+	ids := []int64{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		matched = append(matched, *sub)
+		ids = append(ids, sub.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	if err := consumeConfirmation(ctx, tx, subject, func(tokenHash string, expiresAt time.Time) error {
+		return check(matched, tokenHash, expiresAt)
+	}); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM subscriptions WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		r.log.Error("db error during bulk delete", slog.String("op", op), slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+	return matched, nil
+}
 
-	query := `SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at 
+// общий запрос для List и Stream, без пагинации
+func listQuery(userID uuid.UUID, filter domain.SubscriptionFilter) (string, []interface{}) {
+	query := `SELECT ` + subscriptionColumns + `
               FROM subscriptions 
               WHERE user_id = $1`
 
@@ -121,25 +335,158 @@ func (r *SubscriptionRepository) List(ctx context.Context, userID uuid.UUID, fil
 		query += fmt.Sprintf(" AND service_name ILIKE $%d", len(args))
 	}
 
-	if filter.MinPrice > 0 {
-		args = append(args, filter.MinPrice)
+	if len(filter.ServiceNames) > 0 {
+		query += " AND LOWER(service_name) IN (" + lowerList(&args, filter.ServiceNames) + ")"
+	}
+
+	if len(filter.ExcludeServiceNames) > 0 {
+		query += " AND LOWER(service_name) NOT IN (" + lowerList(&args, filter.ExcludeServiceNames) + ")"
+	}
+
+	if filter.MinPrice != nil {
+		args = append(args, *filter.MinPrice)
 		query += fmt.Sprintf(" AND price >= $%d", len(args))
 	}
 
-	if filter.MaxPrice > 0 {
-		args = append(args, filter.MaxPrice)
+	if filter.MaxPrice != nil {
+		args = append(args, *filter.MaxPrice)
 		query += fmt.Sprintf(" AND price <= $%d", len(args))
 	}
 
+	if filter.Price != nil {
+		args = append(args, *filter.Price)
+		query += fmt.Sprintf(" AND price = $%d", len(args))
+	}
+
+	if filter.CategoryID != nil {
+		args = append(args, *filter.CategoryID)
+		query += fmt.Sprintf(" AND category_id = $%d", len(args))
+	}
+
+	if len(filter.Tags) > 0 {
+		args = append(args, pq.Array(filter.Tags))
+		query += fmt.Sprintf(" AND tags @> $%d", len(args))
+	}
+
+	// % использует trgm индекс, ILIKE ловит короткие подстроки, у которых мало триграмм
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+		query += fmt.Sprintf(" AND (service_name %% $%[1]d OR service_name ILIKE '%%' || $%[1]d || '%%')", len(args))
+	}
+
+	// те же правила, что deriveStatus в сервисе, месяц приходит параметром
+	if filter.Status != "" {
+		args = append(args, filter.StatusMonth)
+		month := fmt.Sprintf("$%d::date", len(args))
+		notPaused := ` AND status <> 'paused' AND NOT ` + sqlPausedIn(month)
+
+		switch filter.Status {
+		case domain.StatusPaused:
+			query += " AND (status = 'paused' OR " + sqlPausedIn(month) + ")"
+		case domain.StatusActive:
+			query += notPaused + ` AND start_date <= ` + month + `
+                   AND (end_date IS NULL OR end_date >= ` + month + `)`
+		case domain.StatusExpired:
+			query += notPaused + ` AND start_date <= ` + month + `
+                   AND end_date + MAKE_INTERVAL(months => grace_period_months) < ` + month
+		case domain.StatusGrace:
+			query += notPaused + ` AND start_date <= ` + month + `
+                   AND end_date < ` + month + `
+                   AND end_date + MAKE_INTERVAL(months => grace_period_months) >= ` + month
+		case domain.StatusUpcoming:
+			query += notPaused + ` AND start_date > ` + month
+		}
+	}
+
+	if filter.StartAfter != nil {
+		args = append(args, *filter.StartAfter)
+		query += fmt.Sprintf(" AND start_date >= $%d", len(args))
+	}
+
+	if filter.StartBefore != nil {
+		args = append(args, *filter.StartBefore)
+		query += fmt.Sprintf(" AND start_date <= $%d", len(args))
+	}
+
+	// бессрочная подписка заканчивается позже любой даты
+	if filter.EndsAfter != nil {
+		args = append(args, *filter.EndsAfter)
+		query += fmt.Sprintf(" AND (end_date IS NULL OR end_date >= $%d)", len(args))
+	}
+
+	if filter.EndsBefore != nil {
+		args = append(args, *filter.EndsBefore)
+		query += fmt.Sprintf(" AND end_date IS NOT NULL AND end_date <= $%d", len(args))
+	}
+
+	return query, args
+}
+
+// плейсхолдеры для IN через запятую, значения в нижнем регистре уходят в args
+func lowerList(args *[]interface{}, values []string) string {
+	holders := make([]string, 0, len(values))
+	for _, v := range values {
+		*args = append(*args, strings.ToLower(v))
+		holders = append(holders, fmt.Sprintf("$%d", len(*args)))
+	}
+	return strings.Join(holders, ", ")
+}
+
+// выражения сортировки, сервис уже проверил поле по domain.SortFields
+var sortColumns = map[string]string{
+	"price":      "price",
+	"start_date": "start_date",
+	"created_at": "created_at",
+}
+
+// listOrder всегда заканчивается на id: любая сортировка списка полная,
+// и равные цены или даты не переставляются между запросами страниц
+func listOrder(filter domain.SubscriptionFilter) string {
+	dir := "ASC"
+	if filter.SortDesc {
+		dir = "DESC"
+	}
+
+	col, ok := sortColumns[filter.Sort]
+	if !ok {
+		return "id " + dir
+	}
+	// id вторым ключом, чтоб порядок равных значений не прыгал между страницами
+	return col + " " + dir + ", id " + dir
+}
+
+func (r *SubscriptionRepository) List(ctx context.Context, userID uuid.UUID, filter domain.SubscriptionFilter) ([]domain.Subscription, error) {
+	const op = "repository.postgres.List"
+
+	query, args := listQuery(userID, filter)
+
+	// курсор идет в ту же сторону, что и порядок по id
+	if filter.AfterID > 0 {
+		args = append(args, filter.AfterID)
+		cmp := ">"
+		if filter.SortDesc {
+			cmp = "<"
+		}
+		query += fmt.Sprintf(" AND id %s $%d", cmp, len(args))
+	}
+
 	limit := filter.Limit
 	if limit <= 0 {
 		limit = 10
 	}
 
+	// стабильный порядок, иначе страницы могут пересекаться
+	order := listOrder(filter)
+	// при поиске без явной сортировки - самые похожие первыми
+	if filter.Query != "" && filter.Sort == "" {
+		args = append(args, filter.Query)
+		order = fmt.Sprintf("similarity(service_name, $%d) DESC, %s", len(args), order)
+	}
+
 	args = append(args, limit)
-	query += fmt.Sprintf(" LIMIT $%d", len(args))
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", order, len(args))
 
-	if filter.Offset > 0 {
+	if filter.Offset > 0 && filter.AfterID == 0 {
 		args = append(args, filter.Offset)
 		query += fmt.Sprintf(" OFFSET $%d", len(args))
 	}
@@ -153,66 +500,448 @@ func (r *SubscriptionRepository) List(ctx context.Context, userID uuid.UUID, fil
 
 	var subs []domain.Subscription
 	for rows.Next() {
-		var sub domain.Subscription
-		err := rows.Scan(
-			&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID,
-			&sub.StartDate, &sub.EndDate, &sub.CreatedAt, &sub.UpdatedAt,
-		)
+		sub, err := scanSubscription(rows)
 		if err != nil {
 			return nil, fmt.Errorf("%s: scan error: %w", op, err)
 		}
-		subs = append(subs, sub)
+		subs = append(subs, *sub)
 	}
 
 	return subs, nil
 }
 
+// Stream отдает все подписки по фильтру построчно прямо из курсора, без слайса в памяти.
+// Limit и Offset игнорируются. Соединение держится, пока итерация не закончится
+func (r *SubscriptionRepository) Stream(ctx context.Context, userID uuid.UUID, filter domain.SubscriptionFilter) iter.Seq2[*domain.Subscription, error] {
+	const op = "repository.postgres.Stream"
+
+	return func(yield func(*domain.Subscription, error) bool) {
+		query, args := listQuery(userID, filter)
+		query += " ORDER BY id"
+
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			r.log.Error("stream fetch failed", slog.String("op", op), slog.String("err", err.Error()))
+			yield(nil, fmt.Errorf("%s: %w", op, err))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			sub, err := scanSubscription(rows)
+			if err != nil {
+				yield(nil, fmt.Errorf("%s: scan error: %w", op, err))
+				return
+			}
+			if !yield(sub, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(nil, fmt.Errorf("%s: %w", op, err))
+		}
+	}
+}
+
 func (r *SubscriptionRepository) GetTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, from, to time.Time) ([]domain.Subscription, error) {
 	const op = "repository.postgres.GetForPeriod"
 
 	// запрос для расчета стоимости за период
-	query := `
-        SELECT service_name,price, start_date, end_date
-        FROM subscriptions 
-        WHERE user_id = $1 
-          AND TO_DATE(start_date, 'MM-YYYY') <= $3
-          AND (end_date IS NULL OR TO_DATE(end_date, 'MM-YYYY') >= $2)`
+	query := costQuery + ` AND s.user_id = $3`
 
-	args := []interface{}{userID, from, to}
+	args := []interface{}{from, to, userID}
 	if serviceName != "" {
-		query += " AND service_name = $4"
+		query += " AND s.service_name = $4"
 		args = append(args, serviceName)
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	subs, err := r.costSubscriptions(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return subs, nil
+}
+
+// ForPeriod - подписки всех пользователей, пересекающиеся с периодом, для бухгалтерских выгрузок
+func (r *SubscriptionRepository) ForPeriod(ctx context.Context, from, to time.Time) ([]domain.Subscription, error) {
+	const op = "repository.postgres.ForPeriod"
+
+	subs, err := r.costSubscriptions(ctx, costQuery+` ORDER BY s.user_id, s.id`, from, to)
+	if err != nil {
+		r.log.Error("period subscriptions failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return subs, nil
+}
+
+// Signups - месяц первой заведенной подписки каждого пользователя, для когорт
+func (r *SubscriptionRepository) Signups(ctx context.Context) (map[uuid.UUID]time.Time, error) {
+	const op = "repository.postgres.Signups"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT user_id, DATE_TRUNC('month', MIN(created_at))::date FROM subscriptions GROUP BY user_id`)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	defer rows.Close()
 
+	signups := make(map[uuid.UUID]time.Time)
+	for rows.Next() {
+		var (
+			userID uuid.UUID
+			month  time.Time
+		)
+		if err := rows.Scan(&userID, &month); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		signups[userID] = month
+	}
+	return signups, rows.Err()
+}
+
+// подписки, пересекающиеся с периодом [$1, $2], с полями для расчета расходов
+var costQuery = `
+        SELECT s.id, s.user_id, s.service_name, s.price, s.start_date, s.end_date, s.status, s.paused_from, s.paused_until, s.cancelled_at,
+               s.category_id, COALESCE(c.name, ''), s.currency, s.billing_period, s.trial_end_date, s.trial_price,
+               s.start_day, s.end_day, s.price_basis, s.tax_country, ` + pausesColumn("s") + `
+        FROM subscriptions s
+        LEFT JOIN categories c ON c.id = s.category_id
+        WHERE s.start_date <= $2
+          AND (s.end_date IS NULL OR s.end_date >= $1)`
+
+func (r *SubscriptionRepository) costSubscriptions(ctx context.Context, query string, args ...any) ([]domain.Subscription, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
 	var subs []domain.Subscription
 	for rows.Next() {
 		var s domain.Subscription
-		if err := rows.Scan(&s.ServiceName, &s.Price, &s.StartDate, &s.EndDate); err != nil {
+		var pauses []byte
+		if err := rows.Scan(&s.ID, &s.UserID, &s.ServiceName, &s.Price, monthScan{&s.StartDate}, nullMonthScan{&s.EndDate}, &s.Status, &s.PausedFrom, &s.PausedUntil, &s.CancelledAt,
+			&s.CategoryID, &s.CategoryName, &s.Currency, &s.BillingPeriod, &s.TrialEndDate, &s.TrialPrice,
+			&s.StartDay, &s.EndDay, &s.PriceBasis, &s.TaxCountry, &pauses); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(pauses, &s.Pauses); err != nil {
 			return nil, err
 		}
 		subs = append(subs, s)
 	}
-	return subs, nil
+	return subs, rows.Err()
+}
+
+// число месяцев между датами включительно, как countMonths в сервисе
+func sqlMonths(from, to string) string {
+	return fmt.Sprintf("GREATEST(0, (EXTRACT(YEAR FROM %[2]s) - EXTRACT(YEAR FROM %[1]s)) * 12 + EXTRACT(MONTH FROM %[2]s) - EXTRACT(MONTH FROM %[1]s) + 1)::int", from, to)
+}
+
+// двенадцатые доли цены на месяц по billing_period, как domain.BillingTwelfths
+const sqlBillingTwelfths = `CASE billing_period WHEN 'weekly' THEN 52 WHEN 'quarterly' THEN 4 WHEN 'yearly' THEN 1 ELSE 12 END`
+
+// sqlBilled - оплачиваемые месяцы подписок, прошедших userCond, общий CTE для AggregateCost и BatchCost.
+// $2, $3 - период, $4 - фильтр сервиса или пустая строка, $5 - excludeFinalMonth
+func sqlBilled(userCond string) string {
+	return `
+        WITH periods AS (
+            SELECT id, user_id, service_name, price, trial_price, currency, ` + sqlBillingTwelfths + ` AS twelfths,
+                GREATEST(start_date, $2::date) AS s,
+                LEAST(COALESCE(CASE WHEN $5 AND cancelled_at IS NOT NULL
+                    THEN (end_date - INTERVAL '1 month')::date
+                    ELSE end_date END, $3::date), $3::date) AS e,
+                TO_DATE(trial_end_date, 'MM-YYYY') AS te
+            FROM subscriptions
+            WHERE ` + userCond + `
+              AND start_date <= $3
+              AND (end_date IS NULL OR end_date >= $2)
+              AND ($4 = '' OR service_name = $4)
+        ), billed AS (
+            SELECT user_id, service_name, price, trial_price, currency, twelfths,
+                ` + sqlMonths("s", "e") + ` - ` + sqlPausedMonths("periods.id", "s", "e") + ` AS months,
+                CASE WHEN te IS NULL THEN 0
+                    ELSE ` + sqlMonths("s", "LEAST(e, te)") + ` - ` + sqlPausedMonths("periods.id", "s", "LEAST(e, te)") + ` END AS trial_months
+            FROM periods
+        )`
 }
 
-func (r *SubscriptionRepository) Exists(ctx context.Context, userID uuid.UUID, serviceName string) (bool, error) {
+// стоимость строки billed, до копейки округляется каждая подписка отдельно, как в Go
+const sqlBilledCost = `(ROUND(price::numeric * (months - trial_months) * twelfths / 12) + ROUND(trial_price::numeric * trial_months * twelfths / 12))::bigint`
+
+// AggregateCost считает расходы на стороне базы, без выгрузки подписок в Go.
+// excludeFinalMonth - у отмененных подписок месяц end_date не считается
+func (r *SubscriptionRepository) AggregateCost(ctx context.Context, userID uuid.UUID, serviceName string, from, to time.Time, excludeFinalMonth bool) ([]domain.CostDetail, error) {
+	const op = "repository.postgres.AggregateCost"
+
+	query := sqlBilled("user_id = $1") + `
+        SELECT service_name, months, ` + sqlBilledCost + `, currency
+        FROM billed
+        WHERE months > 0`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to, serviceName, excludeFinalMonth)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var details []domain.CostDetail
+	for rows.Next() {
+		var d domain.CostDetail
+		if err := rows.Scan(&d.ServiceName, &d.Months, &d.Cost, &d.Currency); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		details = append(details, d)
+	}
+	return details, rows.Err()
+}
+
+// BatchCost - расходы многих пользователей одним запросом, суммы по пользователю и валюте
+func (r *SubscriptionRepository) BatchCost(ctx context.Context, userIDs []uuid.UUID, from, to time.Time, excludeFinalMonth bool) (map[uuid.UUID][]domain.CurrencyCost, error) {
+	const op = "repository.postgres.BatchCost"
+
+	ids := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = id.String()
+	}
+
+	query := sqlBilled("user_id = ANY($1::uuid[])") + `
+        SELECT user_id, currency, SUM(` + sqlBilledCost + `)::bigint
+        FROM billed
+        WHERE months > 0
+        GROUP BY user_id, currency
+        ORDER BY user_id, currency`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids), from, to, "", excludeFinalMonth)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	totals := make(map[uuid.UUID][]domain.CurrencyCost, len(userIDs))
+	for rows.Next() {
+		var userID uuid.UUID
+		var c domain.CurrencyCost
+		if err := rows.Scan(&userID, &c.Currency, &c.Cost); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		totals[userID] = append(totals[userID], c)
+	}
+	return totals, rows.Err()
+}
+
+// строка группировки расходов, незадействованные ключи пустые
+type CostRow struct {
+	ServiceName string
+	Month       *time.Time
+	Months      int
+	Cost        domain.Money
+}
+
+// GroupCost раскладывает период на месяцы через generate_series и группирует
+// оплачиваемые месяцы по сервису, месяцу или обоим. Правила те же, что в AggregateCost
+func (r *SubscriptionRepository) GroupCost(ctx context.Context, userID uuid.UUID, serviceName string, from, to time.Time, excludeFinalMonth, byService, byMonth bool) ([]CostRow, error) {
+	const op = "repository.postgres.GroupCost"
+
+	// месяц первым ключом, строки одного месяца идут подряд
+	serviceCol, monthCol := "''", "NULL::date"
+	var keys []string
+	if byMonth {
+		monthCol = "m"
+		keys = append(keys, "m")
+	}
+	if byService {
+		serviceCol = "service_name"
+		keys = append(keys, "service_name")
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no group keys", op)
+	}
+	group := strings.Join(keys, ", ")
+
+	query := `
+        WITH months AS (
+            SELECT generate_series($2::date, $3::date, INTERVAL '1 month')::date AS m
+        ), subs AS (
+            SELECT id, service_name, price, trial_price, ` + sqlBillingTwelfths + ` AS twelfths,
+                start_date AS s,
+                COALESCE(CASE WHEN $5 AND cancelled_at IS NOT NULL
+                    THEN (end_date - INTERVAL '1 month')::date
+                    ELSE end_date END, $3::date) AS e,
+                TO_DATE(trial_end_date, 'MM-YYYY') AS te
+            FROM subscriptions
+            WHERE user_id = $1
+              AND start_date <= $3
+              AND (end_date IS NULL OR end_date >= $2)
+              AND ($4 = '' OR service_name = $4)
+        ), billed AS (
+            SELECT subs.service_name, months.m, subs.twelfths,
+                CASE WHEN months.m <= subs.te THEN subs.trial_price ELSE subs.price END AS price
+            FROM subs
+            JOIN months ON months.m BETWEEN subs.s AND subs.e
+            WHERE NOT ` + sqlPausedAt("subs.id", "months.m") + `
+        )
+        SELECT ` + serviceCol + `, ` + monthCol + `, COUNT(*)::int, ROUND(SUM(price::numeric * twelfths) / 12)::bigint
+        FROM billed
+        GROUP BY ` + group + `
+        ORDER BY ` + group
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to, serviceName, excludeFinalMonth)
+	if err != nil {
+		r.log.Error("group cost failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var res []CostRow
+	for rows.Next() {
+		var row CostRow
+		if err := rows.Scan(&row.ServiceName, &row.Month, &row.Months, &row.Cost); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		res = append(res, row)
+	}
+	return res, rows.Err()
+}
+
+// строка агрегата по одному сервису пользователя
+type ServiceRow struct {
+	ServiceName  string
+	Count        int
+	CurrentPrice domain.Money
+	Active       bool
+}
+
+// ServiceTotals группирует подписки пользователя по сервису. Текущая цена берется
+// у подписки с самой поздней датой начала, Active - есть активная в месяце month
+func (r *SubscriptionRepository) ServiceTotals(ctx context.Context, userID uuid.UUID, month time.Time) ([]ServiceRow, error) {
+	const op = "repository.postgres.ServiceTotals"
+
+	query := `
+        SELECT service_name,
+               COUNT(*),
+               (ARRAY_AGG(price ORDER BY start_date DESC, id DESC))[1],
+               BOOL_OR(status <> 'paused' AND NOT ` + sqlPausedIn("$2::date") + `
+                   AND start_date <= $2::date
+                   AND (end_date IS NULL OR end_date >= $2::date))
+        FROM subscriptions
+        WHERE user_id = $1
+        GROUP BY service_name
+        ORDER BY service_name`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, month)
+	if err != nil {
+		r.log.Error("service totals failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var res []ServiceRow
+	for rows.Next() {
+		var row ServiceRow
+		if err := rows.Scan(&row.ServiceName, &row.Count, &row.CurrentPrice, &row.Active); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		res = append(res, row)
+	}
+	return res, rows.Err()
+}
+
+// группы фасетов в FacetRow
+const (
+	FacetPrice    = "price"
+	FacetStatus   = "status"
+	FacetCategory = "category"
+)
+
+// FacetRow - число подписок в одной группе фасетов, заполнены только поля своей группы
+type FacetRow struct {
+	Facet string
+	// номер корзины из width_bucket: 0 - дешевле первой границы
+	Bucket       int
+	Status       string
+	CategoryID   *int64
+	CategoryName string
+	Count        int
+}
+
+// статус в месяце month, те же правила, что фильтр status в listQuery и deriveStatus в сервисе
+func sqlStatusAt(month string) string {
+	return `CASE
+            WHEN status = 'paused' OR ` + sqlPausedIn(month) + ` THEN 'paused'
+            WHEN start_date > ` + month + ` THEN 'upcoming'
+            WHEN end_date IS NULL OR end_date >= ` + month + ` THEN 'active'
+            WHEN end_date + MAKE_INTERVAL(months => grace_period_months) >= ` + month + ` THEN 'grace'
+            ELSE 'expired'
+        END`
+}
+
+// Facets считает подписки пользователя одним запросом сразу по трем группировкам:
+// корзина цены по границам bounds, статус в месяце month и категория
+func (r *SubscriptionRepository) Facets(ctx context.Context, userID uuid.UUID, bounds []domain.Money, month time.Time) ([]FacetRow, error) {
+	const op = "repository.postgres.Facets"
+
+	thresholds := make([]int64, 0, len(bounds))
+	for _, b := range bounds {
+		thresholds = append(thresholds, int64(b))
+	}
+
+	// GROUPING - битовая маска несгруппированных колонок, по ней видно группу строки
+	query := `
+        SELECT GROUPING(f.bucket, f.status, f.category_id),
+               COALESCE(f.bucket, 0), COALESCE(f.status, ''), f.category_id, COALESCE(MAX(c.name), ''), COUNT(*)
+        FROM (
+            SELECT WIDTH_BUCKET(price::bigint, $2::bigint[]) AS bucket,
+                   ` + sqlStatusAt("$3::date") + ` AS status,
+                   category_id
+            FROM subscriptions
+            WHERE user_id = $1
+        ) f
+        LEFT JOIN categories c ON c.id = f.category_id
+        GROUP BY GROUPING SETS ((f.bucket), (f.status), (f.category_id))`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, pq.Array(thresholds), month)
+	if err != nil {
+		r.log.Error("facets fetch failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var res []FacetRow
+	for rows.Next() {
+		var (
+			row      FacetRow
+			grouping int
+		)
+		if err := rows.Scan(&grouping, &row.Bucket, &row.Status, &row.CategoryID, &row.CategoryName, &row.Count); err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		switch grouping {
+		case 0b011:
+			row.Facet = FacetPrice
+		case 0b101:
+			row.Facet = FacetStatus
+		default:
+			row.Facet = FacetCategory
+		}
+		res = append(res, row)
+	}
+	return res, rows.Err()
+}
+
+// Exists - у пользователя есть подписка на сервис, которая не закончилась до month
+func (r *SubscriptionRepository) Exists(ctx context.Context, userID uuid.UUID, serviceName string, month time.Time) (bool, error) {
 	const op = "repository.postgres.Exists"
 	query := `select exists(
     select 1 from subscriptions 
     where user_id = $1 
       and service_name = $2 
-      and (end_date IS NULL OR TO_DATE(end_date, 'MM-YYYY') >= DATE_TRUNC('month', NOW()))
+      and (end_date IS NULL OR end_date >= $3)
 )`
 
 	var exists bool
 
-	err := r.db.QueryRowContext(ctx, query, userID, serviceName).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, query, userID, serviceName, month).Scan(&exists)
 	if err != nil {
 		r.log.Error("existence check fail",
 			slog.String("op", op), slog.String("error", err.Error()),
@@ -223,24 +952,148 @@ func (r *SubscriptionRepository) Exists(ctx context.Context, userID uuid.UUID, s
 	return exists, nil
 }
 
-func (r *SubscriptionRepository) Extend(ctx context.Context, id int64, newEndDate string, newPrice int) error {
+func (r *SubscriptionRepository) Extend(ctx context.Context, id int64, newEndDate string, endDay *int, newPrice domain.Money, version int64) error {
 	const op = "repository.postgres.Extend"
-	// обновляем дату и прайс
-	query := `UPDATE subscriptions SET end_date = $1, price = $2, updated_at = NOW() WHERE id = $3`
+	// обновляем дату и прайс, день старого конца к новому месяцу не относится.
+	// Продленная подписка больше не считается ушедшей
+	query := `UPDATE subscriptions SET end_date = $1, end_day = $4, price = $2, churned_at = NULL, updated_at = NOW() WHERE id = $3`
+
+	return r.mutate(ctx, op, id, version, domain.EventExtended, query, monthParam(newEndDate), newPrice, id, endDay)
+}
+
+// Upcoming отдает подписки, у которых end_date в [from, until], ближайшие первыми
+// UpdateTags добавляет и убирает метки одним запросом, результат без повторов и по алфавиту
+func (r *SubscriptionRepository) UpdateTags(ctx context.Context, id int64, add, remove []string) error {
+	const op = "repository.postgres.UpdateTags"
+	query := `UPDATE subscriptions
+    SET tags = ARRAY(SELECT DISTINCT t FROM unnest(tags || $1::text[]) t WHERE t <> ALL($2::text[]) ORDER BY t),
+        updated_at = NOW()
+    WHERE id = $3`
 
-	res, err := r.db.ExecContext(ctx, query, newEndDate, newPrice, id)
+	return r.mutate(ctx, op, id, 0, domain.EventUpdated, query, pq.Array(add), pq.Array(remove), id)
+}
+
+func (r *SubscriptionRepository) Upcoming(ctx context.Context, userID uuid.UUID, from, until time.Time) ([]domain.Subscription, error) {
+	const op = "repository.postgres.Upcoming"
+
+	query := `SELECT ` + subscriptionColumns + `
+              FROM subscriptions
+              WHERE user_id = $1
+                AND ((end_date IS NOT NULL AND end_date BETWEEN $2 AND $3)
+                  OR (trial_flagged_at IS NOT NULL AND cancelled_at IS NULL AND TO_DATE(trial_end_date, 'MM-YYYY') >= $2))
+              ORDER BY COALESCE(end_date, TO_DATE(trial_end_date, 'MM-YYYY')), id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from, until)
 	if err != nil {
-		r.log.Error("extend query exec failed", slog.String("op", op), slog.String("error", err.Error()))
-		return fmt.Errorf("%s: %w", op, err)
+		r.log.Error("upcoming fetch failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer rows.Close()
 
-	rows, err := res.RowsAffected()
+	var subs []domain.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, rows.Err()
+}
+
+// FlagTrialConversions отмечает подписки, у которых month - последний месяц триала,
+// а дальше они продолжаются платно. Каждая отмечается один раз, отмеченные возвращаются
+func (r *SubscriptionRepository) FlagTrialConversions(ctx context.Context, month string) ([]domain.Subscription, error) {
+	const op = "repository.postgres.FlagTrialConversions"
+
+	query := `UPDATE subscriptions SET trial_flagged_at = NOW()
+              WHERE trial_end_date = $1
+                AND trial_flagged_at IS NULL
+                AND cancelled_at IS NULL
+                AND (end_date IS NULL OR end_date > TO_DATE(trial_end_date, 'MM-YYYY'))
+              RETURNING ` + subscriptionColumns
+
+	rows, err := r.db.QueryContext(ctx, query, month)
 	if err != nil {
-		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+		r.log.Error("trial flagging failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	if rows == 0 {
-		return fmt.Errorf("%s: subscription not found", op)
+	defer rows.Close()
+
+	var subs []domain.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		subs = append(subs, *sub)
 	}
+	return subs, rows.Err()
+}
 
-	return nil
+// sqlChurned - подписка истекла вместе с льготой до месяца $1, а у пользователя нет другой
+// подписки на тот же сервис, которая продолжает ее сразу после льготы или позже
+const sqlChurned = `end_date IS NOT NULL
+                AND end_date + MAKE_INTERVAL(months => grace_period_months) < $1::date
+                AND NOT EXISTS (
+                    SELECT 1 FROM subscriptions n
+                    WHERE n.user_id = subscriptions.user_id
+                      AND LOWER(n.service_name) = LOWER(subscriptions.service_name)
+                      AND n.id <> subscriptions.id
+                      AND (n.end_date IS NULL OR n.end_date > subscriptions.end_date)
+                      AND n.start_date <= subscriptions.end_date + MAKE_INTERVAL(months => subscriptions.grace_period_months + 1))`
+
+// FlagChurned отмечает подписки, ушедшие к месяцу month. Каждая отмечается один раз,
+// отмеченные возвращаются
+func (r *SubscriptionRepository) FlagChurned(ctx context.Context, month time.Time) ([]domain.Subscription, error) {
+	const op = "repository.postgres.FlagChurned"
+
+	query := `UPDATE subscriptions SET churned_at = NOW()
+              WHERE churned_at IS NULL
+                AND ` + sqlChurned + `
+              RETURNING ` + subscriptionColumns
+
+	rows, err := r.db.QueryContext(ctx, query, month)
+	if err != nil {
+		r.log.Error("churn flagging failed", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var subs []domain.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, rows.Err()
+}
+
+// Sample отдает детерминированную выборку: одинаковый seed - одинаковые строки
+func (r *SubscriptionRepository) Sample(ctx context.Context, limit int, seed string) ([]domain.Subscription, error) {
+	const op = "repository.postgres.Sample"
+
+	query := `SELECT ` + subscriptionColumns + `
+              FROM subscriptions
+              ORDER BY md5(id::text || $1), id
+              LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, seed, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var subs []domain.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		subs = append(subs, *sub)
+	}
+
+	return subs, rows.Err()
 }
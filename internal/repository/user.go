@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+type UserInterface interface {
+	// Upsert создает или обновляет пользователя по id, true - создан
+	Upsert(ctx context.Context, u *domain.User) (bool, error)
+	Get(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	GetByExternalID(ctx context.Context, externalID string) (*domain.User, error)
+	// active nil - все
+	List(ctx context.Context, active *bool) ([]domain.User, error)
+	SetActive(ctx context.Context, id uuid.UUID, active bool) error
+}
+
+type UserRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+var _ UserInterface = (*UserRepository)(nil)
+
+func NewUserRepository(db *sql.DB, log *slog.Logger) *UserRepository {
+	return &UserRepository{
+		db:  db,
+		log: log.With(slog.String("component", "repository/user")),
+	}
+}
+
+const userColumns = `id, COALESCE(external_id, ''), email, display_name, active, created_at, updated_at, deactivated_at`
+
+func scanUser(row rowScanner) (*domain.User, error) {
+	var u domain.User
+	if err := row.Scan(&u.ID, &u.ExternalID, &u.Email, &u.DisplayName, &u.Active, &u.CreatedAt, &u.UpdatedAt, &u.DeactivatedAt); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// активность тут не меняется, за нее отвечает SetActive
+func (r *UserRepository) Upsert(ctx context.Context, u *domain.User) (bool, error) {
+	const op = "repository.postgres.user.Upsert"
+
+	var created bool
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO users(id, external_id, email, display_name)
+        VALUES ($1, NULLIF($2, ''), $3, $4)
+        ON CONFLICT (id) DO UPDATE SET
+            external_id = COALESCE(EXCLUDED.external_id, users.external_id),
+            email = EXCLUDED.email,
+            display_name = EXCLUDED.display_name,
+            updated_at = NOW()
+        RETURNING (xmax = 0)`,
+		u.ID, u.ExternalID, u.Email, u.DisplayName).Scan(&created)
+	if err != nil {
+		r.log.Error("user upsert failed", slog.String("op", op), slog.String("error", err.Error()))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return created, nil
+}
+
+func (r *UserRepository) Get(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	const op = "repository.postgres.user.Get"
+
+	u, err := scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: user %s: %w", op, id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return u, nil
+}
+
+func (r *UserRepository) GetByExternalID(ctx context.Context, externalID string) (*domain.User, error) {
+	const op = "repository.postgres.user.GetByExternalID"
+
+	u, err := scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE external_id = $1`, externalID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: user %q: %w", op, externalID, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return u, nil
+}
+
+func (r *UserRepository) List(ctx context.Context, active *bool) ([]domain.User, error) {
+	const op = "repository.postgres.user.List"
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+userColumns+` FROM users
+        WHERE $1::boolean IS NULL OR active = $1
+        ORDER BY created_at, id`, active)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan error: %w", op, err)
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+func (r *UserRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	const op = "repository.postgres.user.SetActive"
+
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE users SET active = $2, updated_at = NOW(),
+            deactivated_at = CASE WHEN $2 THEN NULL ELSE COALESCE(deactivated_at, NOW()) END
+        WHERE id = $1`, id, active)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%s: user %s: %w", op, id, domain.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/problem"
+)
+
+// Envelope - тело каждого json ответа с данными: сами данные в data, служебное в meta.
+// Ошибки в конверт не заворачиваются, их отдает problem.Write в application/problem+json
+type Envelope struct {
+	Data any  `json:"data"`
+	Meta Meta `json:"meta"`
+}
+
+// Meta - служебная часть ответа
+type Meta struct {
+	// тот же id, что в заголовке X-Request-ID и в ошибках
+	RequestID string `json:"request_id,omitempty" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	// только у списков с пагинацией
+	Page *Page `json:"page,omitempty"`
+}
+
+// Page - пагинация списка
+type Page struct {
+	Limit  int `json:"limit" example:"10"`
+	Offset int `json:"offset" example:"0"`
+	// сколько элементов в этом ответе
+	Count int `json:"count" example:"10"`
+	// keyset пагинация: курсор следующей страницы, пустой - страница последняя
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJrIjoic3Vicy..."`
+}
+
+// JSON отвечает status и data в конверте
+func JSON(w http.ResponseWriter, status int, data any) {
+	write(w, status, data, Meta{})
+}
+
+// List как JSON, но с пагинацией в meta.page
+func List(w http.ResponseWriter, data any, page Page) {
+	write(w, http.StatusOK, data, Meta{Page: &page})
+}
+
+func write(w http.ResponseWriter, status int, data any, meta Meta) {
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	meta.RequestID = h.Get(problem.RequestIDHeader)
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(Envelope{Data: data, Meta: meta})
+}
@@ -0,0 +1,137 @@
+// Package rpc - интерцепторы и CORS для Connect ручек, сгенерированных из api/proto
+// в gen/. Повторяют HTTP middleware, чтобы две поверхности вели себя одинаково
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// logging пишет каждый вызов, как LogginMiddleware для HTTP
+type logging struct {
+	log *slog.Logger
+}
+
+func Logging(log *slog.Logger) connect.Interceptor {
+	return &logging{log: log}
+}
+
+func (i *logging) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		t := time.Now()
+		resp, err := next(ctx, req)
+		i.write(req.Spec().Procedure, t, err)
+		return resp, err
+	}
+}
+
+func (i *logging) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *logging) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		t := time.Now()
+		err := next(ctx, conn)
+		i.write(conn.Spec().Procedure, t, err)
+		return err
+	}
+}
+
+func (i *logging) write(procedure string, t time.Time, err error) {
+	attrs := []any{
+		slog.String("procedure", procedure),
+		slog.Duration("duration", time.Since(t)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("code", connect.CodeOf(err).String()), slog.String("err", err.Error()))
+	}
+	i.log.Info("rpc processed", attrs...)
+}
+
+// Recover превращает панику в internal, как RecoverMiddleware
+func Recover(log *slog.Logger) connect.HandlerOption {
+	return connect.WithRecover(func(ctx context.Context, spec connect.Spec, _ http.Header, p any) error {
+		log.Error("rpc panic recovred", slog.Any("err", p), slog.String("procedure", spec.Procedure))
+		return connect.NewError(connect.CodeInternal, errors.New("internal error"))
+	})
+}
+
+// auth проверяет Authorization: Bearer <token>
+type auth struct {
+	token string
+}
+
+// Auth - проверка токена, пустой токен - проверка выключена
+func Auth(token string) connect.Interceptor {
+	return &auth{token: token}
+}
+
+func (i *auth) check(header http.Header) error {
+	if i.token == "" {
+		return nil
+	}
+	got, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(i.token)) != 1 {
+		return connect.NewError(connect.CodeUnauthenticated, errors.New("invalid or missing token"))
+	}
+	return nil
+}
+
+func (i *auth) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if err := i.check(req.Header()); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (i *auth) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *auth) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := i.check(conn.RequestHeader()); err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+// CORS пускает браузерных клиентов с перечисленных origin, "*" - любых
+func CORS(origins []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(origins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin != "" && (slices.Contains(origins, "*") || slices.Contains(origins, origin)) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+				// grpc-web отдает статус в заголовках, браузер должен их видеть
+				w.Header().Set("Access-Control-Expose-Headers", "Content-Type, Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin")
+
+				if r.Method == http.MethodOptions {
+					w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+					w.Header().Set("Access-Control-Allow-Headers",
+						"Content-Type, Authorization, Connect-Protocol-Version, Connect-Timeout-Ms, Grpc-Timeout, X-Grpc-Web, X-User-Agent")
+					w.Header().Set("Access-Control-Max-Age", "7200")
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
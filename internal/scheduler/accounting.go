@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// AccountingExport формирует выгрузку для бухгалтерии, как только месяц закрылся
+type AccountingExport struct {
+	exports  service.AccountingServiceInterface
+	interval time.Duration
+	log      *slog.Logger
+
+	lastRun atomic.Int64 // unix nano последней успешной проверки
+}
+
+func NewAccountingExport(exports service.AccountingServiceInterface, interval time.Duration, log *slog.Logger) *AccountingExport {
+	return &AccountingExport{
+		exports:  exports,
+		interval: interval,
+		log:      log.With(slog.String("component", "scheduler/accounting")),
+	}
+}
+
+func (j *AccountingExport) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.log.Info("accounting exporter started", slog.Duration("interval", j.interval))
+	for {
+		j.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			j.log.Info("accounting exporter stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// время последней проверки, нулевое если еще не было
+func (j *AccountingExport) LastRun() time.Time {
+	ns := j.lastRun.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func (j *AccountingExport) runOnce(ctx context.Context) {
+	// выгрузка уже есть - ExportClosed ничего не делает
+	if _, err := j.exports.ExportClosed(ctx); err != nil {
+		j.log.Error("accounting export failed", slog.String("err", err.Error()))
+		return
+	}
+	j.lastRun.Store(time.Now().UnixNano())
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// CohortAggregation пересчитывает агрегаты продуктовой аналитики: когорты и статистику сервисов
+type CohortAggregation struct {
+	analytics service.AnalyticsServiceInterface
+	interval  time.Duration
+	log       *slog.Logger
+
+	lastRun atomic.Int64 // unix nano последнего успешного пересчета
+}
+
+func NewCohortAggregation(analytics service.AnalyticsServiceInterface, interval time.Duration, log *slog.Logger) *CohortAggregation {
+	return &CohortAggregation{
+		analytics: analytics,
+		interval:  interval,
+		log:       log.With(slog.String("component", "scheduler/analytics")),
+	}
+}
+
+func (j *CohortAggregation) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.log.Info("cohort aggregation started", slog.Duration("interval", j.interval))
+	for {
+		j.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			j.log.Info("cohort aggregation stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// время последнего пересчета, нулевое если еще не было
+func (j *CohortAggregation) LastRun() time.Time {
+	ns := j.lastRun.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func (j *CohortAggregation) runOnce(ctx context.Context) {
+	if _, err := j.analytics.RebuildCohorts(ctx); err != nil {
+		j.log.Error("cohort aggregation failed", slog.String("err", err.Error()))
+		return
+	}
+	if _, err := j.analytics.RebuildServiceStats(ctx); err != nil {
+		j.log.Error("service stats aggregation failed", slog.String("err", err.Error()))
+		return
+	}
+	j.lastRun.Store(time.Now().UnixNano())
+}
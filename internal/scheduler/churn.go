@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// ChurnCheck отмечает подписки, которые истекли после льготы и не продлены
+type ChurnCheck struct {
+	subs     service.SubscriptionServiceInterface
+	interval time.Duration
+	log      *slog.Logger
+
+	lastRun atomic.Int64 // unix nano последней успешной проверки
+}
+
+func NewChurnCheck(subs service.SubscriptionServiceInterface, interval time.Duration, log *slog.Logger) *ChurnCheck {
+	return &ChurnCheck{
+		subs:     subs,
+		interval: interval,
+		log:      log.With(slog.String("component", "scheduler/churn")),
+	}
+}
+
+func (j *ChurnCheck) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.log.Info("churn check started", slog.Duration("interval", j.interval))
+	for {
+		j.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			j.log.Info("churn check stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// время последней проверки, нулевое если еще не было
+func (j *ChurnCheck) LastRun() time.Time {
+	ns := j.lastRun.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func (j *ChurnCheck) runOnce(ctx context.Context) {
+	if _, err := j.subs.DetectChurn(ctx); err != nil {
+		j.log.Error("churn check failed", slog.String("err", err.Error()))
+		return
+	}
+	j.lastRun.Store(time.Now().UnixNano())
+}
@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// ExchangeRates периодически забирает свежие курсы у провайдера и сохраняет в базу
+type ExchangeRates struct {
+	rates    service.ExchangeRateServiceInterface
+	interval time.Duration
+	log      *slog.Logger
+
+	lastRun atomic.Int64 // unix nano последнего успешного обновления
+}
+
+func NewExchangeRates(rates service.ExchangeRateServiceInterface, interval time.Duration, log *slog.Logger) *ExchangeRates {
+	return &ExchangeRates{
+		rates:    rates,
+		interval: interval,
+		log:      log.With(slog.String("component", "scheduler/exchangerate")),
+	}
+}
+
+func (j *ExchangeRates) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.log.Info("exchange rates refresher started", slog.Duration("interval", j.interval))
+	for {
+		j.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			j.log.Info("exchange rates refresher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// время последнего обновления, нулевое если еще не было
+func (j *ExchangeRates) LastRun() time.Time {
+	ns := j.lastRun.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func (j *ExchangeRates) runOnce(ctx context.Context) {
+	// провайдер недоступен - считаем по последним сохраненным курсам
+	if err := j.rates.Refresh(ctx); err != nil {
+		j.log.Error("exchange rates refresh failed", slog.String("err", err.Error()))
+		return
+	}
+	j.lastRun.Store(time.Now().UnixNano())
+}
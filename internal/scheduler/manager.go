@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// пауза перед перезапуском упавшей задачи растет вдвое до maxRestartDelay
+const (
+	minRestartDelay = time.Second
+	maxRestartDelay = time.Minute
+)
+
+// Manager запускает фоновые задачи с общим контекстом: Stop отменяет все сразу и ждет их.
+// Паника в задаче не роняет процесс, задача перезапускается с паузой
+type Manager struct {
+	log     *slog.Logger
+	workers []*worker
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	group  *errgroup.Group
+}
+
+type worker struct {
+	name string
+	run  func(ctx context.Context)
+
+	running   atomic.Bool
+	panics    atomic.Int64
+	lastPanic atomic.Value // string
+}
+
+// WorkerStatus - состояние задачи для /admin/system
+type WorkerStatus struct {
+	Name      string `json:"name"`
+	Running   bool   `json:"running"`
+	Panics    int64  `json:"panics"`
+	LastPanic string `json:"last_panic,omitempty"`
+}
+
+func NewManager(log *slog.Logger) *Manager {
+	return &Manager{log: log.With(slog.String("component", "scheduler/manager"))}
+}
+
+// Add регистрирует задачу, до Start. run должен вернуться, когда ctx отменен
+func (m *Manager) Add(name string, run func(ctx context.Context)) {
+	m.workers = append(m.workers, &worker{name: name, run: run})
+}
+
+// Start запускает все задачи и сразу возвращается
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.group, ctx = errgroup.WithContext(ctx)
+	for _, w := range m.workers {
+		m.group.Go(func() error {
+			m.supervise(ctx, w)
+			return nil
+		})
+	}
+	m.log.Info("workers started", slog.Int("count", len(m.workers)))
+}
+
+// Stop отменяет контекст задач и ждет их до конца ctx
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	cancel, group := m.cancel, m.group
+	m.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		group.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		m.log.Info("workers stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduler: workers did not stop: %w", ctx.Err())
+	}
+}
+
+// Health - проверка для /readyz: ошибка, пока задача не работает (упала и ждет перезапуска)
+func (m *Manager) Health(name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for _, w := range m.workers {
+			if w.name != name {
+				continue
+			}
+			if !w.running.Load() {
+				return fmt.Errorf("worker %s is not running", name)
+			}
+			return nil
+		}
+		return fmt.Errorf("worker %s is not registered", name)
+	}
+}
+
+func (m *Manager) Status() []WorkerStatus {
+	out := make([]WorkerStatus, 0, len(m.workers))
+	for _, w := range m.workers {
+		st := WorkerStatus{Name: w.name, Running: w.running.Load(), Panics: w.panics.Load()}
+		if p, ok := w.lastPanic.Load().(string); ok {
+			st.LastPanic = p
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// крутит задачу до отмены ctx, после паники перезапускает с растущей паузой
+func (m *Manager) supervise(ctx context.Context, w *worker) {
+	delay := minRestartDelay
+	for {
+		if !m.runSafe(ctx, w) || ctx.Err() != nil {
+			return
+		}
+
+		m.log.Warn("worker restart scheduled", slog.String("worker", w.name), slog.Duration("in", delay))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxRestartDelay)
+	}
+}
+
+// true - задача упала с паникой
+func (m *Manager) runSafe(ctx context.Context, w *worker) (panicked bool) {
+	w.running.Store(true)
+	defer func() {
+		w.running.Store(false)
+		if r := recover(); r != nil {
+			panicked = true
+			w.panics.Add(1)
+			w.lastPanic.Store(fmt.Sprint(r))
+			m.log.Error("worker panic", slog.String("worker", w.name), slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+		}
+	}()
+
+	w.run(ctx)
+	return false
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/notifier"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// периодически рассылает напоминания об окончании подписок
+type ReminderScheduler struct {
+	reminders service.ReminderServiceInterface
+	notifier  notifier.Notifier
+	templates service.NotificationTemplateServiceInterface
+	interval  time.Duration
+	// тот же, что у ReminderService: по нему же считается snoozed_until
+	clock clock.Clock
+	log   *slog.Logger
+
+	lastRun atomic.Int64 // unix nano последнего прохода
+}
+
+func NewReminderScheduler(reminders service.ReminderServiceInterface, n notifier.Notifier, templates service.NotificationTemplateServiceInterface, interval time.Duration, clk clock.Clock, log *slog.Logger) *ReminderScheduler {
+	return &ReminderScheduler{
+		reminders: reminders,
+		notifier:  n,
+		templates: templates,
+		interval:  interval,
+		clock:     clk,
+		log:       log.With(slog.String("component", "scheduler/reminder")),
+	}
+}
+
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.log.Info("reminder scheduler started", slog.Duration("interval", s.interval))
+	for {
+		s.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.log.Info("reminder scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// время последнего прохода, нулевое если еще не запускался
+func (s *ReminderScheduler) LastRun() time.Time {
+	ns := s.lastRun.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func (s *ReminderScheduler) runOnce(ctx context.Context) {
+	now := s.clock.Now()
+	defer s.lastRun.Store(now.UnixNano())
+
+	due, err := s.reminders.Due(ctx, now)
+	if err != nil {
+		s.log.Error("cant load due reminders", slog.String("err", err.Error()))
+		return
+	}
+
+	for _, d := range due {
+		subject, body := s.templates.Render(ctx, notifier.KindReminder, notifier.ReminderData{
+			SubscriptionID: d.SubscriptionID,
+			UserID:         d.UserID,
+			ServiceName:    d.ServiceName,
+			EndDate:        d.EndDate,
+		})
+		err := s.notifier.Notify(ctx, notifier.Notification{
+			UserID:         d.UserID,
+			SubscriptionID: d.SubscriptionID,
+			Subject:        subject,
+			Body:           body,
+		})
+		if err != nil {
+			s.log.Error("notify failed", slog.Int64("sub_id", d.SubscriptionID), slog.String("err", err.Error()))
+			continue
+		}
+
+		if err := s.reminders.MarkNotified(ctx, d, now); err != nil {
+			s.log.Error("mark notified failed", slog.Int64("sub_id", d.SubscriptionID), slog.String("err", err.Error()))
+		}
+	}
+
+	if len(due) > 0 {
+		s.log.Info("reminders processed", slog.Int("count", len(due)))
+	}
+}
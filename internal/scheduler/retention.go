@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/metrics"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// EventRetention периодически удаляет старые события ленты и обновляет метрики
+type EventRetention struct {
+	activity  service.ActivityServiceInterface
+	retention time.Duration
+	interval  time.Duration
+	log       *slog.Logger
+
+	lastRun atomic.Int64 // unix nano последнего прохода
+}
+
+func NewEventRetention(activity service.ActivityServiceInterface, retention, interval time.Duration, log *slog.Logger) *EventRetention {
+	return &EventRetention{
+		activity:  activity,
+		retention: retention,
+		interval:  interval,
+		log:       log.With(slog.String("component", "scheduler/retention")),
+	}
+}
+
+func (j *EventRetention) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.log.Info("event retention started", slog.Duration("retention", j.retention), slog.Duration("interval", j.interval))
+	for {
+		j.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			j.log.Info("event retention stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// время последнего прохода, нулевое если еще не запускался
+func (j *EventRetention) LastRun() time.Time {
+	ns := j.lastRun.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func (j *EventRetention) runOnce(ctx context.Context) {
+	defer j.lastRun.Store(time.Now().UnixNano())
+
+	// retention 0 - храним вечно, только метрики
+	if j.retention > 0 {
+		n, err := j.activity.Prune(ctx, j.retention)
+		metrics.EventsPruned.Add(n)
+		if err != nil {
+			j.log.Error("events prune failed", slog.Int64("pruned", n), slog.String("err", err.Error()))
+		} else if n > 0 {
+			j.log.Info("events pruned", slog.Int64("pruned", n))
+		}
+	}
+
+	b, err := j.activity.Backlog(ctx)
+	if err != nil {
+		j.log.Error("events backlog failed", slog.String("err", err.Error()))
+		return
+	}
+	metrics.EventsBacklog.Set(b.Total)
+	metrics.EventsOldestAge.Set(b.OldestAgeSeconds)
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// RouteSwitchSync подтягивает выключенные маршруты из базы, чтоб выключение
+// через одну реплику доходило до остальных
+type RouteSwitchSync struct {
+	switches service.RouteSwitchServiceInterface
+	interval time.Duration
+	log      *slog.Logger
+}
+
+func NewRouteSwitchSync(switches service.RouteSwitchServiceInterface, interval time.Duration, log *slog.Logger) *RouteSwitchSync {
+	return &RouteSwitchSync{
+		switches: switches,
+		interval: interval,
+		log:      log.With(slog.String("component", "scheduler/routeswitch")),
+	}
+}
+
+func (j *RouteSwitchSync) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// при ошибке остается прошлый список
+			if err := j.switches.Refresh(ctx); err != nil {
+				j.log.Error("route switches refresh failed", slog.String("err", err.Error()))
+			}
+		}
+	}
+}
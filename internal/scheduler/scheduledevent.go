@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// ScheduledEvents выполняет наступившие отложенные действия над подписками. Действия
+// лежат в базе, поэтому пропущенные за время простоя выполнятся на первом проходе
+type ScheduledEvents struct {
+	events   service.ScheduledEventServiceInterface
+	interval time.Duration
+	log      *slog.Logger
+
+	lastRun atomic.Int64 // unix nano последнего успешного прохода
+}
+
+func NewScheduledEvents(events service.ScheduledEventServiceInterface, interval time.Duration, log *slog.Logger) *ScheduledEvents {
+	return &ScheduledEvents{
+		events:   events,
+		interval: interval,
+		log:      log.With(slog.String("component", "scheduler/scheduledevent")),
+	}
+}
+
+func (j *ScheduledEvents) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.log.Info("scheduled events poller started", slog.Duration("interval", j.interval))
+	for {
+		j.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			j.log.Info("scheduled events poller stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// время последнего прохода, нулевое если еще не было
+func (j *ScheduledEvents) LastRun() time.Time {
+	ns := j.lastRun.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func (j *ScheduledEvents) runOnce(ctx context.Context) {
+	n, err := j.events.RunDue(ctx)
+	if err != nil {
+		j.log.Error("scheduled events run failed", slog.Int("processed", n), slog.String("err", err.Error()))
+		return
+	}
+	if n > 0 {
+		j.log.Debug("scheduled events processed", slog.Int("count", n))
+	}
+	j.lastRun.Store(time.Now().UnixNano())
+}
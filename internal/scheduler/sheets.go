@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// SheetSync периодически синхронизирует подписки с таблицей Google Sheets
+type SheetSync struct {
+	sync     service.SheetSyncServiceInterface
+	interval time.Duration
+	log      *slog.Logger
+
+	lastRun atomic.Int64 // unix nano последнего прохода
+}
+
+func NewSheetSync(sync service.SheetSyncServiceInterface, interval time.Duration, log *slog.Logger) *SheetSync {
+	return &SheetSync{
+		sync:     sync,
+		interval: interval,
+		log:      log.With(slog.String("component", "scheduler/sheets")),
+	}
+}
+
+func (j *SheetSync) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.log.Info("sheet sync started", slog.Duration("interval", j.interval))
+	for {
+		j.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			j.log.Info("sheet sync stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// время последнего прохода, нулевое если еще не запускался
+func (j *SheetSync) LastRun() time.Time {
+	ns := j.lastRun.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func (j *SheetSync) runOnce(ctx context.Context) {
+	// итоги прогона пишет сам сервис
+	_, err := j.sync.Sync(ctx, false)
+	switch {
+	case errors.Is(err, service.ErrSheetSyncRunning):
+		// прогон уже идет из /admin/sheets/sync, этот пропускаем
+		return
+	case err != nil:
+		j.log.Error("sheet sync failed", slog.String("err", err.Error()))
+		return
+	}
+	j.lastRun.Store(time.Now().UnixNano())
+}
@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+)
+
+// TrialCheck отмечает триалы, которые со следующего месяца станут платными
+type TrialCheck struct {
+	subs     service.SubscriptionServiceInterface
+	interval time.Duration
+	log      *slog.Logger
+
+	lastRun atomic.Int64 // unix nano последней успешной проверки
+}
+
+func NewTrialCheck(subs service.SubscriptionServiceInterface, interval time.Duration, log *slog.Logger) *TrialCheck {
+	return &TrialCheck{
+		subs:     subs,
+		interval: interval,
+		log:      log.With(slog.String("component", "scheduler/trial")),
+	}
+}
+
+func (j *TrialCheck) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.log.Info("trial check started", slog.Duration("interval", j.interval))
+	for {
+		j.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			j.log.Info("trial check stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// время последней проверки, нулевое если еще не было
+func (j *TrialCheck) LastRun() time.Time {
+	ns := j.lastRun.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func (j *TrialCheck) runOnce(ctx context.Context) {
+	if _, err := j.subs.FlagTrialConversions(ctx); err != nil {
+		j.log.Error("trial check failed", slog.String("err", err.Error()))
+		return
+	}
+	j.lastRun.Store(time.Now().UnixNano())
+}
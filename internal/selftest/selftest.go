@@ -0,0 +1,152 @@
+// Package selftest - проверки перед переключением трафика на инстанс (--selftest)
+package selftest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/config"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/idcodec"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/pricing"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/service"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/storage/postgres"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+	// проверка не применима: подсистема не настроена
+	StatusSkip = "skip"
+)
+
+// допустимое расхождение часов с базой
+const maxClockSkew = 2 * time.Second
+
+type Check struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+type Report struct {
+	OK        bool      `json:"ok"`
+	StartedAt time.Time `json:"started_at"`
+	Checks    []Check   `json:"checks"`
+}
+
+type checkFunc func(ctx context.Context) (status, detail string)
+
+// Run прогоняет все проверки по порядку, падение одной не останавливает остальные
+func Run(ctx context.Context, cfg *config.Config, log *slog.Logger) *Report {
+	r := &Report{OK: true, StartedAt: time.Now().UTC()}
+
+	var db *sql.DB
+	defer func() {
+		if db != nil {
+			db.Close()
+		}
+	}()
+
+	checks := []struct {
+		name string
+		fn   checkFunc
+	}{
+		{"config", func(ctx context.Context) (string, string) { return checkConfig(cfg) }},
+		{"database", func(ctx context.Context) (string, string) {
+			var err error
+			if db, err = postgres.NewPostgres(cfg, log); err != nil {
+				return StatusFail, err.Error()
+			}
+			return StatusOK, ""
+		}},
+		{"schema_version", func(ctx context.Context) (string, string) {
+			if db == nil {
+				return StatusSkip, "no database connection"
+			}
+			return checkSchema(cfg)
+		}},
+		{"clock_skew", func(ctx context.Context) (string, string) {
+			if db == nil {
+				return StatusSkip, "no database connection"
+			}
+			return checkClock(ctx, db)
+		}},
+		// кеша и внешних получателей уведомлений пока нет, уведомления пишутся в лог
+		{"cache", func(ctx context.Context) (string, string) { return StatusSkip, "not configured" }},
+		{"notification_sinks", func(ctx context.Context) (string, string) { return StatusSkip, "log notifier only" }},
+	}
+
+	for _, c := range checks {
+		t := time.Now()
+		status, detail := c.fn(ctx)
+		r.Checks = append(r.Checks, Check{Name: c.name, Status: status, Detail: detail, Duration: time.Since(t)})
+		if status == StatusFail {
+			r.OK = false
+		}
+	}
+	return r
+}
+
+// те же проверки, что делает main при сборке зависимостей
+func checkConfig(cfg *config.Config) (string, string) {
+	if _, err := strconv.Atoi(cfg.Server.Port); err != nil {
+		return StatusFail, fmt.Sprintf("bad SERVER_PORT %q", cfg.Server.Port)
+	}
+
+	catalog, err := pricing.LoadExpectations(cfg.Pricing.CatalogFile)
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+	if _, err := pricing.NewChecker(cfg.Pricing.Policy, cfg.Pricing.Tolerance, catalog); err != nil {
+		return StatusFail, err.Error()
+	}
+	if _, err := idcodec.New(cfg.API.IDEncoding, cfg.API.IDSalt); err != nil {
+		return StatusFail, err.Error()
+	}
+	if _, err := service.NewCostCanary(cfg.Cost.SQLCanaryPercent, cfg.Cost.SQLCanaryUsers); err != nil {
+		return StatusFail, err.Error()
+	}
+	if cfg.API.ShadowSampleRate < 0 || cfg.API.ShadowSampleRate > 1 {
+		return StatusFail, "API_SHADOW_SAMPLE_RATE must be in 0..1"
+	}
+	return StatusOK, ""
+}
+
+func checkSchema(cfg *config.Config) (string, string) {
+	current, dirty, latest, err := postgres.SchemaVersion(cfg)
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+
+	detail := fmt.Sprintf("current %d, latest %d", current, latest)
+	if dirty {
+		return StatusFail, detail + ", dirty"
+	}
+	// отставание не ошибка: миграции применятся при старте
+	if current > latest {
+		return StatusFail, detail + ", database is ahead of this build"
+	}
+	return StatusOK, detail
+}
+
+func checkClock(ctx context.Context, db *sql.DB) (string, string) {
+	before := time.Now()
+	var dbNow time.Time
+	if err := db.QueryRowContext(ctx, `SELECT NOW()`).Scan(&dbNow); err != nil {
+		return StatusFail, err.Error()
+	}
+	rtt := time.Since(before)
+
+	// середина запроса ближе всего к моменту NOW() в базе
+	skew := dbNow.Sub(before.Add(rtt / 2))
+	detail := fmt.Sprintf("skew %s, rtt %s", skew.Round(time.Millisecond), rtt.Round(time.Millisecond))
+	if skew.Abs() > maxClockSkew {
+		return StatusFail, detail
+	}
+	return StatusOK, detail
+}
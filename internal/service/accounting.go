@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/accounting"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var (
+	ErrBadAccountingPeriod = errors.New("period must be in MM-YYYY format")
+	ErrPeriodNotClosed     = errors.New("period is not closed yet, only past months can be exported")
+)
+
+type AccountingServiceInterface interface {
+	Generate(ctx context.Context, period string) (*domain.AccountingExport, error)
+	// выгрузка прошлого месяца, если ее еще нет. nil - уже была
+	ExportClosed(ctx context.Context) (*domain.AccountingExport, error)
+	List(ctx context.Context) ([]domain.AccountingExport, error)
+	File(ctx context.Context, id int64) (*domain.AccountingExport, []byte, error)
+}
+
+// AccountingService собирает начисления за закрытый месяц в файл для бухгалтерии
+// и хранит его в базе, чтоб повторное скачивание отдавало тот же файл
+type AccountingService struct {
+	repo   repository.AccountingInterface
+	subs   SubscriptionServiceInterface
+	format accounting.Format
+	clock  clock.Clock
+	log    *slog.Logger
+}
+
+var _ AccountingServiceInterface = (*AccountingService)(nil)
+
+func NewAccountingService(repo repository.AccountingInterface, subs SubscriptionServiceInterface, format accounting.Format, clk clock.Clock, log *slog.Logger) *AccountingService {
+	return &AccountingService{
+		repo:   repo,
+		subs:   subs,
+		format: format,
+		clock:  clk,
+		log:    log.With(slog.String("component", "service/accounting")),
+	}
+}
+
+// Generate формирует новый файл за месяц, прошлые выгрузки того же месяца остаются
+func (s *AccountingService) Generate(ctx context.Context, period string) (*domain.AccountingExport, error) {
+	const op = "service accounting Generate"
+
+	month, err := time.Parse("01-2006", period)
+	if err != nil {
+		return nil, ErrBadAccountingPeriod
+	}
+	if !month.Before(s.currentMonth()) {
+		return nil, ErrPeriodNotClosed
+	}
+
+	export, err := s.generate(ctx, month)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return export, nil
+}
+
+func (s *AccountingService) ExportClosed(ctx context.Context) (*domain.AccountingExport, error) {
+	const op = "service accounting ExportClosed"
+
+	month := s.currentMonth().AddDate(0, -1, 0)
+	exists, err := s.repo.Exists(ctx, month, s.format.Name())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if exists {
+		return nil, nil
+	}
+
+	export, err := s.generate(ctx, month)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return export, nil
+}
+
+func (s *AccountingService) List(ctx context.Context) ([]domain.AccountingExport, error) {
+	const op = "service accounting List"
+
+	exports, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return exports, nil
+}
+
+func (s *AccountingService) File(ctx context.Context, id int64) (*domain.AccountingExport, []byte, error) {
+	const op = "service accounting File"
+
+	export, content, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return export, content, nil
+}
+
+func (s *AccountingService) generate(ctx context.Context, month time.Time) (*domain.AccountingExport, error) {
+	lines, err := s.subs.PeriodCharges(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := s.format.Write(&buf, lines); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+
+	export := &domain.AccountingExport{
+		Period: month.Format("01-2006"),
+		Format: s.format.Name(),
+		Rows:   len(lines),
+		Size:   buf.Len(),
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+	if err := s.repo.Create(ctx, month, export, buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("accounting export generated",
+		slog.Int64("id", export.ID), slog.String("period", export.Period), slog.String("format", export.Format), slog.Int("rows", export.Rows))
+	return export, nil
+}
+
+// первое число текущего месяца, месяцы до него закрыты
+func (s *AccountingService) currentMonth() time.Time {
+	return clock.MonthStart(s.clock.Now().UTC())
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/accounting"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// репозиторий, в котором выгрузка любого месяца уже есть
+type existingExports struct {
+	asked []time.Time
+}
+
+func (r *existingExports) Create(context.Context, time.Time, *domain.AccountingExport, []byte) error {
+	return errors.New("unexpected create")
+}
+
+func (r *existingExports) List(context.Context) ([]domain.AccountingExport, error) { return nil, nil }
+
+func (r *existingExports) Get(context.Context, int64) (*domain.AccountingExport, []byte, error) {
+	return nil, nil, domain.ErrNotFound
+}
+
+func (r *existingExports) Exists(_ context.Context, period time.Time, _ string) (bool, error) {
+	r.asked = append(r.asked, period)
+	return true, nil
+}
+
+var errNoCharges = errors.New("charges are not needed here")
+
+// сервис подписок, у которого выгрузка просит только начисления
+type chargesStub struct {
+	SubscriptionServiceInterface
+}
+
+func (chargesStub) PeriodCharges(context.Context, time.Time) ([]domain.AccountingLine, error) {
+	return nil, errNoCharges
+}
+
+func TestAccountingClosedPeriod(t *testing.T) {
+	lastSecond := time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC)
+	firstSecond := lastSecond.Add(time.Second)
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		period     string
+		wantClosed bool
+		// прошлый месяц, который ExportClosed проверяет в репозитории
+		wantPrevious string
+	}{
+		{name: "current month at its last second", now: lastSecond, period: "03-2026", wantClosed: false, wantPrevious: "02-2026"},
+		{name: "month closes at midnight", now: firstSecond, period: "03-2026", wantClosed: true, wantPrevious: "03-2026"},
+		{name: "new month is open", now: firstSecond, period: "04-2026", wantClosed: false, wantPrevious: "03-2026"},
+		{name: "year boundary", now: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), period: "12-2026", wantClosed: true, wantPrevious: "12-2026"},
+	}
+
+	format, err := accounting.New("csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := clock.NewFake(time.Time{})
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk.Set(tt.now)
+			repo := &existingExports{}
+			s := NewAccountingService(repo, chargesStub{}, format, clk, log)
+
+			// закрытый месяц проходит проверку и доходит до начислений, открытый отсекается сразу
+			_, err := s.Generate(context.Background(), tt.period)
+			if !errors.Is(err, ErrPeriodNotClosed) && !errors.Is(err, errNoCharges) {
+				t.Fatalf("Generate(%s): %v", tt.period, err)
+			}
+			closed := errors.Is(err, errNoCharges)
+			if closed != tt.wantClosed {
+				t.Errorf("period %s at %s closed = %v, want %v", tt.period, tt.now.Format(time.RFC3339), closed, tt.wantClosed)
+			}
+
+			if _, err := s.ExportClosed(context.Background()); err != nil {
+				t.Fatalf("ExportClosed: %v", err)
+			}
+			if len(repo.asked) != 1 || repo.asked[0].Format("01-2006") != tt.wantPrevious {
+				t.Errorf("ExportClosed checked %v, want %s", repo.asked, tt.wantPrevious)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+type ActivityServiceInterface interface {
+	Record(ctx context.Context, userID uuid.UUID, subID int64, eventType string, payload map[string]any)
+	Feed(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error)
+	Timeline(ctx context.Context, subID int64) ([]domain.TimelineEntry, error)
+	Prune(ctx context.Context, retention time.Duration) (int64, error)
+	Backlog(ctx context.Context) (*domain.EventBacklog, error)
+}
+
+type ActivityService struct {
+	events repository.EventInterface
+	log    *slog.Logger
+}
+
+var _ ActivityServiceInterface = (*ActivityService)(nil)
+
+func NewActivityService(events repository.EventInterface, log *slog.Logger) *ActivityService {
+	return &ActivityService{
+		events: events,
+		log:    log.With(slog.String("component", "service/activity")),
+	}
+}
+
+// пишем событие в ленту, ошибка записи не должна валить основную операцию
+func (s *ActivityService) Record(ctx context.Context, userID uuid.UUID, subID int64, eventType string, payload map[string]any) {
+	const op = "service activity Record"
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		s.log.Error("event payload marshal fail", slog.String("op", op), slog.String("err", err.Error()))
+		return
+	}
+
+	ev := domain.Event{UserID: userID, SubscriptionID: &subID, Type: eventType, Payload: raw}
+	if _, err := s.events.Add(ctx, ev); err != nil {
+		s.log.Error("event record fail", slog.String("op", op), slog.String("type", eventType), slog.String("err", err.Error()))
+	}
+}
+
+func (s *ActivityService) Feed(ctx context.Context, filter domain.EventFilter) ([]domain.Event, error) {
+	const op = "service activity Feed"
+
+	events, err := s.events.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return events, nil
+}
+
+// размер пачки при удалении старых событий
+const pruneBatch = 1000
+
+// Prune удаляет события старше retention, пачка за пачкой пока есть что удалять
+func (s *ActivityService) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	const op = "service activity Prune"
+
+	before := time.Now().Add(-retention)
+	var total int64
+	for {
+		n, err := s.events.Prune(ctx, before, pruneBatch)
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+		total += n
+
+		if n < pruneBatch || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+func (s *ActivityService) Backlog(ctx context.Context) (*domain.EventBacklog, error) {
+	const op = "service activity Backlog"
+
+	b, err := s.events.Backlog(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+type AnalyticsServiceInterface interface {
+	// пересчитывает когортную таблицу, возвращает число когорт
+	RebuildCohorts(ctx context.Context) (int, error)
+	Cohorts(ctx context.Context) (*domain.CohortReport, error)
+	// пересчитывает статистику сервисов по месяцам, возвращает число строк
+	RebuildServiceStats(ctx context.Context) (int, error)
+	TopServices(ctx context.Context, month time.Time, limit int) (*domain.TopServicesReport, error)
+	Churn(ctx context.Context, from, to time.Time) (*domain.ChurnReport, error)
+}
+
+// AnalyticsService держит агрегаты для продуктовой аналитики. Считать их на каждый
+// запрос дорого: расчет проходит по всем подпискам за все месяцы
+type AnalyticsService struct {
+	repo     repository.AnalyticsInterface
+	subs     SubscriptionServiceInterface
+	currency string
+	log      *slog.Logger
+}
+
+var _ AnalyticsServiceInterface = (*AnalyticsService)(nil)
+
+func NewAnalyticsService(repo repository.AnalyticsInterface, subs SubscriptionServiceInterface, currency string, log *slog.Logger) *AnalyticsService {
+	return &AnalyticsService{
+		repo:     repo,
+		subs:     subs,
+		currency: currency,
+		log:      log.With(slog.String("component", "service/analytics")),
+	}
+}
+
+func (s *AnalyticsService) RebuildCohorts(ctx context.Context) (int, error) {
+	const op = "service analytics RebuildCohorts"
+
+	cohorts, err := s.subs.Cohorts(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.repo.ReplaceCohorts(ctx, s.currency, cohorts); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("cohorts rebuilt", slog.Int("cohorts", len(cohorts)))
+	return len(cohorts), nil
+}
+
+// Cohorts отдает последнюю агрегацию со средними на пользователя когорты
+func (s *AnalyticsService) Cohorts(ctx context.Context) (*domain.CohortReport, error) {
+	const op = "service analytics Cohorts"
+
+	report, err := s.repo.Cohorts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if report.Currency == "" {
+		report.Currency = s.currency
+	}
+
+	for i := range report.Cohorts {
+		c := &report.Cohorts[i]
+		if c.Users == 0 {
+			continue
+		}
+		for j := range c.Months {
+			m := &c.Months[j]
+			m.Retention = math.Round(float64(m.ActiveUsers)/float64(c.Users)*1000) / 1000
+			m.AvgSubscriptions = math.Round(float64(m.Subscriptions)/float64(c.Users)*100) / 100
+			m.AvgSpend = domain.Money(math.Round(float64(m.Spend) / float64(c.Users)))
+		}
+	}
+	return report, nil
+}
+
+func (s *AnalyticsService) RebuildServiceStats(ctx context.Context) (int, error) {
+	const op = "service analytics RebuildServiceStats"
+
+	stats, err := s.subs.ServiceStats(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.repo.ReplaceServiceStats(ctx, s.currency, stats); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("service stats rebuilt", slog.Int("rows", len(stats)))
+	return len(stats), nil
+}
+
+// TopServices - самые популярные и самые доходные сервисы месяца из последней агрегации
+func (s *AnalyticsService) TopServices(ctx context.Context, month time.Time, limit int) (*domain.TopServicesReport, error) {
+	const op = "service analytics TopServices"
+
+	report, err := s.repo.TopServices(ctx, month, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if report.Currency == "" {
+		report.Currency = s.currency
+	}
+	return report, nil
+}
+
+// Churn - доля ушедших подписок по сервисам за [from, to], сначала сервисы с большей долей.
+// Считается по базе на каждый запрос, churned_at ставит фоновая проверка
+func (s *AnalyticsService) Churn(ctx context.Context, from, to time.Time) (*domain.ChurnReport, error) {
+	const op = "service analytics Churn"
+
+	services, err := s.repo.Churn(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range services {
+		c := &services[i]
+		if c.Subscriptions > 0 {
+			c.ChurnRate = math.Round(float64(c.Churned)/float64(c.Subscriptions)*1000) / 1000
+		}
+	}
+	slices.SortStableFunc(services, func(a, b domain.ServiceChurn) int {
+		if a.ChurnRate != b.ChurnRate {
+			return cmp.Compare(b.ChurnRate, a.ChurnRate)
+		}
+		return cmp.Compare(b.Churned, a.Churned)
+	})
+
+	return &domain.ChurnReport{From: from.Format("01-2006"), To: to.Format("01-2006"), Services: services}, nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/storage/blob"
+)
+
+var (
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	ErrEmptyAttachment    = domain.Invalid("attachment is empty")
+)
+
+type AttachmentServiceInterface interface {
+	Upload(ctx context.Context, subscriptionID int64, fileName, contentType string, r io.Reader) (*domain.Attachment, error)
+	List(ctx context.Context, subscriptionID int64) ([]domain.Attachment, error)
+	Open(ctx context.Context, subscriptionID, id int64) (*domain.Attachment, io.ReadCloser, error)
+	Delete(ctx context.Context, subscriptionID, id int64) error
+}
+
+type AttachmentService struct {
+	repo     repository.AttachmentInterface
+	subs     repository.SubscriptionInterface
+	store    blob.Store
+	maxBytes int64
+	log      *slog.Logger
+}
+
+var _ AttachmentServiceInterface = (*AttachmentService)(nil)
+
+func NewAttachmentService(repo repository.AttachmentInterface, subs repository.SubscriptionInterface, store blob.Store, maxBytes int64, log *slog.Logger) *AttachmentService {
+	return &AttachmentService{
+		repo:     repo,
+		subs:     subs,
+		store:    store,
+		maxBytes: maxBytes,
+		log:      log.With(slog.String("component", "service/attachment")),
+	}
+}
+
+// Upload кладет файл в хранилище и пишет метаданные. Если база не приняла
+// запись, файл из хранилища удаляется
+func (s *AttachmentService) Upload(ctx context.Context, subscriptionID int64, fileName, contentType string, r io.Reader) (*domain.Attachment, error) {
+	const op = "service attachment Upload"
+
+	if _, err := s.subs.GetByID(ctx, subscriptionID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// файлы небольшие (договоры, чеки), S3 нужен размер заранее
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: read: %w", op, err)
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, ErrAttachmentTooLarge
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyAttachment
+	}
+
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(data)
+	}
+	sum := sha256.Sum256(data)
+
+	a := domain.Attachment{
+		SubscriptionID: subscriptionID,
+		FileName:       cleanFileName(fileName),
+		ContentType:    contentType,
+		SizeBytes:      int64(len(data)),
+		SHA256:         hex.EncodeToString(sum[:]),
+		StorageKey:     fmt.Sprintf("subscriptions/%d/%s", subscriptionID, uuid.NewString()),
+	}
+
+	if err := s.store.Put(ctx, a.StorageKey, data, contentType); err != nil {
+		return nil, fmt.Errorf("%s: store: %w", op, err)
+	}
+
+	id, err := s.repo.Create(ctx, a)
+	if err != nil {
+		if delErr := s.store.Delete(context.WithoutCancel(ctx), a.StorageKey); delErr != nil {
+			s.log.Error("orphan attachment blob", slog.String("key", a.StorageKey), slog.String("err", delErr.Error()))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("attachment uploaded", slog.Int64("subscription_id", subscriptionID), slog.Int64("id", id), slog.Int64("size", a.SizeBytes))
+	return s.repo.GetByID(ctx, subscriptionID, id)
+}
+
+func (s *AttachmentService) List(ctx context.Context, subscriptionID int64) ([]domain.Attachment, error) {
+	const op = "service attachment List"
+
+	if _, err := s.subs.GetByID(ctx, subscriptionID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	attachments, err := s.repo.List(ctx, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return attachments, nil
+}
+
+// Open отдает метаданные и содержимое файла, закрывает вызывающий
+func (s *AttachmentService) Open(ctx context.Context, subscriptionID, id int64) (*domain.Attachment, io.ReadCloser, error) {
+	const op = "service attachment Open"
+
+	a, err := s.repo.GetByID(ctx, subscriptionID, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	body, err := s.store.Open(ctx, a.StorageKey)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			s.log.Error("attachment blob missing", slog.Int64("id", id), slog.String("key", a.StorageKey))
+			return nil, nil, fmt.Errorf("%s: %w", op, domain.ErrNotFound)
+		}
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return a, body, nil
+}
+
+// Delete убирает метаданные, потом файл. Не удалившийся файл только в лог:
+// для пользователя вложения уже нет
+func (s *AttachmentService) Delete(ctx context.Context, subscriptionID, id int64) error {
+	const op = "service attachment Delete"
+
+	a, err := s.repo.GetByID(ctx, subscriptionID, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.repo.Delete(ctx, subscriptionID, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.store.Delete(ctx, a.StorageKey); err != nil {
+		s.log.Error("orphan attachment blob", slog.String("key", a.StorageKey), slog.String("err", err.Error()))
+	}
+	return nil
+}
+
+// только имя без каталогов, пустое - file
+func cleanFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	if name == "." || name == "/" || name == "" {
+		return "file"
+	}
+	if r := []rune(name); len(r) > 255 {
+		name = string(r[:255])
+	}
+	return name
+}
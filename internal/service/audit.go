@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+type AuditServiceInterface interface {
+	Record(ctx context.Context, rec domain.AuditRecord)
+	List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditRecord, error)
+	Verify(ctx context.Context) (*domain.AuditVerification, error)
+}
+
+type AuditService struct {
+	repo repository.AuditInterface
+	log  *slog.Logger
+}
+
+var _ AuditServiceInterface = (*AuditService)(nil)
+
+func NewAuditService(repo repository.AuditInterface, log *slog.Logger) *AuditService {
+	return &AuditService{
+		repo: repo,
+		log:  log.With(slog.String("component", "service/audit")),
+	}
+}
+
+// пишем запись аудита, как и лента событий ответ клиенту не ломаем
+func (s *AuditService) Record(ctx context.Context, rec domain.AuditRecord) {
+	const op = "service audit Record"
+
+	if _, err := s.repo.Append(ctx, rec); err != nil {
+		s.log.Error("audit record fail",
+			slog.String("op", op),
+			slog.String("action", rec.Action),
+			slog.String("request_id", rec.RequestID),
+			slog.String("err", err.Error()),
+		)
+	}
+}
+
+func (s *AuditService) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditRecord, error) {
+	const op = "service audit List"
+
+	records, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return records, nil
+}
+
+// размер пачки при проверке цепочки
+const auditVerifyBatch = 1000
+
+// Verify проходит всю цепочку: каждая запись должна сходиться со своим хэшем
+// и ссылаться на хэш предыдущей
+func (s *AuditService) Verify(ctx context.Context) (*domain.AuditVerification, error) {
+	const op = "service audit Verify"
+
+	res := &domain.AuditVerification{OK: true}
+	var (
+		lastID   int64
+		prevHash string
+	)
+	for {
+		records, err := s.repo.Chain(ctx, lastID, auditVerifyBatch)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, rec := range records {
+			res.Checked++
+			if rec.PrevHash != prevHash || !rec.Valid() {
+				s.log.Warn("audit chain broken", slog.String("op", op), slog.Int64("id", rec.ID))
+				res.OK = false
+				res.BrokenAt = rec.ID
+				return res, nil
+			}
+			prevHash = rec.Hash
+			lastID = rec.ID
+		}
+
+		if len(records) < auditVerifyBatch {
+			return res, nil
+		}
+	}
+}
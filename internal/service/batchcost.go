@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+const MaxCostBatch = 1000
+
+var ErrCostBatch = domain.Invalid(fmt.Sprintf("user_ids must contain 1..%d valid user ids", MaxCostBatch))
+
+// BatchTotalCost считает итоги за период сразу для многих пользователей одним
+// сгруппированным запросом. Пользователи в порядке запроса без повторов, у кого
+// подписок нет - с нулем в основной валюте
+func (s *SubscriptionService) BatchTotalCost(ctx context.Context, userIDs []uuid.UUID, fromStr, toStr string) ([]domain.UserTotalCost, error) {
+	const op = "service BatchTotalCost"
+
+	if len(userIDs) == 0 || len(userIDs) > MaxCostBatch || slices.Contains(userIDs, uuid.Nil) {
+		return nil, ErrCostBatch
+	}
+	reqFrom, reqTo, err := parseCostPeriod(fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	ids := make([]uuid.UUID, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	byUser, err := s.repo.BatchCost(ctx, ids, reqFrom, reqTo, s.excludeFinalMonth)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := make([]domain.UserTotalCost, 0, len(ids))
+	for _, id := range ids {
+		res = append(res, s.userTotal(id, byUser[id]))
+	}
+	return res, nil
+}
+
+// итог пользователя: основная валюта первой и всегда, остальные по алфавиту
+func (s *SubscriptionService) userTotal(userID uuid.UUID, costs []domain.CurrencyCost) domain.UserTotalCost {
+	byCurrency := make(map[string]domain.Money, len(costs))
+	for _, c := range costs {
+		byCurrency[c.Currency] += c.Cost
+	}
+
+	codes := currenciesOf(s.currency, costs, func(c domain.CurrencyCost) string { return c.Currency })
+	ut := domain.UserTotalCost{UserID: userID, Total: byCurrency[s.currency], Totals: make([]domain.CurrencyCost, 0, len(codes))}
+	for _, code := range codes {
+		ut.Totals = append(ut.Totals, domain.CurrencyCost{Currency: code, Cost: byCurrency[code]})
+	}
+	return ut
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+var ErrBadBillingPeriod = errors.New("billing_period must be weekly, monthly, quarterly or yearly")
+
+func normalizeBillingPeriod(period string) (string, error) {
+	period, ok := domain.NormalizeBillingPeriod(period)
+	if !ok {
+		return "", ErrBadBillingPeriod
+	}
+	return period, nil
+}
+
+// цена подписки в пересчете на месяц, для сравнений с порогами и каталогом цен
+func monthlyPrice(sub domain.Subscription) domain.Money {
+	return sub.Price.ForMonths(sub.BillingPeriod, 1)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var ErrBadBudget = domain.Invalid("invalid budget")
+
+type BudgetServiceInterface interface {
+	Create(ctx context.Context, b domain.Budget) (*domain.Budget, error)
+	GetByID(ctx context.Context, id int64) (*domain.Budget, error)
+	List(ctx context.Context, userID uuid.UUID) ([]domain.Budget, error)
+	Update(ctx context.Context, id int64, b domain.Budget) (*domain.Budget, error)
+	Delete(ctx context.Context, id int64) error
+	Status(ctx context.Context, id int64) (*domain.BudgetStatus, error)
+}
+
+// откуда берем фактические расходы, тот же расчет что у /subscriptions/total
+type spendSource interface {
+	GetTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string) (*domain.TotalCost, error)
+}
+
+type BudgetService struct {
+	repo  repository.BudgetInterface
+	spend spendSource
+	log   *slog.Logger
+}
+
+var _ BudgetServiceInterface = (*BudgetService)(nil)
+
+func NewBudgetService(repo repository.BudgetInterface, spend spendSource, log *slog.Logger) *BudgetService {
+	return &BudgetService{
+		repo:  repo,
+		spend: spend,
+		log:   log.With(slog.String("component", "service/budget")),
+	}
+}
+
+func validateBudget(b domain.Budget) error {
+	if b.UserID == uuid.Nil {
+		return fmt.Errorf("%w: user_id is required", ErrBadBudget)
+	}
+	if p, err := strictDates.Normalize(b.Period); err != nil || p != b.Period {
+		return fmt.Errorf("%w: period must be MM-YYYY", ErrBadBudget)
+	}
+	if b.Amount < 0 {
+		return fmt.Errorf("%w: amount cant be negative", ErrBadBudget)
+	}
+	return nil
+}
+
+func (s *BudgetService) Create(ctx context.Context, b domain.Budget) (*domain.Budget, error) {
+	const op = "service budget Create"
+
+	if err := validateBudget(b); err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.Create(ctx, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s.GetByID(ctx, id)
+}
+
+func (s *BudgetService) GetByID(ctx context.Context, id int64) (*domain.Budget, error) {
+	const op = "service budget GetByID"
+
+	b, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return b, nil
+}
+
+func (s *BudgetService) List(ctx context.Context, userID uuid.UUID) ([]domain.Budget, error) {
+	const op = "service budget List"
+
+	budgets, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return budgets, nil
+}
+
+// владелец бюджета не меняется, user_id из тела игнорируется
+func (s *BudgetService) Update(ctx context.Context, id int64, b domain.Budget) (*domain.Budget, error) {
+	const op = "service budget Update"
+
+	current, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	b.UserID = current.UserID
+
+	if err := validateBudget(b); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, id, b); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s.GetByID(ctx, id)
+}
+
+func (s *BudgetService) Delete(ctx context.Context, id int64) error {
+	const op = "service budget Delete"
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Status сравнивает расходы месяца бюджета (по категории, если задана) с суммой
+func (s *BudgetService) Status(ctx context.Context, id int64) (*domain.BudgetStatus, error) {
+	const op = "service budget Status"
+
+	b, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var category string
+	if b.Category != nil {
+		category = *b.Category
+	}
+
+	total, err := s.spend.GetTotalCost(ctx, b.UserID, category, b.Period, b.Period)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	st := &domain.BudgetStatus{
+		Budget:    *b,
+		Spent:     total.Total,
+		Remaining: b.Amount - total.Total,
+		Overspent: total.Total > b.Amount,
+	}
+	if b.Amount > 0 {
+		st.UsedPercent = math.Round(float64(total.Total)/float64(b.Amount)*1000) / 10
+	}
+
+	if st.Overspent {
+		s.log.Info("budget overspent",
+			slog.String("op", op),
+			slog.Int64("budget_id", b.ID),
+			slog.String("amount", b.Amount.String()),
+			slog.String("spent", st.Spent.String()),
+		)
+	}
+	return st, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// CostCanary решает, какой движок считает расходы: SQL или старый Go
+type CostCanary struct {
+	// процент запросов, уходящих в SQL
+	percent int
+	users   map[uuid.UUID]struct{}
+}
+
+// NewCostCanary принимает процент и список user_id через запятую,
+// которые всегда идут в SQL
+func NewCostCanary(percent int, users string) (*CostCanary, error) {
+	if percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("canary percent must be in 0..100, got %d", percent)
+	}
+
+	c := &CostCanary{percent: percent, users: make(map[uuid.UUID]struct{})}
+	for _, raw := range strings.Split(users, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("bad canary user %q: %w", raw, err)
+		}
+		c.users[id] = struct{}{}
+	}
+	return c, nil
+}
+
+func (c *CostCanary) useSQL(userID uuid.UUID) bool {
+	if c == nil {
+		return false
+	}
+	if _, ok := c.users[userID]; ok {
+		return true
+	}
+	return c.percent > 0 && rand.IntN(100) < c.percent
+}
+
+func (s *SubscriptionService) totalCostSQL(ctx context.Context, userID uuid.UUID, serviceName string, from, to time.Time) (*domain.TotalCost, error) {
+	details, err := s.repo.AggregateCost(ctx, userID, serviceName, from, to, s.excludeFinalMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &domain.TotalCost{Details: []domain.CostDetail{}}
+	res.Details = append(res.Details, details...)
+	s.fillCurrencyTotals(res)
+
+	// помесячная раскладка пока считается только на Go, по тем же строкам что и старый движок
+	subs, err := s.repo.GetTotalCost(ctx, userID, serviceName, from, to)
+	if err != nil {
+		return nil, err
+	}
+	res.Months = s.monthlyBreakdown(subs, from, to, s.billedCost)
+	res.Categories = s.categoryTotals(subs, from, to, s.billedCost)
+	return res, nil
+}
+
+// считает тот же запрос на Go и пишет в лог, если движки разошлись
+func (s *SubscriptionService) compareTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, from, to time.Time, got *domain.TotalCost) {
+	want, err := s.totalCostGo(ctx, userID, serviceName, from, to, s.billedCost)
+	if err != nil {
+		s.log.Warn("cost canary compare skipped", slog.String("err", err.Error()))
+		return
+	}
+
+	if want.Total == got.Total && sameDetails(want.Details, got.Details) {
+		return
+	}
+
+	s.log.Warn("cost engine mismatch",
+		slog.String("user_id", userID.String()),
+		slog.String("service_name", serviceName),
+		slog.String("from", from.Format("01-2006")),
+		slog.String("to", to.Format("01-2006")),
+		slog.String("go_total", want.Total.String()),
+		slog.String("sql_total", got.Total.String()),
+		slog.Any("go_details", want.Details),
+		slog.Any("sql_details", got.Details),
+	)
+}
+
+// порядок строк у движков разный, сравниваем как множества
+func sameDetails(a, b []domain.CostDetail) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	cmp := func(x, y domain.CostDetail) int {
+		if c := strings.Compare(x.ServiceName, y.ServiceName); c != 0 {
+			return c
+		}
+		if c := strings.Compare(x.Currency, y.Currency); c != 0 {
+			return c
+		}
+		if x.Months != y.Months {
+			return x.Months - y.Months
+		}
+		return int(x.Cost - y.Cost)
+	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.SortFunc(a, cmp)
+	slices.SortFunc(b, cmp)
+	return slices.Equal(a, b)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var (
+	ErrBadCatalogEntry = domain.Invalid("bad catalog entry")
+	ErrCatalogConflict = domain.Conflict("name or alias already used by another catalog entry")
+)
+
+type CatalogServiceInterface interface {
+	Create(ctx context.Context, e domain.CatalogEntry) (*domain.CatalogEntry, error)
+	GetByID(ctx context.Context, id int64) (*domain.CatalogEntry, error)
+	List(ctx context.Context) ([]domain.CatalogEntry, error)
+	Update(ctx context.Context, id int64, e domain.CatalogEntry) (*domain.CatalogEntry, error)
+	Delete(ctx context.Context, id int64) error
+	Link(ctx context.Context, id int64) (int64, error)
+}
+
+type CatalogService struct {
+	repo repository.CatalogInterface
+	log  *slog.Logger
+}
+
+var _ CatalogServiceInterface = (*CatalogService)(nil)
+
+func NewCatalogService(repo repository.CatalogInterface, log *slog.Logger) *CatalogService {
+	return &CatalogService{
+		repo: repo,
+		log:  log.With(slog.String("component", "service/catalog")),
+	}
+}
+
+// приводит запись к виду для базы и проверяет что ее названия никем не заняты
+func (s *CatalogService) check(ctx context.Context, e domain.CatalogEntry, exceptID int64) (domain.CatalogEntry, error) {
+	e.Name = strings.Join(strings.Fields(e.Name), " ")
+	if e.Name == "" || len([]rune(e.Name)) > 100 {
+		return e, fmt.Errorf("%w: name must be 1..100 chars", ErrBadCatalogEntry)
+	}
+
+	nameKey := domain.CatalogKey(e.Name)
+	aliases := make([]string, 0, len(e.Aliases))
+	for _, a := range e.Aliases {
+		a = domain.CatalogKey(a)
+		if a == "" || len([]rune(a)) > 100 {
+			return e, fmt.Errorf("%w: alias must be 1..100 chars", ErrBadCatalogEntry)
+		}
+		// алиас равный названию ничего не дает
+		if a != nameKey && !slices.Contains(aliases, a) {
+			aliases = append(aliases, a)
+		}
+	}
+	slices.Sort(aliases)
+	e.Aliases = aliases
+
+	e.LogoURL = strings.TrimSpace(e.LogoURL)
+	if e.LogoURL != "" {
+		u, err := url.Parse(e.LogoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return e, fmt.Errorf("%w: logo_url must be http(s) url", ErrBadCatalogEntry)
+		}
+	}
+
+	taken, err := s.repo.Conflicts(ctx, append([]string{nameKey}, aliases...), exceptID)
+	if err != nil {
+		return e, err
+	}
+	if taken {
+		return e, ErrCatalogConflict
+	}
+	return e, nil
+}
+
+func (s *CatalogService) Create(ctx context.Context, e domain.CatalogEntry) (*domain.CatalogEntry, error) {
+	const op = "service catalog Create"
+
+	e, err := s.check(ctx, e, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.Create(ctx, e)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.Info("catalog entry created", slog.Int64("id", id), slog.String("name", e.Name))
+	return s.GetByID(ctx, id)
+}
+
+func (s *CatalogService) GetByID(ctx context.Context, id int64) (*domain.CatalogEntry, error) {
+	const op = "service catalog GetByID"
+
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return e, nil
+}
+
+func (s *CatalogService) List(ctx context.Context) ([]domain.CatalogEntry, error) {
+	const op = "service catalog List"
+
+	entries, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
+
+// уже привязанные подписки не переименовываются, для них есть Link
+func (s *CatalogService) Update(ctx context.Context, id int64, e domain.CatalogEntry) (*domain.CatalogEntry, error) {
+	const op = "service catalog Update"
+
+	e, err := s.check(ctx, e, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, id, e); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s.GetByID(ctx, id)
+}
+
+func (s *CatalogService) Delete(ctx context.Context, id int64) error {
+	const op = "service catalog Delete"
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.Info("catalog entry deleted", slog.Int64("id", id))
+	return nil
+}
+
+// Link привязывает к записи подписки, созданные до нее
+func (s *CatalogService) Link(ctx context.Context, id int64) (int64, error) {
+	const op = "service catalog Link"
+
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := s.repo.Link(ctx, *e)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.Info("catalog entry linked", slog.Int64("id", id), slog.Int64("subscriptions", n))
+	return n, nil
+}
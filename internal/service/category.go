@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var (
+	ErrBadCategory    = domain.Invalid("category name must be 1..50 chars")
+	ErrCategoryExists = domain.Conflict("category already exists")
+)
+
+type CategoryServiceInterface interface {
+	Create(ctx context.Context, name string) (*domain.Category, error)
+	GetByID(ctx context.Context, id int64) (*domain.Category, error)
+	List(ctx context.Context) ([]domain.Category, error)
+	Update(ctx context.Context, id int64, name string) (*domain.Category, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type CategoryService struct {
+	repo repository.CategoryInterface
+	log  *slog.Logger
+}
+
+var _ CategoryServiceInterface = (*CategoryService)(nil)
+
+func NewCategoryService(repo repository.CategoryInterface, log *slog.Logger) *CategoryService {
+	return &CategoryService{
+		repo: repo,
+		log:  log.With(slog.String("component", "service/category")),
+	}
+}
+
+// проверяет имя и что оно не занято другой категорией
+func (s *CategoryService) checkName(ctx context.Context, name string, exceptID int64) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > 50 {
+		return "", ErrBadCategory
+	}
+
+	taken, err := s.repo.NameTaken(ctx, name, exceptID)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", ErrCategoryExists
+	}
+	return name, nil
+}
+
+func (s *CategoryService) Create(ctx context.Context, name string) (*domain.Category, error) {
+	const op = "service category Create"
+
+	name, err := s.checkName(ctx, name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.Create(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.Info("category created", slog.Int64("id", id), slog.String("name", name))
+	return s.GetByID(ctx, id)
+}
+
+func (s *CategoryService) GetByID(ctx context.Context, id int64) (*domain.Category, error) {
+	const op = "service category GetByID"
+
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return c, nil
+}
+
+func (s *CategoryService) List(ctx context.Context) ([]domain.Category, error) {
+	const op = "service category List"
+
+	categories, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return categories, nil
+}
+
+func (s *CategoryService) Update(ctx context.Context, id int64, name string) (*domain.Category, error) {
+	const op = "service category Update"
+
+	name, err := s.checkName(ctx, name, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, id, name); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s.GetByID(ctx, id)
+}
+
+func (s *CategoryService) Delete(ctx context.Context, id int64) error {
+	const op = "service category Delete"
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.Info("category deleted", slog.Int64("id", id))
+	return nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// DetectChurn отмечает подписки, которые истекли вместе с льготой и не продлены ни
+// продлением, ни новой подпиской на тот же сервис, и пишет событие churned в ленту
+func (s *SubscriptionService) DetectChurn(ctx context.Context) (int, error) {
+	const op = "service DetectChurn"
+
+	subs, err := s.repo.FlagChurned(ctx, s.currentMonth())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, sub := range subs {
+		// repo отмечает только подписки с end_date
+		end, _ := time.Parse("01-2006", *sub.EndDate)
+		s.activity.Record(ctx, sub.UserID, sub.ID, domain.EventChurned, map[string]any{
+			"service_name":        sub.ServiceName,
+			"end_date":            sub.EndDate,
+			"grace_period_months": sub.GracePeriodMonths,
+			"lapsed_on":           end.AddDate(0, sub.GracePeriodMonths+1, 0).Format("01-2006"),
+		})
+	}
+	if len(subs) > 0 {
+		s.log.Info("churned subscriptions flagged", slog.Int("count", len(subs)))
+	}
+	return len(subs), nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// Cohorts раскладывает пользователей по месяцу первой подписки и для каждого месяца
+// с прихода до текущего считает, сколько из них платит, сколько подписок оплачено и
+// на какую сумму. Правила те же, что у выписки, расходы только в основной валюте
+func (s *SubscriptionService) Cohorts(ctx context.Context) ([]domain.Cohort, error) {
+	const op = "service Cohorts"
+
+	signups, err := s.repo.Signups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(signups) == 0 {
+		return []domain.Cohort{}, nil
+	}
+
+	now := s.currentMonth()
+	first := slices.MinFunc(slices.Collect(maps.Values(signups)), func(a, b time.Time) int { return a.Compare(b) })
+	subs, err := s.repo.ForPeriod(ctx, first, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byUser := make(map[uuid.UUID][]domain.Subscription)
+	for _, sub := range subs {
+		byUser[sub.UserID] = append(byUser[sub.UserID], sub)
+	}
+	members := make(map[time.Time][]uuid.UUID)
+	for userID, month := range signups {
+		members[month] = append(members[month], userID)
+	}
+
+	cohorts := []domain.Cohort{}
+	for _, start := range slices.SortedFunc(maps.Keys(members), func(a, b time.Time) int { return a.Compare(b) }) {
+		users := members[start]
+		c := domain.Cohort{Cohort: start.Format("01-2006"), Users: len(users), Months: []domain.CohortMonth{}}
+
+		for m, offset := start, 0; !m.After(now); m, offset = m.AddDate(0, 1, 0), offset+1 {
+			cm := domain.CohortMonth{Month: m.Format("01-2006"), Offset: offset}
+			for _, userID := range users {
+				active := false
+				for _, sub := range byUser[userID] {
+					months, cost := s.billedCost(sub, m, m)
+					if months <= 0 {
+						continue
+					}
+					active = true
+					cm.Subscriptions++
+					if cmp.Or(sub.Currency, s.currency) == s.currency {
+						cm.Spend += cost
+					}
+				}
+				if active {
+					cm.ActiveUsers++
+				}
+			}
+			c.Months = append(c.Months, cm)
+		}
+		cohorts = append(cohorts, c)
+	}
+	return cohorts, nil
+}
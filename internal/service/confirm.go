@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+const deleteConfirmTTL = 10 * time.Minute
+
+// удаление пачкой нашло дорогие подписки, а токена нет - надо выдать
+var errConfirmRequired = errors.New("delete confirmation required")
+
+// токены подтверждения хранятся в базе только хешем
+func newConfirmToken() (token, hash string, err error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(buf)
+	return token, confirmHash(token), nil
+}
+
+func confirmHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkConfirm пропускает удаление только с живым токеном, выданным на этот subject
+func checkConfirm(token string, now time.Time) repository.ConfirmCheck {
+	return func(tokenHash string, expiresAt time.Time) error {
+		if subtle.ConstantTimeCompare([]byte(confirmHash(token)), []byte(tokenHash)) != 1 || now.After(expiresAt) {
+			return ErrBadConfirmToken
+		}
+		return nil
+	}
+}
+
+// issueConfirm выдает токен на subject и сохраняет его хеш
+func (s *SubscriptionService) issueConfirm(ctx context.Context, subject string, now time.Time) (string, time.Time, error) {
+	token, hash, err := newConfirmToken()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("cant issue token: %w", err)
+	}
+	expiresAt := now.Add(deleteConfirmTTL)
+	if err := s.repo.IssueDeleteConfirmation(ctx, subject, hash, expiresAt, now); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+func subscriptionConfirmSubject(id int64) string {
+	return fmt.Sprintf("subscription:%d", id)
+}
+
+// токен пачки действует только на тот же фильтр
+func bulkConfirmSubject(userID uuid.UUID, serviceName string) string {
+	return fmt.Sprintf("subscriptions:%s:%s", userID, serviceName)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+var ErrBadCurrency = errors.New("currency must be an ISO 4217 code like RUB or USD")
+
+func normalizeCurrency(code string) (string, error) {
+	code, ok := domain.NormalizeCurrency(code)
+	if !ok {
+		return "", ErrBadCurrency
+	}
+	return code, nil
+}
+
+// fillCurrencyTotals считает итоги по валютам из деталей. total_cost - только основная валюта,
+// остальные с ней не складываются
+func (s *SubscriptionService) fillCurrencyTotals(res *domain.TotalCost) {
+	byCurrency := map[string]domain.Money{}
+	for _, d := range res.Details {
+		byCurrency[d.Currency] += d.Cost
+	}
+
+	res.Currency = s.currency
+	res.Total = byCurrency[s.currency]
+	// основная валюта в списке всегда, даже с нулем, как и total_cost
+	codes := currenciesOf(s.currency, res.Details, func(d domain.CostDetail) string { return d.Currency })
+	res.Totals = make([]domain.CurrencyCost, 0, len(codes))
+	for _, code := range codes {
+		res.Totals = append(res.Totals, domain.CurrencyCost{Currency: code, Cost: byCurrency[code]})
+	}
+}
+
+// currenciesOf - валюты из items: основная первой, остальные по алфавиту
+func currenciesOf[T any](main string, items []T, currency func(T) string) []string {
+	codes := []string{main}
+	for _, it := range items {
+		if c := currency(it); !slices.Contains(codes, c) {
+			codes = append(codes, c)
+		}
+	}
+	slices.Sort(codes[1:])
+	return codes
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/fx"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var (
+	ErrNoRates = errors.New("exchange rates are not loaded yet")
+	ErrNoRate  = errors.New("no exchange rate for currency")
+)
+
+type ExchangeRateServiceInterface interface {
+	Refresh(ctx context.Context) error
+	Current(ctx context.Context) (*fx.Rates, error)
+	ConvertTotal(ctx context.Context, total *domain.TotalCost, to string) (*domain.TotalCost, error)
+}
+
+// ExchangeRateService забирает курсы у провайдера, хранит их в Postgres и держит
+// последние в памяти, чтоб расчеты не ходили ни к провайдеру, ни в базу
+type ExchangeRateService struct {
+	provider fx.Provider
+	repo     repository.ExchangeRateInterface
+	log      *slog.Logger
+
+	cache atomic.Pointer[fx.Rates]
+}
+
+var _ ExchangeRateServiceInterface = (*ExchangeRateService)(nil)
+
+func NewExchangeRateService(provider fx.Provider, repo repository.ExchangeRateInterface, log *slog.Logger) *ExchangeRateService {
+	return &ExchangeRateService{
+		provider: provider,
+		repo:     repo,
+		log:      log.With(slog.String("component", "service/exchangerate")),
+	}
+}
+
+func (s *ExchangeRateService) Refresh(ctx context.Context) error {
+	const op = "service exchangerate Refresh"
+
+	rates, err := s.provider.Latest(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.repo.Save(ctx, rates); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.cache.Store(rates)
+	s.log.Info("exchange rates refreshed",
+		slog.String("source", rates.Source), slog.String("as_of", rates.AsOf.Format(time.DateOnly)), slog.Int("currencies", len(rates.Rates)))
+	return nil
+}
+
+// Current - последние курсы: из памяти, после рестарта из базы
+func (s *ExchangeRateService) Current(ctx context.Context) (*fx.Rates, error) {
+	const op = "service exchangerate Current"
+
+	if rates := s.cache.Load(); rates != nil {
+		return rates, nil
+	}
+
+	rates, err := s.repo.Latest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if rates == nil {
+		return nil, ErrNoRates
+	}
+	s.cache.CompareAndSwap(nil, rates)
+	return rates, nil
+}
+
+// ConvertTotal пересчитывает расходы в валюту to до суммирования: каждая строка
+// переводится отдельно и округляется, итоги - сумма уже переведенных строк
+func (s *ExchangeRateService) ConvertTotal(ctx context.Context, total *domain.TotalCost, to string) (*domain.TotalCost, error) {
+	to, err := normalizeCurrency(to)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := s.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conv := domain.Conversion{Source: rates.Source, AsOf: rates.AsOf.Format(time.DateOnly), Rates: map[string]float64{}}
+	convert := func(amount domain.Money, from string) (domain.Money, error) {
+		v, ok := rates.Convert(float64(amount), from, to)
+		if !ok {
+			missing := from
+			if _, has := rates.Rates[from]; has {
+				missing = to
+			}
+			return 0, fmt.Errorf("%w: %s (%s)", ErrNoRate, missing, rates.Source)
+		}
+		if _, seen := conv.Rates[from]; !seen {
+			conv.Rates[from], _ = rates.Convert(1, from, to)
+		}
+		return domain.Money(math.Round(v)), nil
+	}
+
+	res := &domain.TotalCost{
+		Currency:  to,
+		Details:   make([]domain.CostDetail, 0, len(total.Details)),
+		Converted: &conv,
+	}
+	for _, d := range total.Details {
+		cost, err := convert(d.Cost, d.Currency)
+		if err != nil {
+			return nil, err
+		}
+		res.Total += cost
+		res.Details = append(res.Details, domain.CostDetail{ServiceName: d.ServiceName, Months: d.Months, Cost: cost, Currency: to})
+	}
+	res.Totals = []domain.CurrencyCost{{Currency: to, Cost: res.Total}}
+
+	// итоги net и gross по валютам переводятся и складываются так же, как totals
+	if total.Tax != nil {
+		tax := *total.Tax
+		if tax.TotalNet, err = convertSum(tax.TotalsNet, convert); err != nil {
+			return nil, err
+		}
+		if tax.TotalGross, err = convertSum(tax.TotalsGross, convert); err != nil {
+			return nil, err
+		}
+		tax.TotalsNet = []domain.CurrencyCost{{Currency: to, Cost: tax.TotalNet}}
+		tax.TotalsGross = []domain.CurrencyCost{{Currency: to, Cost: tax.TotalGross}}
+		res.Tax = &tax
+	}
+
+	// строки одного месяца в разных валютах сливаются в одну
+	for _, m := range total.Months {
+		if len(res.Months) == 0 || res.Months[len(res.Months)-1].Month != m.Month {
+			res.Months = append(res.Months, domain.MonthCost{Month: m.Month, Currency: to, Services: map[string]domain.Money{}})
+		}
+		mc := &res.Months[len(res.Months)-1]
+		for name, cost := range m.Services {
+			converted, err := convert(cost, m.Currency)
+			if err != nil {
+				return nil, err
+			}
+			mc.Services[name] += converted
+			mc.Total += converted
+		}
+	}
+
+	categories, err := convertCategories(total.Categories, to, convert)
+	if err != nil {
+		return nil, err
+	}
+	res.Categories = categories
+	return res, nil
+}
+
+func convertSum(totals []domain.CurrencyCost, convert func(domain.Money, string) (domain.Money, error)) (domain.Money, error) {
+	var sum domain.Money
+	for _, t := range totals {
+		cost, err := convert(t.Cost, t.Currency)
+		if err != nil {
+			return 0, err
+		}
+		sum += cost
+	}
+	return sum, nil
+}
+
+// категории в разных валютах сливаются, порядок как в categoryTotals
+func convertCategories(src []domain.CategoryCost, to string, convert func(domain.Money, string) (domain.Money, error)) ([]domain.CategoryCost, error) {
+	var uncategorized *domain.CategoryCost
+	byID := map[int64]*domain.CategoryCost{}
+	for _, c := range src {
+		cost, err := convert(c.Cost, c.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.CategoryID == nil {
+			if uncategorized == nil {
+				uncategorized = &domain.CategoryCost{Currency: to}
+			}
+			uncategorized.Cost += cost
+			continue
+		}
+		cc, ok := byID[*c.CategoryID]
+		if !ok {
+			cc = &domain.CategoryCost{CategoryID: c.CategoryID, Category: c.Category, Currency: to}
+			byID[*c.CategoryID] = cc
+		}
+		cc.Cost += cost
+	}
+
+	out := make([]domain.CategoryCost, 0, len(byID)+1)
+	for _, cc := range byID {
+		out = append(out, *cc)
+	}
+	slices.SortFunc(out, func(a, b domain.CategoryCost) int {
+		return strings.Compare(*a.Category, *b.Category)
+	})
+	if uncategorized != nil {
+		out = append(out, *uncategorized)
+	}
+	return out, nil
+}
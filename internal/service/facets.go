@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+// Facets считает подписки пользователя по корзинам цены, статусам и категориям, чтоб UI
+// нарисовал фильтры списка без выгрузки всех строк. Пустые корзины и статусы отдаются с нулем
+func (s *SubscriptionService) Facets(ctx context.Context, userID uuid.UUID) (*domain.SubscriptionFacets, error) {
+	const op = "service Facets"
+
+	rows, err := s.repo.Facets(ctx, userID, domain.PriceBucketBounds, s.currentMonth())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := &domain.SubscriptionFacets{
+		PriceBuckets: priceBuckets(domain.PriceBucketBounds),
+		Statuses:     make([]domain.StatusCount, 0, len(domain.Statuses)),
+		Categories:   []domain.CategoryCount{},
+	}
+	for _, st := range domain.Statuses {
+		res.Statuses = append(res.Statuses, domain.StatusCount{Status: st})
+	}
+
+	for _, row := range rows {
+		switch row.Facet {
+		case repository.FacetPrice:
+			if row.Bucket < len(res.PriceBuckets) {
+				res.PriceBuckets[row.Bucket].Count = row.Count
+			}
+		case repository.FacetStatus:
+			for i := range res.Statuses {
+				if res.Statuses[i].Status == row.Status {
+					res.Statuses[i].Count = row.Count
+				}
+			}
+		case repository.FacetCategory:
+			res.Categories = append(res.Categories, domain.CategoryCount{CategoryID: row.CategoryID, Name: row.CategoryName, Count: row.Count})
+		}
+	}
+	return res, nil
+}
+
+// корзины по нижним границам: первая - бесплатные, каждая следующая до следующей границы
+func priceBuckets(bounds []domain.Money) []domain.PriceBucketCount {
+	buckets := make([]domain.PriceBucketCount, 0, len(bounds)+1)
+	var from domain.Money
+	for _, b := range bounds {
+		to := b - 1
+		buckets = append(buckets, domain.PriceBucketCount{MinPrice: from, MaxPrice: &to})
+		from = b
+	}
+	return append(buckets, domain.PriceBucketCount{MinPrice: from})
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// самый дальний горизонт прогноза
+const maxForecastMonths = 24
+
+// Forecast прогнозирует расходы на months месяцев начиная с текущего по тем же
+// правилам пересечения, что и расчет за период. Бессрочные подписки считаются
+// продленными по текущей цене, бессрочная пауза - продолженной; такие месяцы помечены
+func (s *SubscriptionService) Forecast(ctx context.Context, userID uuid.UUID, months int) (*domain.Forecast, error) {
+	const op = "service Forecast"
+
+	if months < 1 || months > maxForecastMonths {
+		return nil, ErrBadHorizon
+	}
+
+	from := s.currentMonth()
+	to := from.AddDate(0, months-1, 0)
+
+	subs, err := s.repo.GetTotalCost(ctx, userID, "", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := &domain.Forecast{Months: make([]domain.ForecastMonth, 0, months)}
+	for m := from; !m.After(to); m = m.AddDate(0, 1, 0) {
+		fm := domain.ForecastMonth{Month: m.Format("01-2006"), Services: map[string]domain.Money{}}
+
+		for _, sub := range subs {
+			months, cost := s.billedCost(sub, m, m)
+			billed := months > 0
+			if billed {
+				fm.Total += cost
+				fm.Services[sub.ServiceName] += cost
+			}
+			if note := forecastAssumption(sub, m, billed); note != "" {
+				fm.Assumptions = append(fm.Assumptions, note)
+			}
+		}
+
+		fm.Assumed = len(fm.Assumptions) > 0
+		res.Total += fm.Total
+		res.Months = append(res.Months, fm)
+	}
+	return res, nil
+}
+
+// допущение, на котором держится сумма подписки в месяце m, пустая строка - его нет
+func forecastAssumption(sub domain.Subscription, m time.Time, billed bool) string {
+	// бессрочная пауза: считаем, что подписку так и не возобновят
+	if sub.PausedFrom != nil && sub.PausedUntil == nil {
+		if from, err := time.Parse("01-2006", *sub.PausedFrom); err == nil && !m.Before(from) {
+			return fmt.Sprintf("%s: paused without end, assumed still paused", sub.ServiceName)
+		}
+	}
+
+	if billed && sub.EndDate == nil {
+		return fmt.Sprintf("%s: open-ended, price %s assumed unchanged", sub.ServiceName, sub.Price)
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// GroupedCost считает расходы с группировкой на стороне базы и собирает
+// вложенный ответ: при both внутри каждого месяца список сервисов
+func (s *SubscriptionService) GroupedCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string, groupBy string) (*domain.GroupedCost, error) {
+	const op = "service GroupedCost"
+
+	if !slices.Contains(domain.GroupByOptions, groupBy) {
+		return nil, ErrBadGroupBy
+	}
+
+	from, to, err := parseCostPeriod(fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+
+	byService := groupBy == domain.GroupByService || groupBy == domain.GroupByBoth
+	byMonth := groupBy == domain.GroupByMonth || groupBy == domain.GroupByBoth
+
+	rows, err := s.repo.GroupCost(ctx, userID, serviceName, from, to, s.excludeFinalMonth, byService, byMonth)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := &domain.GroupedCost{GroupBy: groupBy, Groups: []domain.CostGroup{}}
+	for _, row := range rows {
+		res.Total += row.Cost
+
+		g := domain.CostGroup{ServiceName: row.ServiceName, Months: row.Months, Cost: row.Cost}
+		if row.Month != nil {
+			g.Month = row.Month.Format("01-2006")
+			// в разрезе месяца число месяцев всегда 1, не шумим
+			g.Months = 0
+		}
+
+		if groupBy != domain.GroupByBoth {
+			res.Groups = append(res.Groups, g)
+			continue
+		}
+
+		// база отдает строки по месяцам, сервисы одного месяца идут подряд
+		if n := len(res.Groups); n == 0 || res.Groups[n-1].Month != g.Month {
+			res.Groups = append(res.Groups, domain.CostGroup{Month: g.Month})
+		}
+		last := &res.Groups[len(res.Groups)-1]
+		last.Cost += g.Cost
+		last.Services = append(last.Services, domain.CostGroup{ServiceName: g.ServiceName, Cost: g.Cost})
+	}
+	return res, nil
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// History отдает правки подписки, у удаленной подписки - ErrNotFound
+func (s *SubscriptionService) History(ctx context.Context, id int64) ([]domain.HistoryEntry, error) {
+	const op = "service History"
+
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	history, err := s.repo.History(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return history, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var (
+	ErrIdempotencyKeyReused = domain.ErrIdempotencyKeyReused
+	ErrIdempotencyInFlight  = domain.ErrIdempotencyInFlight
+)
+
+type IdempotencyServiceInterface interface {
+	// Begin отдает сохраненный ответ для повтора или nil если запрос надо выполнить
+	Begin(ctx context.Context, scope, key, requestHash string) (*domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, scope, key string, status int, contentType string, response []byte) error
+	Abort(ctx context.Context, scope, key string)
+}
+
+type IdempotencyService struct {
+	repo repository.IdempotencyInterface
+	ttl  time.Duration
+	log  *slog.Logger
+}
+
+var _ IdempotencyServiceInterface = (*IdempotencyService)(nil)
+
+func NewIdempotencyService(repo repository.IdempotencyInterface, ttl time.Duration, log *slog.Logger) *IdempotencyService {
+	return &IdempotencyService{
+		repo: repo,
+		ttl:  ttl,
+		log:  log.With(slog.String("component", "service/idempotency")),
+	}
+}
+
+func (s *IdempotencyService) Begin(ctx context.Context, scope, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	const op = "service idempotency Begin"
+
+	rec, claimed, err := s.repo.Claim(ctx, scope, key, requestHash, s.ttl)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	// тот же ключ, но другое тело - скорее всего баг клиента
+	if rec.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if rec.StatusCode == 0 {
+		return nil, ErrIdempotencyInFlight
+	}
+
+	s.log.Info("idempotent replay", slog.String("scope", scope))
+	return rec, nil
+}
+
+func (s *IdempotencyService) Complete(ctx context.Context, scope, key string, status int, contentType string, response []byte) error {
+	const op = "service idempotency Complete"
+
+	if err := s.repo.Save(ctx, scope, key, status, contentType, response); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *IdempotencyService) Abort(ctx context.Context, scope, key string) {
+	if err := s.repo.Release(ctx, scope, key); err != nil {
+		s.log.Error("cant release idempotency key", slog.String("scope", scope), slog.String("err", err.Error()))
+	}
+}
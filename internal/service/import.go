@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/importer"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/pricing"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var ErrBadImportMode = errors.New("mode must be strict or lenient")
+
+type ImportServiceInterface interface {
+	ImportCSV(ctx context.Context, r io.Reader, mode string) (*domain.ImportResult, error)
+}
+
+// SubscriptionCreator - путь создания подписки, через который идет каждая строка импорта:
+// те же проверки и нормализация, что у Create, и событие created для вставленных
+type SubscriptionCreator interface {
+	PrepareCreate(ctx context.Context, sub domain.Subscription) (domain.Subscription, error)
+	RecordCreated(ctx context.Context, id int64, sub domain.Subscription)
+}
+
+var _ SubscriptionCreator = (*SubscriptionService)(nil)
+
+type ImportOptions struct {
+	ChunkSize int
+	// если пачка пишется дольше, делаем паузу чтобы не душить OLTP нагрузку
+	TargetLatency time.Duration
+	MaxPause      time.Duration
+}
+
+type ImportService struct {
+	repo  repository.ImportInterface
+	subs  SubscriptionCreator
+	clock clock.Clock
+	opts  ImportOptions
+	log   *slog.Logger
+}
+
+var _ ImportServiceInterface = (*ImportService)(nil)
+
+func NewImportService(repo repository.ImportInterface, subs SubscriptionCreator, clk clock.Clock, opts ImportOptions, log *slog.Logger) *ImportService {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 500
+	}
+	return &ImportService{
+		repo:  repo,
+		subs:  subs,
+		clock: clk,
+		opts:  opts,
+		log:   log.With(slog.String("component", "service/import")),
+	}
+}
+
+func (s *ImportService) ImportCSV(ctx context.Context, r io.Reader, mode string) (*domain.ImportResult, error) {
+	const op = "service ImportCSV"
+
+	if mode == "" {
+		mode = domain.ImportModeStrict
+	}
+	if mode != domain.ImportModeStrict && mode != domain.ImportModeLenient {
+		return nil, ErrBadImportMode
+	}
+
+	// складываем файл на диск: считаем хеш для продолжения и читаем его дважды
+	file, checksum, err := spool(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	res := &domain.ImportResult{Mode: mode}
+
+	// в строгом режиме любая ошибка отклоняет весь файл, проверяем заранее
+	if mode == domain.ImportModeStrict {
+		if err := s.validate(file, mode, res); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if len(res.Errors) > 0 {
+			res.Skipped = res.Total
+			return res, nil
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res.Total, res.Warnings = 0, nil
+	}
+
+	job, resumed, err := s.repo.StartJob(ctx, checksum, mode)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	res.JobID = job.ID
+	if resumed {
+		res.ResumedFrom = job.CommittedRow + 1
+		s.log.Info("resuming import", slog.Int64("job_id", job.ID), slog.Int("from_row", res.ResumedFrom))
+	}
+
+	reader, err := importer.NewReader(file, mode)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	imported := job.Imported
+	chunk := make([]importer.Row, 0, s.opts.ChunkSize)
+	lastRow := job.CommittedRow
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		n, err := s.commitChunk(ctx, job.ID, chunk, lastRow, res)
+		if err != nil {
+			return err
+		}
+		imported += n
+		res.Chunks++
+		chunk = chunk[:0]
+		return nil
+	}
+
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		// уже закоммичено в прошлый раз
+		if row.Row <= job.CommittedRow {
+			continue
+		}
+
+		res.Total++
+		lastRow = row.Row
+		res.AddWarnings(row.Warnings)
+		if len(row.Errors) > 0 {
+			res.AddErrors(row.Errors)
+			continue
+		}
+
+		chunk = append(chunk, row)
+		if len(chunk) >= s.opts.ChunkSize {
+			if err := flush(); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.FinishJob(ctx, job.ID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res.Imported = imported
+	res.Skipped = res.Total - (imported - job.Imported)
+	s.log.Info("import finished",
+		slog.Int64("job_id", job.ID), slog.String("mode", mode),
+		slog.Int("imported", res.Imported), slog.Int("skipped", res.Skipped), slog.Int("chunks", res.Chunks),
+	)
+
+	return res, nil
+}
+
+func (s *ImportService) validate(file io.Reader, mode string, res *domain.ImportResult) error {
+	reader, err := importer.NewReader(file, mode)
+	if err != nil {
+		return err
+	}
+
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		res.Total++
+		res.AddWarnings(row.Warnings)
+		res.AddErrors(row.Errors)
+	}
+}
+
+func (s *ImportService) commitChunk(ctx context.Context, jobID int64, chunk []importer.Row, lastRow int, res *domain.ImportResult) (int, error) {
+	// строка проходит проверки Create: каталог, цена, лимит, политики.
+	// Отказ пропускает строку с ошибкой, сбой останавливает импорт
+	subs := make([]domain.Subscription, 0, len(chunk))
+	rows := make([]int, 0, len(chunk))
+	for _, row := range chunk {
+		sub, err := s.subs.PrepareCreate(ctx, row.Sub)
+		if err != nil {
+			if !rowRejected(err) {
+				return 0, err
+			}
+			res.AddErrors([]domain.ImportIssue{{Row: row.Row, Message: err.Error()}})
+			continue
+		}
+		subs = append(subs, sub)
+		rows = append(rows, row.Row)
+	}
+
+	started := time.Now()
+	ids, err := s.repo.CommitChunk(ctx, jobID, subs, lastRow, clock.MonthStart(s.clock.Now()))
+	if err != nil {
+		return 0, err
+	}
+	elapsed := time.Since(started)
+
+	n := 0
+	for i, id := range ids {
+		if id == 0 {
+			res.AddErrors([]domain.ImportIssue{{Row: rows[i], Message: ErrSubscriptionExists.Error()}})
+			continue
+		}
+		s.subs.RecordCreated(ctx, id, subs[i])
+		n++
+	}
+
+	// база отвечает медленно - притормаживаем пропорционально
+	if s.opts.TargetLatency > 0 && elapsed > s.opts.TargetLatency {
+		pause := elapsed - s.opts.TargetLatency
+		if s.opts.MaxPause > 0 && pause > s.opts.MaxPause {
+			pause = s.opts.MaxPause
+		}
+		s.log.Debug("import backpressure", slog.Duration("chunk_latency", elapsed), slog.Duration("pause", pause))
+
+		select {
+		case <-ctx.Done():
+			return n, ctx.Err()
+		case <-time.After(pause):
+		}
+	}
+
+	return n, nil
+}
+
+// отказ по самой строке, а не сбой базы или хука политик
+func rowRejected(err error) bool {
+	return errors.Is(err, domain.ErrInvalid) || errors.Is(err, domain.ErrConflict) || errors.Is(err, domain.ErrUnknownCategory) ||
+		errors.Is(err, ErrBadTags) || errors.Is(err, ErrBadCurrency) || errors.Is(err, ErrBadBillingPeriod) ||
+		errors.Is(err, pricing.ErrBadBasis) || errors.Is(err, pricing.ErrBadCountry) ||
+		errors.Is(err, pricing.ErrPriceOutOfRange) || errors.Is(err, pricing.ErrNoTaxRate) ||
+		errors.Is(err, ErrPolicyRejected)
+}
+
+func spool(r io.Reader) (*os.File, string, error) {
+	file, err := os.CreateTemp("", "import-*.csv")
+	if err != nil {
+		return nil, "", err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(file, io.TeeReader(r, h)); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, "", err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, "", err
+	}
+
+	return file, hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/notifier"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var (
+	ErrUnknownNotificationKind = domain.NotFound("unknown notification kind")
+	ErrBadTemplate             = domain.Invalid("bad notification template")
+)
+
+type NotificationTemplateServiceInterface interface {
+	List(ctx context.Context) ([]domain.NotificationTemplate, error)
+	Get(ctx context.Context, kind string) (*domain.NotificationTemplate, error)
+	Set(ctx context.Context, kind, subject, body string) (*domain.NotificationTemplate, error)
+	Reset(ctx context.Context, kind string) (*domain.NotificationTemplate, error)
+	Preview(ctx context.Context, kind string, draft *notifier.Template) (*domain.NotificationPreview, error)
+	Render(ctx context.Context, kind string, data any) (subject, body string)
+}
+
+// NotificationTemplateService отдает шаблоны уведомлений: переопределенный в базе
+// или встроенный. Организаций пока нет, поэтому набор шаблонов один на инсталляцию
+type NotificationTemplateService struct {
+	repo repository.NotificationTemplateInterface
+	log  *slog.Logger
+}
+
+var _ NotificationTemplateServiceInterface = (*NotificationTemplateService)(nil)
+
+func NewNotificationTemplateService(repo repository.NotificationTemplateInterface, log *slog.Logger) *NotificationTemplateService {
+	return &NotificationTemplateService{
+		repo: repo,
+		log:  log.With(slog.String("component", "service/notificationtemplate")),
+	}
+}
+
+func defaultTemplate(kind string) (*domain.NotificationTemplate, error) {
+	def, ok := notifier.Default(kind)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownNotificationKind, kind)
+	}
+	return &domain.NotificationTemplate{Kind: kind, Subject: def.Subject, Body: def.Body}, nil
+}
+
+// все виды уведомлений, переопределенные вперемешку со встроенными
+func (s *NotificationTemplateService) List(ctx context.Context) ([]domain.NotificationTemplate, error) {
+	const op = "service notificationtemplate List"
+
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	custom := make(map[string]domain.NotificationTemplate, len(stored))
+	for _, t := range stored {
+		custom[t.Kind] = t
+	}
+
+	out := make([]domain.NotificationTemplate, 0, len(notifier.Kinds()))
+	for _, kind := range notifier.Kinds() {
+		if t, ok := custom[kind]; ok {
+			out = append(out, t)
+			continue
+		}
+		def, _ := defaultTemplate(kind)
+		out = append(out, *def)
+	}
+	return out, nil
+}
+
+func (s *NotificationTemplateService) Get(ctx context.Context, kind string) (*domain.NotificationTemplate, error) {
+	const op = "service notificationtemplate Get"
+
+	def, err := defaultTemplate(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.repo.Get(ctx, kind)
+	if errors.Is(err, domain.ErrNotFound) {
+		return def, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return t, nil
+}
+
+func (s *NotificationTemplateService) Set(ctx context.Context, kind, subject, body string) (*domain.NotificationTemplate, error) {
+	const op = "service notificationtemplate Set"
+
+	if _, err := defaultTemplate(kind); err != nil {
+		return nil, err
+	}
+	tmpl := notifier.Template{Subject: strings.TrimSpace(subject), Body: strings.TrimSpace(body)}
+	if tmpl.Subject == "" || tmpl.Body == "" {
+		return nil, fmt.Errorf("%w: subject and body are required", ErrBadTemplate)
+	}
+	if err := tmpl.Validate(kind); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadTemplate, err.Error())
+	}
+
+	t, err := s.repo.Upsert(ctx, domain.NotificationTemplate{Kind: kind, Subject: tmpl.Subject, Body: tmpl.Body})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.Info("notification template saved", slog.String("kind", kind))
+	return t, nil
+}
+
+// сбрасывает переопределение, дальше уходит встроенный шаблон
+func (s *NotificationTemplateService) Reset(ctx context.Context, kind string) (*domain.NotificationTemplate, error) {
+	const op = "service notificationtemplate Reset"
+
+	def, err := defaultTemplate(kind)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Delete(ctx, kind); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.Info("notification template reset", slog.String("kind", kind))
+	return def, nil
+}
+
+// Preview рендерит черновик (или текущий шаблон, если черновика нет) на примере данных
+func (s *NotificationTemplateService) Preview(ctx context.Context, kind string, draft *notifier.Template) (*domain.NotificationPreview, error) {
+	const op = "service notificationtemplate Preview"
+
+	if draft == nil {
+		t, err := s.Get(ctx, kind)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		draft = &notifier.Template{Subject: t.Subject, Body: t.Body}
+	} else if _, err := defaultTemplate(kind); err != nil {
+		return nil, err
+	}
+
+	subject, body, err := draft.Render(notifier.Sample(kind))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadTemplate, err.Error())
+	}
+	return &domain.NotificationPreview{Subject: subject, Body: body}, nil
+}
+
+// Render для рассылки: любая проблема с переопределением не должна терять
+// уведомление, поэтому откатываемся на встроенный шаблон
+func (s *NotificationTemplateService) Render(ctx context.Context, kind string, data any) (string, string) {
+	def, _ := notifier.Default(kind)
+
+	t, err := s.Get(ctx, kind)
+	if err != nil {
+		s.log.Error("notification template load failed, using default", slog.String("kind", kind), slog.String("err", err.Error()))
+		subject, body, _ := def.Render(data)
+		return subject, body
+	}
+
+	subject, body, err := notifier.Template{Subject: t.Subject, Body: t.Body}.Render(data)
+	if err != nil {
+		s.log.Error("notification template render failed, using default", slog.String("kind", kind), slog.String("err", err.Error()))
+		subject, body, _ = def.Render(data)
+	}
+	return subject, body
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+var (
+	ErrBadOffboardPolicy = domain.Invalid("policy must be cancel or transfer")
+	ErrNoTransferTarget  = domain.Invalid("transfer_to is required for transfer policy and must differ from the user")
+)
+
+// Offboard разбирается с действующими подписками ушедшего пользователя: отменяет
+// с текущего месяца или передает transferTo. Закончившиеся остаются у него для истории.
+// Повторный вызов доделывает то, что не успел прошлый
+func (s *SubscriptionService) Offboard(ctx context.Context, userID uuid.UUID, policy string, transferTo uuid.UUID) (*domain.OffboardResult, error) {
+	const op = "service Offboard"
+
+	switch policy {
+	case domain.OffboardCancel:
+	case domain.OffboardTransfer:
+		if transferTo == uuid.Nil || transferTo == userID {
+			return nil, ErrNoTransferTarget
+		}
+	default:
+		return nil, ErrBadOffboardPolicy
+	}
+
+	currentMonth := s.currentMonth()
+
+	// сначала собираем, чтоб не держать курсор пока меняем подписки
+	var live []domain.Subscription
+	for sub, err := range s.repo.Stream(ctx, userID, domain.SubscriptionFilter{}) {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if isLive(*sub, currentMonth) {
+			live = append(live, *sub)
+		}
+	}
+
+	res := &domain.OffboardResult{Policy: policy}
+	if policy == domain.OffboardTransfer {
+		res.TransferTo = &transferTo
+	}
+
+	for _, sub := range live {
+		if policy == domain.OffboardTransfer {
+			if err := s.repo.Transfer(ctx, sub.ID, transferTo); err != nil {
+				return res, fmt.Errorf("%s: %w", op, err)
+			}
+			s.activity.Record(ctx, userID, sub.ID, domain.EventTransferred, map[string]any{"to": transferTo})
+			s.activity.Record(ctx, transferTo, sub.ID, domain.EventTransferred, map[string]any{"from": userID})
+			res.Transferred = append(res.Transferred, sub.ID)
+			continue
+		}
+
+		// еще не начавшаяся отменяется месяцем старта
+		month := currentMonth
+		if start, _ := time.Parse("01-2006", sub.StartDate); start.After(month) {
+			month = start
+		}
+		if _, err := s.Cancel(ctx, sub.ID, month.Format("01-2006")); err != nil && !errors.Is(err, ErrAlreadyEnded) {
+			return res, fmt.Errorf("%s: %w", op, err)
+		}
+		res.Cancelled = append(res.Cancelled, sub.ID)
+	}
+
+	s.log.Info("user offboarded", slog.String("user_id", userID.String()), slog.String("policy", policy),
+		slog.Int("cancelled", len(res.Cancelled)), slog.Int("transferred", len(res.Transferred)))
+	return res, nil
+}
+
+// не отменена и не закончилась до текущего месяца
+func isLive(sub domain.Subscription, currentMonth time.Time) bool {
+	if sub.CancelledAt != nil {
+		return false
+	}
+	if sub.EndDate == nil {
+		return true
+	}
+	end, _ := time.Parse("01-2006", *sub.EndDate)
+	return !end.Before(currentMonth)
+}
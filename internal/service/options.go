@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/pricing"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+// EventRecorder - куда сервис подписок пишет события, обычно лента ActivityService
+type EventRecorder interface {
+	Record(ctx context.Context, userID uuid.UUID, subID int64, eventType string, payload map[string]any)
+}
+
+// без ленты события просто теряются
+type nopRecorder struct{}
+
+func (nopRecorder) Record(context.Context, uuid.UUID, int64, string, map[string]any) {}
+
+// SubscriptionOption настраивает SubscriptionService. Без опций сервис работает
+// на реальных часах, в RUB, без ленты событий, каталога, политик и налогов
+type SubscriptionOption func(*SubscriptionService)
+
+func WithEventBus(events EventRecorder) SubscriptionOption {
+	return func(s *SubscriptionService) { s.activity = events }
+}
+
+func WithClock(clk clock.Clock) SubscriptionOption {
+	return func(s *SubscriptionService) { s.clock = clk }
+}
+
+// внешние хуки политик перед create и extend
+func WithPolicy(policy PolicyChecker) SubscriptionOption {
+	return func(s *SubscriptionService) { s.policy = policy }
+}
+
+// сверка цены с ожидаемой по каталогу цен
+func WithPriceChecker(prices *pricing.Checker) SubscriptionOption {
+	return func(s *SubscriptionService) { s.prices = prices }
+}
+
+// nil интерфейс оставляет налоговый слой выключенным
+func WithTax(tax pricing.Normalizer) SubscriptionOption {
+	return func(s *SubscriptionService) { s.tax = tax }
+}
+
+// доля пользователей, которым total_cost считает SQL движок
+func WithCostCanary(canary *CostCanary) SubscriptionOption {
+	return func(s *SubscriptionService) { s.canary = canary }
+}
+
+// каталог сервисов для нормализации названий
+func WithCatalog(catalog repository.CatalogInterface) SubscriptionOption {
+	return func(s *SubscriptionService) { s.catalog = catalog }
+}
+
+// выше этой цены удаление идет в два шага
+func WithDeleteConfirmPrice(price domain.Money) SubscriptionOption {
+	return func(s *SubscriptionService) { s.deleteConfirmPrice = price }
+}
+
+// последний месяц отмененной подписки не входит в расходы
+func WithExcludeFinalMonth(exclude bool) SubscriptionOption {
+	return func(s *SubscriptionService) { s.excludeFinalMonth = exclude }
+}
+
+// основная валюта подписок без валюты и total_cost
+func WithCurrency(code string) SubscriptionOption {
+	return func(s *SubscriptionService) { s.currency = code }
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+var (
+	ErrBadPauseRange = domain.Invalid("paused_from and paused_to must be MM-YYYY, from not later than to and not in the past")
+	ErrPauseOverlap  = domain.Conflict("pause overlaps another pause of the subscription")
+	ErrPauseStarted  = domain.Conflict("pause already started, resume the subscription instead")
+)
+
+// SchedulePause планирует сезонную паузу [fromStr, toStr] включительно. Пауза не раньше
+// текущего месяца, не после конца подписки и не пересекается с другими паузами
+func (s *SubscriptionService) SchedulePause(ctx context.Context, id int64, fromStr, toStr string) (*domain.Subscription, error) {
+	const op = "service SchedulePause"
+
+	from, errFrom := time.Parse("01-2006", fromStr)
+	to, errTo := time.Parse("01-2006", toStr)
+	currentMonth := s.currentMonth()
+	if errFrom != nil || errTo != nil || to.Before(from) || from.Before(currentMonth) {
+		return nil, ErrBadPauseRange
+	}
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if sub.EndDate != nil {
+		end, _ := time.Parse("01-2006", *sub.EndDate)
+		if end.Before(from) {
+			return nil, ErrAlreadyEnded
+		}
+	}
+
+	// открытая пауза тянется бесконечно, с ней пересекается все, что позже ее начала
+	for _, r := range pauseRanges(*sub) {
+		if !to.Before(r[0]) && (r[1].IsZero() || !from.After(r[1])) {
+			return nil, ErrPauseOverlap
+		}
+	}
+
+	if err := s.repo.AddPause(ctx, id, from.Format("01-2006"), to.Format("01-2006")); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.activity.Record(ctx, sub.UserID, id, domain.EventPauseScheduled, map[string]any{
+		"paused_from": from.Format("01-2006"),
+		"paused_to":   to.Format("01-2006"),
+	})
+	return s.GetByID(ctx, id)
+}
+
+// UnschedulePause отменяет запланированную паузу, пока она не началась.
+// Начавшуюся или ручную паузу снимает Resume
+func (s *SubscriptionService) UnschedulePause(ctx context.Context, id, pauseID int64) (*domain.Subscription, error) {
+	const op = "service UnschedulePause"
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var pause *domain.PauseRange
+	for i := range sub.Pauses {
+		if sub.Pauses[i].ID == pauseID {
+			pause = &sub.Pauses[i]
+			break
+		}
+	}
+	if pause == nil {
+		return nil, fmt.Errorf("%s: pause %d: %w", op, pauseID, domain.ErrNotFound)
+	}
+
+	currentMonth := s.currentMonth()
+	from, _ := time.Parse("01-2006", pause.PausedFrom)
+	if pause.PausedTo == nil || !from.After(currentMonth) {
+		return nil, ErrPauseStarted
+	}
+
+	if err := s.repo.DeletePause(ctx, id, pauseID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.activity.Record(ctx, sub.UserID, id, domain.EventPauseUnscheduled, map[string]any{
+		"paused_from": pause.PausedFrom,
+		"paused_to":   pause.PausedTo,
+	})
+	return s.GetByID(ctx, id)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var (
+	ErrBadPolicyHook     = errors.New("url must be an absolute http or https URL")
+	ErrPolicyRejected    = errors.New("rejected by policy")
+	ErrPolicyUnavailable = errors.New("policy hook unavailable")
+)
+
+// проверка предлагаемой подписки перед записью, current - состояние до продления
+type PolicyChecker interface {
+	Check(ctx context.Context, action string, proposed domain.Subscription, current *domain.Subscription) error
+}
+
+type PolicyServiceInterface interface {
+	PolicyChecker
+	Create(ctx context.Context, hook domain.PolicyHook) (*domain.PolicyHook, error)
+	List(ctx context.Context) ([]domain.PolicyHook, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// вызов одного хука, реализует policy.Client
+type PolicyHookCaller interface {
+	Check(ctx context.Context, hook domain.PolicyHook, req domain.PolicyRequest) (*domain.PolicyDecision, error)
+}
+
+type PolicyService struct {
+	repo   repository.PolicyHookInterface
+	caller PolicyHookCaller
+	log    *slog.Logger
+}
+
+var _ PolicyServiceInterface = (*PolicyService)(nil)
+
+func NewPolicyService(repo repository.PolicyHookInterface, caller PolicyHookCaller, log *slog.Logger) *PolicyService {
+	return &PolicyService{
+		repo:   repo,
+		caller: caller,
+		log:    log.With(slog.String("component", "service/policy")),
+	}
+}
+
+func (s *PolicyService) Create(ctx context.Context, hook domain.PolicyHook) (*domain.PolicyHook, error) {
+	const op = "service policy Create"
+
+	hook.URL = strings.TrimSpace(hook.URL)
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrBadPolicyHook
+	}
+
+	created, err := s.repo.Create(ctx, hook)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.Info("policy hook registered", slog.Int64("id", created.ID), slog.String("host", u.Host))
+	return created, nil
+}
+
+func (s *PolicyService) List(ctx context.Context) ([]domain.PolicyHook, error) {
+	const op = "service policy List"
+
+	hooks, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return hooks, nil
+}
+
+func (s *PolicyService) Delete(ctx context.Context, id int64) error {
+	const op = "service policy Delete"
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Check вызывает хуки по порядку регистрации, первый отказ блокирует операцию.
+// Недоступный хук с fail_open пропускается, без него операция тоже отклоняется
+func (s *PolicyService) Check(ctx context.Context, action string, proposed domain.Subscription, current *domain.Subscription) error {
+	const op = "service policy Check"
+
+	hooks, err := s.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	req := domain.PolicyRequest{Action: action, Subscription: proposed, Current: current}
+	for _, hook := range hooks {
+		decision, err := s.caller.Check(ctx, hook, req)
+		if err != nil {
+			s.log.Warn("policy hook failed", slog.Int64("hook_id", hook.ID), slog.Bool("fail_open", hook.FailOpen), slog.String("err", err.Error()))
+			if hook.FailOpen {
+				continue
+			}
+			return fmt.Errorf("%w: hook %d", ErrPolicyUnavailable, hook.ID)
+		}
+		if !decision.Allow {
+			s.log.Info("operation rejected by policy", slog.Int64("hook_id", hook.ID), slog.String("action", action), slog.String("reason", decision.Reason))
+			if decision.Reason == "" {
+				return ErrPolicyRejected
+			}
+			return fmt.Errorf("%w: %s", ErrPolicyRejected, decision.Reason)
+		}
+	}
+	return nil
+}
+
+func (s *SubscriptionService) checkPolicy(ctx context.Context, action string, proposed domain.Subscription, current *domain.Subscription) error {
+	if s.policy == nil {
+		return nil
+	}
+	return s.policy.Check(ctx, action, proposed, current)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// ProratedTotalCost - как GetTotalCost, только первый и последний месяц подписки
+// оплачиваются по дням из start_day и end_day. Считается всегда на Go
+func (s *SubscriptionService) ProratedTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string) (*domain.TotalCost, error) {
+	const op = "service ProratedTotalCost"
+
+	reqFrom, reqTo, err := parseCostPeriod(fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.totalCostGo(ctx, userID, serviceName, reqFrom, reqTo, s.proratedCost)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// proratedCost - costFunc для proration=daily: каждый оплачиваемый месяц берется
+// долей по дням, сумма округляется до копейки один раз на строку
+func (s *SubscriptionService) proratedCost(sub domain.Subscription, reqFrom, reqTo time.Time) (int, domain.Money) {
+	months := 0
+	exact := 0.0
+	for m := reqFrom; !m.After(reqTo); m = m.AddDate(0, 1, 0) {
+		if s.billedMonths(sub, m, m) <= 0 {
+			continue
+		}
+		months++
+
+		price := sub.Price
+		if inTrial(sub, m) {
+			price = sub.TrialPrice
+		}
+		days, inMonth := activeDays(sub, m)
+		exact += monthlyExact(price, sub.BillingPeriod) * float64(days) / float64(inMonth)
+	}
+	return months, domain.Money(math.Round(exact))
+}
+
+// сколько дней месяца m подписка действует и сколько в нем дней всего.
+// Неполными бывают только месяц start_date с start_day и месяц end_date с end_day
+func activeDays(sub domain.Subscription, m time.Time) (int, int) {
+	inMonth := time.Date(m.Year(), m.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	month := m.Format("01-2006")
+
+	first, last := 1, inMonth
+	if sub.StartDay != nil && sub.StartDate == month {
+		first = *sub.StartDay
+	}
+	if sub.EndDay != nil && sub.EndDate != nil && *sub.EndDate == month {
+		last = min(*sub.EndDay, inMonth)
+	}
+	return max(0, last-first+1), inMonth
+}
+
+// цена месяца без округления, в копейках
+func monthlyExact(price domain.Money, period string) float64 {
+	twelfths, ok := domain.BillingTwelfths[period]
+	if !ok {
+		twelfths = domain.BillingTwelfths[domain.BillingMonthly]
+	}
+	return float64(price) * float64(twelfths) / 12
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+func TestActiveDays(t *testing.T) {
+	month := func(s string) time.Time {
+		m, _ := time.Parse("01-2006", s)
+		return m
+	}
+
+	tests := []struct {
+		name        string
+		sub         domain.Subscription
+		month       string
+		wantDays    int
+		wantInMonth int
+	}{
+		{
+			name:  "no days is a full month",
+			sub:   domain.Subscription{StartDate: "03-2026", EndDate: ptr("03-2026")},
+			month: "03-2026", wantDays: 31, wantInMonth: 31,
+		},
+		{
+			name:  "first month from start_day",
+			sub:   domain.Subscription{StartDate: "03-2026", StartDay: ptr(10)},
+			month: "03-2026", wantDays: 22, wantInMonth: 31,
+		},
+		{
+			name:  "start_day on the last day",
+			sub:   domain.Subscription{StartDate: "04-2026", StartDay: ptr(30)},
+			month: "04-2026", wantDays: 1, wantInMonth: 30,
+		},
+		{
+			name:  "last month up to end_day",
+			sub:   domain.Subscription{StartDate: "01-2026", EndDate: ptr("06-2026"), EndDay: ptr(15)},
+			month: "06-2026", wantDays: 15, wantInMonth: 30,
+		},
+		{
+			name:  "month between start and end is full",
+			sub:   domain.Subscription{StartDate: "01-2026", StartDay: ptr(20), EndDate: ptr("06-2026"), EndDay: ptr(5)},
+			month: "03-2026", wantDays: 31, wantInMonth: 31,
+		},
+		{
+			name:  "start and end in the same month",
+			sub:   domain.Subscription{StartDate: "03-2026", StartDay: ptr(10), EndDate: ptr("03-2026"), EndDay: ptr(20)},
+			month: "03-2026", wantDays: 11, wantInMonth: 31,
+		},
+		{
+			name:  "same day start and end",
+			sub:   domain.Subscription{StartDate: "03-2026", StartDay: ptr(10), EndDate: ptr("03-2026"), EndDay: ptr(10)},
+			month: "03-2026", wantDays: 1, wantInMonth: 31,
+		},
+		{
+			name:  "end_day past february is cut to its length",
+			sub:   domain.Subscription{StartDate: "01-2026", EndDate: ptr("02-2026"), EndDay: ptr(31)},
+			month: "02-2026", wantDays: 28, wantInMonth: 28,
+		},
+		{
+			name:  "leap february",
+			sub:   domain.Subscription{StartDate: "02-2028", StartDay: ptr(15)},
+			month: "02-2028", wantDays: 15, wantInMonth: 29,
+		},
+		{
+			name:  "nil start_day with end_day",
+			sub:   domain.Subscription{StartDate: "02-2026", EndDate: ptr("02-2026"), EndDay: ptr(14)},
+			month: "02-2026", wantDays: 14, wantInMonth: 28,
+		},
+		{
+			name:  "start_day with nil end_day",
+			sub:   domain.Subscription{StartDate: "02-2026", StartDay: ptr(15), EndDate: ptr("02-2026")},
+			month: "02-2026", wantDays: 14, wantInMonth: 28,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, inMonth := activeDays(tt.sub, month(tt.month))
+			if days != tt.wantDays || inMonth != tt.wantInMonth {
+				t.Errorf("activeDays(%s) = %d/%d, want %d/%d", tt.month, days, inMonth, tt.wantDays, tt.wantInMonth)
+			}
+		})
+	}
+}
+
+func TestProratedCost(t *testing.T) {
+	month := func(s string) time.Time {
+		m, _ := time.Parse("01-2006", s)
+		return m
+	}
+
+	tests := []struct {
+		name       string
+		sub        domain.Subscription
+		from, to   string
+		wantMonths int
+		wantCost   domain.Money
+	}{
+		{
+			name:       "whole months without days",
+			sub:        domain.Subscription{Price: 3100, StartDate: "01-2026", EndDate: ptr("03-2026")},
+			from:       "01-2026",
+			to:         "12-2026",
+			wantMonths: 3, wantCost: 9300,
+		},
+		{
+			name: "partial first and last month",
+			// март 22/31, апрель 15/30
+			sub:        domain.Subscription{Price: 3100, StartDate: "03-2026", StartDay: ptr(10), EndDate: ptr("04-2026"), EndDay: ptr(15)},
+			from:       "01-2026",
+			to:         "12-2026",
+			wantMonths: 2, wantCost: 2200 + 1550,
+		},
+		{
+			name:       "same month",
+			sub:        domain.Subscription{Price: 3100, StartDate: "03-2026", StartDay: ptr(10), EndDate: ptr("03-2026"), EndDay: ptr(20)},
+			from:       "03-2026",
+			to:         "03-2026",
+			wantMonths: 1, wantCost: 1100,
+		},
+		{
+			name:       "february half",
+			sub:        domain.Subscription{Price: 2800, StartDate: "02-2026", StartDay: ptr(15), EndDate: ptr("02-2026")},
+			from:       "02-2026",
+			to:         "02-2026",
+			wantMonths: 1, wantCost: 1400,
+		},
+		{
+			name:       "period cuts off the partial months",
+			sub:        domain.Subscription{Price: 3100, StartDate: "03-2026", StartDay: ptr(10), EndDate: ptr("06-2026"), EndDay: ptr(15)},
+			from:       "04-2026",
+			to:         "05-2026",
+			wantMonths: 2, wantCost: 6200,
+		},
+	}
+
+	s := NewSubscriptionService(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			months, cost := s.proratedCost(tt.sub, month(tt.from), month(tt.to))
+			if months != tt.wantMonths || cost != tt.wantCost {
+				t.Errorf("proratedCost = %d months, %d; want %d months, %d", months, cost, tt.wantMonths, tt.wantCost)
+			}
+		})
+	}
+}
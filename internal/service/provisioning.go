@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+// сколько пользователей за один bulk запрос
+const MaxProvisionBatch = 1000
+
+var (
+	ErrProvisionBatch     = domain.Invalid(fmt.Sprintf("from 1 to %d users per request", MaxProvisionBatch))
+	ErrNoUserIdentity     = errors.New("id or external_id is required")
+	ErrExternalIDConflict = errors.New("external_id already belongs to another user")
+)
+
+type ProvisioningServiceInterface interface {
+	// Provision создает и обновляет пользователей, active=false деактивирует по политике по умолчанию
+	Provision(ctx context.Context, users []ProvisionUser) ([]domain.ProvisionResult, error)
+	// Deactivate - policy и transferTo пустые - берутся из конфига
+	Deactivate(ctx context.Context, ids []uuid.UUID, policy string, transferTo uuid.UUID) ([]domain.ProvisionResult, error)
+	List(ctx context.Context, active *bool) ([]domain.User, error)
+	Get(ctx context.Context, id uuid.UUID) (*domain.User, error)
+}
+
+// строка bulk запроса: id можно не знать, тогда пользователь ищется по external_id
+type ProvisionUser struct {
+	ID          uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ExternalID  string    `json:"external_id" example:"00u1abcd"`
+	Email       string    `json:"email" example:"ivanov@example.com"`
+	DisplayName string    `json:"display_name" example:"Иван Иванов"`
+	// nil - не менять, новый пользователь активен
+	Active *bool `json:"active" example:"true"`
+}
+
+// ProvisioningService заводит пользователей из IdP и при увольнении
+// отменяет или передает их подписки
+type ProvisioningService struct {
+	repo repository.UserInterface
+	subs SubscriptionServiceInterface
+	log  *slog.Logger
+
+	policy     string
+	transferTo uuid.UUID
+}
+
+var _ ProvisioningServiceInterface = (*ProvisioningService)(nil)
+
+func NewProvisioningService(repo repository.UserInterface, subs SubscriptionServiceInterface, policy string, transferTo uuid.UUID, log *slog.Logger) (*ProvisioningService, error) {
+	if !slices.Contains(domain.OffboardPolicies, policy) {
+		return nil, ErrBadOffboardPolicy
+	}
+	if policy == domain.OffboardTransfer && transferTo == uuid.Nil {
+		return nil, ErrNoTransferTarget
+	}
+	return &ProvisioningService{
+		repo:       repo,
+		subs:       subs,
+		policy:     policy,
+		transferTo: transferTo,
+		log:        log.With(slog.String("component", "service/provisioning")),
+	}, nil
+}
+
+func (s *ProvisioningService) Provision(ctx context.Context, users []ProvisionUser) ([]domain.ProvisionResult, error) {
+	if len(users) == 0 || len(users) > MaxProvisionBatch {
+		return nil, ErrProvisionBatch
+	}
+
+	results := make([]domain.ProvisionResult, 0, len(users))
+	for _, pu := range users {
+		res := domain.ProvisionResult{ID: pu.ID, ExternalID: pu.ExternalID}
+		if err := s.provisionOne(ctx, pu, &res); err != nil {
+			res.Status, res.Error = domain.ProvisionError, err.Error()
+			if !errors.Is(err, ErrNoUserIdentity) && !errors.Is(err, ErrExternalIDConflict) {
+				s.log.Error("user provisioning failed", slog.String("id", res.ID.String()), slog.String("err", err.Error()))
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func (s *ProvisioningService) provisionOne(ctx context.Context, pu ProvisionUser, res *domain.ProvisionResult) error {
+	const op = "service provisioning Provision"
+
+	if pu.ID == uuid.Nil && pu.ExternalID == "" {
+		return ErrNoUserIdentity
+	}
+
+	// external_id - ключ IdP, по нему находим уже заведенного пользователя
+	if pu.ExternalID != "" {
+		existing, err := s.repo.GetByExternalID(ctx, pu.ExternalID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+		case err != nil:
+			return fmt.Errorf("%s: %w", op, err)
+		case pu.ID == uuid.Nil:
+			pu.ID = existing.ID
+		case pu.ID != existing.ID:
+			return ErrExternalIDConflict
+		}
+	}
+	if pu.ID == uuid.Nil {
+		pu.ID = uuid.New()
+	}
+	res.ID = pu.ID
+
+	var wasActive bool
+	current, err := s.repo.Get(ctx, pu.ID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("%s: %w", op, err)
+	default:
+		wasActive = current.Active
+	}
+
+	created, err := s.repo.Upsert(ctx, &domain.User{ID: pu.ID, ExternalID: pu.ExternalID, Email: pu.Email, DisplayName: pu.DisplayName})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res.Status = domain.ProvisionUpdated
+	if created {
+		res.Status = domain.ProvisionCreated
+	}
+
+	active := pu.Active == nil || *pu.Active
+	if pu.Active == nil && current != nil {
+		active = wasActive
+	}
+	if !active {
+		// уже деактивированный повторно не трогаем
+		if !created && !wasActive {
+			return nil
+		}
+		return s.deactivateOne(ctx, pu.ID, s.policy, s.transferTo, res)
+	}
+	if !created && !wasActive {
+		// вернувшемуся сотруднику подписки не восстанавливаются, заводятся заново
+		if err := s.repo.SetActive(ctx, pu.ID, true); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+func (s *ProvisioningService) Deactivate(ctx context.Context, ids []uuid.UUID, policy string, transferTo uuid.UUID) ([]domain.ProvisionResult, error) {
+	if len(ids) == 0 || len(ids) > MaxProvisionBatch {
+		return nil, ErrProvisionBatch
+	}
+	if policy == "" {
+		policy = s.policy
+	}
+	if transferTo == uuid.Nil {
+		transferTo = s.transferTo
+	}
+	if !slices.Contains(domain.OffboardPolicies, policy) {
+		return nil, ErrBadOffboardPolicy
+	}
+	if policy == domain.OffboardTransfer && transferTo == uuid.Nil {
+		return nil, ErrNoTransferTarget
+	}
+
+	results := make([]domain.ProvisionResult, 0, len(ids))
+	for _, id := range ids {
+		res := domain.ProvisionResult{ID: id}
+		if err := s.deactivateOne(ctx, id, policy, transferTo, &res); err != nil {
+			res.Status, res.Error = domain.ProvisionError, err.Error()
+			if !errors.Is(err, domain.ErrNotFound) && !errors.Is(err, ErrNoTransferTarget) {
+				s.log.Error("user deactivation failed", slog.String("id", id.String()), slog.String("err", err.Error()))
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// сначала подписки, потом флаг: если упали посередине, повтор доделает остальное
+func (s *ProvisioningService) deactivateOne(ctx context.Context, id uuid.UUID, policy string, transferTo uuid.UUID, res *domain.ProvisionResult) error {
+	const op = "service provisioning Deactivate"
+
+	u, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res.ExternalID = u.ExternalID
+
+	offboard, err := s.subs.Offboard(ctx, id, policy, transferTo)
+	res.Offboard = offboard
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.repo.SetActive(ctx, id, false); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res.Status = domain.ProvisionDeactivated
+	s.log.Info("user deactivated", slog.String("id", id.String()), slog.String("policy", policy))
+	return nil
+}
+
+func (s *ProvisioningService) List(ctx context.Context, active *bool) ([]domain.User, error) {
+	const op = "service provisioning List"
+
+	users, err := s.repo.List(ctx, active)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return users, nil
+}
+
+func (s *ProvisioningService) Get(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	const op = "service provisioning Get"
+
+	u, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return u, nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var (
+	ErrReminderNotApplicable = domain.Invalid("subscription has no end date")
+	ErrReminderWrongUser     = domain.NotFound("subscription belongs to another user")
+	ErrBadSnoozePeriod       = domain.Invalid("snooze days must be between 1 and 90")
+	ErrBadReminderOffsets    = domain.Invalid("days_before must hold up to 10 values between 0 and 365")
+)
+
+const (
+	maxSnoozeDays      = 90
+	maxReminderOffsets = 10
+	maxOffsetDays      = 365
+)
+
+type ReminderServiceInterface interface {
+	Acknowledge(ctx context.Context, subID int64, userID uuid.UUID) (*domain.ReminderState, error)
+	Snooze(ctx context.Context, subID int64, userID uuid.UUID, days int) (*domain.ReminderState, error)
+	// SetOffsets задает свои сроки напоминаний в днях до конца подписки, пустой список
+	// возвращает общий срок REMINDER_LEAD_MONTHS
+	SetOffsets(ctx context.Context, subID int64, userID uuid.UUID, daysBefore []int) (*domain.ReminderState, error)
+	Due(ctx context.Context, now time.Time) ([]domain.DueReminder, error)
+	MarkNotified(ctx context.Context, reminder domain.DueReminder, at time.Time) error
+}
+
+type ReminderService struct {
+	reminders  repository.ReminderInterface
+	subs       repository.SubscriptionInterface
+	activity   ActivityServiceInterface
+	leadMonths int
+	repeat     time.Duration
+	clock      clock.Clock
+	log        *slog.Logger
+}
+
+var _ ReminderServiceInterface = (*ReminderService)(nil)
+
+func NewReminderService(reminders repository.ReminderInterface, subs repository.SubscriptionInterface, activity ActivityServiceInterface, leadMonths int, repeat time.Duration, clk clock.Clock, log *slog.Logger) *ReminderService {
+	return &ReminderService{
+		reminders:  reminders,
+		subs:       subs,
+		activity:   activity,
+		leadMonths: leadMonths,
+		repeat:     repeat,
+		clock:      clk,
+		log:        log.With(slog.String("component", "service/reminder")),
+	}
+}
+
+func (s *ReminderService) Acknowledge(ctx context.Context, subID int64, userID uuid.UUID) (*domain.ReminderState, error) {
+	const op = "service reminder Acknowledge"
+
+	sub, err := s.ownedSubscription(ctx, subID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.reminders.Acknowledge(ctx, subID, userID, *sub.EndDate); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.reminders.Get(ctx, subID, userID)
+}
+
+func (s *ReminderService) Snooze(ctx context.Context, subID int64, userID uuid.UUID, days int) (*domain.ReminderState, error) {
+	const op = "service reminder Snooze"
+
+	if days < 1 || days > maxSnoozeDays {
+		return nil, ErrBadSnoozePeriod
+	}
+
+	if _, err := s.ownedSubscription(ctx, subID, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	until := s.clock.Now().AddDate(0, 0, days)
+	if err := s.reminders.Snooze(ctx, subID, userID, until); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.reminders.Get(ctx, subID, userID)
+}
+
+func (s *ReminderService) SetOffsets(ctx context.Context, subID int64, userID uuid.UUID, daysBefore []int) (*domain.ReminderState, error) {
+	const op = "service reminder SetOffsets"
+
+	days, err := normalizeOffsets(daysBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	// конец подписки может появиться позже, поэтому сроки можно задать и бессрочной
+	sub, err := s.subs.GetByID(ctx, subID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if sub.UserID != userID {
+		return nil, ErrReminderWrongUser
+	}
+
+	if err := s.reminders.SetOffsets(ctx, subID, days); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.reminders.Get(ctx, subID, userID)
+}
+
+// убирает повторы и сортирует от дальнего срока к ближнему
+func normalizeOffsets(daysBefore []int) ([]int, error) {
+	if len(daysBefore) > maxReminderOffsets {
+		return nil, ErrBadReminderOffsets
+	}
+	days := make([]int, 0, len(daysBefore))
+	for _, d := range daysBefore {
+		if d < 0 || d > maxOffsetDays {
+			return nil, ErrBadReminderOffsets
+		}
+		if !slices.Contains(days, d) {
+			days = append(days, d)
+		}
+	}
+	slices.Sort(days)
+	slices.Reverse(days)
+	return days, nil
+}
+
+func (s *ReminderService) Due(ctx context.Context, now time.Time) ([]domain.DueReminder, error) {
+	const op = "service reminder Due"
+
+	// окно: с текущего месяца и на leadMonths вперед
+	currMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	until := currMonth.AddDate(0, s.leadMonths, 0)
+
+	due, err := s.reminders.ListDue(ctx, now, until, now.Add(-s.repeat))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// подписки со своими сроками в ListDue не попадают
+	byOffset, err := s.reminders.ListDueOffsets(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return append(due, byOffset...), nil
+}
+
+func (s *ReminderService) MarkNotified(ctx context.Context, reminder domain.DueReminder, at time.Time) error {
+	const op = "service reminder MarkNotified"
+
+	if err := s.reminders.MarkNotified(ctx, reminder.SubscriptionID, reminder.UserID, at); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	payload := map[string]any{
+		"service_name": reminder.ServiceName,
+		"end_date":     reminder.EndDate,
+	}
+	if reminder.DaysBefore != nil {
+		if err := s.reminders.MarkOffsetSent(ctx, reminder.SubscriptionID, *reminder.DaysBefore, reminder.EndOn); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		payload["days_before"] = *reminder.DaysBefore
+	}
+
+	s.activity.Record(ctx, reminder.UserID, reminder.SubscriptionID, domain.EventReminderSent, payload)
+	return nil
+}
+
+func (s *ReminderService) ownedSubscription(ctx context.Context, subID int64, userID uuid.UUID) (*domain.Subscription, error) {
+	sub, err := s.subs.GetByID(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+
+	// чужие подписки не трогаем
+	if sub.UserID != userID {
+		return nil, ErrReminderWrongUser
+	}
+
+	if sub.EndDate == nil {
+		return nil, ErrReminderNotApplicable
+	}
+
+	return sub, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var (
+	ErrBadRoute       = domain.Invalid(`route must look like "POST /subscriptions/import"`)
+	ErrRouteProtected = domain.Invalid("admin routes cant be disabled")
+)
+
+type RouteSwitchServiceInterface interface {
+	Disabled(route string) (string, bool)
+	List(ctx context.Context) ([]domain.DisabledRoute, error)
+	Disable(ctx context.Context, route, reason string) error
+	Enable(ctx context.Context, route string) error
+	Refresh(ctx context.Context) error
+}
+
+// RouteSwitchService держит список выключенных маршрутов в памяти, чтоб проверка
+// на каждом запросе не ходила в базу. Другие реплики подтягивают изменения через Refresh
+type RouteSwitchService struct {
+	repo repository.RouteSwitchInterface
+	log  *slog.Logger
+
+	// route -> причина
+	disabled atomic.Pointer[map[string]string]
+}
+
+var _ RouteSwitchServiceInterface = (*RouteSwitchService)(nil)
+
+func NewRouteSwitchService(repo repository.RouteSwitchInterface, log *slog.Logger) *RouteSwitchService {
+	s := &RouteSwitchService{
+		repo: repo,
+		log:  log.With(slog.String("component", "service/routeswitch")),
+	}
+	s.disabled.Store(&map[string]string{})
+	return s
+}
+
+func (s *RouteSwitchService) Disabled(route string) (string, bool) {
+	reason, ok := (*s.disabled.Load())[route]
+	return reason, ok
+}
+
+func (s *RouteSwitchService) List(ctx context.Context) ([]domain.DisabledRoute, error) {
+	const op = "service routeswitch List"
+
+	routes, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return routes, nil
+}
+
+func (s *RouteSwitchService) Disable(ctx context.Context, route, reason string) error {
+	const op = "service routeswitch Disable"
+
+	route, err := normalizeRoute(route)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Disable(ctx, route, reason); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.Warn("route disabled", slog.String("route", route), slog.String("reason", reason))
+	return s.Refresh(ctx)
+}
+
+func (s *RouteSwitchService) Enable(ctx context.Context, route string) error {
+	const op = "service routeswitch Enable"
+
+	route, err := normalizeRoute(route)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Enable(ctx, route); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.log.Warn("route enabled", slog.String("route", route))
+	return s.Refresh(ctx)
+}
+
+// Refresh перечитывает список из базы
+func (s *RouteSwitchService) Refresh(ctx context.Context) error {
+	const op = "service routeswitch Refresh"
+
+	routes, err := s.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	disabled := make(map[string]string, len(routes))
+	for _, r := range routes {
+		disabled[r.Route] = r.Reason
+	}
+	s.disabled.Store(&disabled)
+	return nil
+}
+
+// шаблон в том виде, как его видит mux: "МЕТОД /путь"
+func normalizeRoute(route string) (string, error) {
+	method, path, ok := strings.Cut(strings.TrimSpace(route), " ")
+	method = strings.ToUpper(method)
+	path = strings.TrimSpace(path)
+
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return "", ErrBadRoute
+	}
+	if !ok || !strings.HasPrefix(path, "/") {
+		return "", ErrBadRoute
+	}
+
+	// иначе можно выключить и саму кнопку включения
+	if strings.HasPrefix(path, "/admin/") {
+		return "", ErrRouteProtected
+	}
+	return method + " " + path, nil
+}
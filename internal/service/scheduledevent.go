@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var (
+	ErrBadScheduledEvent    = domain.Invalid("invalid scheduled event")
+	ErrScheduledEventClosed = domain.Conflict("scheduled event already ran or was cancelled")
+)
+
+const (
+	// сколько действий выполняем за один проход поллера, остальные - на следующем
+	scheduledBatch = 100
+	// на сколько месяцев можно поставить одно автопродление
+	maxRenewMonths = 24
+	// сколько ждем хук политик при автопродлении
+	scheduledPolicyTimeout = 3 * time.Second
+)
+
+// продление по умолчанию - один период оплаты, недельные продлеваются помесячно
+var renewMonths = map[string]int{
+	domain.BillingWeekly:    1,
+	domain.BillingMonthly:   1,
+	domain.BillingQuarterly: 3,
+	domain.BillingYearly:    12,
+}
+
+type ScheduledEventServiceInterface interface {
+	// Schedule ставит отложенное действие подписке пользователя
+	Schedule(ctx context.Context, subID int64, userID uuid.UUID, ev domain.ScheduledEvent) (*domain.ScheduledEvent, error)
+	List(ctx context.Context, subID int64, userID uuid.UUID) ([]domain.ScheduledEvent, error)
+	// Cancel отменяет действие, пока оно не выполнено
+	Cancel(ctx context.Context, subID int64, userID uuid.UUID, eventID int64) (*domain.ScheduledEvent, error)
+	// RunDue выполняет наступившие действия, отдает сколько обработано
+	RunDue(ctx context.Context) (int, error)
+}
+
+type ScheduledEventService struct {
+	events      repository.ScheduledEventInterface
+	subs        repository.SubscriptionInterface
+	policy      PolicyChecker
+	clock       clock.Clock
+	maxAttempts int
+	log         *slog.Logger
+}
+
+var _ ScheduledEventServiceInterface = (*ScheduledEventService)(nil)
+
+// policy может быть nil - тогда хуки политик не вызываются
+func NewScheduledEventService(events repository.ScheduledEventInterface, subs repository.SubscriptionInterface, policy PolicyChecker, clk clock.Clock, maxAttempts int, log *slog.Logger) *ScheduledEventService {
+	return &ScheduledEventService{
+		events:      events,
+		subs:        subs,
+		policy:      policy,
+		clock:       clk,
+		maxAttempts: max(maxAttempts, 1),
+		log:         log.With(slog.String("component", "service/scheduledevent")),
+	}
+}
+
+func (s *ScheduledEventService) Schedule(ctx context.Context, subID int64, userID uuid.UUID, ev domain.ScheduledEvent) (*domain.ScheduledEvent, error) {
+	const op = "service scheduledevent Schedule"
+
+	sub, err := s.ownedSubscription(ctx, subID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.check(&ev, *sub); err != nil {
+		return nil, err
+	}
+	ev.SubscriptionID = subID
+
+	created, err := s.events.Create(ctx, ev)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return created, nil
+}
+
+// check проверяет действие и дописывает умолчания
+func (s *ScheduledEventService) check(ev *domain.ScheduledEvent, sub domain.Subscription) error {
+	if !slices.Contains(domain.ScheduledEventKinds, ev.Kind) {
+		return fmt.Errorf("%w: kind must be auto_renew, trial_conversion or price_change", ErrBadScheduledEvent)
+	}
+	if !ev.RunAt.After(s.clock.Now()) {
+		return fmt.Errorf("%w: run_at must be in the future", ErrBadScheduledEvent)
+	}
+	if ev.Price != nil && ev.Kind != domain.ScheduledPriceChange {
+		return fmt.Errorf("%w: price is only for price_change", ErrBadScheduledEvent)
+	}
+	if ev.Months != 0 && ev.Kind != domain.ScheduledAutoRenew {
+		return fmt.Errorf("%w: months is only for auto_renew", ErrBadScheduledEvent)
+	}
+
+	switch ev.Kind {
+	case domain.ScheduledPriceChange:
+		if ev.Price == nil || *ev.Price < 0 {
+			return fmt.Errorf("%w: price_change needs a price not below zero", ErrBadScheduledEvent)
+		}
+	case domain.ScheduledAutoRenew:
+		if sub.EndDate == nil {
+			return fmt.Errorf("%w: auto_renew needs a subscription with end_date", ErrBadScheduledEvent)
+		}
+		if ev.Months == 0 {
+			ev.Months = renewMonths[sub.BillingPeriod]
+		}
+		if ev.Months < 1 || ev.Months > maxRenewMonths {
+			return fmt.Errorf("%w: months must be in 1..%d", ErrBadScheduledEvent, maxRenewMonths)
+		}
+	case domain.ScheduledTrialConversion:
+		if sub.TrialEndDate == nil {
+			return fmt.Errorf("%w: trial_conversion needs a subscription with trial_end_date", ErrBadScheduledEvent)
+		}
+		start, _ := time.Parse("01-2006", sub.StartDate)
+		if !clock.MonthStart(ev.RunAt).After(start) {
+			return fmt.Errorf("%w: trial_conversion must run after the start month", ErrBadScheduledEvent)
+		}
+	}
+	return nil
+}
+
+func (s *ScheduledEventService) List(ctx context.Context, subID int64, userID uuid.UUID) ([]domain.ScheduledEvent, error) {
+	const op = "service scheduledevent List"
+
+	if _, err := s.ownedSubscription(ctx, subID, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	events, err := s.events.ListBySubscription(ctx, subID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return events, nil
+}
+
+func (s *ScheduledEventService) Cancel(ctx context.Context, subID int64, userID uuid.UUID, eventID int64) (*domain.ScheduledEvent, error) {
+	const op = "service scheduledevent Cancel"
+
+	if _, err := s.ownedSubscription(ctx, subID, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ev, err := s.events.Get(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if ev.SubscriptionID != subID {
+		return nil, fmt.Errorf("%s: scheduled event %d: %w", op, eventID, domain.ErrNotFound)
+	}
+
+	ok, err := s.events.Cancel(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		return nil, ErrScheduledEventClosed
+	}
+
+	return s.events.Get(ctx, eventID)
+}
+
+func (s *ScheduledEventService) RunDue(ctx context.Context) (int, error) {
+	const op = "service scheduledevent RunDue"
+
+	now := s.clock.Now()
+	n := 0
+	for ; n < scheduledBatch && ctx.Err() == nil; n++ {
+		ev, err := s.events.RunNext(ctx, now, s.maxAttempts, s.plan)
+		if err != nil {
+			return n, fmt.Errorf("%s: %w", op, err)
+		}
+		if ev == nil {
+			break
+		}
+
+		attrs := []any{slog.Int64("id", ev.ID), slog.Int64("subscription_id", ev.SubscriptionID),
+			slog.String("kind", ev.Kind), slog.String("status", ev.Status)}
+		if ev.LastError != nil {
+			attrs = append(attrs, slog.String("reason", *ev.LastError))
+		}
+		if ev.Status == domain.ScheduledFailed {
+			s.log.Error("scheduled event gave up", attrs...)
+			continue
+		}
+		s.log.Info("scheduled event processed", attrs...)
+	}
+	return n, nil
+}
+
+// plan решает, что делать с подпиской по наступившему действию. Вызывается под
+// блокировкой подписки, поэтому видит ее состояние на момент выполнения. Продление
+// проходит те же хуки политик, что и ручное: отказ пропускает действие с причиной
+func (s *ScheduledEventService) plan(ctx context.Context, ev domain.ScheduledEvent, sub domain.Subscription) (*domain.ScheduledChange, error) {
+	runMonth := clock.MonthStart(ev.RunAt)
+	var end time.Time
+	if sub.EndDate != nil {
+		var err error
+		if end, err = time.Parse("01-2006", *sub.EndDate); err != nil {
+			return nil, fmt.Errorf("bad end_date %q: %w", *sub.EndDate, err)
+		}
+	}
+
+	switch ev.Kind {
+	case domain.ScheduledAutoRenew:
+		if sub.CancelledAt != nil {
+			return &domain.ScheduledChange{SkipReason: "subscription is cancelled"}, nil
+		}
+		if sub.EndDate == nil {
+			return &domain.ScheduledChange{SkipReason: "subscription has no end date"}, nil
+		}
+		// на паузе не продлеваем, цепочка остановится до ручного продления
+		if deriveStatus(&sub, ev.RunAt) == domain.StatusPaused {
+			return &domain.ScheduledChange{SkipReason: "subscription is paused"}, nil
+		}
+		newEnd := end.AddDate(0, ev.Months, 0).Format("01-2006")
+		extended := sub
+		extended.EndDate = &newEnd
+		reason, err := s.checkPolicy(ctx, domain.PolicyActionExtend, extended, &sub)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			return &domain.ScheduledChange{SkipReason: reason}, nil
+		}
+		return &domain.ScheduledChange{
+			EndDate:   &newEnd,
+			EventType: domain.EventExtended,
+			Payload: map[string]any{
+				"old_end_date":       sub.EndDate,
+				"new_end_date":       newEnd,
+				"scheduled_event_id": ev.ID,
+			},
+			// продление повторяется, пока следующее не отменят
+			Next: &domain.ScheduledEvent{
+				SubscriptionID: sub.ID,
+				Kind:           domain.ScheduledAutoRenew,
+				RunAt:          ev.RunAt.AddDate(0, ev.Months, 0),
+				Months:         ev.Months,
+			},
+		}, nil
+
+	case domain.ScheduledTrialConversion:
+		if sub.EndDate != nil && end.Before(runMonth) {
+			return &domain.ScheduledChange{SkipReason: "subscription ended before run_at"}, nil
+		}
+		trialEnd, ok := trialEnd(sub)
+		if !ok || trialEnd.Before(runMonth) {
+			return &domain.ScheduledChange{SkipReason: "trial already ended"}, nil
+		}
+		// триал кончается месяцем раньше run_at, с месяца run_at подписка платная
+		newTrialEnd := runMonth.AddDate(0, -1, 0).Format("01-2006")
+		return &domain.ScheduledChange{
+			TrialEndDate: &newTrialEnd,
+			EventType:    domain.EventTrialConverted,
+			Payload: map[string]any{
+				"old_trial_end_date": sub.TrialEndDate,
+				"trial_end_date":     newTrialEnd,
+				"price":              sub.Price,
+				"scheduled_event_id": ev.ID,
+			},
+		}, nil
+
+	case domain.ScheduledPriceChange:
+		if ev.Price == nil {
+			return nil, errors.New("price_change without price")
+		}
+		if sub.EndDate != nil && end.Before(runMonth) {
+			return &domain.ScheduledChange{SkipReason: "subscription ended before run_at"}, nil
+		}
+		if sub.Price == *ev.Price {
+			return &domain.ScheduledChange{SkipReason: "price is already set"}, nil
+		}
+		return &domain.ScheduledChange{
+			Price:     ev.Price,
+			EventType: domain.EventPriceChanged,
+			Payload: map[string]any{
+				"old_price":          sub.Price,
+				"new_price":          *ev.Price,
+				"scheduled_event_id": ev.ID,
+			},
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown scheduled event kind %q", ev.Kind)
+}
+
+// checkPolicy отдает причину отказа хука. Недоступный хук - ошибка, действие повторится
+func (s *ScheduledEventService) checkPolicy(ctx context.Context, action string, proposed domain.Subscription, current *domain.Subscription) (string, error) {
+	if s.policy == nil {
+		return "", nil
+	}
+	// хук зовется под блокировкой подписки: медленный не должен долго держать ее писателей,
+	// по таймауту действие повторится позже
+	ctx, cancel := context.WithTimeout(ctx, scheduledPolicyTimeout)
+	defer cancel()
+	err := s.policy.Check(ctx, action, proposed, current)
+	if errors.Is(err, ErrPolicyRejected) {
+		return err.Error(), nil
+	}
+	return "", err
+}
+
+// подписка пользователя, чужая выглядит как несуществующая
+func (s *ScheduledEventService) ownedSubscription(ctx context.Context, subID int64, userID uuid.UUID) (*domain.Subscription, error) {
+	sub, err := s.subs.GetByID(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.UserID != userID {
+		return nil, fmt.Errorf("subscription %d: %w", subID, domain.ErrNotFound)
+	}
+	return sub, nil
+}
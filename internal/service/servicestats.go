@@ -0,0 +1,60 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// за сколько месяцев до текущего считается статистика сервисов
+const serviceStatsHorizon = 24
+
+// ServiceStats для каждого месяца горизонта и сервиса считает платящих пользователей,
+// оплаченные подписки и выручку. Правила те же, что у когорт, выручка только в основной валюте
+func (s *SubscriptionService) ServiceStats(ctx context.Context) ([]domain.ServiceMonth, error) {
+	const op = "service ServiceStats"
+
+	now := s.currentMonth()
+	from := now.AddDate(0, -(serviceStatsHorizon - 1), 0)
+	subs, err := s.repo.ForPeriod(ctx, from, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stats := []domain.ServiceMonth{}
+	for m := from; !m.After(now); m = m.AddDate(0, 1, 0) {
+		byService := make(map[string]*domain.ServiceMonth)
+		users := make(map[string]map[uuid.UUID]bool)
+		for _, sub := range subs {
+			months, cost := s.billedCost(sub, m, m)
+			if months <= 0 {
+				continue
+			}
+			st, ok := byService[sub.ServiceName]
+			if !ok {
+				st = &domain.ServiceMonth{Month: m.Format("01-2006"), ServiceName: sub.ServiceName}
+				byService[sub.ServiceName] = st
+				users[sub.ServiceName] = make(map[uuid.UUID]bool)
+			}
+			st.Subscriptions++
+			users[sub.ServiceName][sub.UserID] = true
+			if cmp.Or(sub.Currency, s.currency) == s.currency {
+				st.Revenue += cost
+			}
+		}
+
+		month := make([]domain.ServiceMonth, 0, len(byService))
+		for name, st := range byService {
+			st.Subscribers = len(users[name])
+			month = append(month, *st)
+		}
+		slices.SortFunc(month, func(a, b domain.ServiceMonth) int { return strings.Compare(a.ServiceName, b.ServiceName) })
+		stats = append(stats, month...)
+	}
+	return stats, nil
+}
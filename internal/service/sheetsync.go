@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/importer"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
+)
+
+var ErrSheetSyncRunning = errors.New("sheet sync is already running")
+
+// SheetSource - таблица-реестр подписок, первая строка - заголовок
+type SheetSource interface {
+	Rows(ctx context.Context) ([][]string, error)
+}
+
+type SheetSyncServiceInterface interface {
+	Sync(ctx context.Context, dryRun bool) (*domain.SheetSyncReport, error)
+	// отчет последнего прогона, nil если еще не запускалась
+	Last() *domain.SheetSyncReport
+}
+
+// SheetSyncService сверяет таблицу с базой: новые строки создает, измененные цены и даты
+// обновляет через SubscriptionService, так что работают те же проверки, каталог и история
+type SheetSyncService struct {
+	source  SheetSource
+	subs    SubscriptionServiceInterface
+	catalog repository.CatalogInterface
+	// заголовок колонки в нижнем регистре -> поле подписки
+	columns map[string]string
+	log     *slog.Logger
+
+	running sync.Mutex
+	last    atomic.Pointer[domain.SheetSyncReport]
+}
+
+var _ SheetSyncServiceInterface = (*SheetSyncService)(nil)
+
+// columns - пары поле=заголовок, поля без пары ищутся по своему имени.
+// catalog может быть nil, тогда названия сравниваются как есть
+func NewSheetSyncService(source SheetSource, subs SubscriptionServiceInterface, catalog repository.CatalogInterface, columns []string, log *slog.Logger) (*SheetSyncService, error) {
+	mapping := make(map[string]string, len(columns))
+	for _, pair := range columns {
+		field, header, ok := strings.Cut(pair, "=")
+		field = strings.TrimSpace(field)
+		header = strings.ToLower(strings.TrimSpace(header))
+		if !ok || header == "" || !slices.Contains(importer.Fields, field) {
+			return nil, fmt.Errorf("bad sheet column %q: want field=Header, fields: %s", pair, strings.Join(importer.Fields, ", "))
+		}
+		mapping[header] = field
+	}
+
+	return &SheetSyncService{
+		source:  source,
+		subs:    subs,
+		catalog: catalog,
+		columns: mapping,
+		log:     log.With(slog.String("component", "service/sheetsync")),
+	}, nil
+}
+
+func (s *SheetSyncService) Last() *domain.SheetSyncReport {
+	return s.last.Load()
+}
+
+func (s *SheetSyncService) Sync(ctx context.Context, dryRun bool) (*domain.SheetSyncReport, error) {
+	const op = "service sheetsync Sync"
+
+	// ручной запуск и планировщик не должны писать одновременно
+	if !s.running.TryLock() {
+		return nil, ErrSheetSyncRunning
+	}
+	defer s.running.Unlock()
+
+	rows, err := s.source.Rows(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	reader, err := importer.NewTableReader(s.renameHeader(rows), domain.ImportModeLenient)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	report := &domain.SheetSyncReport{
+		StartedAt: time.Now().UTC(),
+		DryRun:    dryRun,
+		Created:   []domain.SheetRowChange{},
+		Updated:   []domain.SheetRowChange{},
+		Missing:   []domain.SheetRowChange{},
+		Warnings:  []domain.ImportIssue{},
+		Errors:    []domain.ImportIssue{},
+	}
+
+	// подписки пользователей, встреченных в таблице, и какие из них нашлись в строках
+	existing := map[uuid.UUID][]domain.Subscription{}
+	seen := map[int64]bool{}
+
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		report.Rows++
+		report.Warnings = append(report.Warnings, row.Warnings...)
+		if len(row.Errors) > 0 {
+			report.Errors = append(report.Errors, row.Errors...)
+			continue
+		}
+
+		if err := s.syncRow(ctx, row, dryRun, existing, seen, report); err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			report.Errors = append(report.Errors, domain.ImportIssue{Row: row.Row, Message: err.Error()})
+		}
+	}
+
+	for userID, subs := range existing {
+		for _, sub := range subs {
+			if !seen[sub.ID] && sub.Status != domain.StatusExpired {
+				report.Missing = append(report.Missing, domain.SheetRowChange{UserID: userID, ServiceName: sub.ServiceName})
+			}
+		}
+	}
+	slices.SortFunc(report.Missing, func(a, b domain.SheetRowChange) int {
+		if c := strings.Compare(a.UserID.String(), b.UserID.String()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ServiceName, b.ServiceName)
+	})
+
+	report.FinishedAt = time.Now().UTC()
+	s.last.Store(report)
+	s.log.Info("sheet synced",
+		slog.Bool("dry_run", dryRun), slog.Int("rows", report.Rows),
+		slog.Int("created", len(report.Created)), slog.Int("updated", len(report.Updated)),
+		slog.Int("missing", len(report.Missing)), slog.Int("errors", len(report.Errors)),
+	)
+	return report, nil
+}
+
+// заголовки из SHEETS_COLUMNS переименовываются в поля, остальные остаются как есть
+func (s *SheetSyncService) renameHeader(rows [][]string) [][]string {
+	if len(rows) == 0 {
+		return rows
+	}
+
+	header := slices.Clone(rows[0])
+	for i, name := range header {
+		if field, ok := s.columns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			header[i] = field
+		}
+	}
+	return append([][]string{header}, rows[1:]...)
+}
+
+func (s *SheetSyncService) syncRow(ctx context.Context, row importer.Row, dryRun bool, existing map[uuid.UUID][]domain.Subscription, seen map[int64]bool, report *domain.SheetSyncReport) error {
+	want := row.Sub
+	userSubs, ok := existing[want.UserID]
+	if !ok {
+		var err error
+		if userSubs, err = s.userSubscriptions(ctx, want.UserID); err != nil {
+			return err
+		}
+		existing[want.UserID] = userSubs
+	}
+
+	name, err := s.canonicalName(ctx, want.ServiceName)
+	if err != nil {
+		return err
+	}
+
+	idx := slices.IndexFunc(userSubs, func(sub domain.Subscription) bool {
+		return !seen[sub.ID] && sub.Status != domain.StatusExpired && domain.CatalogKey(sub.ServiceName) == domain.CatalogKey(name)
+	})
+	if idx < 0 {
+		change := domain.SheetRowChange{Row: row.Row, UserID: want.UserID, ServiceName: want.ServiceName}
+		if !dryRun {
+			id, err := s.subs.Create(ctx, want)
+			if err != nil {
+				return err
+			}
+			// повтор той же подписки ниже по таблице сравнится с этой
+			want.ID, want.ServiceName = id, name
+			existing[want.UserID] = append(existing[want.UserID], want)
+			seen[id] = true
+		}
+		report.Created = append(report.Created, change)
+		return nil
+	}
+
+	cur := userSubs[idx]
+	seen[cur.ID] = true
+
+	updated, changes := applySheetRow(cur, want)
+	if len(changes) == 0 {
+		report.Unchanged++
+		return nil
+	}
+	if !dryRun {
+		if _, err := s.subs.Update(ctx, cur.ID, updated); err != nil {
+			return err
+		}
+	}
+	report.Updated = append(report.Updated, domain.SheetRowChange{Row: row.Row, UserID: cur.UserID, ServiceName: cur.ServiceName, Changes: changes})
+	return nil
+}
+
+func (s *SheetSyncService) userSubscriptions(ctx context.Context, userID uuid.UUID) ([]domain.Subscription, error) {
+	rows, err := s.subs.Stream(ctx, userID, domain.SubscriptionFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []domain.Subscription
+	for sub, err := range rows {
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, nil
+}
+
+// название, под которым подписка окажется в базе после сверки с каталогом
+func (s *SheetSyncService) canonicalName(ctx context.Context, name string) (string, error) {
+	if s.catalog == nil {
+		return name, nil
+	}
+	entry, err := s.catalog.Match(ctx, domain.CatalogKey(name))
+	if err != nil || entry == nil {
+		return name, err
+	}
+	return entry.Name, nil
+}
+
+// таблица задает цену, период оплаты, даты и валюту, остальные поля подписки не трогаются
+func applySheetRow(cur, want domain.Subscription) (domain.Subscription, map[string]domain.FieldChange) {
+	changes := map[string]domain.FieldChange{}
+	if cur.Price != want.Price {
+		changes["price"] = domain.FieldChange{Old: cur.Price, New: want.Price}
+		cur.Price = want.Price
+	}
+	if cur.StartDate != want.StartDate {
+		changes["start_date"] = domain.FieldChange{Old: cur.StartDate, New: want.StartDate}
+		cur.StartDate = want.StartDate
+	}
+	if derefString(cur.EndDate) != derefString(want.EndDate) {
+		changes["end_date"] = domain.FieldChange{Old: cur.EndDate, New: want.EndDate}
+		cur.EndDate = want.EndDate
+	}
+	// пустая валюта в таблице - оставляем как есть
+	if want.Currency != "" && cur.Currency != want.Currency {
+		changes["currency"] = domain.FieldChange{Old: cur.Currency, New: want.Currency}
+		cur.Currency = want.Currency
+	}
+	if want.BillingPeriod != "" && cur.BillingPeriod != want.BillingPeriod {
+		changes["billing_period"] = domain.FieldChange{Old: cur.BillingPeriod, New: want.BillingPeriod}
+		cur.BillingPeriod = want.BillingPeriod
+	}
+	return cur, changes
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// Statement собирает выписку за месяц по тем же правилам, что и расчет расходов
+// на Go: пауза и политика последнего месяца учитываются. Валюту ставит handler
+func (s *SubscriptionService) Statement(ctx context.Context, userID uuid.UUID, monthStr string) (*domain.Statement, error) {
+	const op = "service Statement"
+
+	month, err := time.Parse("01-2006", monthStr)
+	if err != nil {
+		return nil, fmt.Errorf("bad month format")
+	}
+
+	subs, err := s.repo.GetTotalCost(ctx, userID, "", month, month)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	st := &domain.Statement{
+		UserID:      userID,
+		Period:      monthStr,
+		Lines:       []domain.StatementLine{},
+		GeneratedAt: s.clock.Now().UTC(),
+	}
+	for _, sub := range subs {
+		months, cost := s.billedCost(sub, month, month)
+		if months <= 0 {
+			continue
+		}
+
+		line := domain.StatementLine{
+			SubscriptionID: sub.ID,
+			ServiceName:    sub.ServiceName,
+			Price:          sub.Price,
+			BillingPeriod:  sub.BillingPeriod,
+			Months:         months,
+			Subtotal:       cost,
+			Trial:          inTrial(sub, month),
+		}
+		if line.Trial {
+			line.Price = sub.TrialPrice
+		}
+		st.Total += line.Subtotal
+		st.Lines = append(st.Lines, line)
+	}
+
+	slices.SortFunc(st.Lines, func(a, b domain.StatementLine) int {
+		return cmp.Or(cmp.Compare(a.ServiceName, b.ServiceName), cmp.Compare(a.SubscriptionID, b.SubscriptionID))
+	})
+	return st, nil
+}
+
+// PeriodCharges - начисления всех пользователей за месяц для бухгалтерии,
+// по тем же правилам, что и выписка
+func (s *SubscriptionService) PeriodCharges(ctx context.Context, month time.Time) ([]domain.AccountingLine, error) {
+	const op = "service PeriodCharges"
+
+	subs, err := s.repo.ForPeriod(ctx, month, month)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lines := []domain.AccountingLine{}
+	for _, sub := range subs {
+		months, cost := s.billedCost(sub, month, month)
+		if months <= 0 {
+			continue
+		}
+		price := sub.Price
+		if inTrial(sub, month) {
+			price = sub.TrialPrice
+		}
+		lines = append(lines, domain.AccountingLine{
+			Period:         month,
+			UserID:         sub.UserID,
+			SubscriptionID: sub.ID,
+			ServiceName:    sub.ServiceName,
+			CategoryName:   sub.CategoryName,
+			BillingPeriod:  sub.BillingPeriod,
+			Price:          price,
+			Months:         months,
+			Amount:         cost,
+			Currency:       cmp.Or(sub.Currency, s.currency),
+		})
+	}
+	return lines, nil
+}
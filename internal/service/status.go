@@ -0,0 +1,44 @@
+package service
+
+import (
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// статус для отдачи клиенту: пауза хранится в базе и важнее всего,
+// остальное считается по датам относительно текущего месяца
+func deriveStatus(sub *domain.Subscription, now time.Time) string {
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	// запланированная пауза, которая уже идет, тоже пауза
+	if sub.Status == domain.StatusPaused || pausedIn(pauseRanges(*sub), currentMonth) {
+		return domain.StatusPaused
+	}
+
+	start, err := time.Parse("01-2006", sub.StartDate)
+	if err == nil && start.After(currentMonth) {
+		return domain.StatusUpcoming
+	}
+
+	// end_date включительно: подписка до 03-2026 в марте еще активна
+	if sub.EndDate != nil {
+		end, err := time.Parse("01-2006", *sub.EndDate)
+		if err == nil && end.Before(currentMonth) {
+			// после конца еще grace_period_months месяцев льготы
+			if !end.AddDate(0, sub.GracePeriodMonths, 0).Before(currentMonth) {
+				return domain.StatusGrace
+			}
+			return domain.StatusExpired
+		}
+	}
+
+	return domain.StatusActive
+}
+
+func (s *SubscriptionService) withStatus(sub *domain.Subscription) *domain.Subscription {
+	if sub != nil {
+		sub.Status = deriveStatus(sub, s.clock.Now())
+	}
+	return sub
+}
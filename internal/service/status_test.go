@@ -0,0 +1,121 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestWithStatus(t *testing.T) {
+	// последняя секунда месяца и первая секунда следующего
+	lastSecond := func(month string) time.Time {
+		m, _ := time.Parse("01-2006", month)
+		return m.AddDate(0, 1, 0).Add(-time.Second)
+	}
+	firstSecond := func(month string) time.Time {
+		m, _ := time.Parse("01-2006", month)
+		return m
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		sub  domain.Subscription
+		want string
+	}{
+		{
+			name: "starts next month",
+			now:  lastSecond("03-2026"),
+			sub:  domain.Subscription{StartDate: "04-2026"},
+			want: domain.StatusUpcoming,
+		},
+		{
+			name: "start month begins",
+			now:  firstSecond("04-2026"),
+			sub:  domain.Subscription{StartDate: "04-2026"},
+			want: domain.StatusActive,
+		},
+		{
+			name: "open ended",
+			now:  firstSecond("01-2030"),
+			sub:  domain.Subscription{StartDate: "01-2024"},
+			want: domain.StatusActive,
+		},
+		{
+			name: "end month is still active",
+			now:  lastSecond("03-2026"),
+			sub:  domain.Subscription{StartDate: "01-2026", EndDate: ptr("03-2026")},
+			want: domain.StatusActive,
+		},
+		{
+			name: "month after end without grace",
+			now:  firstSecond("04-2026"),
+			sub:  domain.Subscription{StartDate: "01-2026", EndDate: ptr("03-2026")},
+			want: domain.StatusExpired,
+		},
+		{
+			name: "first grace month",
+			now:  firstSecond("04-2026"),
+			sub:  domain.Subscription{StartDate: "01-2026", EndDate: ptr("03-2026"), GracePeriodMonths: 2},
+			want: domain.StatusGrace,
+		},
+		{
+			name: "last grace month",
+			now:  lastSecond("05-2026"),
+			sub:  domain.Subscription{StartDate: "01-2026", EndDate: ptr("03-2026"), GracePeriodMonths: 2},
+			want: domain.StatusGrace,
+		},
+		{
+			name: "grace is over",
+			now:  firstSecond("06-2026"),
+			sub:  domain.Subscription{StartDate: "01-2026", EndDate: ptr("03-2026"), GracePeriodMonths: 2},
+			want: domain.StatusExpired,
+		},
+		{
+			name: "manual pause wins over dates",
+			now:  firstSecond("06-2026"),
+			sub:  domain.Subscription{StartDate: "01-2026", EndDate: ptr("03-2026"), Status: domain.StatusPaused},
+			want: domain.StatusPaused,
+		},
+		{
+			name: "scheduled pause has started",
+			now:  firstSecond("06-2026"),
+			sub: domain.Subscription{StartDate: "01-2026",
+				Pauses: []domain.PauseRange{{PausedFrom: "06-2026", PausedTo: ptr("08-2026")}}},
+			want: domain.StatusPaused,
+		},
+		{
+			name: "scheduled pause not started yet",
+			now:  lastSecond("05-2026"),
+			sub: domain.Subscription{StartDate: "01-2026",
+				Pauses: []domain.PauseRange{{PausedFrom: "06-2026", PausedTo: ptr("08-2026")}}},
+			want: domain.StatusActive,
+		},
+		{
+			name: "scheduled pause is over",
+			now:  firstSecond("09-2026"),
+			sub: domain.Subscription{StartDate: "01-2026",
+				Pauses: []domain.PauseRange{{PausedFrom: "06-2026", PausedTo: ptr("08-2026")}}},
+			want: domain.StatusActive,
+		},
+	}
+
+	clk := clock.NewFake(time.Time{})
+	s := NewSubscriptionService(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), WithClock(clk))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk.Set(tt.now)
+			sub := tt.sub
+			if got := s.withStatus(&sub).Status; got != tt.want {
+				t.Errorf("status at %s = %q, want %q", tt.now.Format(time.RFC3339), got, tt.want)
+			}
+		})
+	}
+}
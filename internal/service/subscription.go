@@ -4,64 +4,235 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"iter"
 	"log/slog"
-	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/audit"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/dates"
 	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/pricing"
 	"github.com/mmoldabe-dev/EffectiveTask/internal/repository"
 )
 
-var ErrSubscriptionExists = errors.New("subscription already exists")
+var (
+	ErrSubscriptionExists = domain.ErrSubscriptionExists
+	ErrBadConfirmToken    = domain.Conflict("confirm token is invalid or expired")
+	ErrAlreadyEnded       = domain.Conflict("subscription already ended")
+	ErrBadCancelMonth     = domain.Invalid("cancel month is outside of subscription period")
+	ErrBadTransition      = domain.Conflict("transition is not allowed in current status")
+	ErrUserRequired       = domain.Invalid("user_id is required")
+	ErrBadSort            = domain.Invalid("unsupported sort field")
+	ErrBadStatus          = domain.Invalid("unsupported status")
+	ErrBadPeriod          = domain.Invalid("from must not be later than to")
+	ErrPeriodTooLong      = domain.Invalid("period is longer than 10 years")
+	ErrBadWindow          = domain.Invalid("within_months must be in 0..24")
+	ErrBadGroupBy         = domain.Invalid("group_by must be service, month or both")
+	ErrBadHorizon         = domain.Invalid("months must be in 1..24")
+	ErrNegativePrice      = domain.Invalid("price must not be negative")
+)
 
 type SubscriptionServiceInterface interface {
 	Create(ctx context.Context, sub domain.Subscription) (int64, error)
+	PriceWarning(sub domain.Subscription) string
 	GetByID(ctx context.Context, id int64) (*domain.Subscription, error)
-	Delete(ctx context.Context, id int64) error
+	Update(ctx context.Context, id int64, sub domain.Subscription) (*domain.Subscription, error)
+	Delete(ctx context.Context, id int64, confirmToken string) (*domain.DeleteConfirmation, error)
+	DeleteByFilter(ctx context.Context, userID uuid.UUID, serviceName, confirmToken string) (int64, *domain.BulkDeleteConfirmation, error)
 	List(ctx context.Context, userID uuid.UUID, filter domain.SubscriptionFilter) ([]domain.Subscription, error)
-	GetTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string) (int64, []string, error)
-	Extend(ctx context.Context, id int64, newEndDateStr string, newPrice int) error
+	Stream(ctx context.Context, userID uuid.UUID, filter domain.SubscriptionFilter) (iter.Seq2[*domain.Subscription, error], error)
+	Services(ctx context.Context, userID uuid.UUID) ([]ServiceSummary, error)
+	GetTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string) (*domain.TotalCost, error)
+	GroupedCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string, groupBy string) (*domain.GroupedCost, error)
+	Extend(ctx context.Context, id int64, newEndDateStr string, newPrice domain.Money, version int64) error
+	Cancel(ctx context.Context, id int64, monthStr string) (*domain.Subscription, error)
+	Pause(ctx context.Context, id int64) (*domain.Subscription, error)
+	Resume(ctx context.Context, id int64) (*domain.Subscription, error)
+	SchedulePause(ctx context.Context, id int64, fromStr, toStr string) (*domain.Subscription, error)
+	UnschedulePause(ctx context.Context, id, pauseID int64) (*domain.Subscription, error)
+	History(ctx context.Context, id int64) ([]domain.HistoryEntry, error)
+	Statement(ctx context.Context, userID uuid.UUID, monthStr string) (*domain.Statement, error)
+	PeriodCharges(ctx context.Context, month time.Time) ([]domain.AccountingLine, error)
+	Offboard(ctx context.Context, userID uuid.UUID, policy string, transferTo uuid.UUID) (*domain.OffboardResult, error)
+	Upcoming(ctx context.Context, userID uuid.UUID, withinMonths int) ([]domain.UpcomingRenewal, error)
+	Facets(ctx context.Context, userID uuid.UUID) (*domain.SubscriptionFacets, error)
+	FlagTrialConversions(ctx context.Context) (int, error)
+	DetectChurn(ctx context.Context) (int, error)
+	Forecast(ctx context.Context, userID uuid.UUID, months int) (*domain.Forecast, error)
+	ProratedTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string) (*domain.TotalCost, error)
+	BatchTotalCost(ctx context.Context, userIDs []uuid.UUID, fromStr, toStr string) ([]domain.UserTotalCost, error)
+	TaxedTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string, daily bool, basis string) (*domain.TotalCost, error)
+	UpdateTags(ctx context.Context, id int64, add, remove []string) (*domain.Subscription, error)
+	Cohorts(ctx context.Context) ([]domain.Cohort, error)
+	ServiceStats(ctx context.Context) ([]domain.ServiceMonth, error)
 }
 
 type SubscriptionService struct {
-	repo repository.SubscriptionInterface
-	log  *slog.Logger
+	repo     repository.SubscriptionInterface
+	activity EventRecorder
+	log      *slog.Logger
+
+	// выше этой цены удаление идет в два шага, 0 - выключено
+	deleteConfirmPrice domain.Money
+
+	prices *pricing.Checker
+	canary *CostCanary
+
+	// приведение цен к net или gross по ставкам НДС, nil - налоговый слой выключен
+	tax pricing.Normalizer
+
+	// каталог сервисов для нормализации названий, nil - названия как ввели
+	catalog repository.CatalogInterface
+
+	// внешние хуки политик перед create и extend, nil - без проверок
+	policy PolicyChecker
+
+	// последний месяц отмененной подписки в расходы не входит
+	excludeFinalMonth bool
+
+	// основная валюта: ставится подпискам без валюты, в ней считается total_cost
+	currency string
+
+	// текущее время для статусов и проверок по месяцам
+	clock clock.Clock
 }
 
 var _ SubscriptionServiceInterface = (*SubscriptionService)(nil)
 
-func NewSubscriptionService(repo repository.SubscriptionInterface, log *slog.Logger) *SubscriptionService {
-	return &SubscriptionService{
-		repo: repo,
-		log:  log.With(slog.String("component", "service")),
+func NewSubscriptionService(repo repository.SubscriptionInterface, log *slog.Logger, opts ...SubscriptionOption) *SubscriptionService {
+	s := &SubscriptionService{
+		repo:     repo,
+		activity: nopRecorder{},
+		log:      log.With(slog.String("component", "service")),
+		currency: "RUB",
+		clock:    clock.Real{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 func (s *SubscriptionService) Create(ctx context.Context, sub domain.Subscription) (int64, error) {
 	const op = "service Create"
 
+	sub, err := s.PrepareCreate(ctx, sub)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := s.repo.Create(ctx, sub)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("sub created", slog.Int64("id", id))
+	s.RecordCreated(ctx, id, sub)
+	return id, nil
+}
+
+// PrepareCreate проверяет и нормализует новую подписку так, как ее сохранит Create.
+// Через него же идут строки импорта
+func (s *SubscriptionService) PrepareCreate(ctx context.Context, sub domain.Subscription) (domain.Subscription, error) {
+	const op = "service PrepareCreate"
+
 	// отрицательная цена это странно
 	if sub.Price < 0 {
-		return 0, fmt.Errorf("op:%s, price must be positive", op)
+		return sub, fmt.Errorf("%s: %w", op, ErrNegativePrice)
 	}
 
-	// проверяем нет ли уже такой подписки у юзера
-	exists, err := s.repo.Exists(ctx, sub.UserID, sub.ServiceName)
+	tags, err := normalizeTags(sub.Tags)
 	if err != nil {
-		return 0, fmt.Errorf("%s, %w", op, err)
+		return sub, err
 	}
-	if exists {
-		return 0, ErrSubscriptionExists
+	sub.Tags = tags
+
+	if sub.Currency == "" {
+		sub.Currency = s.currency
+	}
+	if sub.Currency, err = normalizeCurrency(sub.Currency); err != nil {
+		return sub, err
+	}
+	if sub.BillingPeriod == "" {
+		sub.BillingPeriod = domain.BillingMonthly
+	}
+	if sub.BillingPeriod, err = normalizeBillingPeriod(sub.BillingPeriod); err != nil {
+		return sub, err
+	}
+	if sub.PriceBasis, sub.TaxCountry, err = s.resolveTax(sub.PriceBasis, sub.TaxCountry); err != nil {
+		return sub, err
 	}
 
-	id, err := s.repo.Create(ctx, sub)
+	if err := s.matchCatalog(ctx, &sub); err != nil {
+		return sub, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// сверяем цену с каталогом, ловим ошибки ввода вроде 500000 вместо 500.
+	// В каталоге месячные цены, годовую сравниваем в пересчете на месяц
+	if warning, err := s.prices.Check(sub.ServiceName, monthlyPrice(sub)); err != nil {
+		return sub, err
+	} else if warning != "" {
+		s.log.Warn("suspicious price", slog.String("user_id", sub.UserID.String()), slog.String("warning", warning))
+	}
+
+	// проверяем нет ли уже такой подписки у юзера. Гонку двух одновременных
+	// бессрочных вставок закрывает уникальный индекс, репозиторий вернет тот же ErrSubscriptionExists
+	exists, err := s.repo.Exists(ctx, sub.UserID, sub.ServiceName, s.currentMonth())
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return sub, fmt.Errorf("%s, %w", op, err)
+	}
+	if exists {
+		return sub, ErrSubscriptionExists
 	}
 
-	s.log.Info("sub created", slog.Int64("id", id))
-	return id, nil
+	// внешние правила последними: хук видит запись такой, какой она будет сохранена
+	if err := s.checkPolicy(ctx, domain.PolicyActionCreate, sub, nil); err != nil {
+		return sub, err
+	}
+	return sub, nil
+}
+
+// RecordCreated пишет в ленту и аудит запроса созданную подписку
+func (s *SubscriptionService) RecordCreated(ctx context.Context, id int64, sub domain.Subscription) {
+	sub.ID = id
+	audit.Record(ctx, domain.AuditChange{SubscriptionID: id, Action: domain.EventCreated, After: &sub})
+	s.activity.Record(ctx, sub.UserID, id, domain.EventCreated, map[string]any{
+		"service_name":   sub.ServiceName,
+		"price":          sub.Price,
+		"billing_period": sub.BillingPeriod,
+		"start_date":     sub.StartDate,
+		"end_date":       sub.EndDate,
+	})
+}
+
+// привязывает подписку к каталогу сервисов и ставит каноническое название,
+// нераспознанное название остается как ввели
+func (s *SubscriptionService) matchCatalog(ctx context.Context, sub *domain.Subscription) error {
+	sub.CatalogID = nil
+	if s.catalog == nil {
+		return nil
+	}
+
+	entry, err := s.catalog.Match(ctx, domain.CatalogKey(sub.ServiceName))
+	if err != nil || entry == nil {
+		return err
+	}
+	sub.CatalogID = &entry.ID
+	sub.ServiceName = entry.Name
+	return nil
+}
+
+// текст предупреждения о нетипичной цене для ответа клиенту
+func (s *SubscriptionService) PriceWarning(sub domain.Subscription) string {
+	if sub.BillingPeriod == "" {
+		sub.BillingPeriod = domain.BillingMonthly
+	}
+	warning, _ := s.prices.Check(sub.ServiceName, monthlyPrice(sub))
+	return warning
 }
 
 func (s *SubscriptionService) GetByID(ctx context.Context, id int64) (*domain.Subscription, error) {
@@ -72,97 +243,435 @@ func (s *SubscriptionService) GetByID(ctx context.Context, id int64) (*domain.Su
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return sub, nil
+	return s.withStatus(sub), nil
 }
 
-func (s *SubscriptionService) Delete(ctx context.Context, id int64) error {
+// Update заменяет подписку. sub.Version != 0 - только поверх этой версии, иначе ErrVersionConflict
+func (s *SubscriptionService) Update(ctx context.Context, id int64, sub domain.Subscription) (*domain.Subscription, error) {
+	const op = "service Update"
+
+	if sub.Price < 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrNegativePrice)
+	}
+
+	tags, err := normalizeTags(sub.Tags)
+	if err != nil {
+		return nil, err
+	}
+	sub.Tags = tags
+
+	if err := s.matchCatalog(ctx, &sub); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	old, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if sub.Version != 0 && sub.Version != old.Version {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrVersionConflict)
+	}
+
+	// замена без валюты не переводит подписку в основную валюту
+	if sub.Currency == "" {
+		sub.Currency = old.Currency
+	}
+	if sub.Currency, err = normalizeCurrency(sub.Currency); err != nil {
+		return nil, err
+	}
+	// и без периода оплаты оставляет прежний
+	if sub.BillingPeriod == "" {
+		sub.BillingPeriod = old.BillingPeriod
+	}
+	if sub.BillingPeriod, err = normalizeBillingPeriod(sub.BillingPeriod); err != nil {
+		return nil, err
+	}
+	// база цены и страна тоже остаются прежними, если их не прислали
+	if sub.PriceBasis == "" {
+		sub.PriceBasis = old.PriceBasis
+	}
+	if sub.TaxCountry == "" {
+		sub.TaxCountry = old.TaxCountry
+	}
+	if sub.PriceBasis, sub.TaxCountry, err = s.resolveTax(sub.PriceBasis, sub.TaxCountry); err != nil {
+		return nil, err
+	}
+
+	// если меняем юзера или сервис - проверяем что не будет дубля
+	if old.UserID != sub.UserID || old.ServiceName != sub.ServiceName {
+		exists, err := s.repo.Exists(ctx, sub.UserID, sub.ServiceName, s.currentMonth())
+		if err != nil {
+			return nil, fmt.Errorf("%s, %w", op, err)
+		}
+		if exists {
+			return nil, ErrSubscriptionExists
+		}
+	}
+
+	if err := s.repo.Update(ctx, id, sub); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.activity.Record(ctx, sub.UserID, id, domain.EventUpdated, map[string]any{
+		"service_name":   sub.ServiceName,
+		"price":          sub.Price,
+		"billing_period": sub.BillingPeriod,
+		"start_date":     sub.StartDate,
+		"end_date":       sub.EndDate,
+	})
+	if old.Price != sub.Price {
+		s.activity.Record(ctx, sub.UserID, id, domain.EventPriceChanged, map[string]any{
+			"old_price": old.Price,
+			"new_price": sub.Price,
+		})
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+func (s *SubscriptionService) Delete(ctx context.Context, id int64, confirmToken string) (*domain.DeleteConfirmation, error) {
 	const op = "service Delete"
 
-	if err := s.repo.Delete(ctx, id); err != nil {
-		return fmt.Errorf("%s, %w", op, err)
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	if s.deleteConfirmPrice > 0 && monthlyPrice(*sub) > s.deleteConfirmPrice {
+		now := s.clock.Now()
+		subject := subscriptionConfirmSubject(id)
+
+		// первый вызов - выдаем токен, второй - гасим его вместе с удалением
+		if confirmToken == "" {
+			token, expiresAt, err := s.issueConfirm(ctx, subject, now)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			s.log.Info("delete confirmation issued", slog.Int64("id", id))
+			return &domain.DeleteConfirmation{ID: id, Token: token, ExpiresAt: expiresAt}, nil
+		}
+
+		if err := s.repo.DeleteConfirmed(ctx, id, subject, checkConfirm(confirmToken, now)); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	} else if err := s.repo.Delete(ctx, id); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.recordDeleted(ctx, *sub, false)
+	return nil, nil
+}
+
+// DeleteByFilter удаляет подписки юзера, с serviceName - только этого сервиса. Если среди
+// них есть дороже DELETE_CONFIRM_PRICE, первый вызов ничего не удаляет и отдает токен,
+// как одиночное удаление
+func (s *SubscriptionService) DeleteByFilter(ctx context.Context, userID uuid.UUID, serviceName, confirmToken string) (int64, *domain.BulkDeleteConfirmation, error) {
+	const op = "service DeleteByFilter"
+
+	// без юзера это было бы удаление всей таблицы
+	if userID == uuid.Nil {
+		return 0, nil, ErrUserRequired
+	}
+
+	now := s.clock.Now()
+	subject := bulkConfirmSubject(userID, serviceName)
+	matched := 0
+	deleted, err := s.repo.DeleteByFilter(ctx, userID, serviceName, subject, func(subs []domain.Subscription, tokenHash string, expiresAt time.Time) error {
+		expensive := slices.ContainsFunc(subs, func(sub domain.Subscription) bool {
+			return monthlyPrice(sub) > s.deleteConfirmPrice
+		})
+		if s.deleteConfirmPrice <= 0 || !expensive {
+			return nil
+		}
+		if confirmToken == "" {
+			matched = len(subs)
+			return errConfirmRequired
+		}
+		return checkConfirm(confirmToken, now)(tokenHash, expiresAt)
+	})
+	if errors.Is(err, errConfirmRequired) {
+		token, expiresAt, err := s.issueConfirm(ctx, subject, now)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		s.log.Info("bulk delete confirmation issued", slog.String("user_id", userID.String()), slog.Int("matched", matched))
+		return 0, &domain.BulkDeleteConfirmation{Token: token, ExpiresAt: expiresAt, Matched: matched}, nil
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, sub := range deleted {
+		s.recordDeleted(ctx, sub, true)
+	}
+	s.log.Info("bulk delete", slog.String("user_id", userID.String()), slog.String("service_name", serviceName), slog.Int("deleted", len(deleted)))
+	return int64(len(deleted)), nil, nil
+}
+
+// в ленте остается, что было удалено: сама подписка и ее история уходят вместе со строкой
+func (s *SubscriptionService) recordDeleted(ctx context.Context, sub domain.Subscription, bulk bool) {
+	audit.Record(ctx, domain.AuditChange{SubscriptionID: sub.ID, Action: domain.EventDeleted, Before: &sub})
+	s.activity.Record(ctx, sub.UserID, sub.ID, domain.EventDeleted, map[string]any{
+		"service_name": sub.ServiceName,
+		"price":        sub.Price,
+		"start_date":   sub.StartDate,
+		"end_date":     sub.EndDate,
+		"bulk":         bulk,
+	})
 }
 
 func (s *SubscriptionService) List(ctx context.Context, userID uuid.UUID, filter domain.SubscriptionFilter) ([]domain.Subscription, error) {
 	const op = "service List"
 
 	// валидация цен, чтоб мин не был больше макса
-	if filter.MinPrice > 0 && filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
-		return nil, fmt.Errorf("min price cant be greater than max")
+	if err := checkPriceRange(filter); err != nil {
+		return nil, err
 	}
 
+	// сортировка только по белому списку
+	if filter.Sort != "" && !slices.Contains(domain.SortFields, filter.Sort) {
+		return nil, fmt.Errorf("%s: %w %q, use one of: %s", op, ErrBadSort, filter.Sort, strings.Join(domain.SortFields, ", "))
+	}
+	if filter.Status != "" && !slices.Contains(domain.Statuses, filter.Status) {
+		return nil, fmt.Errorf("%s: %w %q, use one of: %s", op, ErrBadStatus, filter.Status, strings.Join(domain.Statuses, ", "))
+	}
+	filter.StatusMonth = s.currentMonth()
+
 	subs, err := s.repo.List(ctx, userID, filter)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	for i := range subs {
+		s.withStatus(&subs[i])
+	}
 	return subs, nil
 }
 
-func (s *SubscriptionService) GetTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string) (int64, []string, error) {
+// Stream - как List, только без пагинации и без загрузки всего в память
+func (s *SubscriptionService) Stream(ctx context.Context, userID uuid.UUID, filter domain.SubscriptionFilter) (iter.Seq2[*domain.Subscription, error], error) {
+	if err := checkPriceRange(filter); err != nil {
+		return nil, err
+	}
+	if filter.Status != "" && !slices.Contains(domain.Statuses, filter.Status) {
+		return nil, fmt.Errorf("%w %q, use one of: %s", ErrBadStatus, filter.Status, strings.Join(domain.Statuses, ", "))
+	}
+	filter.StatusMonth = s.currentMonth()
+
+	rows := s.repo.Stream(ctx, userID, filter)
+	return func(yield func(*domain.Subscription, error) bool) {
+		for sub, err := range rows {
+			if !yield(s.withStatus(sub), err) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (s *SubscriptionService) GetTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string) (*domain.TotalCost, error) {
 	const op = "service GetTotalCost"
-	layout := "01-2006"
 
-	reqFrom, err := time.Parse(layout, fromStr)
+	reqFrom, reqTo, err := parseCostPeriod(fromStr, toStr)
 	if err != nil {
-		return 0, nil, fmt.Errorf("bad from date format")
+		return nil, err
 	}
-	reqTo, err := time.Parse(layout, toStr)
+
+	if s.canary.useSQL(userID) {
+		res, err := s.totalCostSQL(ctx, userID, serviceName, reqFrom, reqTo)
+		if err == nil {
+			// сверяем со старым движком в фоне, ответ уже готов
+			go s.compareTotalCost(context.WithoutCancel(ctx), userID, serviceName, reqFrom, reqTo, res)
+			return res, nil
+		}
+		s.log.Error("sql cost engine failed, fallback to go", slog.String("err", err.Error()))
+	}
+
+	res, err := s.totalCostGo(ctx, userID, serviceName, reqFrom, reqTo, s.billedCost)
 	if err != nil {
-		return 0, nil, fmt.Errorf("bad to date format")
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	return res, nil
+}
 
+// расходы одной подписки за период: оплачиваемые месяцы и сумма
+type costFunc func(sub domain.Subscription, reqFrom, reqTo time.Time) (int, domain.Money)
+
+func (s *SubscriptionService) totalCostGo(ctx context.Context, userID uuid.UUID, serviceName string, reqFrom, reqTo time.Time, cost costFunc) (*domain.TotalCost, error) {
 	subs, err := s.repo.GetTotalCost(ctx, userID, serviceName, reqFrom, reqTo)
 	if err != nil {
-		return 0, nil, fmt.Errorf("%s: %w", op, err)
+		return nil, err
 	}
+	return s.costOf(subs, reqFrom, reqTo, cost), nil
+}
 
-	var totalCost int64
-	var details []string
+// costOf собирает ответ total по уже выбранным подпискам
+func (s *SubscriptionService) costOf(subs []domain.Subscription, reqFrom, reqTo time.Time, cost costFunc) *domain.TotalCost {
+	res := &domain.TotalCost{
+		Details:    []domain.CostDetail{},
+		Months:     s.monthlyBreakdown(subs, reqFrom, reqTo, cost),
+		Categories: s.categoryTotals(subs, reqFrom, reqTo, cost),
+	}
 	for _, sub := range subs {
-		subStart, _ := time.Parse(layout, sub.StartDate)
+		if months, c := cost(sub, reqFrom, reqTo); months > 0 {
+			res.Details = append(res.Details, domain.CostDetail{ServiceName: sub.ServiceName, Months: months, Cost: c, Currency: sub.Currency})
+		}
+	}
+	s.fillCurrencyTotals(res)
+
+	return res
+}
+
+// сколько месяцев подписки из [reqFrom, reqTo] оплачивается
+func (s *SubscriptionService) billedMonths(sub domain.Subscription, reqFrom, reqTo time.Time) int {
+	layout := "01-2006"
+	subStart, _ := time.Parse(layout, sub.StartDate)
+
+	var subEnd time.Time
+	if sub.EndDate != nil {
+		subEnd, _ = time.Parse(layout, *sub.EndDate)
+		// отмена посреди месяца: месяц отмены уже не списывается
+		if s.excludeFinalMonth && sub.CancelledAt != nil {
+			subEnd = subEnd.AddDate(0, -1, 0)
+		}
+	} else {
+		subEnd = reqTo
+	}
+
+	// считаем пересечение периодов
+	intersectStart := maxDate(reqFrom, subStart)
+	intersectEnd := minDate(reqTo, subEnd)
+
+	months := countMonths(intersectStart, intersectEnd)
+	// месяцы на паузе не оплачиваются
+	return months - pausedMonths(sub, intersectStart, intersectEnd)
+}
+
+// monthlyBreakdown раскладывает расходы по месяцам периода. Месяцы без расходов
+// тоже в ответе, чтоб на графике не было дыр. Каждая валюта считается отдельно
+func (s *SubscriptionService) monthlyBreakdown(subs []domain.Subscription, reqFrom, reqTo time.Time, cost costFunc) []domain.MonthCost {
+	currencies := currenciesOf(s.currency, subs, func(sub domain.Subscription) string { return sub.Currency })
+	months := make([]domain.MonthCost, 0, countMonths(reqFrom, reqTo)*len(currencies))
+	for m := reqFrom; !m.After(reqTo); m = m.AddDate(0, 1, 0) {
+		for _, currency := range currencies {
+			mc := domain.MonthCost{Month: m.Format("01-2006"), Currency: currency, Services: map[string]domain.Money{}}
+			for _, sub := range subs {
+				if months, c := cost(sub, m, m); sub.Currency == currency && months > 0 {
+					mc.Total += c
+					mc.Services[sub.ServiceName] += c
+				}
+			}
+			months = append(months, mc)
+		}
+	}
+	return months
+}
 
-		var subEnd time.Time
-		if sub.EndDate != nil {
-			subEnd, _ = time.Parse(layout, *sub.EndDate)
-		} else {
-			subEnd = reqTo
+// categoryTotals суммирует расходы по категориям отдельно по валютам, категории по имени,
+// подписки без категории последними строками
+func (s *SubscriptionService) categoryTotals(subs []domain.Subscription, reqFrom, reqTo time.Time, cost costFunc) []domain.CategoryCost {
+	type key struct {
+		id       int64
+		currency string
+	}
+	uncategorized := map[string]*domain.CategoryCost{}
+	byKey := map[key]*domain.CategoryCost{}
+	for _, sub := range subs {
+		_, c := cost(sub, reqFrom, reqTo)
+		if c <= 0 {
+			continue
 		}
 
-		// считаем пересечение периодов
-		intersectStart := maxDate(reqFrom, subStart)
-		intersectEnd := minDate(reqTo, subEnd)
+		if sub.CategoryID == nil {
+			cc, ok := uncategorized[sub.Currency]
+			if !ok {
+				cc = &domain.CategoryCost{Currency: sub.Currency}
+				uncategorized[sub.Currency] = cc
+			}
+			cc.Cost += c
+			continue
+		}
 
-		months := countMonths(intersectStart, intersectEnd)
+		k := key{*sub.CategoryID, sub.Currency}
+		cc, ok := byKey[k]
+		if !ok {
+			name := sub.CategoryName
+			cc = &domain.CategoryCost{CategoryID: sub.CategoryID, Category: &name, Currency: sub.Currency}
+			byKey[k] = cc
+		}
+		cc.Cost += c
+	}
 
-		if months > 0 {
-			cost := int64(sub.Price) * int64(months)
-			totalCost += cost
-			details = append(details, fmt.Sprintf("%s: %d", sub.ServiceName, cost))
+	byCurrency := func(a, b domain.CategoryCost) int {
+		return strings.Compare(a.Currency, b.Currency)
+	}
+	totals := make([]domain.CategoryCost, 0, len(byKey)+len(uncategorized))
+	for _, cc := range byKey {
+		totals = append(totals, *cc)
+	}
+	slices.SortFunc(totals, func(a, b domain.CategoryCost) int {
+		if c := strings.Compare(*a.Category, *b.Category); c != 0 {
+			return c
 		}
+		return byCurrency(a, b)
+	})
+
+	rest := make([]domain.CategoryCost, 0, len(uncategorized))
+	for _, cc := range uncategorized {
+		rest = append(rest, *cc)
+	}
+	slices.SortFunc(rest, byCurrency)
+	return append(totals, rest...)
+}
+
+// самый длинный период для расчета расходов
+const maxPeriodMonths = 120
+
+func parseCostPeriod(fromStr, toStr string) (time.Time, time.Time, error) {
+	layout := "01-2006"
+
+	reqFrom, err := time.Parse(layout, fromStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, domain.Invalid("bad from date format")
+	}
+	reqTo, err := time.Parse(layout, toStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, domain.Invalid("bad to date format")
 	}
 
-	return totalCost, details, nil
+	// перевернутый период раньше молча давал 0, а вековой - долгий скан
+	if reqFrom.After(reqTo) {
+		return time.Time{}, time.Time{}, ErrBadPeriod
+	}
+	if countMonths(reqFrom, reqTo) > maxPeriodMonths {
+		return time.Time{}, time.Time{}, ErrPeriodTooLong
+	}
+	return reqFrom, reqTo, nil
 }
 
-var monthYearRegex = regexp.MustCompile(`^(0[1-9]|1[0-2])-\d{4}$`)
+// строгий разбор дат из запросов: MM-YYYY или YYYY-MM-DD, альтернативные форматы приводит хендлер
+var strictDates = dates.Parser{}
 
-func (s *SubscriptionService) Extend(ctx context.Context, id int64, newEndDateStr string, newPrice int) error {
+// newEndDateStr - MM-YYYY или YYYY-MM-DD, день сохраняется в end_day.
+// version != 0 - продление только если подписку с тех пор не меняли
+func (s *SubscriptionService) Extend(ctx context.Context, id int64, newEndDateStr string, newPrice domain.Money, version int64) error {
 	const op = "service Extend"
 
-	if !monthYearRegex.MatchString(newEndDateStr) {
-		return fmt.Errorf("%s: invalid date format", op)
+	newEndDateStr, endDay, err := strictDates.NormalizeDay(newEndDateStr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, domain.Invalid("invalid date format"))
 	}
 
 	if newPrice < 0 {
-		return fmt.Errorf("%s: price cant be negative", op)
+		return fmt.Errorf("%s: %w", op, domain.Invalid("price cant be negative"))
 	}
 
 	sub, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("%s: sub not found: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// устаревшую версию отбиваем до хуков, окончательно ее проверит репозиторий под блокировкой
+	if version != 0 && sub.Version != version {
+		return fmt.Errorf("%s: %w", op, domain.ErrVersionConflict)
 	}
 
 	startDate, errS := time.Parse("01-2006", sub.StartDate)
@@ -171,31 +680,176 @@ func (s *SubscriptionService) Extend(ctx context.Context, id int64, newEndDateSt
 		return fmt.Errorf("%s: internal date parse error", op)
 	}
 
-	now := time.Now()
-	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	currentMonth := s.currentMonth()
 
 	// нельзя продлевать в прошлое
 	if newEndDate.Before(currentMonth) {
-		return fmt.Errorf("%s: cant extend to the past", op)
+		return fmt.Errorf("%s: %w", op, domain.Invalid("cant extend to the past"))
 	}
 
-	if newEndDate.Before(startDate) {
-		return fmt.Errorf("%s: new end date before start", op)
+	if newEndDate.Before(startDate) || (newEndDate.Equal(startDate) && dayBefore(endDay, sub.StartDay)) {
+		return fmt.Errorf("%s: %w", op, domain.Invalid("new end date before start"))
 	}
 
 	if sub.EndDate != nil {
 		oldEndDate, _ := time.Parse("01-2006", *sub.EndDate)
 		if newEndDate.Before(oldEndDate) || newEndDate.Equal(oldEndDate) {
-			return fmt.Errorf("%s: new date must be after old one", op)
+			return fmt.Errorf("%s: %w", op, domain.Invalid("new date must be after old one"))
 		}
 	}
 
-	err = s.repo.Extend(ctx, id, newEndDateStr, newPrice)
+	// хук политик видит подписку уже продленной
+	extended := *sub
+	extended.EndDate, extended.EndDay, extended.Price = &newEndDateStr, endDay, newPrice
+	if err := s.checkPolicy(ctx, domain.PolicyActionExtend, extended, sub); err != nil {
+		return err
+	}
+
+	err = s.repo.Extend(ctx, id, newEndDateStr, endDay, newPrice, version)
 	if err != nil {
 		// логируем если база не обновилась
 		s.log.Error("extend update faild", slog.String("op", op), slog.String("err", err.Error()))
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	s.activity.Record(ctx, sub.UserID, id, domain.EventExtended, map[string]any{
+		"old_end_date": sub.EndDate,
+		"new_end_date": newEndDateStr,
+		"new_end_day":  endDay,
+	})
+	if newPrice != sub.Price {
+		s.activity.Record(ctx, sub.UserID, id, domain.EventPriceChanged, map[string]any{
+			"old_price": sub.Price,
+			"new_price": newPrice,
+		})
+	}
+
 	return nil
 }
+
+// закрывает подписку на указанном месяце, пустой месяц - текущий
+func (s *SubscriptionService) Cancel(ctx context.Context, id int64, monthStr string) (*domain.Subscription, error) {
+	const op = "service Cancel"
+
+	currentMonth := s.currentMonth()
+	if monthStr == "" {
+		monthStr = currentMonth.Format("01-2006")
+	}
+
+	monthStr, endDay, err := strictDates.NormalizeDay(monthStr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, domain.Invalid("invalid date format"))
+	}
+	cancelMonth, _ := time.Parse("01-2006", monthStr)
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// уже закончилась или уже отменена - отменять нечего
+	if sub.CancelledAt != nil {
+		return nil, ErrAlreadyEnded
+	}
+	if sub.EndDate != nil {
+		oldEnd, _ := time.Parse("01-2006", *sub.EndDate)
+		if oldEnd.Before(currentMonth) {
+			return nil, ErrAlreadyEnded
+		}
+		// отмена не может продлить подписку
+		if cancelMonth.After(oldEnd) {
+			return nil, ErrBadCancelMonth
+		}
+	}
+
+	startDate, _ := time.Parse("01-2006", sub.StartDate)
+	if cancelMonth.Before(startDate) || (cancelMonth.Equal(startDate) && dayBefore(endDay, sub.StartDay)) {
+		return nil, ErrBadCancelMonth
+	}
+
+	if err := s.repo.Cancel(ctx, id, monthStr, endDay); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.activity.Record(ctx, sub.UserID, id, domain.EventCancelled, map[string]any{
+		"old_end_date": sub.EndDate,
+		"new_end_date": monthStr,
+		"new_end_day":  endDay,
+	})
+	s.log.Info("sub cancelled", slog.Int64("id", id), slog.String("month", monthStr))
+
+	return s.GetByID(ctx, id)
+}
+
+// ставит подписку на паузу с текущего месяца
+func (s *SubscriptionService) Pause(ctx context.Context, id int64) (*domain.Subscription, error) {
+	const op = "service Pause"
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	currentMonth := s.currentMonth()
+
+	// на паузу можно только активную и не закончившуюся
+	if sub.Status != domain.StatusActive {
+		return nil, ErrBadTransition
+	}
+	if sub.EndDate != nil {
+		end, _ := time.Parse("01-2006", *sub.EndDate)
+		if end.Before(currentMonth) {
+			return nil, ErrAlreadyEnded
+		}
+	}
+
+	from := currentMonth.Format("01-2006")
+	if err := s.repo.SetPause(ctx, id, domain.StatusPaused, &from, nil); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.activity.Record(ctx, sub.UserID, id, domain.EventPaused, map[string]any{"paused_from": from})
+	return s.GetByID(ctx, id)
+}
+
+// снимает паузу, текущий месяц уже оплачивается
+func (s *SubscriptionService) Resume(ctx context.Context, id int64) (*domain.Subscription, error) {
+	const op = "service Resume"
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if sub.Status != domain.StatusPaused || sub.PausedFrom == nil {
+		return nil, ErrBadTransition
+	}
+
+	from, err := time.Parse("01-2006", *sub.PausedFrom)
+	if err != nil {
+		return nil, fmt.Errorf("%s: bad paused_from %q: %w", op, *sub.PausedFrom, err)
+	}
+
+	// пауза длится по прошлый месяц. Возобновили в месяце ее начала - пауза так и не
+	// наступила, перевернутую пару не храним, обе колонки очищаются
+	until := s.currentMonth().AddDate(0, -1, 0)
+	untilStr := until.Format("01-2006")
+	pausedFrom, pausedUntil := sub.PausedFrom, &untilStr
+	if until.Before(from) {
+		pausedFrom, pausedUntil = nil, nil
+	}
+	if err := s.repo.SetPause(ctx, id, domain.StatusActive, pausedFrom, pausedUntil); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.activity.Record(ctx, sub.UserID, id, domain.EventResumed, map[string]any{
+		"paused_from":  sub.PausedFrom,
+		"paused_until": pausedUntil,
+	})
+	return s.GetByID(ctx, id)
+}
+
+// первое число текущего месяца по часам сервиса
+func (s *SubscriptionService) currentMonth() time.Time {
+	return clock.MonthStart(s.clock.Now())
+}
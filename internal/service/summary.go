@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// сводка по одному сервису пользователя
+type ServiceSummary struct {
+	ServiceName   string       `json:"service_name" example:"Netflix"`
+	Subscriptions int          `json:"subscriptions" example:"3"`
+	CurrentPrice  domain.Money `json:"current_price" example:"799"`
+	Active        bool         `json:"active" example:"true"`
+}
+
+// Services отдает каждый сервис пользователя один раз: сколько было подписок,
+// последняя цена и есть ли активная подписка в текущем месяце
+func (s *SubscriptionService) Services(ctx context.Context, userID uuid.UUID) ([]ServiceSummary, error) {
+	const op = "service Services"
+
+	rows, err := s.repo.ServiceTotals(ctx, userID, s.currentMonth())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := make([]ServiceSummary, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, ServiceSummary{
+			ServiceName:   row.ServiceName,
+			Subscriptions: row.Count,
+			CurrentPrice:  row.CurrentPrice,
+			Active:        row.Active,
+		})
+	}
+	return res, nil
+}
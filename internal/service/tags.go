@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+var ErrBadTags = errors.New("tags must be 1..30 chars, at most 20 per subscription")
+
+const (
+	maxTags   = 20
+	maxTagLen = 30
+)
+
+// normalizeTags приводит метки к нижнему регистру, убирает повторы и сортирует
+func normalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || len([]rune(t)) > maxTagLen {
+			return nil, ErrBadTags
+		}
+		out = append(out, t)
+	}
+
+	slices.Sort(out)
+	out = slices.Compact(out)
+	if len(out) > maxTags {
+		return nil, ErrBadTags
+	}
+	return out, nil
+}
+
+// UpdateTags добавляет и убирает метки, остальные поля не трогает
+func (s *SubscriptionService) UpdateTags(ctx context.Context, id int64, add, remove []string) (*domain.Subscription, error) {
+	const op = "service UpdateTags"
+
+	add, err := normalizeTags(add)
+	if err != nil {
+		return nil, err
+	}
+	remove, err = normalizeTags(remove)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// лимит проверяем по итоговому набору
+	result := slices.DeleteFunc(append(slices.Clone(sub.Tags), add...), func(t string) bool {
+		return slices.Contains(remove, t)
+	})
+	if _, err := normalizeTags(result); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateTags(ctx, id, add, remove); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s.GetByID(ctx, id)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/pricing"
+)
+
+var ErrTaxNotConfigured = domain.Invalid("tax_basis is not available: tax rates are not configured")
+
+// resolveTax проверяет базу цены и страну подписки. С налоговым слоем пустые
+// заменяются умолчаниями, чтоб в базе лежало, на какой основе введена цена
+func (s *SubscriptionService) resolveTax(basis, country string) (string, string, error) {
+	if s.tax == nil {
+		return pricing.ValidateTax(basis, country)
+	}
+	return s.tax.Resolve(basis, country)
+}
+
+// TaxedTotalCost считает расходы, приведя каждую подписку к basis до суммирования:
+// net и gross цены иначе складываются в бессмысленный итог. Считается всегда на Go,
+// SQL движок про налоги не знает
+func (s *SubscriptionService) TaxedTotalCost(ctx context.Context, userID uuid.UUID, serviceName string, fromStr, toStr string, daily bool, basis string) (*domain.TotalCost, error) {
+	const op = "service TaxedTotalCost"
+
+	if s.tax == nil {
+		return nil, ErrTaxNotConfigured
+	}
+	if basis != pricing.BasisNet && basis != pricing.BasisGross {
+		return nil, pricing.ErrBadBasis
+	}
+	reqFrom, reqTo, err := parseCostPeriod(fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := s.repo.GetTotalCost(ctx, userID, serviceName, reqFrom, reqTo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	summary := &domain.TaxSummary{Basis: basis, Rates: map[string]float64{}}
+	net, err := s.onBasis(subs, pricing.BasisNet, summary)
+	if err != nil {
+		return nil, err
+	}
+	gross, err := s.onBasis(subs, pricing.BasisGross, summary)
+	if err != nil {
+		return nil, err
+	}
+
+	cost := s.billedCost
+	if daily {
+		cost = s.proratedCost
+	}
+	netRes := s.costOf(net, reqFrom, reqTo, cost)
+	grossRes := s.costOf(gross, reqFrom, reqTo, cost)
+	summary.TotalNet, summary.TotalsNet = netRes.Total, netRes.Totals
+	summary.TotalGross, summary.TotalsGross = grossRes.Total, grossRes.Totals
+
+	res := netRes
+	if basis == pricing.BasisGross {
+		res = grossRes
+	}
+	res.Tax = summary
+	return res, nil
+}
+
+// копии подписок с ценами в basis, ставки стран попадают в summary
+func (s *SubscriptionService) onBasis(subs []domain.Subscription, basis string, summary *domain.TaxSummary) ([]domain.Subscription, error) {
+	out := make([]domain.Subscription, len(subs))
+	for i, sub := range subs {
+		from, country, err := s.tax.Resolve(sub.PriceBasis, sub.TaxCountry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sub.ServiceName, err)
+		}
+		price, rate, err := s.tax.Normalize(sub.Price, from, basis, country)
+		if err != nil {
+			return nil, err
+		}
+		trial, _, err := s.tax.Normalize(sub.TrialPrice, from, basis, country)
+		if err != nil {
+			return nil, err
+		}
+
+		sub.Price, sub.TrialPrice = price, trial
+		summary.Rates[country] = rate
+		out[i] = sub
+	}
+	return out, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// поля, которые сервис кладет в payload событий
+type eventPayload struct {
+	ServiceName *string       `json:"service_name"`
+	Price       *domain.Money `json:"price"`
+	StartDate   *string       `json:"start_date"`
+	EndDate     *string       `json:"end_date"`
+	NewEndDate  *string       `json:"new_end_date"`
+	NewPrice    *domain.Money `json:"new_price"`
+}
+
+// статус в истории: отмена видна отдельно, в базе ее нет как статуса
+const timelineCancelled = "cancelled"
+
+// Timeline проигрывает события подписки и отдает состояние после каждого.
+// Если старые события уже удалены очисткой, первые состояния будут неполными
+func (s *ActivityService) Timeline(ctx context.Context, subID int64) ([]domain.TimelineEntry, error) {
+	const op = "service activity Timeline"
+
+	events, err := s.events.ListBySubscription(ctx, subID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	state := domain.TimelineState{Status: domain.StatusActive}
+	timeline := make([]domain.TimelineEntry, 0, len(events))
+	for _, ev := range events {
+		var p eventPayload
+		if err := json.Unmarshal(ev.Payload, &p); err != nil {
+			s.log.Warn("bad event payload", slog.Int64("event_id", ev.ID), slog.String("err", err.Error()))
+			continue
+		}
+
+		switch ev.Type {
+		case domain.EventCreated, domain.EventUpdated:
+			if p.ServiceName != nil {
+				state.ServiceName = *p.ServiceName
+			}
+			if p.Price != nil {
+				state.Price = *p.Price
+			}
+			if p.StartDate != nil {
+				state.StartDate = *p.StartDate
+			}
+			state.EndDate = p.EndDate
+		case domain.EventPriceChanged:
+			if p.NewPrice != nil {
+				state.Price = *p.NewPrice
+			}
+		case domain.EventExtended:
+			state.EndDate = p.NewEndDate
+		case domain.EventCancelled:
+			state.EndDate = p.NewEndDate
+			state.Status = timelineCancelled
+		case domain.EventPaused:
+			state.Status = domain.StatusPaused
+		case domain.EventResumed:
+			state.Status = domain.StatusActive
+		case domain.EventChurned:
+			state.Status = domain.StatusExpired
+		default:
+			// напоминания и прочее состояние не меняют
+			continue
+		}
+
+		// EndDate копируем, иначе все записи будут смотреть на одно значение
+		entry := domain.TimelineEntry{At: ev.CreatedAt, Event: ev.Type, State: state}
+		if state.EndDate != nil {
+			end := *state.EndDate
+			entry.State.EndDate = &end
+		}
+		timeline = append(timeline, entry)
+	}
+
+	return timeline, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// billedCost - оплачиваемые месяцы подписки из [reqFrom, reqTo] и их стоимость:
+// месяцы триала по trial_price, остальные по price. Части округляются отдельно,
+// как в AggregateCost
+func (s *SubscriptionService) billedCost(sub domain.Subscription, reqFrom, reqTo time.Time) (int, domain.Money) {
+	months := s.billedMonths(sub, reqFrom, reqTo)
+	if months <= 0 {
+		return 0, 0
+	}
+
+	trial := 0
+	if trialEnd, ok := trialEnd(sub); ok && !trialEnd.Before(reqFrom) {
+		trial = s.billedMonths(sub, reqFrom, minDate(reqTo, trialEnd))
+	}
+	return months, sub.Price.ForMonths(sub.BillingPeriod, months-trial) + sub.TrialPrice.ForMonths(sub.BillingPeriod, trial)
+}
+
+// последний месяц триала, false - триала нет
+func trialEnd(sub domain.Subscription) (time.Time, bool) {
+	if sub.TrialEndDate == nil {
+		return time.Time{}, false
+	}
+	end, err := time.Parse("01-2006", *sub.TrialEndDate)
+	return end, err == nil
+}
+
+// месяц m приходится на триал
+func inTrial(sub domain.Subscription, m time.Time) bool {
+	end, ok := trialEnd(sub)
+	return ok && !m.After(end)
+}
+
+// FlagTrialConversions отмечает триалы, которые заканчиваются в текущем месяце
+// и со следующего станут платными, и пишет событие в ленту. Отмеченные попадают в Upcoming
+func (s *SubscriptionService) FlagTrialConversions(ctx context.Context) (int, error) {
+	const op = "service FlagTrialConversions"
+
+	subs, err := s.repo.FlagTrialConversions(ctx, s.currentMonth().Format("01-2006"))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, sub := range subs {
+		end, _ := trialEnd(sub)
+		s.activity.Record(ctx, sub.UserID, sub.ID, domain.EventTrialEnding, map[string]any{
+			"trial_end_date": sub.TrialEndDate,
+			"converts_on":    end.AddDate(0, 1, 0).Format("01-2006"),
+			"trial_price":    sub.TrialPrice,
+			"price":          sub.Price,
+		})
+	}
+	if len(subs) > 0 {
+		s.log.Info("trial conversions flagged", slog.Int("count", len(subs)))
+	}
+	return len(subs), nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/clock"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
+
+// самое длинное окно для ближайших окончаний
+const maxUpcomingMonths = 24
+
+// Upcoming отдает подписки, которые заканчиваются с текущего месяца
+// и на withinMonths вперед, с остатком в днях и месяцах. Триалы, которые
+// фоновая проверка отметила как скоро платные, тоже тут, с trial_conversion
+func (s *SubscriptionService) Upcoming(ctx context.Context, userID uuid.UUID, withinMonths int) ([]domain.UpcomingRenewal, error) {
+	const op = "service Upcoming"
+
+	if withinMonths < 0 || withinMonths > maxUpcomingMonths {
+		return nil, ErrBadWindow
+	}
+
+	now := s.clock.Now().UTC()
+	currMonth := clock.MonthStart(now)
+
+	until := currMonth.AddDate(0, withinMonths, 0)
+	subs, err := s.repo.Upcoming(ctx, userID, currMonth, until)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := make([]domain.UpcomingRenewal, 0, len(subs))
+	for _, sub := range subs {
+		var (
+			item  domain.UpcomingRenewal
+			end   time.Time
+			found bool
+		)
+		if te, ok := trialEnd(sub); ok && sub.TrialFlaggedAt != nil && !te.Before(currMonth) {
+			item.TrialConversion = &domain.TrialConversion{
+				ConvertsOn: te.AddDate(0, 1, 0).Format("01-2006"),
+				TrialPrice: sub.TrialPrice,
+				Price:      sub.Price,
+			}
+			end, found = te, true
+		}
+		// остаток до end_date, если подписка заканчивается в окне, иначе до конца триала
+		if sub.EndDate != nil {
+			if subEnd, err := time.Parse("01-2006", *sub.EndDate); err == nil && !subEnd.Before(currMonth) && !subEnd.After(until) {
+				end, found = subEnd, true
+			}
+		}
+		if !found {
+			continue
+		}
+
+		// end_date включительно: подписка идет до конца своего месяца
+		left := end.AddDate(0, 1, 0).Sub(now)
+		item.Subscription = *s.withStatus(&sub)
+		item.DaysRemaining = int((left + 24*time.Hour - 1) / (24 * time.Hour))
+		item.MonthsRemaining = countMonths(currMonth, end) - 1
+		res = append(res, item)
+	}
+
+	// из базы по end_date, а у триалов срок свой
+	slices.SortStableFunc(res, func(a, b domain.UpcomingRenewal) int {
+		return cmp.Compare(a.DaysRemaining, b.DaysRemaining)
+	})
+	return res, nil
+}
@@ -1,6 +1,11 @@
 package service
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+)
 
 func maxDate(a, b time.Time) time.Time {
 	// выбираем познию дату
@@ -29,3 +34,76 @@ func countMonths(start, end time.Time) int {
 	// инклюзивно считаем месяцы, +1 чтоб текущий тоже зашел
 	return years*12 + months + 1
 }
+
+// диапазоны пауз подписки, у открытой конец - нулевое время.
+// Ручная пауза есть и в Pauses, и в paused_from: пересечения считаются один раз
+func pauseRanges(sub domain.Subscription) [][2]time.Time {
+	var ranges [][2]time.Time
+	add := func(from string, to *string) {
+		start, err := time.Parse("01-2006", from)
+		if err != nil {
+			return
+		}
+		var end time.Time
+		if to != nil {
+			if end, err = time.Parse("01-2006", *to); err != nil {
+				return
+			}
+		}
+		ranges = append(ranges, [2]time.Time{start, end})
+	}
+
+	for _, p := range sub.Pauses {
+		add(p.PausedFrom, p.PausedTo)
+	}
+	if sub.PausedFrom != nil {
+		add(*sub.PausedFrom, sub.PausedUntil)
+	}
+	return ranges
+}
+
+// месяц m приходится на одну из пауз
+func pausedIn(ranges [][2]time.Time, m time.Time) bool {
+	for _, r := range ranges {
+		if !m.Before(r[0]) && (r[1].IsZero() || !m.After(r[1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// сколько месяцев из [start, end] подписка была на паузе
+func pausedMonths(sub domain.Subscription, start, end time.Time) int {
+	ranges := pauseRanges(sub)
+	if len(ranges) == 0 {
+		return 0
+	}
+
+	paused := 0
+	for m := start; !m.After(end); m = m.AddDate(0, 1, 0) {
+		if pausedIn(ranges, m) {
+			paused++
+		}
+	}
+	return paused
+}
+
+// в одном месяце день end раньше дня start, без любого из дней - месяц целиком
+func dayBefore(end, start *int) bool {
+	return end != nil && start != nil && *end < *start
+}
+
+func checkPriceRange(filter domain.SubscriptionFilter) error {
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return fmt.Errorf("min price cant be greater than max")
+	}
+	return nil
+}
+
+// nil строка как пустая, для сравнения необязательных дат
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
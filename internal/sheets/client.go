@@ -0,0 +1,214 @@
+package sheets
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+	sheetsAPI       = "https://sheets.googleapis.com/v4/spreadsheets/"
+	readonlyScope   = "https://www.googleapis.com/auth/spreadsheets.readonly"
+)
+
+// ключ сервисного аккаунта, json из Google Cloud Console
+type credentials struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// Client читает значения таблиц Google Sheets от имени сервисного аккаунта,
+// без SDK: токен получается обменом подписанного JWT (RFC 7523)
+type Client struct {
+	email    string
+	key      *rsa.PrivateKey
+	tokenURI string
+	http     *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewClient читает ключ сервисного аккаунта. Таблицу надо расшарить на его client_email
+func NewClient(credentialsFile string) (*Client, error) {
+	raw, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("read credentials: %w", err)
+	}
+
+	var creds credentials
+	if err := json.Unmarshal(raw, &creds); err != nil {
+		return nil, fmt.Errorf("parse credentials: %w", err)
+	}
+	if creds.ClientEmail == "" || creds.PrivateKey == "" {
+		return nil, errors.New("credentials must have client_email and private_key")
+	}
+	if creds.TokenURI == "" {
+		creds.TokenURI = defaultTokenURI
+	}
+
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return nil, errors.New("private_key is not PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private_key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private_key must be RSA")
+	}
+
+	return &Client{
+		email:    creds.ClientEmail,
+		key:      key,
+		tokenURI: creds.TokenURI,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Values отдает ячейки диапазона построчно как видны в таблице.
+// Пустые ячейки в конце строки Google не присылает, строки бывают разной длины
+func (c *Client) Values(ctx context.Context, spreadsheetID, rng string) ([][]string, error) {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u := sheetsAPI + url.PathEscape(spreadsheetID) + "/values/" + url.PathEscape(rng) +
+		"?majorDimension=ROWS&valueRenderOption=FORMATTED_VALUE"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sheets values: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := check(resp, "sheets values"); err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Values [][]string `json:"values"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("sheets values: decode: %w", err)
+	}
+	return body.Values, nil
+}
+
+// токен живет час, берем новый за минуту до конца
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.token != "" && now.Before(c.expires.Add(-time.Minute)) {
+		return c.token, nil
+	}
+
+	assertion, err := c.assertion(now)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oauth token: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := check(resp, "oauth token"); err != nil {
+		return "", err
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("oauth token: decode: %w", err)
+	}
+
+	c.token = tok.AccessToken
+	c.expires = now.Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// JWT с RS256 подписью ключом сервисного аккаунта
+func (c *Client) assertion(now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iss":   c.email,
+		"scope": readonlyScope,
+		"aud":   c.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(nil, c.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("sign jwt: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+func check(resp *http.Response, what string) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s: %s: %s", what, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// Sheet - диапазон одной таблицы, источник строк для синхронизации
+type Sheet struct {
+	client        *Client
+	spreadsheetID string
+	rng           string
+}
+
+func (c *Client) Sheet(spreadsheetID, rng string) *Sheet {
+	return &Sheet{client: c, spreadsheetID: spreadsheetID, rng: rng}
+}
+
+func (s *Sheet) Rows(ctx context.Context) ([][]string, error) {
+	return s.client.Values(ctx, s.spreadsheetID, s.rng)
+}
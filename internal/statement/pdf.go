@@ -0,0 +1,68 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/domain"
+	"github.com/mmoldabe-dev/EffectiveTask/internal/pdf"
+)
+
+// строк таблицы на страницу A4 моноширинным шрифтом
+const linesPerPage = 48
+
+// помесячная цена без суффикса
+var periodSuffix = map[string]string{
+	domain.BillingWeekly:    "/wk",
+	domain.BillingQuarterly: "/qtr",
+	domain.BillingYearly:    "/yr",
+}
+
+// WritePDF рисует выписку минимальным PDF на страницах A4. Кириллица транслитерируется,
+// WinAnsi ее не покрывает
+func WritePDF(w io.Writer, st *domain.Statement) error {
+	rows := make([]string, 0, len(st.Lines))
+	for _, l := range st.Lines {
+		name := pdf.Latin(l.ServiceName)
+		if l.Trial {
+			name += " (trial)"
+		}
+		rows = append(rows, fmt.Sprintf("%-35s %12s %6d %12s",
+			pdf.Truncate(name, 35), l.Price.String()+periodSuffix[l.BillingPeriod], l.Months, l.Subtotal))
+	}
+
+	header := []string{
+		fmt.Sprintf("Statement %s", st.Period),
+		fmt.Sprintf("User: %s", st.UserID),
+		fmt.Sprintf("Generated: %s", st.GeneratedAt.Format("2006-01-02 15:04 MST")),
+		"",
+		fmt.Sprintf("%-35s %12s %6s %12s", "Service", "Price", "Months", "Subtotal"),
+		strings.Repeat("-", 68),
+	}
+	footer := []string{
+		strings.Repeat("-", 68),
+		fmt.Sprintf("%-55s %12s", "Total, "+st.Currency, st.Total),
+	}
+
+	// пустая выписка - все равно одна страница с шапкой и итогом
+	var pages [][]string
+	for len(rows) > linesPerPage {
+		pages = append(pages, rows[:linesPerPage])
+		rows = rows[linesPerPage:]
+	}
+	pages = append(pages, rows)
+
+	doc := pdf.NewWriter(w, pdf.A4)
+	for i, page := range pages {
+		text := append(append([]string{}, header...), page...)
+		if i == len(pages)-1 {
+			text = append(text, footer...)
+		}
+		text = append(text, "", fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+		if err := doc.Page(text); err != nil {
+			return err
+		}
+	}
+	return doc.Close()
+}
@@ -0,0 +1,39 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mmoldabe-dev/EffectiveTask/internal/config"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store - хранилище файлов вложений, ключи вида subscriptions/10/<uuid>
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New выбирает хранилище по STORAGE_BACKEND, пустой - вложения выключены (nil)
+func New(cfg *config.Config) (Store, error) {
+	switch cfg.Storage.Backend {
+	case "":
+		return nil, nil
+	case "local":
+		return NewLocal(cfg.Storage.LocalDir)
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:  cfg.Storage.S3Endpoint,
+			Bucket:    cfg.Storage.S3Bucket,
+			Region:    cfg.Storage.S3Region,
+			AccessKey: cfg.Storage.S3AccessKey,
+			SecretKey: cfg.Storage.S3SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (local or s3)", cfg.Storage.Backend)
+	}
+}
@@ -1,10 +1,14 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -44,7 +48,14 @@ func NewPostgres(cfg *config.Config, log *slog.Logger) (*sql.DB, error) {
 	return db, nil
 }
 
-// миграция бд
+// ключ advisory lock на время миграций, общий для всех инстансов
+const migrationsLockKey = 0x6d696772
+
+// как часто пробуем взять лок, пока его держит другой инстанс
+const migrationsLockPoll = 500 * time.Millisecond
+
+// миграция бд. Несколько инстансов стартуют одновременно: миграции катит тот,
+// кто взял advisory lock, остальные ждут до DB_MIGRATIONS_LOCK_TIMEOUT
 func RunMigrations(cfg *config.Config, log *slog.Logger) error {
 	const op = "storage. RunMigrations"
 
@@ -59,6 +70,48 @@ func RunMigrations(cfg *config.Config, log *slog.Logger) error {
 	}
 	defer m.Close()
 
+	// 0 - не ждать, одна попытка взять лок
+	lockTimeout := max(cfg.Database.MigrationsLockTimeout, migrationsLockPoll)
+	m.LockTimeout = lockTimeout
+
+	latest, err := latestMigration("migrations")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// быстрый путь: схема уже свежая, лок не нужен
+	if upToDate(m, latest) {
+		log.Info("schema is up to date", slog.Uint64("version", uint64(latest)))
+		return nil
+	}
+
+	db, err := sql.Open("postgres", migrationDSN)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+
+	// лок сессионный, поэтому держим одно соединение до конца
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	if err := waitMigrationsLock(ctx, conn, log); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationsLockKey)
+
+	// пока ждали, другой инстанс мог все накатить
+	if upToDate(m, latest) {
+		log.Info("migrations applied by another instance", slog.Uint64("version", uint64(latest)))
+		return nil
+	}
+
 	log.Info("checking and applying migrations...")
 	if err := m.Up(); err != nil {
 		if errors.Is(err, migrate.ErrNoChange) {
@@ -71,3 +124,81 @@ func RunMigrations(cfg *config.Config, log *slog.Logger) error {
 	log.Info("migrations applied successfully")
 	return nil
 }
+
+func upToDate(m *migrate.Migrate, latest uint) bool {
+	current, dirty, err := m.Version()
+	return err == nil && !dirty && current >= latest
+}
+
+func waitMigrationsLock(ctx context.Context, conn *sql.Conn, log *slog.Logger) error {
+	ticker := time.NewTicker(migrationsLockPoll)
+	defer ticker.Stop()
+
+	for waited := false; ; waited = true {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, migrationsLockKey).Scan(&locked); err != nil {
+			return fmt.Errorf("migrations lock: %w", err)
+		}
+		if locked {
+			return nil
+		}
+		if !waited {
+			log.Info("migrations are running on another instance, waiting")
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("migrations lock wait: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// SchemaVersion отдает примененную версию схемы и последнюю из папки миграций
+func SchemaVersion(cfg *config.Config) (current uint, dirty bool, latest uint, err error) {
+	const op = "storage. SchemaVersion"
+
+	migrationDSN := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		cfg.Database.User, cfg.Database.Password, cfg.Database.Host,
+		cfg.Database.Port, cfg.Database.DBName, cfg.Database.SSLMode,
+	)
+
+	m, err := migrate.New("file://migrations", migrationDSN)
+	if err != nil {
+		return 0, false, 0, fmt.Errorf("%s: failed to create migrate instance: %w", op, err)
+	}
+	defer m.Close()
+
+	current, dirty, err = m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	latest, err = latestMigration("migrations")
+	if err != nil {
+		return 0, false, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return current, dirty, latest, nil
+}
+
+// номер последней up миграции по именам файлов 0000N_name.up.sql
+func latestMigration(dir string) (uint, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, f := range files {
+		num, _, ok := strings.Cut(filepath.Base(f), "_")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(num, 10, 32)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, uint(v))
+	}
+	return latest, nil
+}
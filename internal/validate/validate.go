@@ -0,0 +1,187 @@
+// Package validate проверяет тела запросов по тегам validate на DTO и собирает
+// все нарушения сразу, а не только первое
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError - нарушенное правило одного поля, field - имя из json тега
+type FieldError struct {
+	Field   string `json:"field" example:"service_name"`
+	Rule    string `json:"rule" example:"required"`
+	Message string `json:"message" example:"service_name is required"`
+
+	// формат и аргументы Message, чтоб перевести текст на язык клиента
+	format string
+	args   []any
+}
+
+// Errors - все нарушения запроса, пустой список - запрос прошел проверку
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add добавляет нарушение, текст собирается из format и args как в fmt.Sprintf
+func (e *Errors) Add(field, rule, format string, args ...any) {
+	*e = append(*e, FieldError{Field: field, Rule: rule, Message: sprintf(format, args...), format: format, args: args})
+}
+
+// Localize пересобирает тексты нарушений через sprintf, обычно i18n.Localizer.Sprintf
+func (e Errors) Localize(sprintf func(format string, args ...any) string) Errors {
+	out := make(Errors, len(e))
+	for i, fe := range e {
+		if fe.format != "" {
+			fe.Message = sprintf(fe.format, fe.args...)
+		}
+		out[i] = fe
+	}
+	return out
+}
+
+func sprintf(format string, args ...any) string {
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Has - есть ли уже нарушение по полю, зависимые проверки по нему не имеют смысла
+func (e Errors) Has(field string) bool {
+	for _, fe := range e {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Struct проверяет поля структуры (или указателя на нее) по тегу validate, например
+// `validate:"required,max=100"`. Правила:
+//   - required: не нулевое значение, строка не из одних пробелов
+//   - min=N, max=N: для чисел значение, для строк длина в символах, для слайсов число элементов
+//   - gt=N: число строго больше N
+//
+// nil указатель без required не проверяется. Встроенные структуры проверяются как свои поля.
+// После первого нарушенного правила поле дальше не проверяется
+func Struct(v any) Errors {
+	var errs Errors
+	structFields(reflect.Indirect(reflect.ValueOf(v)), &errs)
+	return errs
+}
+
+func structFields(v reflect.Value, errs *Errors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			structFields(v.Field(i), errs)
+			continue
+		}
+		tag := f.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+		field(fieldName(f), v.Field(i), strings.Split(tag, ","), errs)
+	}
+}
+
+func field(name string, v reflect.Value, rules []string, errs *Errors) {
+	for _, rule := range rules {
+		rule, arg, _ := strings.Cut(rule, "=")
+
+		if rule == "required" {
+			if isBlank(v) {
+				errs.Add(name, rule, "%s is required", name)
+				return
+			}
+			continue
+		}
+
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return
+			}
+			v = v.Elem()
+		}
+
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: bad argument %q of rule %s on %s", arg, rule, name))
+		}
+		if format, args := check(name, v, rule, n); format != "" {
+			errs.Add(name, rule, format, args...)
+			return
+		}
+	}
+}
+
+// формат и аргументы текста нарушения, пустой формат - правило выполнено
+func check(name string, v reflect.Value, rule string, n float64) (string, []any) {
+	arg := strconv.FormatFloat(n, 'f', -1, 64)
+
+	var size float64
+	unit := ""
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = v.Float()
+	case reflect.String:
+		size, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Map:
+		size, unit = float64(v.Len()), " items"
+	default:
+		panic(fmt.Sprintf("validate: rule %s is not supported for %s (%s)", rule, name, v.Kind()))
+	}
+
+	// единица в самом формате, а не в аргументах: переводится весь текст целиком
+	switch rule {
+	case "min":
+		if size < n {
+			return "%s must be at least %s" + unit, []any{name, arg}
+		}
+	case "max":
+		if size > n {
+			return "%s must be at most %s" + unit, []any{name, arg}
+		}
+	case "gt":
+		if unit != "" {
+			panic(fmt.Sprintf("validate: rule gt is for numbers, %s is %s", name, v.Kind()))
+		}
+		if size <= n {
+			return "%s must be greater than %s", []any{name, arg}
+		}
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %s on %s", rule, name))
+	}
+	return "", nil
+}
+
+func isBlank(v reflect.Value) bool {
+	if v.Kind() == reflect.String {
+		return strings.TrimSpace(v.String()) == ""
+	}
+	return v.IsZero()
+}
+
+// имя поля как его видит клиент
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
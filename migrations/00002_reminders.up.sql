@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS subscription_reminders (
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    acknowledged_end_date VARCHAR(7),
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    snoozed_until TIMESTAMP WITH TIME ZONE,
+    last_notified_at TIMESTAMP WITH TIME ZONE,
+
+    PRIMARY KEY (subscription_id, user_id)
+);
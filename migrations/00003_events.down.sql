@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_subscription_events_user_created;
+DROP TABLE IF EXISTS subscription_events;
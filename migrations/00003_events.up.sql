@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS subscription_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    subscription_id BIGINT,
+    type VARCHAR(32) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_subscription_events_user_created ON subscription_events(user_id, created_at DESC, id DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS check_status;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS paused_until;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS paused_from;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS status;
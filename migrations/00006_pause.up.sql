@@ -0,0 +1,5 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'active';
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS paused_from VARCHAR(7);
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS paused_until VARCHAR(7);
+
+ALTER TABLE subscriptions ADD CONSTRAINT check_status CHECK (status IN ('active', 'paused'));
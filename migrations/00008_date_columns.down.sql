@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS end_on;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS start_on;
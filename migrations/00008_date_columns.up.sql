@@ -0,0 +1,6 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS start_on DATE;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS end_on DATE;
+
+UPDATE subscriptions
+SET start_on = TO_DATE(start_date, 'MM-YYYY'),
+    end_on = TO_DATE(end_date, 'MM-YYYY');
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_subscription_events_created;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_subscription_events_created ON subscription_events(created_at, id);
@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS check_grace_period;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS grace_period_months;
@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS grace_period_months INT NOT NULL DEFAULT 0;
+
+ALTER TABLE subscriptions ADD CONSTRAINT check_grace_period CHECK (grace_period_months >= 0);
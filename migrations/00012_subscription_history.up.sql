@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS subscription_history (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    action VARCHAR(32) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}'::jsonb,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_subscription_history_sub ON subscription_history(subscription_id, changed_at, id);
@@ -0,0 +1,16 @@
+-- payload в json, а не jsonb: хэш считается по исходному тексту
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    at TIMESTAMP WITH TIME ZONE NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(255) NOT NULL,
+    entity_id VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(64) NOT NULL,
+    status INT NOT NULL,
+    payload JSON NOT NULL,
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL
+);
+
+CREATE INDEX idx_audit_log_actor ON audit_log(actor, id DESC);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_id, id DESC);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS budgets (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    category VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_budgets_user ON budgets(user_id, id);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS disabled_routes (
+    route VARCHAR(255) PRIMARY KEY,
+    reason TEXT NOT NULL DEFAULT '',
+    disabled_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscriptions_category;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_categories_name ON categories(LOWER(name));
+
+INSERT INTO categories(name) VALUES ('Streaming'), ('Music'), ('Cloud'), ('Software'), ('Fitness'), ('News'), ('Gaming');
+
+-- удаление категории не трогает подписки, они просто остаются без категории
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS category_id BIGINT REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_subscriptions_category ON subscriptions(user_id, category_id);
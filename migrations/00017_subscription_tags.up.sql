@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- под фильтр tags @> '{work}'
+CREATE INDEX idx_subscriptions_tags ON subscriptions USING GIN (tags);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS attachments;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS notes;
@@ -0,0 +1,15 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+
+-- сами файлы лежат в хранилище (диск или S3), здесь только метаданные
+CREATE TABLE IF NOT EXISTS attachments (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    storage_key VARCHAR(512) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_attachments_subscription ON attachments(subscription_id, id);
@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS catalog_id;
+DROP TABLE IF EXISTS service_catalog;
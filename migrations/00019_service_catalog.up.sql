@@ -0,0 +1,14 @@
+-- канонические названия сервисов. aliases хранятся в нижнем регистре с одиночными пробелами
+CREATE TABLE IF NOT EXISTS service_catalog (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    logo_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_service_catalog_name ON service_catalog(LOWER(name));
+CREATE INDEX idx_service_catalog_aliases ON service_catalog USING GIN (aliases);
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS catalog_id BIGINT REFERENCES service_catalog(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+-- до этой миграции все цены были в рублях
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB';
@@ -0,0 +1,13 @@
+-- курсы валют по дням: сколько единиц currency за одну единицу base.
+-- храним все загрузки, чтоб пересчет за прошлую дату давал тот же результат
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    source VARCHAR(32) NOT NULL,
+    as_of DATE NOT NULL,
+    base CHAR(3) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    rate NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    fetched_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source, as_of, currency)
+);
+
+CREATE INDEX idx_exchange_rates_fetched ON exchange_rates(fetched_at DESC);
@@ -0,0 +1,7 @@
+-- переопределенные шаблоны уведомлений, нет строки - используется встроенный
+CREATE TABLE IF NOT EXISTS notification_templates (
+    kind VARCHAR(50) PRIMARY KEY,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+-- копейки округляются до целых
+COMMENT ON COLUMN budgets.amount IS NULL;
+COMMENT ON COLUMN subscriptions.price IS NULL;
+
+ALTER TABLE budgets ALTER COLUMN amount TYPE BIGINT USING ROUND(amount / 100.0)::bigint;
+ALTER TABLE subscriptions ALTER COLUMN price TYPE INTEGER USING ROUND(price / 100.0)::integer;
@@ -0,0 +1,6 @@
+-- цены и бюджеты в копейках (центах), чтоб хранить дробные суммы без float
+ALTER TABLE subscriptions ALTER COLUMN price TYPE BIGINT USING price::bigint * 100;
+ALTER TABLE budgets ALTER COLUMN amount TYPE BIGINT USING amount * 100;
+
+COMMENT ON COLUMN subscriptions.price IS 'minor units (kopecks, cents)';
+COMMENT ON COLUMN budgets.amount IS 'minor units (kopecks, cents)';
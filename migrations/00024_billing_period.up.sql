@@ -0,0 +1,3 @@
+-- за какой период указана цена, до этой миграции все цены помесячные
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS billing_period VARCHAR(10) NOT NULL DEFAULT 'monthly'
+    CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly'));
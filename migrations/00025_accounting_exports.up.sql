@@ -0,0 +1,12 @@
+-- файлы бухгалтерской выгрузки за закрытые месяцы, повторная выгрузка месяца - новая строка
+CREATE TABLE IF NOT EXISTS accounting_exports (
+    id BIGSERIAL PRIMARY KEY,
+    period DATE NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    content BYTEA NOT NULL,
+    row_count INT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounting_exports_period ON accounting_exports(period, format);
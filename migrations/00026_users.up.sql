@@ -0,0 +1,12 @@
+-- пользователи из IdP. subscriptions.user_id без внешнего ключа: подписки
+-- пользователей, которых никто не провижинил, продолжают работать
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY,
+    external_id VARCHAR(255) UNIQUE,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deactivated_at TIMESTAMP WITH TIME ZONE
+);
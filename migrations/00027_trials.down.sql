@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_subscriptions_trial_end;
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS trial_flagged_at,
+    DROP COLUMN IF EXISTS trial_price,
+    DROP COLUMN IF EXISTS trial_end_date;
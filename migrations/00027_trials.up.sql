@@ -0,0 +1,8 @@
+-- пробный период: месяцы по trial_end_date включительно оплачиваются по trial_price
+-- (в копейках за тот же billing_period), trial_flagged_at - фоновая проверка отметила скорый переход на полную цену
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS trial_end_date VARCHAR(7),
+    ADD COLUMN IF NOT EXISTS trial_price BIGINT NOT NULL DEFAULT 0 CHECK (trial_price >= 0),
+    ADD COLUMN IF NOT EXISTS trial_flagged_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_trial_end ON subscriptions(trial_end_date) WHERE trial_end_date IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS end_day,
+    DROP COLUMN IF EXISTS start_day;
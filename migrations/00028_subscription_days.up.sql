@@ -0,0 +1,4 @@
+-- день месяца начала и конца для посуточного расчета (proration=daily), NULL - месяц целиком
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS start_day SMALLINT CHECK (start_day BETWEEN 1 AND 31),
+    ADD COLUMN IF NOT EXISTS end_day SMALLINT CHECK (end_day BETWEEN 1 AND 31);
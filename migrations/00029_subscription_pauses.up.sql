@@ -0,0 +1,18 @@
+-- все паузы подписки: ручная (paused_to пустой, пока не сняли) и запланированные сезонные.
+-- Месяцы с paused_from по paused_to включительно не оплачиваются
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    paused_from VARCHAR(7) NOT NULL,
+    paused_to VARCHAR(7),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_pauses_subscription ON subscription_pauses(subscription_id);
+
+-- переносим паузы, которые раньше жили только в subscriptions
+INSERT INTO subscription_pauses(subscription_id, paused_from, paused_to)
+SELECT id, paused_from, paused_until
+FROM subscriptions
+WHERE paused_from IS NOT NULL
+  AND (paused_until IS NULL OR TO_DATE(paused_until, 'MM-YYYY') >= TO_DATE(paused_from, 'MM-YYYY'));
@@ -0,0 +1,3 @@
+UPDATE subscriptions
+SET start_on = TO_DATE(start_date, 'MM-YYYY'),
+    end_on = TO_DATE(end_date, 'MM-YYYY');
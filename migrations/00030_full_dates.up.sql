@@ -0,0 +1,6 @@
+-- DATE колонки хранят полную дату: день из start_day/end_day, без него первое число месяца
+UPDATE subscriptions
+SET start_on = TO_DATE(start_date, 'MM-YYYY') + COALESCE(start_day, 1) - 1,
+    end_on = TO_DATE(end_date, 'MM-YYYY') + COALESCE(end_day, 1) - 1
+WHERE start_on IS DISTINCT FROM TO_DATE(start_date, 'MM-YYYY') + COALESCE(start_day, 1) - 1
+   OR end_on IS DISTINCT FROM TO_DATE(end_date, 'MM-YYYY') + COALESCE(end_day, 1) - 1;
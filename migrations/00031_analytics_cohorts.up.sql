@@ -0,0 +1,13 @@
+-- когорты пользователей по месяцу первой подписки: сколько из них платит, сколько
+-- у них подписок и расходов в каждом месяце. Пересчитывается целиком фоновой агрегацией
+CREATE TABLE IF NOT EXISTS analytics_cohorts (
+    cohort DATE NOT NULL,
+    month DATE NOT NULL,
+    cohort_users INT NOT NULL,
+    active_users INT NOT NULL,
+    subscriptions INT NOT NULL,
+    spend BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cohort, month)
+);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_subscriptions_user_dates;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN start_date TYPE VARCHAR(7) USING TO_CHAR(start_date, 'MM-YYYY'),
+    ALTER COLUMN end_date TYPE VARCHAR(7) USING TO_CHAR(end_date, 'MM-YYYY');
+
+ALTER TABLE subscriptions ADD CONSTRAINT check_start_date_format CHECK (start_date ~ '^(0[1-9]|1[0-2])-\d{4}$');
+ALTER TABLE subscriptions ADD CONSTRAINT check_end_date_format CHECK (end_date IS NULL OR end_date ~ '^(0[1-9]|1[0-2])-\d{4}$');
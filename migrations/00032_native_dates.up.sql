@@ -0,0 +1,9 @@
+-- start_date и end_date хранятся как DATE первым числом месяца, формат MM-YYYY остается в API
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS check_start_date_format;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS check_end_date_format;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN start_date TYPE DATE USING TO_DATE(start_date, 'MM-YYYY'),
+    ALTER COLUMN end_date TYPE DATE USING TO_DATE(end_date, 'MM-YYYY');
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_dates ON subscriptions(user_id, start_date, end_date);
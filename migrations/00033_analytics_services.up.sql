@@ -0,0 +1,12 @@
+-- сервисы по месяцам на всей платформе: платящие пользователи, подписки и выручка.
+-- Пересчитывается целиком фоновой агрегацией вместе с когортами
+CREATE TABLE IF NOT EXISTS analytics_services (
+    month DATE NOT NULL,
+    service_name TEXT NOT NULL,
+    subscribers INT NOT NULL,
+    subscriptions INT NOT NULL,
+    revenue BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (month, service_name)
+);
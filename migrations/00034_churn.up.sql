@@ -0,0 +1,15 @@
+-- подписка истекла после льготы и не продлена: отмечается фоновой проверкой один раз
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS churned_at TIMESTAMP WITH TIME ZONE;
+
+-- уже истекшие отмечаем сразу, без событий, чтобы проверка не завалила ленту историей
+UPDATE subscriptions s
+SET churned_at = NOW()
+WHERE s.end_date IS NOT NULL
+  AND s.end_date + MAKE_INTERVAL(months => s.grace_period_months) < DATE_TRUNC('month', NOW())
+  AND NOT EXISTS (
+      SELECT 1 FROM subscriptions n
+      WHERE n.user_id = s.user_id
+        AND LOWER(n.service_name) = LOWER(s.service_name)
+        AND n.id <> s.id
+        AND (n.end_date IS NULL OR n.end_date > s.end_date)
+        AND n.start_date <= s.end_date + MAKE_INTERVAL(months => s.grace_period_months + 1));
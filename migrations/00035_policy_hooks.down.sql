@@ -0,0 +1 @@
+DROP TABLE IF EXISTS policy_hooks;
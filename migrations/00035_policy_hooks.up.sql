@@ -0,0 +1,9 @@
+-- внешние проверки перед созданием и продлением подписки: сервис шлет предлагаемую
+-- запись на url и отказывает, если хук ее отклонил
+CREATE TABLE IF NOT EXISTS policy_hooks (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL DEFAULT '',
+    fail_open BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
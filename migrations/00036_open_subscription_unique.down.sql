@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_subscriptions_open_unique;
@@ -0,0 +1,15 @@
+-- одна бессрочная подписка на сервис у юзера: проверка Exists перед вставкой
+-- не спасает от параллельных запросов, индекс держит это на уровне базы.
+-- Предикат без NOW(), поэтому датированные подписки по-прежнему ловит только Exists
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM subscriptions WHERE end_date IS NULL
+        GROUP BY user_id, service_name HAVING COUNT(*) > 1
+    ) THEN
+        RAISE EXCEPTION 'duplicate open-ended subscriptions exist, close or merge them before migrating';
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_open_unique
+    ON subscriptions(user_id, service_name) WHERE end_date IS NULL;
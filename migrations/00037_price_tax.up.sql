@@ -0,0 +1,5 @@
+-- база цены (без НДС или с ним) и страна для ставки. Пустые - умолчания деплоя,
+-- так старые записи считаются как раньше
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS price_basis VARCHAR(5) NOT NULL DEFAULT '' CHECK (price_basis IN ('', 'net', 'gross')),
+    ADD COLUMN IF NOT EXISTS tax_country VARCHAR(2) NOT NULL DEFAULT '' CHECK (tax_country = '' OR tax_country ~ '^[A-Z]{2}$');
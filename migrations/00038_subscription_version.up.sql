@@ -0,0 +1,3 @@
+-- версия подписки для оптимистичной блокировки: растет на каждой правке,
+-- замена и продление проходят только с той версией, которую видел клиент
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
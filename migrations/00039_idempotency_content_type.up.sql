@@ -0,0 +1,2 @@
+-- тип ответа, чтоб повтор по Idempotency-Key отдавал тот же Content-Type, что и первый ответ
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS content_type VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+-- свои сроки напоминаний подписки: за сколько дней до конца слать, вместо REMINDER_LEAD_MONTHS.
+-- sent_for - дата конца, для которой напоминание уже ушло, после продления оно пойдет снова
+CREATE TABLE IF NOT EXISTS subscription_reminder_offsets (
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    days_before SMALLINT NOT NULL CHECK (days_before BETWEEN 0 AND 365),
+    sent_for DATE,
+
+    PRIMARY KEY (subscription_id, days_before)
+);
@@ -0,0 +1,21 @@
+-- отложенные действия над подписками: выполняет поллер, когда наступит run_at.
+-- Таблица переживает перезапуск, строка берется FOR UPDATE SKIP LOCKED и действие
+-- коммитится вместе со сменой статуса, поэтому каждое выполняется ровно один раз
+CREATE TABLE IF NOT EXISTS scheduled_events (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('auto_renew', 'trial_conversion', 'price_change')),
+    run_at TIMESTAMPTZ NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'done', 'skipped', 'failed', 'cancelled')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    executed_at TIMESTAMPTZ
+);
+
+-- поллер смотрит только на ждущие
+CREATE INDEX IF NOT EXISTS scheduled_events_due_idx ON scheduled_events (run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS scheduled_events_subscription_idx ON scheduled_events (subscription_id);
+-- повтор того же действия на то же время не ставит второе
+CREATE UNIQUE INDEX IF NOT EXISTS scheduled_events_pending_uniq ON scheduled_events (subscription_id, kind, run_at) WHERE status = 'pending';
@@ -0,0 +1,11 @@
+-- токены подтверждения дорогих удалений. В базе, а не в памяти: переживают перезапуск
+-- и работают при нескольких репликах. Токен хранится хешем
+CREATE TABLE IF NOT EXISTS delete_confirmations (
+    -- что подтверждается: subscription:<id>
+    subject TEXT PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS delete_confirmations_expires_idx ON delete_confirmations (expires_at);
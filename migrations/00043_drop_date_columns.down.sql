@@ -0,0 +1,5 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS start_on DATE, ADD COLUMN IF NOT EXISTS end_on DATE;
+
+UPDATE subscriptions
+SET start_on = start_date + COALESCE(start_day, 1) - 1,
+    end_on = end_date + COALESCE(end_day, 1) - 1;
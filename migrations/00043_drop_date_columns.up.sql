@@ -0,0 +1,2 @@
+-- день хранится в start_day/end_day, полные даты собираются при чтении
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS start_on, DROP COLUMN IF EXISTS end_on;